@@ -1,11 +1,15 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"os"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/NebulousLabs/Sia/modules/consensus"
 	"github.com/NebulousLabs/Sia/node/api"
 	"github.com/NebulousLabs/Sia/types"
 )
@@ -17,6 +21,18 @@ var (
 		Long:  "Print the current state of consensus such as current block, block height, and target.",
 		Run:   wrap(consensuscmd),
 	}
+
+	consensusSnapshotCmd = &cobra.Command{
+		Use:   "snapshot [path]",
+		Short: "Export a snapshot of the consensus state",
+		Long: `Export a snapshot of the consensus state at the current height to the given file.
+The snapshot contains the unspent outputs, file contracts, siafund pool and delayed
+outputs along with a checksum of its contents. The block id and checksum printed after
+the export should be published along with the snapshot. A new node can be bootstrapped
+from it with 'siad --snapshot [path] --snapshot-anchor [blockid] --snapshot-checksum [checksum]',
+using the block id and checksum published by a node that you trust.`,
+		Run: wrap(consensussnapshotcmd),
+	}
 )
 
 // consensuscmd is the handler for the command `siac consensus`.
@@ -47,6 +63,40 @@ Progress (estimated): %.1f%%
 	}
 }
 
+// consensussnapshotcmd is the handler for the command `siac consensus snapshot`.
+// Writes a snapshot of the consensus state to a file.
+func consensussnapshotcmd(path string) {
+	resp, err := apiGet("/consensus/snapshot")
+	if err != nil {
+		die("Could not export snapshot:", err)
+	}
+	defer resp.Body.Close()
+	f, err := os.Create(path)
+	if err != nil {
+		die("Could not create snapshot file:", err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		die("Could not write snapshot:", err)
+	}
+	// Print the anchor and checksum from the snapshot itself, so that they
+	// match it even if a block arrived during the export.
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		die("Could not read snapshot:", err)
+	}
+	height, id, checksum, err := consensus.ReadSnapshotHeader(bufio.NewReader(f))
+	if err != nil {
+		die("Could not read snapshot:", err)
+	}
+	fmt.Printf(`Exported consensus snapshot to %v.
+The snapshot was taken at height %v.
+Block:    %v
+Checksum: %v
+Publish the block and checksum along with the snapshot. Nodes can be bootstrapped from it with
+  siad --snapshot %v --snapshot-anchor %v --snapshot-checksum %v
+`, path, height, id, checksum, path, id, checksum)
+}
+
 // estimatedHeightAt returns the estimated block height for the given time.
 // Block height is estimated by calculating the minutes since a known block in
 // the past and dividing by 10 minutes (the block time).
@@ -2,11 +2,14 @@ package main
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"strconv"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/node/api"
 )
 
@@ -18,6 +21,21 @@ var (
 		Run:   wrap(gatewayaddresscmd),
 	}
 
+	gatewayBanCmd = &cobra.Command{
+		Use:   "ban [address]",
+		Short: "Ban a peer",
+		Long: `Add an IP address to the blocklist and disconnect from any peers using it.
+If a full address (IP and port) is given, its IP address is banned.`,
+		Run: wrap(gatewaybancmd),
+	}
+
+	gatewayBlocklistCmd = &cobra.Command{
+		Use:   "blocklist",
+		Short: "View the blocklist",
+		Long:  "View the addresses on the blocklist and the peers that are temporarily banned for misbehaving.",
+		Run:   wrap(gatewayblocklistcmd),
+	}
+
 	gatewayCmd = &cobra.Command{
 		Use:   "gateway",
 		Short: "Perform gateway actions",
@@ -35,8 +53,10 @@ var (
 	gatewayDisconnectCmd = &cobra.Command{
 		Use:   "disconnect [address]",
 		Short: "Disconnect from a peer",
-		Long:  "Disconnect from a peer. Does not remove the peer from the node list.",
-		Run:   wrap(gatewaydisconnectcmd),
+		Long: `Disconnect from a peer. With --unpin, the identity key pinned for the peer
+is removed as well, so that a peer that changed its key is accepted again. The
+peer does not need to be connected to be unpinned.`,
+		Run: wrap(gatewaydisconnectcmd),
 	}
 
 	gatewayListCmd = &cobra.Command{
@@ -45,6 +65,22 @@ var (
 		Long:  "View the current peer list.",
 		Run:   wrap(gatewaylistcmd),
 	}
+
+	gatewaySetLimitsCmd = &cobra.Command{
+		Use:   "setlimits [maxinboundpeers] [maxoutboundpeers] [maxdownloadspeed] [maxuploadspeed]",
+		Short: "Set the peer and bandwidth limits",
+		Long: `Set the maximum number of inbound and outbound peers, and the maximum download
+and upload speed of all peer connections together. Speeds are given per second,
+e.g. 1MB or 500KiB. A speed of 0 means unlimited.`,
+		Run: wrap(gatewaysetlimitscmd),
+	}
+
+	gatewayUnbanCmd = &cobra.Command{
+		Use:   "unban [address]",
+		Short: "Unban a peer",
+		Long:  "Remove an address from the blocklist and lift any temporary ban of it.",
+		Run:   wrap(gatewayunbancmd),
+	}
 )
 
 // gatewayconnectcmd is the handler for the command `siac gateway add [address]`.
@@ -60,11 +96,57 @@ func gatewayconnectcmd(addr string) {
 // gatewaydisconnectcmd is the handler for the command `siac gateway remove [address]`.
 // Removes a peer from the peer list.
 func gatewaydisconnectcmd(addr string) {
-	err := post("/gateway/disconnect/"+addr, "")
+	err := post("/gateway/disconnect/"+addr, "unpin="+strconv.FormatBool(gatewayUnpin))
 	if err != nil {
 		die("Could not remove peer:", err)
 	}
 	fmt.Println("Removed", addr, "from peer list.")
+	if gatewayUnpin {
+		fmt.Println("Removed the identity key pinned for", addr+".")
+	}
+}
+
+// gatewaybancmd is the handler for the command `siac gateway ban [address]`.
+// Adds an address to the blocklist.
+func gatewaybancmd(addr string) {
+	err := post("/gateway/blocklist", "action=ban&address="+url.QueryEscape(addr))
+	if err != nil {
+		die("Could not ban peer:", err)
+	}
+	fmt.Println("Added", addr, "to the blocklist.")
+}
+
+// gatewayunbancmd is the handler for the command `siac gateway unban
+// [address]`. Removes an address from the blocklist.
+func gatewayunbancmd(addr string) {
+	err := post("/gateway/blocklist", "action=unban&address="+url.QueryEscape(addr))
+	if err != nil {
+		die("Could not unban peer:", err)
+	}
+	fmt.Println("Removed", addr, "from the blocklist.")
+}
+
+// gatewayblocklistcmd is the handler for the command `siac gateway
+// blocklist`. Prints the blocklist and the temporarily banned peers.
+func gatewayblocklistcmd() {
+	var gbg api.GatewayBlocklistGET
+	err := getAPI("/gateway/blocklist", &gbg)
+	if err != nil {
+		die("Could not get blocklist:", err)
+	}
+	if len(gbg.Blocklist) == 0 && len(gbg.Bans) == 0 {
+		fmt.Println("No banned peers.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Address\tBanned Until")
+	for _, addr := range gbg.Blocklist {
+		fmt.Fprintf(w, "%v\t%v\n", addr, "until unbanned")
+	}
+	for _, ban := range gbg.Bans {
+		fmt.Fprintf(w, "%v\t%v\n", ban.Host, ban.Expires.Format("2006-01-02 15:04:05"))
+	}
+	w.Flush()
 }
 
 // gatewayaddresscmd is the handler for the command `siac gateway address`.
@@ -87,7 +169,51 @@ func gatewaycmd() {
 		die("Could not get gateway address:", err)
 	}
 	fmt.Println("Address:", info.NetAddress)
+	fmt.Println("Public key:", info.PublicKey.String())
 	fmt.Println("Active peers:", len(info.Peers))
+	fmt.Printf("Peer limits: %v inbound, %v outbound\n", info.Settings.MaxInboundPeers, info.Settings.MaxOutboundPeers)
+	fmt.Printf("Bandwidth limits: %v down, %v up\n", speedUnits(info.Settings.MaxDownloadSpeed), speedUnits(info.Settings.MaxUploadSpeed))
+}
+
+// speedUnits returns a bandwidth limit in human-readable units.
+func speedUnits(bps int64) string {
+	if bps == 0 {
+		return "unlimited"
+	}
+	return filesizeUnits(bps) + "/s"
+}
+
+// parseSpeed converts a bandwidth limit of the form 1MB to bytes per second.
+// A limit of 0 means unlimited.
+func parseSpeed(speed string) (string, error) {
+	if speed == "0" {
+		return speed, nil
+	}
+	return parseFilesize(speed)
+}
+
+// gatewaysetlimitscmd is the handler for the command `siac gateway setlimits
+// [maxinboundpeers] [maxoutboundpeers] [maxdownloadspeed] [maxuploadspeed]`.
+// Sets the gateway's peer and bandwidth limits.
+func gatewaysetlimitscmd(inbound, outbound, download, upload string) {
+	download, err := parseSpeed(download)
+	if err != nil {
+		die("Could not parse download speed:", err)
+	}
+	upload, err = parseSpeed(upload)
+	if err != nil {
+		die("Could not parse upload speed:", err)
+	}
+	values := url.Values{}
+	values.Set("maxinboundpeers", inbound)
+	values.Set("maxoutboundpeers", outbound)
+	values.Set("maxdownloadspeed", download)
+	values.Set("maxuploadspeed", upload)
+	err = post("/gateway", values.Encode())
+	if err != nil {
+		die("Could not set gateway limits:", err)
+	}
+	fmt.Println("Gateway limits updated.")
 }
 
 // gatewaylistcmd is the handler for the command `siac gateway list`.
@@ -102,11 +228,17 @@ func gatewaylistcmd() {
 		fmt.Println("No peers to show.")
 		return
 	}
+	traffic := make(map[modules.NetAddress]modules.PeerTraffic)
+	for _, pt := range info.Traffic {
+		traffic[pt.NetAddress] = pt
+	}
 	fmt.Println(len(info.Peers), "active peers:")
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "Version\tOutbound\tAddress")
+	fmt.Fprintln(w, "Version\tOutbound\tEncrypted\tDownloaded\tUploaded\tAddress")
 	for _, peer := range info.Peers {
-		fmt.Fprintf(w, "%v\t%v\t%v\n", peer.Version, yesNo(!peer.Inbound), peer.NetAddress)
+		pt := traffic[peer.NetAddress]
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", peer.Version, yesNo(!peer.Inbound), yesNo(peer.Encrypted),
+			filesizeUnits(int64(pt.Download)), filesizeUnits(int64(pt.Upload)), peer.NetAddress)
 	}
 	w.Flush()
 }
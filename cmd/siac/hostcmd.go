@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/node/api"
@@ -17,7 +18,7 @@ import (
 
 var (
 	hostAnnounceCmd = &cobra.Command{
-		Use:   "announce",
+		Use:   "announce [address...]",
 		Short: "Announce yourself as a host",
 		Long: `Announce yourself as a host on the network.
 Announcing will also configure the host to start accepting contracts.
@@ -25,7 +26,10 @@ You can revert this by running:
 	siac host config acceptingcontracts false
 You may also supply a specific address to be announced, e.g.:
 	siac host announce my-host-domain.com:9001
-Doing so will override the standard connectivity checks.`,
+Doing so will override the standard connectivity checks.
+Several addresses may be supplied in order of preference, e.g. to announce
+an IPv6 or onion address alongside an IPv4 address:
+	siac host announce 1.2.3.4:9982 [2001:db8::1]:9982`,
 		Run: hostannouncecmd,
 	}
 
@@ -43,6 +47,7 @@ Doing so will override the standard connectivity checks.`,
 
 Available settings:
      acceptingcontracts:   boolean
+     maintenancemode:      boolean
      maxduration:          blocks
      maxdownloadbatchsize: bytes
      maxrevisebatchsize:   bytes
@@ -68,6 +73,11 @@ For a description of each parameter, see doc/API.md.
 
 To configure the host to accept new contracts, set acceptingcontracts to true:
 	siac host config acceptingcontracts true
+
+To drain the host before retiring it, enable maintenance mode. The host will
+refuse new contracts and renewals, stop announcing, and keep serving its
+existing contracts until they expire:
+	siac host config maintenancemode true
 `,
 		Run: wrap(hostconfigcmd),
 	}
@@ -81,8 +91,17 @@ To configure the host to accept new contracts, set acceptingcontracts to true:
 
 	hostFolderCmd = &cobra.Command{
 		Use:   "folder",
-		Short: "Add, remove, or resize a storage folder",
-		Long:  "Add, remove, or resize a storage folder.",
+		Short: "Add, remove, resize, or migrate a storage folder",
+		Long:  "Add, remove, resize, or migrate a storage folder.",
+	}
+
+	hostFolderMigrateCmd = &cobra.Command{
+		Use:   "migrate [path] [newpath]",
+		Short: "Move a storage folder to a new path",
+		Long: `Move a storage folder to a new path, for example on a different disk. The
+data is copied in the background while the host keeps running. The progress of
+the migration is shown by 'siac host'.`,
+		Run: wrap(hostfoldermigratecmd),
 	}
 
 	hostFolderRemoveCmd = &cobra.Command{
@@ -182,6 +201,7 @@ func hostcmd() {
 
 Host Internal Settings:
 	acceptingcontracts:   %v
+	maintenancemode:      %v
 	maxduration:          %v Weeks
 	maxdownloadbatchsize: %v
 	maxrevisebatchsize:   %v
@@ -226,7 +246,8 @@ RPC Stats:
 `,
 			connectabilityString,
 
-			yesNo(is.AcceptingContracts), periodUnits(is.MaxDuration),
+			yesNo(is.AcceptingContracts), yesNo(is.MaintenanceMode),
+			periodUnits(is.MaxDuration),
 			filesizeUnits(int64(is.MaxDownloadBatchSize)),
 			filesizeUnits(int64(is.MaxReviseBatchSize)), netaddr,
 			is.WindowSize/6,
@@ -283,6 +304,18 @@ RPC Stats:
 			currencyUnits(totalRevenue))
 	}
 
+	// if the host is draining its contracts, report the progress
+	if ms := hg.MaintenanceStatus; ms.MaintenanceMode {
+		fmt.Printf(`
+Maintenance Mode:
+	Active Obligations: %v
+	Last Expiry:        block %v (~%v)
+	Time Remaining:     ~%v days
+`, ms.ActiveObligations, ms.LastExpiryHeight,
+			time.Unix(int64(ms.LastExpiryTime), 0).Format("2006-01-02"),
+			ms.RemainingBlocks/144) // 144 blocks per day
+	}
+
 	// if wallet is locked print warning
 	walletstatus := new(api.WalletGET)
 	walleterr := getAPI("/wallet", walletstatus)
@@ -309,6 +342,10 @@ RPC Stats:
 		curSize := int64(folder.Capacity - folder.CapacityRemaining)
 		pctUsed := 100 * (float64(curSize) / float64(folder.Capacity))
 		fmt.Fprintf(w, "\t%s\t%s\t%.2f\t%s\n", filesizeUnits(curSize), filesizeUnits(int64(folder.Capacity)), pctUsed, folder.Path)
+		if folder.MigrationPath != "" && folder.ProgressDenominator != 0 {
+			pctMigrated := 100 * (float64(folder.ProgressNumerator) / float64(folder.ProgressDenominator))
+			fmt.Fprintf(w, "\t\t\t\tmigrating to %s (%.2f%%)\n", folder.MigrationPath, pctMigrated)
+		}
 	}
 	w.Flush()
 }
@@ -346,7 +383,7 @@ func hostconfigcmd(param, value string) {
 		value = c.String()
 
 	// bool (allow "yes" and "no")
-	case "acceptingcontracts":
+	case "acceptingcontracts", "maintenancemode":
 		switch strings.ToLower(value) {
 		case "yes":
 			value = "true"
@@ -395,11 +432,8 @@ func hostannouncecmd(cmd *cobra.Command, args []string) {
 	switch len(args) {
 	case 0:
 		err = post("/host/announce", "")
-	case 1:
-		err = post("/host/announce", "netaddress="+args[0])
 	default:
-		cmd.UsageFunc()(cmd)
-		os.Exit(exitCodeUsage)
+		err = post("/host/announce", "netaddress="+strings.Join(args, ","))
 	}
 	if err != nil {
 		die("Could not announce host:", err)
@@ -436,6 +470,15 @@ func hostfolderaddcmd(path, size string) {
 	fmt.Println("Added folder", path)
 }
 
+// hostfoldermigratecmd moves a folder of the host to a new path.
+func hostfoldermigratecmd(path, newpath string) {
+	err := post("/host/storage/folders/migrate", fmt.Sprintf("path=%s&newpath=%s", abs(path), abs(newpath)))
+	if err != nil {
+		die("Could not migrate folder:", err)
+	}
+	fmt.Printf("Migrating folder %v to %v\n", path, newpath)
+}
+
 // hostfolderremovecmd removes a folder from the host.
 func hostfolderremovecmd(path string) {
 	err := post("/host/storage/folders/remove", "path="+abs(path))
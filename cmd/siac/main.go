@@ -17,12 +17,33 @@ import (
 
 var (
 	// Flags.
-	addr              string // override default API address
-	hostVerbose       bool   // display additional host info
-	initForce         bool   // destroy and reencrypt the wallet on init if it already exists
-	initPassword      bool   // supply a custom password when creating a wallet
-	renterListVerbose bool   // Show additional info about uploaded files.
-	renterShowHistory bool   // Show download history in addition to download queue.
+	addr                         string // override default API address
+	gatewayUnpin                 bool   // Remove the identity key pinned for the peer.
+	hostVerbose                  bool   // display additional host info
+	initForce                    bool   // destroy and reencrypt the wallet on init if it already exists
+	initPassword                 bool   // supply a custom password when creating a wallet
+	renterListVerbose            bool   // Show additional info about uploaded files.
+	renterShowHistory            bool   // Show download history in addition to download queue.
+	tpoolAddress                 string // Only list transaction sets related to this address.
+	tpoolVerbose                 bool   // List the transactions of each transaction set.
+	walletAddressPurpose         string // Derivation namespace of the new address.
+	walletConsolidateMaxInputs   int    // Maximum number of outputs to consolidate.
+	walletPolicyAllowlist        string // Addresses that the wallet may send siacoins to.
+	walletPolicyDailyLimit       string // Maximum siacoins sent in the last 24 hours.
+	walletPolicyNewSendPassword  bool   // Prompt for a new send password.
+	walletPolicyTransactionLimit string // Maximum siacoins sent by a single transaction.
+	walletSendChange             string // Address that change is sent to.
+	walletSendDryRun             bool   // Build and sign the transaction without broadcasting it.
+	walletSendFee                string // Explicit miner fee for the transaction.
+	walletSendInputs             string // Outputs to spend when sending.
+	walletSendPassword           bool   // Prompt for the wallet's send password.
+	walletSignRaw                bool   // Print the signed transaction in the raw /tpool/raw format.
+	walletSignToSign             string // Parent ids of the inputs to sign.
+	walletTransactionsCSV        string // File to export the transaction history to.
+	walletTransactionsLabel      string // Only list transactions whose labels contain this text.
+	walletWatchPubkeys           string // Public keys whose standard addresses are watched.
+	walletWatchRemove            bool   // Stop watching the supplied addresses.
+	walletWatchUnused            bool   // Skip the rescan when watching new or multisig addresses.
 )
 
 var (
@@ -263,7 +284,7 @@ func main() {
 
 	root.AddCommand(hostCmd)
 	hostCmd.AddCommand(hostConfigCmd, hostAnnounceCmd, hostFolderCmd, hostSectorCmd)
-	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderRemoveCmd, hostFolderResizeCmd)
+	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderMigrateCmd, hostFolderRemoveCmd, hostFolderResizeCmd)
 	hostSectorCmd.AddCommand(hostSectorDeleteCmd)
 	hostCmd.Flags().BoolVarP(&hostVerbose, "verbose", "v", false, "Display detailed host info")
 
@@ -277,14 +298,43 @@ func main() {
 
 	root.AddCommand(walletCmd)
 	walletCmd.AddCommand(walletAddressCmd, walletAddressesCmd, walletChangepasswordCmd, walletInitCmd, walletInitSeedCmd,
-		walletLoadCmd, walletLockCmd, walletSeedsCmd, walletSendCmd, walletSweepCmd,
-		walletBalanceCmd, walletTransactionsCmd, walletUnlockCmd)
+		walletLabelCmd, walletLoadCmd, walletLockCmd, walletSeedsCmd, walletSendCmd, walletSweepCmd,
+		walletBackupCmd, walletBalanceCmd, walletBumpFeeCmd, walletConsolidateCmd, walletFreezeCmd, walletFundWatchedCmd, walletMultisigCmd, walletPolicyCmd, walletPubkeyCmd,
+		walletRestoreCmd, walletSignCmd, walletTransactionsCmd, walletUnfreezeCmd, walletUnlockCmd, walletUnspentCmd, walletWatchCmd)
+	walletAddressCmd.Flags().StringVarP(&walletAddressPurpose, "purpose", "", "", "Derivation namespace of the address: renter, host or miner")
+	walletConsolidateCmd.Flags().IntVarP(&walletConsolidateMaxInputs, "max-inputs", "", 0, "Maximum number of outputs to merge, defaults to 35")
+	walletConsolidateCmd.Flags().BoolVarP(&walletSendDryRun, "dry-run", "", false, "Print the signed transaction and fee without broadcasting it")
 	walletInitCmd.Flags().BoolVarP(&initPassword, "password", "p", false, "Prompt for a custom password")
 	walletInitCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet and re-encrypt")
 	walletInitSeedCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet")
 	walletLoadCmd.AddCommand(walletLoad033xCmd, walletLoadSeedCmd, walletLoadSiagCmd)
+	walletPolicyCmd.Flags().StringVarP(&walletPolicyTransactionLimit, "transaction-limit", "", "", "Maximum siacoins sent by a single transaction, e.g. 100SC")
+	walletPolicyCmd.Flags().StringVarP(&walletPolicyDailyLimit, "daily-limit", "", "", "Maximum siacoins sent in the last 24 hours, e.g. 1KS")
+	walletPolicyCmd.Flags().StringVarP(&walletPolicyAllowlist, "allowlist", "", "", "Comma separated addresses that siacoins may be sent to")
+	walletPolicyCmd.Flags().BoolVarP(&walletPolicyNewSendPassword, "new-send-password", "", false, "Prompt for a new send password")
+	walletPolicyCmd.Flags().BoolVarP(&walletSendPassword, "send-password", "", false, "Prompt for the current send password")
 	walletSendCmd.AddCommand(walletSendSiacoinsCmd, walletSendSiafundsCmd)
+	walletSendSiacoinsCmd.Flags().StringVarP(&walletSendFee, "fee", "", "", "Miner fee to pay, e.g. 1SC; defaults to the transaction pool estimate")
+	walletSendSiacoinsCmd.Flags().StringVarP(&walletSendInputs, "inputs", "", "", "Comma separated ids of the outputs to spend, defaults to automatic selection")
+	walletSendSiacoinsCmd.Flags().StringVarP(&walletSendChange, "change", "", "", "Address to send change to, defaults to a new wallet address")
+	walletSendSiacoinsCmd.Flags().BoolVarP(&walletSendDryRun, "dry-run", "", false, "Print the signed transaction and fee without broadcasting it")
+	walletSendSiacoinsCmd.Flags().BoolVarP(&walletSendPassword, "send-password", "", false, "Prompt for the wallet's send password")
 	walletUnlockCmd.Flags().BoolVarP(&initPassword, "password", "p", false, "Display interactive password prompt even if SIA_WALLET_PASSWORD is set")
+	walletMultisigCmd.Flags().BoolVarP(&walletWatchUnused, "unused", "", false, "Skip the blockchain rescan because the address has never been used")
+	walletSignCmd.Flags().BoolVarP(&walletSignRaw, "raw", "", false, "Print the signed transaction base64-encoded, ready for /tpool/raw")
+	walletSignCmd.Flags().StringVarP(&walletSignToSign, "tosign", "", "", "Comma separated parent ids of the inputs to sign, defaults to all inputs")
+	walletSignCmd.Flags().BoolVarP(&walletSendPassword, "send-password", "", false, "Prompt for the wallet's send password")
+	walletSendSiafundsCmd.Flags().BoolVarP(&walletSendPassword, "send-password", "", false, "Prompt for the wallet's send password")
+	walletBumpFeeCmd.Flags().BoolVarP(&walletSendPassword, "send-password", "", false, "Prompt for the wallet's send password")
+	walletBackupCmd.Flags().BoolVarP(&walletSendPassword, "send-password", "", false, "Prompt for the wallet's send password")
+	walletSeedsCmd.Flags().BoolVarP(&walletSendPassword, "send-password", "", false, "Prompt for the wallet's send password")
+	walletTransactionsCmd.Flags().StringVarP(&walletTransactionsCSV, "csv", "", "", "Export the transactions to a CSV file instead of printing them")
+	walletTransactionsCmd.Flags().StringVarP(&walletTransactionsLabel, "label", "", "", "Only show transactions whose label or address labels contain this text")
+	walletFundWatchedCmd.Flags().StringVarP(&walletSendFee, "fee", "", "", "Miner fee to pay, e.g. 1SC; defaults to the transaction pool estimate")
+	walletFundWatchedCmd.Flags().StringVarP(&walletSendChange, "change", "", "", "Address that change is sent to; defaults to the address of the first input")
+	walletWatchCmd.Flags().StringVarP(&walletWatchPubkeys, "pubkeys", "", "", "Comma separated public keys whose addresses are watched, e.g. ed25519:<hex>")
+	walletWatchCmd.Flags().BoolVarP(&walletWatchRemove, "remove", "", false, "Stop watching the supplied addresses")
+	walletWatchCmd.Flags().BoolVarP(&walletWatchUnused, "unused", "", false, "Skip the blockchain rescan because the addresses have never been used")
 
 	root.AddCommand(renterCmd)
 	renterCmd.AddCommand(renterFilesDeleteCmd, renterFilesDownloadCmd,
@@ -302,9 +352,16 @@ func main() {
 	renterExportCmd.AddCommand(renterExportContractTxnsCmd)
 
 	root.AddCommand(gatewayCmd)
-	gatewayCmd.AddCommand(gatewayConnectCmd, gatewayDisconnectCmd, gatewayAddressCmd, gatewayListCmd)
+	gatewayCmd.AddCommand(gatewayConnectCmd, gatewayDisconnectCmd, gatewayAddressCmd, gatewayListCmd, gatewayBanCmd, gatewayUnbanCmd, gatewayBlocklistCmd, gatewaySetLimitsCmd)
+	gatewayDisconnectCmd.Flags().BoolVarP(&gatewayUnpin, "unpin", "", false, "Also remove the identity key pinned for the peer, e.g. after it changed its key")
 
 	root.AddCommand(consensusCmd)
+	consensusCmd.AddCommand(consensusSnapshotCmd)
+
+	root.AddCommand(tpoolCmd)
+	tpoolCmd.AddCommand(tpoolFeeCmd, tpoolListCmd)
+	tpoolListCmd.Flags().StringVarP(&tpoolAddress, "address", "", "", "Only list the sets that spend from or send to this address")
+	tpoolListCmd.Flags().BoolVarP(&tpoolVerbose, "verbose", "v", false, "List the transactions of each set and how they depend on each other")
 
 	root.AddCommand(bashcomplCmd)
 	root.AddCommand(mangenCmd)
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/NebulousLabs/Sia/node/api"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	tpoolCmd = &cobra.Command{
+		Use:   "tpool",
+		Short: "Print info about the transaction pool",
+		Long:  "Print the number and size of the unconfirmed transaction sets, and the current fee estimates.",
+		Run:   wrap(tpoolcmd),
+	}
+
+	tpoolFeeCmd = &cobra.Command{
+		Use:   "fee [target]",
+		Short: "Estimate the fee for a confirmation target",
+		Long:  "Print the fee estimated to confirm a transaction within the given number of blocks.",
+		Run:   wrap(tpoolfeecmd),
+	}
+
+	tpoolListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the unconfirmed transaction sets",
+		Long: `List the transaction sets in the transaction pool, with the sets paying the
+highest fee per byte first. The age of a set is the number of blocks since it
+entered the pool. Sets that pay a low fee and keep aging are unlikely to be
+confirmed.`,
+		Run: wrap(tpoollistcmd),
+	}
+)
+
+// tpoolcmd is the handler for the command `siac tpool`.
+// Prints info about the transaction pool.
+func tpoolcmd() {
+	var ttg api.TpoolTransactionsGET
+	err := getAPI("/tpool/transactions", &ttg)
+	if err != nil {
+		die("Could not get transaction pool:", err)
+	}
+	var fees api.TpoolFeeGET
+	err = getAPI("/tpool/fee", &fees)
+	if err != nil {
+		die("Could not get fee estimation:", err)
+	}
+	var numTxns int
+	var size uint64
+	for _, set := range ttg.Sets {
+		numTxns += len(set.Transactions)
+		size += set.Size
+	}
+	fmt.Printf(`Transaction Sets:  %v
+Transactions:      %v
+Size:              %v
+
+Minimum Fee:       %v / KB
+Maximum Fee:       %v / KB
+Next Block Fee:    %v / KB
+`, len(ttg.Sets), numTxns, filesizeUnits(int64(size)), fees.Minimum.Mul64(1e3).HumanString(),
+		fees.Maximum.Mul64(1e3).HumanString(), fees.Estimate.Mul64(1e3).HumanString())
+}
+
+// tpoolfeecmd is the handler for the command `siac tpool fee [target]`.
+// Prints the fee estimated to confirm a transaction within target blocks.
+func tpoolfeecmd(target string) {
+	n, err := strconv.ParseUint(target, 10, 64)
+	if err != nil || n == 0 {
+		die("Target must be a number of blocks greater than 0")
+	}
+	var fees api.TpoolFeeGET
+	err = getAPI(fmt.Sprintf("/tpool/fee?target=%v", n), &fees)
+	if err != nil {
+		die("Could not get fee estimation:", err)
+	}
+	fmt.Printf("Estimated fee to confirm within %v blocks: %v / KB\n", fees.Target, fees.Estimate.Mul64(1e3).HumanString())
+}
+
+// tpoollistcmd is the handler for the command `siac tpool list`.
+// Lists the transaction sets in the transaction pool.
+func tpoollistcmd() {
+	call := "/tpool/transactions"
+	if tpoolAddress != "" {
+		var addr types.UnlockHash
+		if err := addr.LoadString(tpoolAddress); err != nil {
+			die("Could not parse address:", err)
+		}
+		call += "?address=" + addr.String()
+	}
+	var ttg api.TpoolTransactionsGET
+	err := getAPI(call, &ttg)
+	if err != nil {
+		die("Could not get transaction pool:", err)
+	}
+	if len(ttg.Sets) == 0 {
+		fmt.Println("No transaction sets to show.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Set ID\tTxns\tSize\tFee\tFee / KB\tAge")
+	for _, set := range ttg.Sets {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v blocks\n", set.ID, len(set.Transactions), filesizeUnits(int64(set.Size)),
+			currencyUnits(set.Fees), set.FeePerByte.Mul64(1e3).HumanString(), set.Age)
+		if !tpoolVerbose {
+			continue
+		}
+		for _, txn := range set.Transactions {
+			fmt.Fprintf(w, "  %v\t\t%v\t%v\t\t%v blocks\n", txn.ID, filesizeUnits(int64(txn.Size)), currencyUnits(txn.Fees), txn.Age)
+			for _, parent := range txn.Parents {
+				fmt.Fprintf(w, "    spends output of %v\t\t\t\t\t\n", parent)
+			}
+			for _, child := range txn.Children {
+				fmt.Fprintf(w, "    spent by %v\t\t\t\t\t\n", child)
+			}
+		}
+	}
+	w.Flush()
+}
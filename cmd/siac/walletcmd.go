@@ -1,16 +1,29 @@
 package main
 
 import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"math/big"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
+	"github.com/NebulousLabs/entropy-mnemonics"
 	"github.com/spf13/cobra"
 	"golang.org/x/crypto/ssh/terminal"
 
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/wallet"
 	"github.com/NebulousLabs/Sia/node/api"
 	"github.com/NebulousLabs/Sia/types"
 )
@@ -19,8 +32,10 @@ var (
 	walletAddressCmd = &cobra.Command{
 		Use:   "address",
 		Short: "Get a new wallet address",
-		Long:  "Generate a new wallet address from the wallet's primary seed.",
-		Run:   wrap(walletaddresscmd),
+		Long: `Generate a new wallet address from the wallet's primary seed. If --purpose
+is supplied, the address is taken from the derivation namespace reserved for
+the renter, host or miner instead of the wallet's own.`,
+		Run: wrap(walletaddresscmd),
 	}
 
 	walletAddressesCmd = &cobra.Command{
@@ -30,6 +45,17 @@ var (
 		Run:   wrap(walletaddressescmd),
 	}
 
+	walletBackupCmd = &cobra.Command{
+		Use:   "backup [destination]",
+		Short: "Export an encrypted backup of the wallet",
+		Long: `Export a portable backup of the wallet to [destination], encrypted with a backup
+password. The backup contains the wallet's seeds, address progress, siag keys,
+watch-only and multisig addresses and frozen outputs, and can be restored into
+a fresh node with 'siac wallet restore'. The wallet must be unlocked.
+If a send password is set, pass --send-password to be prompted for it.`,
+		Run: wrap(walletbackupcmd),
+	}
+
 	walletBalanceCmd = &cobra.Command{
 		Use:   "balance",
 		Short: "View wallet balance",
@@ -63,6 +89,48 @@ The smallest unit of siacoins is the hasting. One siacoin is 10^24 hastings. Oth
 		Run: wrap(walletbalancecmd),
 	}
 
+	walletBumpFeeCmd = &cobra.Command{
+		Use:   "bump-fee [transaction id] [fee]",
+		Short: "Raise the fee of a stuck transaction",
+		Long: `Raise the total miner fee paid for an unconfirmed transaction sent by the
+wallet to [fee], e.g. 1SC. If the transaction sent change back to the wallet, a
+child transaction that spends the change pays the difference. Otherwise the
+transaction is rebuilt with the same inputs and a higher fee, replacing the
+original.`,
+		Run: wrap(walletbumpfeecmd),
+	}
+
+	walletConsolidateCmd = &cobra.Command{
+		Use:   "consolidate",
+		Short: "Merge small outputs into a single output",
+		Long: `Spend the wallet's smallest spendable outputs into a single new output, which
+makes future transactions smaller and cheaper. At most --max-inputs outputs are
+merged. Frozen outputs are never used. --dry-run prints the transaction and its
+fee without broadcasting it.`,
+		Run: wrap(walletconsolidatecmd),
+	}
+
+	walletFreezeCmd = &cobra.Command{
+		Use:   "freeze [outputid...]",
+		Short: "Prevent the wallet from spending outputs",
+		Long: `Freeze outputs so that the wallet never uses them to fund transactions and the
+defragger never merges them. Output ids are listed by 'siac wallet unspent'.
+Use 'siac wallet unfreeze' to allow the outputs to be spent again.`,
+		Run: walletfreezecmd,
+	}
+
+	walletFundWatchedCmd = &cobra.Command{
+		Use:   "fund-watched [amount] [dest]",
+		Short: "Build an unsigned transaction funded from watch-only addresses",
+		Long: `Build a transaction sending [amount] to [dest], funded from watch-only
+addresses that were added with 'siac wallet watch --pubkeys'. The unsigned
+transaction is printed as JSON. Sign it offline with 'siac wallet sign', then
+broadcast the signed transaction through /tpool/raw.
+Change is sent back to the address of the first input unless --change is
+supplied.`,
+		Run: wrap(walletfundwatchedcmd),
+	}
+
 	walletInitCmd = &cobra.Command{
 		Use:   "init",
 		Short: "Initialize and encrypt a new wallet",
@@ -107,6 +175,16 @@ By default the wallet encryption / unlock password is the same as the generated
 		Run:     wrap(walletloadsiagcmd),
 	}
 
+	walletLabelCmd = &cobra.Command{
+		Use:   "label [address|transaction id] [label]",
+		Short: "Label an address or transaction, or list labels",
+		Long: `Assign a label to an address or to a transaction in the wallet's history.
+Labels are shown by 'siac wallet transactions', which can search them with
+--label. If only an address or transaction id is supplied, its label is
+removed. If no arguments are supplied, all labels are listed.`,
+		Run: walletlabelcmd,
+	}
+
 	walletLockCmd = &cobra.Command{
 		Use:   "lock",
 		Short: "Lock the wallet",
@@ -114,11 +192,60 @@ By default the wallet encryption / unlock password is the same as the generated
 		Run:   wrap(walletlockcmd),
 	}
 
+	walletMultisigCmd = &cobra.Command{
+		Use:   "multisig [required] [pubkey...]",
+		Short: "Create or list multisig addresses",
+		Long: `Create an M-of-N multisig address that requires [required] signatures from the
+supplied public keys. Public keys have the form 'ed25519:<hex>'; each cosigner
+can obtain one with 'siac wallet pubkey'. The wallet tracks the balance of the
+address, and 'siac wallet sign' adds the wallet's signatures to transactions
+spending from it. Pass the partially signed transaction to the next cosigner
+until enough signatures are present, then broadcast it through /tpool/raw.
+Creating an address triggers a blockchain rescan unless --unused is supplied.
+If no arguments are supplied, the tracked multisig addresses are listed.`,
+		Run: walletmultisigcmd,
+	}
+
+	walletPolicyCmd = &cobra.Command{
+		Use:   "policy",
+		Short: "View or change the wallet's spending policy",
+		Long: `View the wallet's spending policy, or change it using the flags below.
+--transaction-limit and --daily-limit cap the siacoins sent by a single
+transaction and in the last 24 hours, including the miner fee; a limit of 0SC
+removes it. --allowlist restricts sends to a comma separated list of addresses;
+an empty list allows any address. Sends to the wallet's own addresses are
+always allowed.
+
+--new-send-password prompts for a password that must then be supplied with
+every siacoin send and policy change; leave it empty to remove the password.
+If a send password is set, pass --send-password to be prompted for it.`,
+		Run: walletpolicycmd,
+	}
+
+	walletPubkeyCmd = &cobra.Command{
+		Use:   "pubkey",
+		Short: "Get a public key for a multisig address",
+		Long:  "Generate a new wallet address and print its public key, which can be shared with cosigners to create a multisig address.",
+		Run:   wrap(walletpubkeycmd),
+	}
+
+	walletRestoreCmd = &cobra.Command{
+		Use:   "restore [source]",
+		Short: "Restore a wallet backup",
+		Long: `Restore a backup created by 'siac wallet backup'. If the wallet has not been
+initialized yet, it is initialized from the backup and encrypted with the
+supplied wallet password; unlock it afterwards to sync it with the blockchain.
+Otherwise the wallet must be unlocked, and the seeds and addresses of the
+backup are added to it.`,
+		Run: wrap(walletrestorecmd),
+	}
+
 	walletSeedsCmd = &cobra.Command{
 		Use:   "seeds",
 		Short: "View information about your seeds",
-		Long:  "View your primary and auxiliary wallet seeds.",
-		Run:   wrap(walletseedscmd),
+		Long: `View your primary and auxiliary wallet seeds.
+If a send password is set, pass --send-password to be prompted for it.`,
+		Run: wrap(walletseedscmd),
 	}
 
 	walletSendCmd = &cobra.Command{
@@ -136,7 +263,12 @@ By default the wallet encryption / unlock password is the same as the generated
 'amount' can be specified in units, e.g. 1.23KS. Run 'wallet --help' for a list of units.
 If no unit is supplied, hastings will be assumed.
 
-A miner fee of 10 SC is levied on all transactions.`,
+By default the miner fee is derived from the transaction pool's fee estimate and
+inputs are selected automatically. Use --fee to pay an explicit fee, --inputs to
+choose the outputs to spend (as listed by /wallet/unspent), and --change to choose
+where change is sent. --dry-run prints the signed transaction and its fee
+without broadcasting it. If the wallet has a send password, pass
+--send-password to be prompted for it.`,
 		Run: wrap(walletsendsiacoinscmd),
 	}
 
@@ -148,6 +280,18 @@ Run 'wallet send --help' to see a list of available units.`,
 		Run: wrap(walletsendsiafundscmd),
 	}
 
+	walletSignCmd = &cobra.Command{
+		Use:   "sign [txnfile]",
+		Short: "Sign a transaction",
+		Long: `Sign a JSON-encoded transaction, such as one built from the outputs listed by
+/wallet/unspent. If siad is running with an unlocked wallet, the wallet's keys
+are used. Otherwise you will be prompted for a seed and the transaction is
+signed offline, without contacting siad.
+The signed transaction is printed as JSON, or base64-encoded if --raw is
+supplied. The raw form can be broadcast by any node through /tpool/raw.`,
+		Run: wrap(walletsigncmd),
+	}
+
 	walletSweepCmd = &cobra.Command{
 		Use:   "sweep",
 		Short: "Sweep siacoins and siafunds from a seed.",
@@ -159,8 +303,13 @@ will be sent to your wallet.`,
 	walletTransactionsCmd = &cobra.Command{
 		Use:   "transactions",
 		Short: "View transactions",
-		Long:  "View transactions related to addresses spendable by the wallet, providing a net flow of siacoins and siafunds for each transaction",
-		Run:   wrap(wallettransactionscmd),
+		Long: `View transactions related to addresses spendable by the wallet, providing a net
+flow of siacoins and siafunds for each transaction along with its label.
+Use --label to only show transactions whose label, or the label of one of
+their addresses, contains the given text. Use --csv to export the
+transactions, including their confirmation times, labels and exact siacoin
+and siafund flows, to a CSV file.`,
+		Run: wrap(wallettransactionscmd),
 	}
 
 	walletUnlockCmd = &cobra.Command{
@@ -172,6 +321,37 @@ SIA_WALLET_PASSWORD environment variable is set, the unlock command will
 use it instead of displaying the typical interactive prompt.`,
 		Run: wrap(walletunlockcmd),
 	}
+
+	walletUnfreezeCmd = &cobra.Command{
+		Use:   "unfreeze [outputid...]",
+		Short: "Allow the wallet to spend frozen outputs",
+		Long:  "Unfreeze outputs that were frozen with 'siac wallet freeze'.",
+		Run:   walletunfreezecmd,
+	}
+
+	walletUnspentCmd = &cobra.Command{
+		Use:   "unspent",
+		Short: "List the wallet's unspent outputs",
+		Long: `List the siacoin and siafund outputs of the wallet, including the height at
+which they were confirmed and whether the wallet can spend them. Outputs may be
+unspendable because they are dust, spent by an unconfirmed transaction, frozen,
+or belong to a watch-only address.`,
+		Run: wrap(walletunspentcmd),
+	}
+
+	walletWatchCmd = &cobra.Command{
+		Use:   "watch [address...]",
+		Short: "Watch addresses without being able to spend from them",
+		Long: `Add watch-only addresses to the wallet. The wallet will track the balance and
+transactions of these addresses, but cannot spend from them. Adding addresses
+triggers a blockchain rescan unless --unused is supplied.
+Use --pubkeys to watch the addresses of public keys held by an offline
+wallet. The wallet can then build unsigned transactions spending from them
+with 'siac wallet fund-watched'.
+Use --remove to stop watching addresses.
+If no addresses are supplied, the currently watched addresses are listed.`,
+		Run: walletwatchcmd,
+	}
 )
 
 const askPasswordText = "We need to encrypt the new data using the current wallet password, please provide: "
@@ -207,7 +387,7 @@ func confirmPassword(prev string) error {
 // receive coins.
 func walletaddresscmd() {
 	addr := new(api.WalletAddressGET)
-	err := getAPI("/wallet/address", addr)
+	err := getAPI("/wallet/address?purpose="+walletAddressPurpose, addr)
 	if err != nil {
 		die("Could not generate new address:", err)
 	}
@@ -318,6 +498,54 @@ func walletload033xcmd(source string) {
 	fmt.Println("Wallet loading successful.")
 }
 
+// walletlabelcmd assigns, removes or lists address and transaction labels.
+func walletlabelcmd(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		var wlg api.WalletLabelsGET
+		err := getAPI("/wallet/labels", &wlg)
+		if err != nil {
+			die("Could not get labels:", err)
+		}
+		if len(wlg.AddressLabels) == 0 && len(wlg.TransactionLabels) == 0 {
+			fmt.Println("No labels have been assigned.")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, al := range wlg.AddressLabels {
+			fmt.Fprintf(w, "address\t%v\t%v\n", al.Address, al.Label)
+		}
+		for _, tl := range wlg.TransactionLabels {
+			fmt.Fprintf(w, "transaction\t%v\t%v\n", tl.TransactionID, tl.Label)
+		}
+		w.Flush()
+		return
+	} else if len(args) > 2 {
+		cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+
+	// Addresses include a checksum, so anything that does not parse as an
+	// address is treated as a transaction id.
+	vals := url.Values{}
+	var addr types.UnlockHash
+	if addr.LoadString(args[0]) == nil {
+		vals.Set("address", args[0])
+	} else {
+		vals.Set("transactionid", args[0])
+	}
+	if len(args) == 2 {
+		vals.Set("label", args[1])
+	}
+	if err := post("/wallet/labels", vals.Encode()); err != nil {
+		die("Could not update label:", err)
+	}
+	if len(args) == 2 {
+		fmt.Println("Label assigned.")
+	} else {
+		fmt.Println("Label removed.")
+	}
+}
+
 // walletloadseedcmd adds a seed to the wallet's list of seeds
 func walletloadseedcmd() {
 	seed, err := passwordPrompt("New seed: ")
@@ -360,8 +588,10 @@ func walletlockcmd() {
 
 // walletseedcmd returns the current seed {
 func walletseedscmd() {
+	vals := url.Values{}
+	setSendPassword(vals)
 	var seedInfo api.WalletSeedsGET
-	err := getAPI("/wallet/seeds", &seedInfo)
+	err := getAPI("/wallet/seeds?"+vals.Encode(), &seedInfo)
 	if err != nil {
 		die("Error retrieving the current seed:", err)
 	}
@@ -388,16 +618,71 @@ func walletsendsiacoinscmd(amount, dest string) {
 	if err != nil {
 		die("Could not parse amount:", err)
 	}
-	err = post("/wallet/siacoins", fmt.Sprintf("amount=%s&destination=%s", hastings, dest))
+	if walletSendFee == "" && walletSendInputs == "" && walletSendChange == "" && !walletSendDryRun && !walletSendPassword {
+		err = post("/wallet/siacoins", fmt.Sprintf("amount=%s&destination=%s", hastings, dest))
+		if err != nil {
+			die("Could not send siacoins:", err)
+		}
+		fmt.Printf("Sent %s hastings to %s\n", hastings, dest)
+		return
+	}
+
+	vals := url.Values{}
+	vals.Set("amount", hastings)
+	vals.Set("destination", dest)
+	if walletSendFee != "" {
+		fee, err := parseCurrency(walletSendFee)
+		if err != nil {
+			die("Could not parse fee:", err)
+		}
+		vals.Set("fee", fee)
+	}
+	if walletSendInputs != "" {
+		vals.Set("inputs", walletSendInputs)
+	}
+	if walletSendChange != "" {
+		vals.Set("changeaddress", walletSendChange)
+	}
+	if walletSendDryRun {
+		vals.Set("dryrun", "true")
+	}
+	setSendPassword(vals)
+	var wsp api.WalletSiacoinsPOST
+	err = postResp("/wallet/siacoins", vals.Encode(), &wsp)
 	if err != nil {
 		die("Could not send siacoins:", err)
 	}
-	fmt.Printf("Sent %s hastings to %s\n", hastings, dest)
+	if walletSendDryRun {
+		for _, txn := range wsp.Transactions {
+			js, _ := json.MarshalIndent(txn, "", "\t")
+			fmt.Println(string(js))
+		}
+		fmt.Printf("Dry run: sending %s hastings to %s would pay a fee of %s\n", hastings, dest, currencyUnits(wsp.Fee))
+		return
+	}
+	fmt.Printf("Sent %s hastings to %s with a fee of %s\n", hastings, dest, currencyUnits(wsp.Fee))
+}
+
+// setSendPassword prompts for the wallet's send password and adds it to vals
+// if the --send-password flag is set.
+func setSendPassword(vals url.Values) {
+	if !walletSendPassword {
+		return
+	}
+	password, err := passwordPrompt("Send password: ")
+	if err != nil {
+		die("Reading password failed:", err)
+	}
+	vals.Set("sendpassword", password)
 }
 
 // walletsendsiafundscmd sends siafunds to a destination address.
 func walletsendsiafundscmd(amount, dest string) {
-	err := post("/wallet/siafunds", fmt.Sprintf("amount=%s&destination=%s", amount, dest))
+	vals := url.Values{}
+	vals.Set("amount", amount)
+	vals.Set("destination", dest)
+	setSendPassword(vals)
+	err := post("/wallet/siafunds", vals.Encode())
 	if err != nil {
 		die("Could not send siafunds:", err)
 	}
@@ -449,6 +734,13 @@ Estimated Fee:       %v / KB
 `, encStatus, status.Height, currencyUnits(status.ConfirmedSiacoinBalance), delta,
 		status.ConfirmedSiacoinBalance, status.SiafundBalance, status.SiacoinClaimBalance,
 		fees.Maximum.Mul64(1e3).HumanString())
+
+	if !status.WatchOnlySiacoinBalance.IsZero() || !status.WatchOnlySiafundBalance.IsZero() {
+		fmt.Printf(`
+Watch-Only Balance:  %v
+Watch-Only Siafunds: %v SF
+`, currencyUnits(status.WatchOnlySiacoinBalance), status.WatchOnlySiafundBalance)
+	}
 }
 
 // walletsweepcmd sweeps coins and funds from a seed.
@@ -466,43 +758,105 @@ func walletsweepcmd() {
 	fmt.Printf("Swept %v and %v SF from seed.\n", currencyUnits(swept.Coins), swept.Funds)
 }
 
+// transactionFlows returns the siacoins and siafunds that pt moved into and
+// out of the wallet, and the miner fees that the wallet paid.
+func transactionFlows(pt modules.ProcessedTransaction) (incomingSiacoins, outgoingSiacoins, fees, incomingSiafunds, outgoingSiafunds types.Currency) {
+	// Determine the number of outgoing siacoins and siafunds.
+	for _, input := range pt.Inputs {
+		if input.FundType == types.SpecifierSiacoinInput && input.WalletAddress {
+			outgoingSiacoins = outgoingSiacoins.Add(input.Value)
+		}
+		if input.FundType == types.SpecifierSiafundInput && input.WalletAddress {
+			outgoingSiafunds = outgoingSiafunds.Add(input.Value)
+		}
+	}
+
+	// Determine the number of incoming siacoins and siafunds.
+	for _, output := range pt.Outputs {
+		if output.FundType == types.SpecifierMinerPayout {
+			incomingSiacoins = incomingSiacoins.Add(output.Value)
+		}
+		if output.FundType == types.SpecifierSiacoinOutput && output.WalletAddress {
+			incomingSiacoins = incomingSiacoins.Add(output.Value)
+		}
+		if output.FundType == types.SpecifierSiafundOutput && output.WalletAddress {
+			incomingSiafunds = incomingSiafunds.Add(output.Value)
+		}
+		// The wallet only pays the fees of transactions that it funds.
+		if output.FundType == types.SpecifierMinerFee && !outgoingSiacoins.IsZero() {
+			fees = fees.Add(output.Value)
+		}
+	}
+	return
+}
+
+// siacoinDecimal formats an amount of hastings as an exact decimal number of
+// siacoins, without units.
+func siacoinDecimal(hastings *big.Int) string {
+	s := new(big.Rat).SetFrac(hastings, types.SiacoinPrecision.Big()).FloatString(24)
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
+
+// netFlow returns incoming minus outgoing, which may be negative.
+func netFlow(incoming, outgoing types.Currency) *big.Int {
+	return new(big.Int).Sub(incoming.Big(), outgoing.Big())
+}
+
+// writeTransactionsCSV writes txns to filename as CSV. Values are given in
+// siacoins and siafunds along with the confirmation time so that they can be
+// converted to any currency.
+func writeTransactionsCSV(filename string, txns []api.LabeledTransaction) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Write([]string{"timestamp", "height", "transaction id", "label", "address labels",
+		"siacoins in", "siacoins out", "miner fees", "net siacoins",
+		"siafunds in", "siafunds out", "net siafunds"})
+	for _, txn := range txns {
+		scIn, scOut, fees, sfIn, sfOut := transactionFlows(txn.ProcessedTransaction)
+		var timestamp, height string
+		if uint64(txn.ConfirmationTimestamp) != unconfirmedTransactionTimestamp {
+			timestamp = time.Unix(int64(txn.ConfirmationTimestamp), 0).UTC().Format(time.RFC3339)
+			height = fmt.Sprint(txn.ConfirmationHeight)
+		}
+		var addrLabels []string
+		for _, al := range txn.AddressLabels {
+			addrLabels = append(addrLabels, al.Label)
+		}
+		w.Write([]string{timestamp, height, txn.TransactionID.String(), txn.Label, strings.Join(addrLabels, "; "),
+			siacoinDecimal(scIn.Big()), siacoinDecimal(scOut.Big()), siacoinDecimal(fees.Big()), siacoinDecimal(netFlow(scIn, scOut)),
+			sfIn.String(), sfOut.String(), netFlow(sfIn, sfOut).String()})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
 // wallettransactionscmd lists all of the transactions related to the wallet,
 // providing a net flow of siacoins and siafunds for each.
 func wallettransactionscmd() {
 	wtg := new(api.WalletTransactionsGET)
-	err := getAPI("/wallet/transactions?startheight=0&endheight=10000000", wtg)
+	err := getAPI("/wallet/transactions?startheight=0&endheight=10000000&label="+url.QueryEscape(walletTransactionsLabel), wtg)
 	if err != nil {
 		die("Could not fetch transaction history:", err)
 	}
-	fmt.Println("             [timestamp]    [height]                                                   [transaction id]    [net siacoins]   [net siafunds]")
 	txns := append(wtg.ConfirmedTransactions, wtg.UnconfirmedTransactions...)
-	for _, txn := range txns {
-		// Determine the number of outgoing siacoins and siafunds.
-		var outgoingSiacoins types.Currency
-		var outgoingSiafunds types.Currency
-		for _, input := range txn.Inputs {
-			if input.FundType == types.SpecifierSiacoinInput && input.WalletAddress {
-				outgoingSiacoins = outgoingSiacoins.Add(input.Value)
-			}
-			if input.FundType == types.SpecifierSiafundInput && input.WalletAddress {
-				outgoingSiafunds = outgoingSiafunds.Add(input.Value)
-			}
+	if walletTransactionsCSV != "" {
+		if err := writeTransactionsCSV(walletTransactionsCSV, txns); err != nil {
+			die("Could not export transactions:", err)
 		}
+		fmt.Printf("Exported %v transactions to %v.\n", len(txns), walletTransactionsCSV)
+		return
+	}
 
-		// Determine the number of incoming siacoins and siafunds.
-		var incomingSiacoins types.Currency
-		var incomingSiafunds types.Currency
-		for _, output := range txn.Outputs {
-			if output.FundType == types.SpecifierMinerPayout {
-				incomingSiacoins = incomingSiacoins.Add(output.Value)
-			}
-			if output.FundType == types.SpecifierSiacoinOutput && output.WalletAddress {
-				incomingSiacoins = incomingSiacoins.Add(output.Value)
-			}
-			if output.FundType == types.SpecifierSiafundOutput && output.WalletAddress {
-				incomingSiafunds = incomingSiafunds.Add(output.Value)
-			}
-		}
+	fmt.Println("             [timestamp]    [height]                                                   [transaction id]    [net siacoins]   [net siafunds]   [label]")
+	for _, txn := range txns {
+		incomingSiacoins, outgoingSiacoins, _, incomingSiafunds, outgoingSiafunds := transactionFlows(txn.ProcessedTransaction)
 
 		// Convert the siacoins to a float.
 		incomingSiacoinsFloat, _ := new(big.Rat).SetFrac(incomingSiacoins.Big(), types.SiacoinPrecision.Big()).Float64()
@@ -522,10 +876,11 @@ func wallettransactionscmd() {
 		fmt.Printf("%67v%15.2f SC", txn.TransactionID, incomingSiacoinsFloat-outgoingSiacoinsFloat)
 		// For siafunds, need to avoid having a negative types.Currency.
 		if incomingSiafunds.Cmp(outgoingSiafunds) >= 0 {
-			fmt.Printf("%14v SF\n", incomingSiafunds.Sub(outgoingSiafunds))
+			fmt.Printf("%14v SF", incomingSiafunds.Sub(outgoingSiafunds))
 		} else {
-			fmt.Printf("-%14v SF\n", outgoingSiafunds.Sub(incomingSiafunds))
+			fmt.Printf("-%14v SF", outgoingSiafunds.Sub(incomingSiafunds))
 		}
+		fmt.Printf("   %v\n", txn.Label)
 	}
 }
 
@@ -555,3 +910,474 @@ func walletunlockcmd() {
 		die("Could not unlock wallet:", err)
 	}
 }
+
+// walletwatchcmd adds or removes watch-only addresses, or lists them if no
+// addresses are supplied.
+func walletwatchcmd(cmd *cobra.Command, args []string) {
+	if walletWatchPubkeys != "" {
+		var ucs []types.UnlockConditions
+		for _, pkStr := range strings.Split(walletWatchPubkeys, ",") {
+			var pk types.SiaPublicKey
+			pk.LoadString(pkStr)
+			if len(pk.Key) == 0 {
+				die("Could not parse public key:", pkStr)
+			}
+			ucs = append(ucs, types.UnlockConditions{
+				PublicKeys:         []types.SiaPublicKey{pk},
+				SignaturesRequired: 1,
+			})
+		}
+		ucBytes, err := json.Marshal(ucs)
+		if err != nil {
+			die("Could not encode unlock conditions:", err)
+		}
+		vals := url.Values{}
+		vals.Set("addresses", strings.Join(args, ","))
+		vals.Set("unlockconditions", string(ucBytes))
+		vals.Set("remove", fmt.Sprint(walletWatchRemove))
+		vals.Set("unused", fmt.Sprint(walletWatchUnused))
+		if err := post("/wallet/watch", vals.Encode()); err != nil {
+			die("Could not update watched addresses:", err)
+		}
+		for _, uc := range ucs {
+			if walletWatchRemove {
+				fmt.Println("Stopped watching", uc.UnlockHash())
+			} else {
+				fmt.Println("Now watching", uc.UnlockHash())
+			}
+		}
+		if len(args) > 0 {
+			fmt.Println("Updated", len(args), "other address(es).")
+		}
+		return
+	}
+	if len(args) == 0 {
+		var wwg api.WalletWatchGET
+		err := getAPI("/wallet/watch", &wwg)
+		if err != nil {
+			die("Could not get watched addresses:", err)
+		}
+		if len(wwg.Addresses) == 0 {
+			fmt.Println("No addresses are being watched.")
+			return
+		}
+		for _, addr := range wwg.Addresses {
+			fmt.Println(addr)
+		}
+		return
+	}
+
+	err := post("/wallet/watch", fmt.Sprintf("addresses=%s&remove=%t&unused=%t",
+		strings.Join(args, ","), walletWatchRemove, walletWatchUnused))
+	if err != nil {
+		die("Could not update watched addresses:", err)
+	}
+	if walletWatchRemove {
+		fmt.Println("Stopped watching", len(args), "address(es).")
+	} else {
+		fmt.Println("Now watching", len(args), "address(es).")
+	}
+}
+
+// walletfundwatchedcmd builds an unsigned transaction funded from watch-only
+// addresses.
+func walletfundwatchedcmd(amount, dest string) {
+	hastings, err := parseCurrency(amount)
+	if err != nil {
+		die("Could not parse amount:", err)
+	}
+	vals := url.Values{}
+	vals.Set("amount", hastings)
+	vals.Set("destination", dest)
+	if walletSendFee != "" {
+		fee, err := parseCurrency(walletSendFee)
+		if err != nil {
+			die("Could not parse fee:", err)
+		}
+		vals.Set("fee", fee)
+	}
+	if walletSendChange != "" {
+		vals.Set("changeaddress", walletSendChange)
+	}
+	var wwfp api.WalletWatchFundPOST
+	err = postResp("/wallet/watch/fund", vals.Encode(), &wwfp)
+	if err != nil {
+		die("Could not build transaction:", err)
+	}
+	txnBytes, err := json.MarshalIndent(wwfp.Transaction, "", "  ")
+	if err != nil {
+		die("Could not encode transaction:", err)
+	}
+	fmt.Println(string(txnBytes))
+}
+
+// walletsigncmd signs a transaction, either with the keys of an unlocked
+// wallet or offline with a seed.
+func walletsigncmd(txnfile string) {
+	txnBytes, err := ioutil.ReadFile(txnfile)
+	if err != nil {
+		die("Could not read transaction:", err)
+	}
+	var txn types.Transaction
+	if err := json.Unmarshal(txnBytes, &txn); err != nil {
+		die("Could not decode transaction:", err)
+	}
+	var toSign []crypto.Hash
+	if walletSignToSign != "" {
+		for _, idStr := range strings.Split(walletSignToSign, ",") {
+			var id crypto.Hash
+			if err := id.LoadString(idStr); err != nil {
+				die("Could not parse input id:", err)
+			}
+			toSign = append(toSign, id)
+		}
+	}
+
+	// Use the daemon's wallet if it is available and unlocked. Otherwise,
+	// sign offline using a seed.
+	var status api.WalletGET
+	if err := getAPI("/wallet", &status); err == nil && status.Unlocked {
+		vals := url.Values{}
+		vals.Set("transaction", string(txnBytes))
+		vals.Set("tosign", walletSignToSign)
+		setSendPassword(vals)
+		var wsp api.WalletSignPOST
+		if err := postResp("/wallet/sign", vals.Encode(), &wsp); err != nil {
+			die("Could not sign transaction:", err)
+		}
+		txn = wsp.Transaction
+	} else {
+		seedStr, err := passwordPrompt("Seed: ")
+		if err != nil {
+			die("Reading seed failed:", err)
+		}
+		seed, err := modules.StringToSeed(seedStr, mnemonics.English)
+		if err != nil {
+			die("Invalid seed:", err)
+		}
+		if err := wallet.SignTransaction(&txn, seed, toSign); err != nil {
+			die("Could not sign transaction:", err)
+		}
+	}
+
+	// Report the inputs that still need signatures from other cosigners.
+	for _, sci := range txn.SiacoinInputs {
+		reportSignatures(txn, crypto.Hash(sci.ParentID), sci.UnlockConditions)
+	}
+	for _, sfi := range txn.SiafundInputs {
+		reportSignatures(txn, crypto.Hash(sfi.ParentID), sfi.UnlockConditions)
+	}
+
+	if walletSignRaw {
+		fmt.Println(base64.StdEncoding.EncodeToString(encoding.Marshal(txn)))
+		return
+	}
+	signed, err := json.MarshalIndent(txn, "", "  ")
+	if err != nil {
+		die("Could not encode transaction:", err)
+	}
+	fmt.Println(string(signed))
+}
+
+// walletmultisigcmd creates a multisig address, or lists the tracked multisig
+// addresses if no arguments are supplied.
+func walletmultisigcmd(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		var wmg api.WalletMultisigGET
+		err := getAPI("/wallet/multisig", &wmg)
+		if err != nil {
+			die("Could not get multisig addresses:", err)
+		}
+		if len(wmg.Addresses) == 0 {
+			fmt.Println("No multisig addresses.")
+			return
+		}
+		for _, ma := range wmg.Addresses {
+			fmt.Printf("%v (%v of %v)\n", ma.Address, ma.UnlockConditions.SignaturesRequired, len(ma.UnlockConditions.PublicKeys))
+		}
+		return
+	}
+	if len(args) < 3 {
+		cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+
+	vals := url.Values{}
+	vals.Set("required", args[0])
+	vals.Set("publickeys", strings.Join(args[1:], ","))
+	vals.Set("unused", fmt.Sprint(walletWatchUnused))
+	var wmp api.WalletMultisigPOST
+	err := postResp("/wallet/multisig", vals.Encode(), &wmp)
+	if err != nil {
+		die("Could not create multisig address:", err)
+	}
+	ucBytes, err := json.MarshalIndent(wmp.UnlockConditions, "", "  ")
+	if err != nil {
+		die("Could not encode unlock conditions:", err)
+	}
+	fmt.Printf("Created multisig address %v\nUnlock conditions:\n%s\n", wmp.Address, ucBytes)
+}
+
+// walletpubkeycmd prints the public key of a new wallet address.
+func walletpubkeycmd() {
+	var wag api.WalletAddressGET
+	err := getAPI("/wallet/address", &wag)
+	if err != nil {
+		die("Could not generate new address:", err)
+	}
+	var wucg api.WalletUnlockConditionsGET
+	err = getAPI("/wallet/unlockconditions/"+wag.Address.String(), &wucg)
+	if err != nil {
+		die("Could not get unlock conditions:", err)
+	}
+	for _, pk := range wucg.UnlockConditions.PublicKeys {
+		fmt.Println(pk.String())
+	}
+}
+
+// reportSignatures prints a note to stderr if the input with the given parent
+// id does not yet have enough signatures.
+func reportSignatures(txn types.Transaction, parentID crypto.Hash, uc types.UnlockConditions) {
+	var signed uint64
+	for _, sig := range txn.TransactionSignatures {
+		if sig.ParentID == parentID && len(sig.Signature) > 0 {
+			signed++
+		}
+	}
+	if signed < uc.SignaturesRequired {
+		fmt.Fprintf(os.Stderr, "Input %v has %v of %v required signatures; pass the transaction to the next cosigner.\n", parentID, signed, uc.SignaturesRequired)
+	}
+}
+
+// walletbumpfeecmd raises the fee of an unconfirmed transaction.
+func walletbumpfeecmd(txid, amount string) {
+	fee, err := parseCurrency(amount)
+	if err != nil {
+		die("Could not parse fee:", err)
+	}
+	vals := url.Values{}
+	vals.Set("transactionid", txid)
+	vals.Set("fee", fee)
+	setSendPassword(vals)
+	var wbp api.WalletBumpFeePOST
+	err = postResp("/wallet/bumpfee", vals.Encode(), &wbp)
+	if err != nil {
+		die("Could not raise fee:", err)
+	}
+	for _, id := range wbp.TransactionIDs {
+		fmt.Println("Submitted transaction", id)
+	}
+}
+
+// walletconsolidatecmd merges the wallet's smallest outputs into one.
+func walletconsolidatecmd() {
+	vals := url.Values{}
+	if walletConsolidateMaxInputs > 0 {
+		vals.Set("maxinputs", fmt.Sprint(walletConsolidateMaxInputs))
+	}
+	vals.Set("dryrun", fmt.Sprint(walletSendDryRun))
+	var wcp api.WalletConsolidatePOST
+	err := postResp("/wallet/consolidate", vals.Encode(), &wcp)
+	if err != nil {
+		die("Could not consolidate outputs:", err)
+	}
+	if walletSendDryRun {
+		for _, txn := range wcp.Transactions {
+			js, _ := json.MarshalIndent(txn, "", "\t")
+			fmt.Println(string(js))
+		}
+		fmt.Printf("Dry run: consolidating would pay a fee of %s\n", currencyUnits(wcp.Fee))
+		return
+	}
+	for _, txid := range wcp.TransactionIDs {
+		fmt.Println("Submitted consolidation transaction", txid)
+	}
+	fmt.Printf("Paid a fee of %s\n", currencyUnits(wcp.Fee))
+}
+
+// walletfreezecmd freezes the supplied outputs.
+func walletfreezecmd(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	err := post("/wallet/freeze", "outputids="+strings.Join(args, ","))
+	if err != nil {
+		die("Could not freeze outputs:", err)
+	}
+	fmt.Println("Froze", len(args), "output(s).")
+}
+
+// walletunfreezecmd unfreezes the supplied outputs.
+func walletunfreezecmd(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	err := post("/wallet/freeze", "remove=true&outputids="+strings.Join(args, ","))
+	if err != nil {
+		die("Could not unfreeze outputs:", err)
+	}
+	fmt.Println("Unfroze", len(args), "output(s).")
+}
+
+// walletpolicycmd displays the wallet's spending policy, or changes it if any
+// of the policy flags are supplied.
+func walletpolicycmd(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	vals := url.Values{}
+	if cmd.Flags().Changed("transaction-limit") {
+		limit, err := parseCurrency(walletPolicyTransactionLimit)
+		if err != nil {
+			die("Could not parse transaction limit:", err)
+		}
+		vals.Set("transactionlimit", limit)
+	}
+	if cmd.Flags().Changed("daily-limit") {
+		limit, err := parseCurrency(walletPolicyDailyLimit)
+		if err != nil {
+			die("Could not parse daily limit:", err)
+		}
+		vals.Set("dailylimit", limit)
+	}
+	if cmd.Flags().Changed("allowlist") {
+		vals.Set("allowlist", walletPolicyAllowlist)
+	}
+	if walletPolicyNewSendPassword {
+		password, err := passwordPrompt("New send password: ")
+		if err != nil {
+			die("Reading password failed:", err)
+		}
+		if err := confirmPassword(password); err != nil {
+			die(err)
+		}
+		vals.Set("newsendpassword", password)
+	}
+
+	if len(vals) == 0 {
+		var wpg api.WalletPolicyGET
+		err := getAPI("/wallet/policy", &wpg)
+		if err != nil {
+			die("Could not get spending policy:", err)
+		}
+		limitString := func(c types.Currency) string {
+			if c.IsZero() {
+				return "none"
+			}
+			return currencyUnits(c)
+		}
+		fmt.Printf("Transaction limit: %v\n", limitString(wpg.TransactionLimit))
+		fmt.Printf("Daily limit:       %v (%v sent in the last 24 hours)\n", limitString(wpg.DailyLimit), currencyUnits(wpg.DailySpent))
+		if len(wpg.Allowlist) == 0 {
+			fmt.Println("Allowlist:         any address")
+		} else {
+			fmt.Println("Allowlist:")
+			for _, addr := range wpg.Allowlist {
+				fmt.Println("\t" + addr.String())
+			}
+		}
+		fmt.Printf("Send password:     %v\n", yesNo(wpg.SendPassword))
+		return
+	}
+
+	setSendPassword(vals)
+	err := post("/wallet/policy", vals.Encode())
+	if err != nil {
+		die("Could not change spending policy:", err)
+	}
+	fmt.Println("Spending policy updated.")
+}
+
+// walletunspentcmd lists the unspent outputs of the wallet.
+func walletunspentcmd() {
+	var wug api.WalletUnspentGET
+	err := getAPI("/wallet/unspent", &wug)
+	if err != nil {
+		die("Could not get unspent outputs:", err)
+	}
+	if len(wug.Outputs) == 0 {
+		fmt.Println("The wallet has no unspent outputs.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tType\tValue\tHeight\tStatus")
+	for _, uo := range wug.Outputs {
+		value := currencyUnits(uo.Value)
+		fundType := "SC"
+		if uo.FundType == types.SpecifierSiafundOutput {
+			value = uo.Value.String() + " SF"
+			fundType = "SF"
+		}
+		var status string
+		switch {
+		case uo.WatchOnly:
+			status = "watch-only"
+		case uo.Frozen:
+			status = "frozen"
+		case uo.Pending:
+			status = "pending"
+		case uo.Dust:
+			status = "dust"
+		case uo.Spendable:
+			status = "spendable"
+		default:
+			status = "locked"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", uo.ID, fundType, value, uo.ConfirmationHeight, status)
+	}
+	w.Flush()
+}
+
+// walletbackupcmd exports an encrypted backup of the wallet.
+func walletbackupcmd(destination string) {
+	destination, err := filepath.Abs(destination)
+	if err != nil {
+		die("Could not resolve destination:", err)
+	}
+	password, err := passwordPrompt("Backup password: ")
+	if err != nil {
+		die("Reading password failed:", err)
+	} else if password == "" {
+		die("Backup password cannot be blank")
+	}
+	if err := confirmPassword(password); err != nil {
+		die(err)
+	}
+	vals := url.Values{}
+	vals.Set("destination", destination)
+	vals.Set("backuppassword", password)
+	setSendPassword(vals)
+	err = post("/wallet/backup", vals.Encode())
+	if err != nil {
+		die("Could not create backup:", err)
+	}
+	fmt.Println("Backup written to", destination)
+}
+
+// walletrestorecmd restores an encrypted backup of the wallet.
+func walletrestorecmd(source string) {
+	source, err := filepath.Abs(source)
+	if err != nil {
+		die("Could not resolve source:", err)
+	}
+	backupPassword, err := passwordPrompt("Backup password: ")
+	if err != nil {
+		die("Reading password failed:", err)
+	}
+	password, err := passwordPrompt("Wallet password: ")
+	if err != nil {
+		die("Reading password failed:", err)
+	}
+	vals := url.Values{}
+	vals.Set("source", source)
+	vals.Set("backuppassword", backupPassword)
+	vals.Set("encryptionpassword", password)
+	err = post("/wallet/backup/restore", vals.Encode())
+	if err != nil {
+		die("Could not restore backup:", err)
+	}
+	fmt.Println("Backup restored")
+}
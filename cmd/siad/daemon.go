@@ -52,6 +52,46 @@ func verifyAPISecurity(config Config) error {
 	return nil
 }
 
+// verifySPVModules checks that no module that needs full blocks is loaded
+// alongside a consensus set in SPV mode. An SPV consensus set only follows
+// block headers, so the other modules would never see the transactions that
+// are relevant to them.
+func verifySPVModules(config Config) error {
+	if !config.Siad.SPV {
+		return nil
+	}
+	unsupported := strings.Map(func(m rune) rune {
+		if m == 'g' || m == 'c' {
+			return -1
+		}
+		return m
+	}, config.Siad.Modules)
+	if unsupported != "" {
+		return errors.New("--spv can only be used with the gateway and consensus modules, but --modules also includes " + unsupported)
+	}
+	return nil
+}
+
+// verifySnapshotModules checks that no module that subscribes to consensus
+// changes from the beginning is loaded alongside a consensus set bootstrapped
+// from a snapshot. The snapshot only contains the current state, so those
+// modules would miss the outputs and contracts created below it.
+func verifySnapshotModules(config Config) error {
+	if config.Siad.Snapshot == "" {
+		return nil
+	}
+	unsupported := strings.Map(func(m rune) rune {
+		if m == 'g' || m == 'c' {
+			return -1
+		}
+		return m
+	}, config.Siad.Modules)
+	if unsupported != "" {
+		return errors.New("--snapshot can only be used with the gateway and consensus modules, but --modules also includes " + unsupported)
+	}
+	return nil
+}
+
 // processNetAddr adds a ':' to a bare integer, so that it is a proper port
 // number.
 func processNetAddr(addr string) string {
@@ -103,7 +143,9 @@ func processConfig(config Config) (Config, error) {
 	config.Siad.Modules, err1 = processModules(config.Siad.Modules)
 	config.Siad.Profile, err2 = processProfileFlags(config.Siad.Profile)
 	err3 := verifyAPISecurity(config)
-	err := build.JoinErrors([]error{err1, err2, err3}, ", and ")
+	err4 := verifySPVModules(config)
+	err5 := verifySnapshotModules(config)
+	err := build.JoinErrors([]error{err1, err2, err3, err4, err5}, ", and ")
 	if err != nil {
 		return Config{}, err
 	}
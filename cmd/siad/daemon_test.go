@@ -200,3 +200,60 @@ func TestVerifyAPISecurity(t *testing.T) {
 		t.Error("public + securityOff with authentication was rejected:", err)
 	}
 }
+
+// TestVerifySPVModules checks that --spv is only accepted when no module that
+// needs full blocks is loaded.
+func TestVerifySPVModules(t *testing.T) {
+	tests := []struct {
+		modules string
+		spv     bool
+		valid   bool
+	}{
+		{"cghrtw", false, true},
+		{"gc", true, true},
+		{"cg", true, true},
+		{"gcw", true, false},
+		{"gcr", true, false},
+		{"gch", true, false},
+		{"gctm", true, false},
+	}
+	for _, test := range tests {
+		var config Config
+		config.Siad.Modules = test.modules
+		config.Siad.SPV = test.spv
+		err := verifySPVModules(config)
+		if test.valid && err != nil {
+			t.Errorf("modules %q with spv=%v were rejected: %v", test.modules, test.spv, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("modules %q with spv=%v were accepted", test.modules, test.spv)
+		}
+	}
+}
+
+// TestVerifySnapshotModules checks that --snapshot is only accepted when no
+// module that subscribes to consensus changes from the beginning is loaded.
+func TestVerifySnapshotModules(t *testing.T) {
+	tests := []struct {
+		modules  string
+		snapshot string
+		valid    bool
+	}{
+		{"cghrtw", "", true},
+		{"gc", "consensus.snapshot", true},
+		{"cg", "consensus.snapshot", true},
+		{"gcw", "consensus.snapshot", false},
+		{"gct", "consensus.snapshot", false},
+		{"gce", "consensus.snapshot", false},
+	}
+	for _, test := range tests {
+		var config Config
+		config.Siad.Modules = test.modules
+		config.Siad.Snapshot = test.snapshot
+		err := verifySnapshotModules(config)
+		if test.valid && err != nil {
+			t.Errorf("modules %q with snapshot %q were rejected: %v", test.modules, test.snapshot, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("modules %q with snapshot %q were accepted", test.modules, test.snapshot)
+		}
+	}
+}
@@ -40,6 +40,10 @@ type Config struct {
 		Modules           string
 		NoBootstrap       bool
 		RequiredUserAgent string
+		SPV               bool
+		Snapshot          string
+		SnapshotAnchor    string
+		SnapshotChecksum  string
 		AuthenticateAPI   bool
 
 		Profile    string
@@ -163,6 +167,10 @@ func main() {
 	root.Flags().StringVarP(&globalConfig.Siad.APIaddr, "api-addr", "", "localhost:9980", "which host:port the API server listens on")
 	root.Flags().StringVarP(&globalConfig.Siad.SiaDir, "sia-directory", "d", "", "location of the sia directory")
 	root.Flags().BoolVarP(&globalConfig.Siad.NoBootstrap, "no-bootstrap", "", false, "disable bootstrapping on this run")
+	root.Flags().BoolVarP(&globalConfig.Siad.SPV, "spv", "", false, "only sync block headers, fetching full blocks on demand (requires -M gc)")
+	root.Flags().StringVarP(&globalConfig.Siad.Snapshot, "snapshot", "", "", "bootstrap a new consensus database from this snapshot file (gateway and consensus modules only)")
+	root.Flags().StringVarP(&globalConfig.Siad.SnapshotAnchor, "snapshot-anchor", "", "", "id of the trusted block that the snapshot must end at")
+	root.Flags().StringVarP(&globalConfig.Siad.SnapshotChecksum, "snapshot-checksum", "", "", "trusted checksum that the snapshot must match")
 	root.Flags().StringVarP(&globalConfig.Siad.Profile, "profile", "", "", "enable profiling with flags 'cmt' for CPU, memory, trace")
 	root.Flags().StringVarP(&globalConfig.Siad.RPCaddr, "rpc-addr", "", ":9981", "which port the gateway listens on")
 	root.Flags().StringVarP(&globalConfig.Siad.Modules, "modules", "M", "cghrtw", "enabled modules, see 'siad modules' for more info")
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/modules/consensus"
 	"github.com/NebulousLabs/Sia/modules/explorer"
@@ -468,6 +469,35 @@ func isAddrInUseErr(err error) bool {
 	return false
 }
 
+// importConsensusSnapshot seeds a new consensus database from a snapshot file.
+// If a consensus database already exists, the snapshot is ignored so that the
+// same flags can be used on later runs.
+func importConsensusSnapshot(filename, anchor, checksum, consensusDir string) error {
+	var anchorID types.BlockID
+	if err := anchorID.LoadString(anchor); err != nil {
+		return errors.New("--snapshot requires a valid --snapshot-anchor block id: " + err.Error())
+	}
+	var trustedChecksum crypto.Hash
+	if err := trustedChecksum.LoadString(checksum); err != nil {
+		return errors.New("--snapshot requires a valid --snapshot-checksum: " + err.Error())
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fmt.Println("Importing consensus snapshot...")
+	height, err := consensus.ImportSnapshot(f, anchorID, trustedChecksum, consensusDir)
+	if err == consensus.ErrSnapshotExistingDB {
+		fmt.Println("Consensus database already exists, ignoring snapshot.")
+		return nil
+	} else if err != nil {
+		return errors.New("unable to import consensus snapshot: " + err.Error())
+	}
+	fmt.Printf("Imported consensus snapshot at height %v.\n", height)
+	return nil
+}
+
 // loadModules loads the modules defined by the server's config and makes their
 // API routes available.
 func (srv *Server) loadModules() error {
@@ -491,7 +521,18 @@ func (srv *Server) loadModules() error {
 	if strings.Contains(srv.config.Siad.Modules, "c") {
 		i++
 		fmt.Printf("(%d/%d) Loading consensus...\n", i, len(srv.config.Siad.Modules))
-		cs, err = consensus.New(g, !srv.config.Siad.NoBootstrap, filepath.Join(srv.config.Siad.SiaDir, modules.ConsensusDir))
+		consensusDir := filepath.Join(srv.config.Siad.SiaDir, modules.ConsensusDir)
+		if srv.config.Siad.Snapshot != "" {
+			err = importConsensusSnapshot(srv.config.Siad.Snapshot, srv.config.Siad.SnapshotAnchor, srv.config.Siad.SnapshotChecksum, consensusDir)
+			if err != nil {
+				return err
+			}
+		}
+		newConsensus := consensus.New
+		if srv.config.Siad.SPV {
+			newConsensus = consensus.NewSPV
+		}
+		cs, err = newConsensus(g, !srv.config.Siad.NoBootstrap, consensusDir)
 		if err != nil {
 			return err
 		}
@@ -2,6 +2,7 @@ package modules
 
 import (
 	"errors"
+	"io"
 
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/types"
@@ -120,6 +121,11 @@ type (
 		// the consensus set.
 		MinimumValidChildTimestamp types.Timestamp
 
+		// BlockHeight is the height of the block most recently appended to the
+		// consensus set by the change. It is not set for the changes returned
+		// by TryTransactionSet.
+		BlockHeight types.BlockHeight
+
 		// Synced indicates whether or not the ConsensusSet is synced with its
 		// peers.
 		Synced bool
@@ -214,13 +220,35 @@ type (
 		// blockchain.
 		CurrentBlock() types.Block
 
+		// FetchBlock returns the block with the given id. Consensus sets in
+		// SPV mode download blocks that are not stored locally from their
+		// peers, verifying them against the header chain.
+		FetchBlock(types.BlockID) (types.Block, error)
+
 		// Flush will cause the consensus set to finish all in-progress
 		// routines.
 		Flush() error
 
+		// HeaderAtHeight returns the header found at the input height of the
+		// heaviest known header chain, with a bool to indicate whether that
+		// header exists.
+		HeaderAtHeight(types.BlockHeight) (types.BlockHeader, bool)
+
+		// HeaderHeight returns the height of the heaviest known header chain.
+		HeaderHeight() types.BlockHeight
+
 		// Height returns the current height of consensus.
 		Height() types.BlockHeight
 
+		// Snapshot writes a snapshot of the consensus state at the current
+		// height. A new node can be bootstrapped from the snapshot instead of
+		// replaying the blockchain.
+		Snapshot(io.Writer) error
+
+		// SPV returns true if the consensus set only synchronizes block
+		// headers, fetching full blocks on demand.
+		SPV() bool
+
 		// Synced returns true if the consensus set is synced with the network.
 		Synced() bool
 
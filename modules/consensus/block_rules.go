@@ -41,6 +41,14 @@ func (rh stdBlockRuleHelper) minimumValidChildTimestamp(blockMap dbBucket, pb *p
 		// parent lies at the first 32 bytes, and the timestamp of the block
 		// lies at bytes 40-48.
 		parentBytes := blockMap.Get(parent[:])
+		if parentBytes == nil {
+			// Consensus sets bootstrapped from a snapshot do not have the
+			// blocks below the snapshot. Treat the earliest known block like
+			// the genesis block.
+			windowTimes[i] = windowTimes[i-1]
+			parent = types.BlockID{}
+			continue
+		}
 		copy(parent[:], parentBytes[:32])
 		windowTimes[i] = types.Timestamp(encoding.DecUint64(parentBytes[40:48]))
 	}
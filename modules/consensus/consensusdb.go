@@ -75,6 +75,11 @@ var (
 	// FieldOakInit is a field in BucketOak that gets set to "true" after the
 	// oak initialiation process has completed.
 	FieldOakInit = []byte("OakInit")
+
+	// FieldSnapshotHeight is a field in BlockHeight that is set when the
+	// consensus set was bootstrapped from a snapshot. It contains the height
+	// of the earliest block that was imported after the genesis block.
+	FieldSnapshotHeight = []byte("SnapshotHeight")
 )
 
 var (
@@ -81,6 +81,11 @@ type ConsensusSet struct {
 	// whether the consensus set is synced with the network.
 	synced bool
 
+	// spv is true if the consensus set is running in SPV mode. In SPV mode,
+	// only the header chain is synchronized with the network, and full blocks
+	// are fetched on demand.
+	spv bool
+
 	// Interfaces to abstract the dependencies of the ConsensusSet.
 	marshaler       marshaler
 	blockRuleHelper blockRuleHelper
@@ -98,6 +103,21 @@ type ConsensusSet struct {
 // there is an existing block database present in the persist directory, it
 // will be loaded.
 func New(gateway modules.Gateway, bootstrap bool, persistDir string) (*ConsensusSet, error) {
+	return newConsensusSet(gateway, bootstrap, persistDir, false)
+}
+
+// NewSPV returns a new ConsensusSet running in SPV mode. Instead of
+// downloading and validating every block, an SPV consensus set synchronizes
+// the chain of block headers, checking the work of each header against the
+// difficulty adjustment rules. Full blocks can be requested with FetchBlock.
+// Subscribers are only sent the genesis block, so an SPV consensus set cannot
+// back the modules that track transactions, such as the wallet or the renter.
+func NewSPV(gateway modules.Gateway, bootstrap bool, persistDir string) (*ConsensusSet, error) {
+	return newConsensusSet(gateway, bootstrap, persistDir, true)
+}
+
+// newConsensusSet creates a ConsensusSet, optionally running in SPV mode.
+func newConsensusSet(gateway modules.Gateway, bootstrap bool, persistDir string, spv bool) (*ConsensusSet, error) {
 	// Check for nil dependencies.
 	if gateway == nil {
 		return nil, errNilGateway
@@ -116,6 +136,7 @@ func New(gateway modules.Gateway, bootstrap bool, persistDir string) (*Consensus
 		},
 
 		dosBlocks: make(map[types.BlockID]struct{}),
+		spv:       spv,
 
 		marshaler:       stdMarshaler{},
 		blockRuleHelper: stdBlockRuleHelper{},
@@ -163,17 +184,34 @@ func New(gateway modules.Gateway, bootstrap bool, persistDir string) (*Consensus
 		}
 		defer cs.tg.Done()
 
-		// Register RPCs
-		gateway.RegisterRPC("SendBlocks", cs.rpcSendBlocks)
+		// Register RPCs. Consensus sets in SPV mode do not have any blocks to
+		// share, so they only take part in header synchronization and refuse
+		// requests for blocks.
+		gateway.RegisterRPC("SendHeaders", cs.rpcSendHeaders)
 		gateway.RegisterRPC("RelayHeader", cs.threadedRPCRelayHeader)
-		gateway.RegisterRPC("SendBlk", cs.rpcSendBlk)
-		gateway.RegisterConnectCall("SendBlocks", cs.threadedReceiveBlocks)
-		cs.tg.OnStop(func() {
-			cs.gateway.UnregisterRPC("SendBlocks")
-			cs.gateway.UnregisterRPC("RelayHeader")
-			cs.gateway.UnregisterRPC("SendBlk")
-			cs.gateway.UnregisterConnectCall("SendBlocks")
-		})
+		if cs.spv {
+			gateway.RegisterRPC("SendBlocks", cs.rpcSPVNoBlocks)
+			gateway.RegisterRPC("SendBlk", cs.rpcSPVNoBlocks)
+			gateway.RegisterConnectCall("SendHeaders", cs.threadedReceiveHeaders)
+			cs.tg.OnStop(func() {
+				cs.gateway.UnregisterRPC("SendHeaders")
+				cs.gateway.UnregisterRPC("SendBlocks")
+				cs.gateway.UnregisterRPC("RelayHeader")
+				cs.gateway.UnregisterRPC("SendBlk")
+				cs.gateway.UnregisterConnectCall("SendHeaders")
+			})
+		} else {
+			gateway.RegisterRPC("SendBlocks", cs.rpcSendBlocks)
+			gateway.RegisterRPC("SendBlk", cs.rpcSendBlk)
+			gateway.RegisterConnectCall("SendBlocks", cs.threadedReceiveBlocks)
+			cs.tg.OnStop(func() {
+				cs.gateway.UnregisterRPC("SendHeaders")
+				cs.gateway.UnregisterRPC("SendBlocks")
+				cs.gateway.UnregisterRPC("RelayHeader")
+				cs.gateway.UnregisterRPC("SendBlk")
+				cs.gateway.UnregisterConnectCall("SendBlocks")
+			})
+		}
 
 		// Mark that we are synced with the network.
 		cs.mu.Lock()
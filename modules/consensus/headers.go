@@ -0,0 +1,501 @@
+package consensus
+
+// headers.go implements the header chain that is used by consensus sets
+// running in SPV mode. An SPV consensus set does not download or validate full
+// blocks during synchronization. Instead, it downloads the chain of block
+// headers and checks that every header carries enough work according to the
+// same difficulty adjustment rules that full nodes use. Full blocks are only
+// fetched on demand, and are checked against the Merkle root committed to in
+// the header chain.
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+var (
+	errBadMerkleRoot   = errors.New("block does not match the Merkle root of its header")
+	errNoPeerHasBlock  = errors.New("unable to fetch block from any peer")
+	errSPVNoBlocks     = errors.New("consensus set is running in SPV mode and does not serve full blocks")
+	errUnknownHeader   = errors.New("block header is not in the header chain")
+	errWrongFetchBlock = errors.New("peer returned a different block than the one requested")
+)
+
+var (
+	// HeaderHeight is a bucket that stores the current height of the header
+	// chain. It is only used by consensus sets in SPV mode.
+	HeaderHeight = []byte("HeaderHeight")
+
+	// HeaderMap is a database bucket containing all of the processed headers,
+	// keyed by their id. It is only used by consensus sets in SPV mode.
+	HeaderMap = []byte("HeaderMap")
+
+	// HeaderPath is a database bucket containing a mapping from the height of
+	// a header to the id of the header at that height. HeaderPath only
+	// includes headers in the heaviest known header chain.
+	HeaderPath = []byte("HeaderPath")
+)
+
+// processedHeader is a block header along with the information that is needed
+// to validate its children without having access to the full block.
+type processedHeader struct {
+	Header      types.BlockHeader
+	Height      types.BlockHeight
+	Depth       types.Target
+	ChildTarget types.Target
+
+	// TotalTime and TotalTarget are the oak difficulty adjustment totals of
+	// the header, see storeBlockTotals.
+	TotalTime   int64
+	TotalTarget types.Target
+}
+
+// heavierThan returns true if the processedHeader is sufficiently heavier than
+// 'cmp', using the same rules as processedBlock.heavierThan.
+func (ph *processedHeader) heavierThan(cmp *processedHeader) bool {
+	requirement := cmp.Depth.AddDifficulties(cmp.ChildTarget.MulDifficulty(SurpassThreshold))
+	return requirement.Cmp(ph.Depth) > 0 // Inversed, because the smaller target is actually heavier.
+}
+
+// initHeaders creates the header chain buckets and seeds them with the genesis
+// header if they do not exist yet.
+func (cs *ConsensusSet) initHeaders(tx *bolt.Tx) error {
+	if tx.Bucket(HeaderMap) != nil {
+		return nil
+	}
+	for _, bucket := range [][]byte{HeaderHeight, HeaderMap, HeaderPath} {
+		if _, err := tx.CreateBucket(bucket); err != nil {
+			return err
+		}
+	}
+
+	genesis := processedHeader{
+		Header:      cs.blockRoot.Block.Header(),
+		Depth:       cs.blockRoot.Depth,
+		ChildTarget: cs.blockRoot.ChildTarget,
+	}
+	genesis.TotalTime, genesis.TotalTarget = blockTotals(0, 0, types.GenesisTimestamp, types.GenesisTimestamp, types.RootDepth, types.RootTarget)
+	if err := putHeaderMap(tx, &genesis); err != nil {
+		return err
+	}
+	return setHeaderPath(tx, 0, genesis.Header.ID())
+}
+
+// headerHeight returns the height of the header chain.
+func headerHeight(tx *bolt.Tx) types.BlockHeight {
+	var height types.BlockHeight
+	err := encoding.Unmarshal(tx.Bucket(HeaderHeight).Get(HeaderHeight), &height)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return height
+}
+
+// getHeaderMap returns the processed header with the input id.
+func getHeaderMap(tx *bolt.Tx, id types.BlockID) (*processedHeader, error) {
+	phBytes := tx.Bucket(HeaderMap).Get(id[:])
+	if phBytes == nil {
+		return nil, errNilItem
+	}
+	var ph processedHeader
+	if err := encoding.Unmarshal(phBytes, &ph); err != nil {
+		return nil, err
+	}
+	return &ph, nil
+}
+
+// putHeaderMap adds a processed header to the header map.
+func putHeaderMap(tx *bolt.Tx, ph *processedHeader) error {
+	id := ph.Header.ID()
+	return tx.Bucket(HeaderMap).Put(id[:], encoding.Marshal(*ph))
+}
+
+// getHeaderPath returns the header id at 'height' in the header path.
+func getHeaderPath(tx *bolt.Tx, height types.BlockHeight) (id types.BlockID, err error) {
+	idBytes := tx.Bucket(HeaderPath).Get(encoding.Marshal(height))
+	if idBytes == nil {
+		return types.BlockID{}, errNilItem
+	}
+	copy(id[:], idBytes)
+	return id, nil
+}
+
+// setHeaderPath sets the header at 'height' in the header path to 'id', and
+// makes 'height' the new height of the header chain. Any headers above
+// 'height' are removed from the path.
+func setHeaderPath(tx *bolt.Tx, height types.BlockHeight, id types.BlockID) error {
+	hp := tx.Bucket(HeaderPath)
+	hh := tx.Bucket(HeaderHeight)
+	if oldBytes := hh.Get(HeaderHeight); oldBytes != nil {
+		var oldHeight types.BlockHeight
+		if err := encoding.Unmarshal(oldBytes, &oldHeight); err != nil {
+			return err
+		}
+		for h := oldHeight; h > height; h-- {
+			if err := hp.Delete(encoding.Marshal(h)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := hp.Put(encoding.Marshal(height), id[:]); err != nil {
+		return err
+	}
+	return hh.Put(HeaderHeight, encoding.Marshal(height))
+}
+
+// minimumValidChildHeaderTimestamp returns the earliest timestamp that a child
+// of 'ph' may have, computed from the headers in the header map.
+func minimumValidChildHeaderTimestamp(tx *bolt.Tx, ph *processedHeader) types.Timestamp {
+	windowTimes := make(types.TimestampSlice, types.MedianTimestampWindow)
+	windowTimes[0] = ph.Header.Timestamp
+	parent := ph.Header.ParentID
+	for i := uint64(1); i < types.MedianTimestampWindow; i++ {
+		// If the genesis header is 'parent', use the genesis timestamp for
+		// all remaining times.
+		if parent == (types.BlockID{}) {
+			windowTimes[i] = windowTimes[i-1]
+			continue
+		}
+		pph, err := getHeaderMap(tx, parent)
+		if build.DEBUG && err != nil {
+			panic(err)
+		} else if err != nil {
+			windowTimes[i] = windowTimes[i-1]
+			continue
+		}
+		parent = pph.Header.ParentID
+		windowTimes[i] = pph.Header.Timestamp
+	}
+	sort.Sort(windowTimes)
+	return windowTimes[len(windowTimes)/2]
+}
+
+// headerTargetAdjustmentBase mirrors targetAdjustmentBase, walking the header
+// map instead of the block map.
+func headerTargetAdjustmentBase(tx *bolt.Tx, child *processedHeader) *big.Rat {
+	var windowSize types.BlockHeight
+	parent := child.Header.ParentID
+	timestamp := child.Header.Timestamp
+	for windowSize = 0; windowSize < types.TargetWindow && parent != (types.BlockID{}); windowSize++ {
+		ph, err := getHeaderMap(tx, parent)
+		if build.DEBUG && err != nil {
+			panic(err)
+		} else if err != nil {
+			break
+		}
+		timestamp = ph.Header.Timestamp
+		parent = ph.Header.ParentID
+	}
+
+	timePassed := child.Header.Timestamp - timestamp
+	expectedTimePassed := types.BlockFrequency * windowSize
+	return big.NewRat(int64(timePassed), int64(expectedTimePassed))
+}
+
+// newChildHeader creates the processed header of 'h', a child of 'parent',
+// computing its depth, oak totals and child target in the same way that
+// newChild does for full blocks.
+func (cs *ConsensusSet) newChildHeader(tx *bolt.Tx, parent *processedHeader, h types.BlockHeader) *processedHeader {
+	child := &processedHeader{
+		Header: h,
+		Height: parent.Height + 1,
+		Depth:  parent.Depth.AddDifficulties(parent.ChildTarget),
+	}
+	child.TotalTime, child.TotalTarget = blockTotals(child.Height, parent.TotalTime, parent.Header.Timestamp, h.Timestamp, parent.TotalTarget, parent.ChildTarget)
+
+	if parent.Height < types.OakHardforkBlock {
+		if child.Height%(types.TargetWindow/2) != 0 {
+			child.ChildTarget = parent.ChildTarget
+		} else {
+			adjustment := clampTargetAdjustment(headerTargetAdjustmentBase(tx, child))
+			child.ChildTarget = types.RatToTarget(new(big.Rat).Mul(parent.ChildTarget.Rat(), adjustment))
+		}
+	} else {
+		child.ChildTarget = cs.childTargetOak(parent.TotalTime, parent.TotalTarget, parent.ChildTarget, parent.Height, parent.Header.Timestamp)
+	}
+	return child
+}
+
+// acceptHeader validates a header against the header chain and adds it to the
+// header map. If the header creates a heavier chain than the current header
+// path, the path is switched to the new chain. The returned bool indicates
+// whether the header path changed.
+func (cs *ConsensusSet) acceptHeader(tx *bolt.Tx, h types.BlockHeader) (bool, error) {
+	id := h.ID()
+	if tx.Bucket(HeaderMap).Get(id[:]) != nil {
+		return false, modules.ErrBlockKnown
+	}
+	parent, err := getHeaderMap(tx, h.ParentID)
+	if err == errNilItem {
+		return false, errOrphan
+	} else if err != nil {
+		return false, err
+	}
+
+	// Check the proof of work and the timestamp of the header.
+	if !checkHeaderTarget(h, parent.ChildTarget) {
+		return false, modules.ErrBlockUnsolved
+	}
+	if minTimestamp := minimumValidChildHeaderTimestamp(tx, parent); minTimestamp > h.Timestamp {
+		return false, errEarlyTimestamp
+	}
+	if h.Timestamp > types.CurrentTimestamp()+types.ExtremeFutureThreshold {
+		return false, errExtremeFutureTimestamp
+	}
+	if h.Timestamp > types.CurrentTimestamp()+types.FutureThreshold {
+		return false, errFutureTimestamp
+	}
+
+	child := cs.newChildHeader(tx, parent, h)
+	if err := putHeaderMap(tx, child); err != nil {
+		return false, err
+	}
+
+	// Switch the header path if the new header is heavier than the current
+	// tip.
+	tipID, err := getHeaderPath(tx, headerHeight(tx))
+	if err != nil {
+		return false, err
+	}
+	tip, err := getHeaderMap(tx, tipID)
+	if err != nil {
+		return false, err
+	}
+	if !child.heavierThan(tip) {
+		return false, nil
+	}
+	if err := setHeaderPath(tx, child.Height, id); err != nil {
+		return false, err
+	}
+	// Walk backwards, rewriting the path until it joins the old chain.
+	current := child
+	for current.Height > 0 {
+		pathID, err := getHeaderPath(tx, current.Height-1)
+		if err == nil && pathID == current.Header.ParentID {
+			break
+		}
+		current, err = getHeaderMap(tx, current.Header.ParentID)
+		if err != nil {
+			return false, err
+		}
+		currentID := current.Header.ID()
+		if err := tx.Bucket(HeaderPath).Put(encoding.Marshal(current.Height), currentID[:]); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// managedAcceptHeaders adds a set of headers to the header chain. Headers that
+// are already known are skipped. The returned bool indicates whether the
+// header path changed.
+func (cs *ConsensusSet) managedAcceptHeaders(headers []types.BlockHeader) (bool, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	changed := false
+	err := cs.db.Update(func(tx *bolt.Tx) error {
+		for _, h := range headers {
+			pathChanged, err := cs.acceptHeader(tx, h)
+			if err == modules.ErrBlockKnown {
+				continue
+			} else if err != nil {
+				return err
+			}
+			changed = changed || pathChanged
+		}
+		return nil
+	})
+	return changed, err
+}
+
+// chainHeight returns the height of the chain that the consensus set serves
+// headers from: the header chain in SPV mode, and the block chain otherwise.
+func (cs *ConsensusSet) chainHeight(tx *bolt.Tx) types.BlockHeight {
+	if cs.spv {
+		return headerHeight(tx)
+	}
+	return blockHeight(tx)
+}
+
+// chainID returns the id at 'height' in the chain that the consensus set
+// serves headers from.
+func (cs *ConsensusSet) chainID(tx *bolt.Tx, height types.BlockHeight) (types.BlockID, error) {
+	if cs.spv {
+		return getHeaderPath(tx, height)
+	}
+	return getPath(tx, height)
+}
+
+// chainHeader returns the header with the given id and its height from the
+// chain that the consensus set serves headers from.
+func (cs *ConsensusSet) chainHeader(tx *bolt.Tx, id types.BlockID) (types.BlockHeader, types.BlockHeight, error) {
+	if cs.spv {
+		ph, err := getHeaderMap(tx, id)
+		if err != nil {
+			return types.BlockHeader{}, 0, err
+		}
+		return ph.Header, ph.Height, nil
+	}
+	pb, err := getBlockMap(tx, id)
+	if err != nil {
+		return types.BlockHeader{}, 0, err
+	}
+	return pb.Block.Header(), pb.Height, nil
+}
+
+// headerHistory is the header chain equivalent of blockHistory.
+func (cs *ConsensusSet) headerHistory(tx *bolt.Tx) (blockIDs [32]types.BlockID) {
+	height := cs.chainHeight(tx)
+	step := types.BlockHeight(1)
+	for i := 0; i < 31; i++ {
+		blockID, err := cs.chainID(tx, height)
+		if build.DEBUG && err != nil {
+			panic(err)
+		}
+		blockIDs[i] = blockID
+		if i >= 9 {
+			step *= 2
+		}
+		if height <= step {
+			break
+		}
+		height -= step
+	}
+	blockID, err := cs.chainID(tx, 0)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	blockIDs[31] = blockID
+	return blockIDs
+}
+
+// managedFetchBlock requests the block with the given id from the consensus
+// set's peers, returning the first block that matches the header chain.
+func (cs *ConsensusSet) managedFetchBlock(header types.BlockHeader) (types.Block, error) {
+	id := header.ID()
+	for _, p := range cs.gateway.Peers() {
+		var b types.Block
+		err := cs.gateway.RPC(p.NetAddress, "SendBlk", func(conn modules.PeerConn) error {
+			if err := encoding.WriteObject(conn, id); err != nil {
+				return err
+			}
+			if err := encoding.ReadObject(conn, &b, types.BlockSizeLimit); err != nil {
+				return err
+			}
+			// The block id commits to the Merkle root of the block, but the
+			// root is checked explicitly to verify that the contents of the
+			// block are the ones included in the header chain.
+			if b.ID() != id || b.ParentID != header.ParentID {
+				return errWrongFetchBlock
+			}
+			if b.MerkleRoot() != header.MerkleRoot {
+				return errBadMerkleRoot
+			}
+			return nil
+		})
+		if err != nil {
+			cs.log.Debugf("WARN: unable to fetch block %v from %v: %v", id, p.NetAddress, err)
+			continue
+		}
+		return b, nil
+	}
+	return types.Block{}, errNoPeerHasBlock
+}
+
+// FetchBlock returns the block with the given id. Blocks that are stored
+// locally are returned directly. In SPV mode, blocks in the header chain are
+// downloaded from peers and verified against their header.
+func (cs *ConsensusSet) FetchBlock(id types.BlockID) (types.Block, error) {
+	if err := cs.tg.Add(); err != nil {
+		return types.Block{}, err
+	}
+	defer cs.tg.Done()
+
+	if b, exists := cs.BlockByID(id); exists {
+		return b, nil
+	}
+	if !cs.spv {
+		return types.Block{}, errNilItem
+	}
+
+	var header types.BlockHeader
+	cs.mu.RLock()
+	err := cs.db.View(func(tx *bolt.Tx) error {
+		ph, err := getHeaderMap(tx, id)
+		if err != nil {
+			return errUnknownHeader
+		}
+		header = ph.Header
+		return nil
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return types.Block{}, err
+	}
+	return cs.managedFetchBlock(header)
+}
+
+// HeaderAtHeight returns the header at the given height of the heaviest known
+// chain. In SPV mode this is the header chain, otherwise it is the block
+// chain.
+func (cs *ConsensusSet) HeaderAtHeight(height types.BlockHeight) (header types.BlockHeader, exists bool) {
+	if err := cs.tg.Add(); err != nil {
+		return types.BlockHeader{}, false
+	}
+	defer cs.tg.Done()
+
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		id, err := cs.chainID(tx, height)
+		if err != nil {
+			return err
+		}
+		header, _, err = cs.chainHeader(tx, id)
+		if err != nil {
+			return err
+		}
+		exists = true
+		return nil
+	})
+	return header, exists
+}
+
+// HeaderHeight returns the height of the heaviest known header chain. For
+// consensus sets that are not in SPV mode, this is the block height.
+func (cs *ConsensusSet) HeaderHeight() (height types.BlockHeight) {
+	if err := cs.tg.Add(); err != nil {
+		return 0
+	}
+	defer cs.tg.Done()
+
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		height = cs.chainHeight(tx)
+		return nil
+	})
+	return height
+}
+
+// SPV returns true if the consensus set is running in SPV mode.
+func (cs *ConsensusSet) SPV() bool {
+	return cs.spv
+}
+
+// rpcSPVNoBlocks answers the SendBlocks and SendBlk RPCs of consensus sets in
+// SPV mode. They do not store full blocks, so the connection is closed with
+// errSPVNoBlocks instead of leaving the peer to wait for blocks that never
+// arrive.
+func (cs *ConsensusSet) rpcSPVNoBlocks(conn modules.PeerConn) error {
+	return errSPVNoBlocks
+}
@@ -0,0 +1,106 @@
+package consensus
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/gateway"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestSPVHeaderSync checks that a consensus set in SPV mode synchronizes the
+// header chain of a full node and can fetch full blocks on demand.
+func TestSPVHeaderSync(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	testdir := build.TempDir(modules.ConsensusDir, t.Name()+"-spv")
+	g, err := gateway.New("localhost:0", false, filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	spv, err := NewSPV(g, false, filepath.Join(testdir, modules.ConsensusDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer spv.Close()
+	if !spv.SPV() || cst.cs.SPV() {
+		t.Fatal("SPV reports the wrong mode")
+	}
+
+	// Connecting to the full node triggers the SendHeaders connect call. The
+	// RPCs are registered asynchronously, so retry the connection until the
+	// header chain has been downloaded.
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if spv.HeaderHeight() == cst.cs.Height() {
+			return nil
+		}
+		_ = g.Disconnect(cst.gateway.Address())
+		_ = g.Connect(cst.gateway.Address())
+		return errNilItem
+	})
+	if err != nil {
+		t.Fatalf("header chain did not sync: have %v, want %v", spv.HeaderHeight(), cst.cs.Height())
+	}
+	for i := types.BlockHeight(0); i <= cst.cs.Height(); i++ {
+		h, exists := spv.HeaderAtHeight(i)
+		if !exists {
+			t.Fatal("missing header at height", i)
+		}
+		b, _ := cst.cs.BlockAtHeight(i)
+		if h != b.Header() {
+			t.Fatal("header mismatch at height", i)
+		}
+	}
+	// The SPV consensus set should not have applied any blocks.
+	if spv.Height() != 0 {
+		t.Fatal("SPV consensus set should not download full blocks")
+	}
+
+	// Fetch the current block of the full node on demand.
+	want := cst.cs.CurrentBlock()
+	b, err := spv.FetchBlock(want.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.ID() != want.ID() || b.MerkleRoot() != want.MerkleRoot() {
+		t.Fatal("fetched the wrong block")
+	}
+	// Blocks outside of the header chain cannot be fetched.
+	if _, err := spv.FetchBlock(types.BlockID{1}); err != errUnknownHeader {
+		t.Fatal("expected errUnknownHeader, got", err)
+	}
+
+	// New blocks on the full node are relayed to the SPV consensus set as
+	// headers.
+	_, err = cst.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if spv.HeaderHeight() != cst.cs.Height() {
+			return errNilItem
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("relayed header was not added to the header chain")
+	}
+
+	// The SPV consensus set refuses requests for full blocks.
+	err = cst.gateway.RPC(g.Address(), "SendBlk", cst.cs.managedReceiveBlock(want.ID()))
+	if err == nil {
+		t.Fatal("SPV consensus set should not serve full blocks")
+	}
+}
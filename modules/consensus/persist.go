@@ -47,6 +47,15 @@ func (cs *ConsensusSet) loadDB() error {
 			return err
 		}
 
+		// Consensus sets in SPV mode track the header chain in a separate set
+		// of buckets.
+		if cs.spv {
+			err = cs.initHeaders(tx)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Check that the genesis block is correct - typically only incorrect
 		// in the event of developer binaries vs. release binaires.
 		genesisID, err := getPath(tx, 0)
@@ -66,8 +66,14 @@ func (cs *ConsensusSet) targetAdjustmentBase(blockMap *bolt.Bucket, pb *processe
 	parent := pb.Block.ParentID
 	current := pb.Block.ID()
 	for windowSize = 0; windowSize < types.TargetWindow && parent != (types.BlockID{}); windowSize++ {
+		// Stop at the earliest known block if the consensus set was
+		// bootstrapped from a snapshot.
+		parentBytes := blockMap.Get(parent[:])
+		if parentBytes == nil {
+			break
+		}
 		current = parent
-		copy(parent[:], blockMap.Get(parent[:])[:32])
+		copy(parent[:], parentBytes[:32])
 	}
 	timestamp := types.Timestamp(encoding.DecUint64(blockMap.Get(current[:])[40:48]))
 
@@ -0,0 +1,475 @@
+package consensus
+
+// snapshot.go implements the export and import of consensus snapshots. A
+// snapshot contains the consensus state at the current height: the block path,
+// the unspent siacoin and siafund outputs, the open file contracts and their
+// expirations, the siafund pool and the delayed siacoin outputs. It also
+// contains the most recent processed blocks, which a node bootstrapped from the
+// snapshot needs to validate the blocks that follow it.
+//
+// The snapshot is a stream of sia-encoded objects. Buckets are written in a
+// fixed order and their contents in byte order, so exporting the same state
+// always produces the same snapshot. The snapshot checksum covers everything
+// that is imported, and is checked against a checksum obtained from a trusted
+// node.
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/persist"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+var (
+	// ErrSnapshotExistingDB is returned when importing a snapshot into a
+	// directory that already contains a consensus database.
+	ErrSnapshotExistingDB = errors.New("a consensus database already exists")
+
+	errSnapshotAnchor    = errors.New("snapshot does not end at the trusted block")
+	errSnapshotBucket    = errors.New("snapshot contains an unexpected bucket")
+	errSnapshotChain     = errors.New("snapshot blocks do not form a chain ending at the snapshot height")
+	errSnapshotChecksum  = errors.New("snapshot state does not match the trusted checksum")
+	errSnapshotReorg     = errors.New("cannot reorg below the blocks imported from the snapshot")
+	errSnapshotSubscribe = errors.New("cannot subscribe from the beginning of a consensus set that was bootstrapped from a snapshot")
+
+	snapshotMetadata = persist.Metadata{
+		Header:  "Consensus Snapshot",
+		Version: "1.1",
+	}
+
+	// snapshotStateBuckets are the buckets, besides the delayed siacoin output
+	// and file contract expiration buckets, that make up the consensus state.
+	snapshotStateBuckets = [][]byte{
+		BlockPath,
+		SiacoinOutputs,
+		FileContracts,
+		SiafundOutputs,
+		SiafundPool,
+	}
+)
+
+// snapshotHeader is the first object in a snapshot after the metadata.
+type snapshotHeader struct {
+	Height    types.BlockHeight
+	BlockID   types.BlockID
+	Checksum  crypto.Hash
+	NumBlocks uint64
+}
+
+// snapshotDepth returns the number of recent blocks that are included in a
+// snapshot at the given height. Enough blocks are included to compute the
+// timestamp and difficulty rules for the next block, and to handle short
+// reorgs.
+func snapshotDepth(height types.BlockHeight) types.BlockHeight {
+	depth := types.MaturityDelay
+	if height < types.OakHardforkBlock && depth < types.TargetWindow {
+		depth = types.TargetWindow
+	}
+	if depth < types.BlockHeight(types.MedianTimestampWindow) {
+		depth = types.BlockHeight(types.MedianTimestampWindow)
+	}
+	if depth > height {
+		depth = height
+	}
+	return depth
+}
+
+// snapshotBlockIDs returns the ids of the blocks included in a snapshot at
+// the current height: the genesis block followed by the most recent blocks,
+// oldest first.
+func snapshotBlockIDs(tx *bolt.Tx) ([]types.BlockID, error) {
+	height := blockHeight(tx)
+	depth := snapshotDepth(height)
+	heights := []types.BlockHeight{0}
+	for h := height - depth + 1; h <= height && depth > 0; h++ {
+		heights = append(heights, h)
+	}
+	var ids []types.BlockID
+	for _, h := range heights {
+		id, err := getPath(tx, h)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// snapshotHeight returns the height of the earliest block that was imported
+// after the genesis block, and false if the consensus set was not bootstrapped
+// from a snapshot. The blocks between the genesis block and that height are
+// missing from the block map, so they cannot be reverted.
+func snapshotHeight(tx *bolt.Tx) (types.BlockHeight, bool) {
+	heightBytes := tx.Bucket(BlockHeight).Get(FieldSnapshotHeight)
+	if heightBytes == nil {
+		return 0, false
+	}
+	var height types.BlockHeight
+	if err := encoding.Unmarshal(heightBytes, &height); err != nil {
+		manageErr(tx, err)
+	}
+	return height, true
+}
+
+// isSnapshotDelayedBucket returns true if name is the name of a delayed
+// siacoin output or file contract expiration bucket that can exist in a
+// consensus set at the given height. Delayed siacoin outputs mature within
+// MaturityDelay blocks, and file contracts that expire at or below the
+// current height have already been removed.
+func isSnapshotDelayedBucket(name []byte, height types.BlockHeight) bool {
+	var prefix []byte
+	switch {
+	case bytes.HasPrefix(name, prefixDSCO):
+		prefix = prefixDSCO
+	case bytes.HasPrefix(name, prefixFCEX):
+		prefix = prefixFCEX
+	default:
+		return false
+	}
+	var bh types.BlockHeight
+	if len(name) != len(prefix)+8 || encoding.Unmarshal(name[len(prefix):], &bh) != nil {
+		return false
+	}
+	if bytes.Equal(prefix, prefixDSCO) {
+		return bh > height && bh <= height+types.MaturityDelay
+	}
+	return bh > height
+}
+
+// snapshotChecksum returns a checksum of everything that a snapshot at the
+// current height imports: the included processed blocks and their oak totals,
+// the state buckets, and the delayed siacoin output and file contract
+// expiration buckets along with their names, which encode their heights.
+func snapshotChecksum(tx *bolt.Tx, ids []types.BlockID) crypto.Hash {
+	tree := crypto.NewTree()
+	oak := tx.Bucket(BucketOak)
+	for _, id := range ids {
+		tree.Push(id[:])
+		tree.Push(tx.Bucket(BlockMap).Get(id[:]))
+		tree.Push(oak.Get(id[:]))
+	}
+	tree.Push(FieldOakInit)
+	tree.Push(oak.Get(FieldOakInit))
+
+	pushBucket := func(name []byte, b *bolt.Bucket) error {
+		tree.Push(name)
+		return b.ForEach(func(k, v []byte) error {
+			tree.Push(k)
+			tree.Push(v)
+			return nil
+		})
+	}
+	for _, name := range snapshotStateBuckets {
+		if err := pushBucket(name, tx.Bucket(name)); err != nil {
+			manageErr(tx, err)
+		}
+	}
+	height := blockHeight(tx)
+	err := tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		if !isSnapshotDelayedBucket(name, height) {
+			return nil
+		}
+		return pushBucket(name, b)
+	})
+	if err != nil {
+		manageErr(tx, err)
+	}
+	return tree.Root()
+}
+
+// writeSnapshotBucket writes the name of a bucket followed by its key/value
+// pairs, terminated by an empty key.
+func writeSnapshotBucket(enc *encoding.Encoder, name []byte, b *bolt.Bucket) error {
+	if err := enc.Encode(name); err != nil {
+		return err
+	}
+	err := b.ForEach(func(k, v []byte) error {
+		return enc.EncodeAll(k, v)
+	})
+	if err != nil {
+		return err
+	}
+	return enc.Encode([]byte{})
+}
+
+// Snapshot writes a snapshot of the consensus state at the current height to
+// w.
+func (cs *ConsensusSet) Snapshot(w io.Writer) error {
+	if err := cs.tg.Add(); err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+
+	bw := bufio.NewWriter(w)
+	enc := encoding.NewEncoder(bw)
+	err := cs.db.View(func(tx *bolt.Tx) error {
+		ids, err := snapshotBlockIDs(tx)
+		if err != nil {
+			return err
+		}
+		height := blockHeight(tx)
+		header := snapshotHeader{
+			Height:    height,
+			BlockID:   currentBlockID(tx),
+			Checksum:  snapshotChecksum(tx, ids),
+			NumBlocks: uint64(len(ids)),
+		}
+		if err := enc.EncodeAll(snapshotMetadata, header); err != nil {
+			return err
+		}
+
+		// Write the included blocks.
+		for _, id := range ids {
+			pb, err := getBlockMap(tx, id)
+			if err != nil {
+				return err
+			}
+			if err := enc.Encode(*pb); err != nil {
+				return err
+			}
+		}
+
+		// Write the state buckets.
+		for _, name := range snapshotStateBuckets {
+			if err := writeSnapshotBucket(enc, name, tx.Bucket(name)); err != nil {
+				return err
+			}
+		}
+		err = tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			if !isSnapshotDelayedBucket(name, height) {
+				return nil
+			}
+			return writeSnapshotBucket(enc, name, b)
+		})
+		if err != nil {
+			return err
+		}
+
+		// Write the oak totals of the included blocks.
+		oak := tx.Bucket(BucketOak)
+		if err := enc.Encode(BucketOak); err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := enc.EncodeAll(id[:], oak.Get(id[:])); err != nil {
+				return err
+			}
+		}
+		if err := enc.EncodeAll(FieldOakInit, oak.Get(FieldOakInit), []byte{}); err != nil {
+			return err
+		}
+
+		// An empty bucket name marks the end of the snapshot.
+		return enc.Encode([]byte{})
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// validSnapshotBucket returns true if a bucket with the given name may appear
+// in a snapshot at the given height.
+func validSnapshotBucket(name []byte, height types.BlockHeight) bool {
+	if bytes.Equal(name, BucketOak) || isSnapshotDelayedBucket(name, height) {
+		return true
+	}
+	for _, bucket := range snapshotStateBuckets {
+		if bytes.Equal(name, bucket) {
+			return true
+		}
+	}
+	return false
+}
+
+// importSnapshot fills an empty consensus database with the contents of a
+// snapshot.
+func importSnapshot(tx *bolt.Tx, dec *encoding.Decoder, header snapshotHeader) error {
+	for _, bucket := range [][]byte{BlockHeight, BlockMap, BlockPath, BucketOak, Consistency, FileContracts, SiacoinOutputs, SiafundOutputs, SiafundPool} {
+		if _, err := tx.CreateBucket(bucket); err != nil {
+			return err
+		}
+	}
+
+	// Read the processed blocks and check that they form a chain from the
+	// snapshot depth to the trusted block.
+	var blocks []processedBlock
+	for i := uint64(0); i < header.NumBlocks; i++ {
+		var pb processedBlock
+		if err := dec.Decode(&pb); err != nil {
+			return err
+		}
+		if i == 0 && pb.Block.ID() != types.GenesisID {
+			return errSnapshotChain
+		} else if i > 1 && (pb.Block.ParentID != blocks[i-1].Block.ID() || pb.Height != blocks[i-1].Height+1) {
+			return errSnapshotChain
+		}
+		addBlockMap(tx, &pb)
+		blocks = append(blocks, pb)
+	}
+	if len(blocks) == 0 || blocks[len(blocks)-1].Block.ID() != header.BlockID || blocks[len(blocks)-1].Height != header.Height {
+		return errSnapshotChain
+	}
+	// The oak bucket may only contain the totals of the included blocks.
+	oakKeys := map[string]struct{}{string(FieldOakInit): {}}
+	for _, pb := range blocks {
+		id := pb.Block.ID()
+		oakKeys[string(id[:])] = struct{}{}
+	}
+
+	// Read the buckets.
+	for {
+		var name []byte
+		if err := dec.Decode(&name); err != nil {
+			return err
+		}
+		if len(name) == 0 {
+			break
+		}
+		if !validSnapshotBucket(name, header.Height) {
+			return errSnapshotBucket
+		}
+		b, err := tx.CreateBucketIfNotExists(name)
+		if err != nil {
+			return err
+		}
+		for {
+			var k, v []byte
+			if err := dec.Decode(&k); err != nil {
+				return err
+			}
+			if len(k) == 0 {
+				break
+			}
+			if _, ok := oakKeys[string(k)]; bytes.Equal(name, BucketOak) && !ok {
+				return errSnapshotBucket
+			}
+			if err := dec.Decode(&v); err != nil {
+				return err
+			}
+			if err := b.Put(k, v); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Set the block height and check that the included blocks are on the
+	// block path.
+	err := tx.Bucket(BlockHeight).Put(BlockHeight, encoding.Marshal(header.Height))
+	if err != nil {
+		return err
+	}
+	var ids []types.BlockID
+	for _, pb := range blocks {
+		id, err := getPath(tx, pb.Height)
+		if err != nil || id != pb.Block.ID() {
+			return errSnapshotChain
+		}
+		ids = append(ids, id)
+	}
+	if snapshotChecksum(tx, ids) != header.Checksum {
+		return errSnapshotChecksum
+	}
+
+	// Start the change log with the genesis block, followed by a single
+	// change that applies the included blocks. The change log does not cover
+	// the outputs and contracts created below the included blocks, so the
+	// height of the earliest included block is recorded to refuse
+	// subscriptions from the beginning and reorgs below it.
+	cs := &ConsensusSet{blockRoot: blocks[0]}
+	if err := cs.createChangeLog(tx); err != nil {
+		return err
+	}
+	if len(blocks) > 1 {
+		var ce changeEntry
+		for _, pb := range blocks[1:] {
+			ce.AppliedBlocks = append(ce.AppliedBlocks, pb.Block.ID())
+		}
+		if err := appendChangeLog(tx, ce); err != nil {
+			return err
+		}
+	}
+	if len(blocks) > 1 && blocks[1].Height > 1 {
+		err = tx.Bucket(BlockHeight).Put(FieldSnapshotHeight, encoding.Marshal(blocks[1].Height))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Bucket(Consistency).Put(Consistency, encoding.Marshal(false))
+}
+
+// readSnapshotHeader reads the metadata and header of a snapshot.
+func readSnapshotHeader(dec *encoding.Decoder) (snapshotHeader, error) {
+	var md persist.Metadata
+	var header snapshotHeader
+	if err := dec.DecodeAll(&md, &header); err != nil {
+		return snapshotHeader{}, err
+	}
+	if md.Header != snapshotMetadata.Header {
+		return snapshotHeader{}, persist.ErrBadHeader
+	} else if md.Version != snapshotMetadata.Version {
+		return snapshotHeader{}, persist.ErrBadVersion
+	}
+	return header, nil
+}
+
+// ReadSnapshotHeader returns the height, the id of the last block and the
+// checksum of a snapshot written by Snapshot. The block id and checksum of a
+// snapshot exported by a trusted node are published along with it, so that
+// ImportSnapshot can verify copies obtained from anywhere.
+func ReadSnapshotHeader(r io.Reader) (types.BlockHeight, types.BlockID, crypto.Hash, error) {
+	header, err := readSnapshotHeader(encoding.NewDecoder(r))
+	return header.Height, header.BlockID, header.Checksum, err
+}
+
+// ImportSnapshot creates a consensus database in persistDir from a snapshot
+// written by Snapshot. The snapshot must end at the trusted block 'anchor',
+// and its contents must match 'checksum', the SnapshotChecksum of a trusted
+// node at that block. A consensus set created in persistDir afterwards will
+// continue to synchronize from the height of the snapshot. The height of the
+// snapshot is returned.
+func ImportSnapshot(r io.Reader, anchor types.BlockID, checksum crypto.Hash, persistDir string) (types.BlockHeight, error) {
+	filename := filepath.Join(persistDir, DatabaseFilename)
+	if _, err := os.Stat(filename); err == nil {
+		return 0, ErrSnapshotExistingDB
+	}
+	if err := os.MkdirAll(persistDir, 0700); err != nil {
+		return 0, err
+	}
+
+	dec := encoding.NewDecoder(bufio.NewReader(r))
+	header, err := readSnapshotHeader(dec)
+	if err != nil {
+		return 0, err
+	}
+	if header.BlockID != anchor {
+		return 0, errSnapshotAnchor
+	} else if header.Checksum != checksum {
+		return 0, errSnapshotChecksum
+	}
+
+	db, err := persist.OpenDatabase(dbMetadata, filename)
+	if err != nil {
+		return 0, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		return importSnapshot(tx, dec, header)
+	})
+	if closeErr := db.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(filename)
+		return 0, err
+	}
+	return header.Height, nil
+}
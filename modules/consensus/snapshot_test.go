@@ -0,0 +1,256 @@
+package consensus
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/gateway"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+// TestSnapshot checks that a consensus set bootstrapped from a snapshot has
+// the same state as the exporting consensus set and continues to accept
+// blocks.
+func TestSnapshot(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+	// Mine past the snapshot depth so that the snapshot omits old blocks.
+	for cst.cs.Height() <= types.OakHardforkBlock+types.BlockHeight(types.MedianTimestampWindow) {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var snapshot, snapshot2 bytes.Buffer
+	if err := cst.cs.Snapshot(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if err := cst.cs.Snapshot(&snapshot2); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(snapshot.Bytes(), snapshot2.Bytes()) {
+		t.Fatal("snapshots of the same state differ")
+	}
+
+	// The header of the snapshot names the block and checksum that are
+	// published along with it.
+	anchor := cst.cs.CurrentBlock().ID()
+	_, id, trusted, err := ReadSnapshotHeader(bytes.NewReader(snapshot.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != anchor {
+		t.Fatal("snapshot header has the wrong block id")
+	}
+
+	// Importing with the wrong anchor or checksum should fail.
+	testdir := build.TempDir(modules.ConsensusDir, t.Name()+"-import")
+	csDir := filepath.Join(testdir, modules.ConsensusDir)
+	if _, err := ImportSnapshot(bytes.NewReader(snapshot.Bytes()), types.BlockID{}, trusted, csDir); err != errSnapshotAnchor {
+		t.Fatal("expected errSnapshotAnchor, got", err)
+	}
+	if _, err := ImportSnapshot(bytes.NewReader(snapshot.Bytes()), anchor, crypto.Hash{1}, csDir); err != errSnapshotChecksum {
+		t.Fatal("expected errSnapshotChecksum, got", err)
+	}
+	height, err := ImportSnapshot(bytes.NewReader(snapshot.Bytes()), anchor, trusted, csDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height != cst.cs.Height() {
+		t.Fatalf("imported height %v, expected %v", height, cst.cs.Height())
+	}
+	if _, err := ImportSnapshot(bytes.NewReader(snapshot.Bytes()), anchor, trusted, csDir); err != ErrSnapshotExistingDB {
+		t.Fatal("expected ErrSnapshotExistingDB, got", err)
+	}
+
+	g, err := gateway.New("localhost:0", false, filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	cs, err := New(g, false, csDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+	if cs.Height() != cst.cs.Height() || cs.CurrentBlock().ID() != anchor {
+		t.Fatal("bootstrapped consensus set is not at the snapshot block")
+	}
+	if _, exists := cs.BlockAtHeight(1); exists {
+		t.Fatal("blocks below the snapshot depth should not be included")
+	}
+	checksum := func(cs *ConsensusSet) (h [32]byte) {
+		_ = cs.db.View(func(tx *bolt.Tx) error {
+			h = consensusChecksum(tx)
+			return nil
+		})
+		return
+	}
+	if checksum(cs) != checksum(cst.cs) {
+		t.Fatal("bootstrapped consensus set has a different state")
+	}
+
+	// The bootstrapped consensus set should accept the next block.
+	b, err := cst.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.AcceptBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	if checksum(cs) != checksum(cst.cs) {
+		t.Fatal("consensus sets diverged after accepting a block")
+	}
+
+	// The change log does not cover the state below the snapshot, so
+	// subscribing from the beginning should fail.
+	ms := newMockSubscriber()
+	if err := cs.ConsensusSetSubscribe(&ms, modules.ConsensusChangeBeginning, nil); err != errSnapshotSubscribe {
+		t.Fatal("expected errSnapshotSubscribe, got", err)
+	}
+	if err := cs.ConsensusSetSubscribe(&ms, modules.ConsensusChangeRecent, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// A fork from below the snapshot cannot be applied, because the blocks
+	// that it would revert are missing.
+	errRollback := errors.New("rollback")
+	err = cs.db.Update(func(tx *bolt.Tx) error {
+		fork := &processedBlock{
+			Block:  types.Block{ParentID: types.GenesisID},
+			Height: 1,
+		}
+		if _, _, err := cs.forkBlockchain(tx, fork); err != errSnapshotReorg {
+			t.Error("expected errSnapshotReorg, got", err)
+		}
+		return errRollback
+	})
+	if err != errRollback {
+		t.Fatal(err)
+	}
+}
+
+// TestIsSnapshotDelayedBucket checks that only delayed siacoin output and file
+// contract expiration buckets that can exist at the snapshot height are
+// accepted.
+func TestIsSnapshotDelayedBucket(t *testing.T) {
+	height := types.BlockHeight(1000)
+	name := func(prefix []byte, bh types.BlockHeight) []byte {
+		return append(append([]byte(nil), prefix...), encoding.Marshal(bh)...)
+	}
+	tests := []struct {
+		name  []byte
+		valid bool
+	}{
+		{name(prefixDSCO, height+1), true},
+		{name(prefixDSCO, height+types.MaturityDelay), true},
+		{name(prefixDSCO, height), false},
+		{name(prefixDSCO, height+types.MaturityDelay+1), false},
+		{name(prefixFCEX, height+1), true},
+		{name(prefixFCEX, height+100000), true},
+		{name(prefixFCEX, height), false},
+		{append(name(prefixDSCO, height+1), 0), false},
+		{prefixDSCO, false},
+		{append([]byte("dscoX"), encoding.Marshal(height+1)...), false},
+		{SiacoinOutputs, false},
+	}
+	for _, test := range tests {
+		if isSnapshotDelayedBucket(test.name, height) != test.valid {
+			t.Errorf("bucket %q: expected valid=%v", test.name, test.valid)
+		}
+	}
+}
+
+// TestSnapshotChecksumCoverage checks that the snapshot checksum changes when
+// delayed outputs are moved to a bucket of a different height, or when an
+// included processed block is altered.
+func TestSnapshotChecksumCoverage(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	errRollback := errors.New("rollback")
+	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		ids, err := snapshotBlockIDs(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		before := snapshotChecksum(tx, ids)
+
+		// Move the outputs maturing at the next height to the bucket of the
+		// height after it. The set of bucket names stays the same.
+		height := blockHeight(tx)
+		from := tx.Bucket(append(append([]byte(nil), prefixDSCO...), encoding.Marshal(height+1)...))
+		to := tx.Bucket(append(append([]byte(nil), prefixDSCO...), encoding.Marshal(height+2)...))
+		var keys, vals [][]byte
+		from.ForEach(func(k, v []byte) error {
+			keys = append(keys, append([]byte(nil), k...))
+			vals = append(vals, append([]byte(nil), v...))
+			return nil
+		})
+		if len(keys) == 0 {
+			t.Fatal("expected delayed outputs at height", height+1)
+		}
+		for i := range keys {
+			if err := from.Delete(keys[i]); err != nil {
+				t.Fatal(err)
+			}
+			if err := to.Put(keys[i], vals[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if snapshotChecksum(tx, ids) == before {
+			t.Fatal("checksum does not cover the heights of delayed siacoin outputs")
+		}
+		for i := range keys {
+			if err := to.Delete(keys[i]); err != nil {
+				t.Fatal(err)
+			}
+			if err := from.Put(keys[i], vals[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if snapshotChecksum(tx, ids) != before {
+			t.Fatal("checksum changed after restoring the delayed outputs")
+		}
+
+		// Alter the processed block at the snapshot height.
+		last := ids[len(ids)-1]
+		pb, err := getBlockMap(tx, last)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pb.Depth[0]++
+		if err := tx.Bucket(BlockMap).Put(last[:], encoding.Marshal(*pb)); err != nil {
+			t.Fatal(err)
+		}
+		if snapshotChecksum(tx, ids) == before {
+			t.Fatal("checksum does not cover the included processed blocks")
+		}
+		return errRollback
+	})
+	if err != errRollback {
+		t.Fatal(err)
+	}
+}
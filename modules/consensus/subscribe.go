@@ -82,6 +82,7 @@ func (cs *ConsensusSet) computeConsensusChange(tx *bolt.Tx, ce changeEntry) (mod
 		cs.log.Critical("could not find process block for known block")
 	}
 	cc.ChildTarget = pb.ChildTarget
+	cc.BlockHeight = pb.Height
 	cc.MinimumValidChildTimestamp = cs.blockRuleHelper.minimumValidChildTimestamp(tx.Bucket(BlockMap), pb)
 
 	currentBlock := currentBlockID(tx)
@@ -138,6 +139,13 @@ func (cs *ConsensusSet) managedInitializeSubscribe(subscriber modules.ConsensusS
 
 	cs.mu.RLock()
 	err := cs.db.View(func(tx *bolt.Tx) error {
+		// The change log of a consensus set bootstrapped from a snapshot
+		// does not cover the state below the snapshot, so it cannot be
+		// replayed from the beginning.
+		genesis := cs.genesisEntry()
+		if _, ok := snapshotHeight(tx); ok && (start == modules.ConsensusChangeBeginning || start == genesis.ID()) {
+			return errSnapshotSubscribe
+		}
 		if start == modules.ConsensusChangeBeginning {
 			// Special case: for modules.ConsensusChangeBeginning, create an
 			// initial node pointing to the genesis block. The subscriber will
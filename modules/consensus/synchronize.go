@@ -22,9 +22,10 @@ const (
 )
 
 var (
-	errEarlyStop         = errors.New("initial blockchain download did not complete by the time shutdown was issued")
-	errNilProcBlock      = errors.New("nil processed block was fetched from the database")
-	errSendBlocksStalled = errors.New("SendBlocks RPC timed and never received any blocks")
+	errEarlyStop          = errors.New("initial blockchain download did not complete by the time shutdown was issued")
+	errNilProcBlock       = errors.New("nil processed block was fetched from the database")
+	errSendBlocksStalled  = errors.New("SendBlocks RPC timed and never received any blocks")
+	errSendHeadersStalled = errors.New("SendHeaders RPC timed and never received any headers")
 
 	// ibdLoopDelay is the time that threadedInitialBlockchainDownload waits
 	// between attempts to synchronize with the network if the last attempt
@@ -44,6 +45,14 @@ var (
 		Testing:  types.BlockHeight(3),
 	}).(types.BlockHeight)
 
+	// MaxCatchUpHeaders is the maximum number of headers that can be sent in a
+	// single batch of the SendHeaders RPC.
+	MaxCatchUpHeaders = build.Select(build.Var{
+		Standard: types.BlockHeight(1000),
+		Dev:      types.BlockHeight(500),
+		Testing:  types.BlockHeight(10),
+	}).(types.BlockHeight)
+
 	// minIBDWaitTime is the time threadedInitialBlockchainDownload waits before
 	// exiting if there are >= 1 and <= minNumOutbound peers synced. This timeout
 	// will primarily affect miners who have multiple nodes daisy chained off each
@@ -90,6 +99,18 @@ func isTimeoutErr(err error) bool {
 	return (err.Error() == "Read timeout" || err.Error() == "Write timeout")
 }
 
+// isInvalidBlockErr returns true if err indicates that a block or header
+// received from a peer is invalid, as opposed to known, orphaned, slightly in
+// the future or not extending the longest chain. Peers that send invalid
+// blocks are penalized.
+func isInvalidBlockErr(err error) bool {
+	switch err {
+	case nil, modules.ErrBlockKnown, modules.ErrNonExtendingBlock, errOrphan, errFutureTimestamp, errNoBlockMap, errInconsistentSet:
+		return false
+	}
+	return true
+}
+
 // blockHistory returns up to 32 block ids, starting with recent blocks and
 // then proving exponentially increasingly less recent blocks. The genesis
 // block is always included as the last block. This block history can be used
@@ -230,6 +251,9 @@ func (cs *ConsensusSet) managedReceiveBlocks(conn modules.PeerConn) (returnErr e
 		// sharing is implemented, block already in database should also be
 		// ignored.
 		if acceptErr != nil && acceptErr != modules.ErrNonExtendingBlock && acceptErr != modules.ErrBlockKnown {
+			if isInvalidBlockErr(acceptErr) {
+				cs.gateway.PenalizePeer(conn.RPCAddr(), modules.MisbehaviorInvalidBlock)
+			}
 			return acceptErr
 		}
 	}
@@ -314,6 +338,15 @@ func (cs *ConsensusSet) rpcSendBlocks(conn modules.PeerConn) error {
 			if pb.Height == csHeight {
 				break
 			}
+			// Consensus sets bootstrapped from a snapshot cannot send the
+			// blocks below the snapshot.
+			childID, err := getPath(tx, pb.Height+1)
+			if err != nil {
+				continue
+			}
+			if _, err := getBlockMap(tx, childID); err != nil {
+				continue
+			}
 			found = true
 			// Start from the child of the common block.
 			start = pb.Height + 1
@@ -385,6 +418,183 @@ func (cs *ConsensusSet) rpcSendBlocks(conn modules.PeerConn) error {
 	return nil
 }
 
+// managedReceiveHeaders is the calling end of the SendHeaders RPC, without the
+// threadgroup wrapping. It is used by consensus sets in SPV mode to download
+// the header chain.
+func (cs *ConsensusSet) managedReceiveHeaders(conn modules.PeerConn) (returnErr error) {
+	err := conn.SetDeadline(time.Now().Add(sendBlocksTimeout))
+	if err != nil {
+		return err
+	}
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+	go func() {
+		select {
+		case <-cs.tg.StopChan():
+		case <-finishedChan:
+		}
+		conn.Close()
+	}()
+
+	stalled := true
+	defer func() {
+		if isTimeoutErr(returnErr) && stalled {
+			returnErr = errSendHeadersStalled
+		}
+	}()
+
+	// Get the header ids to send.
+	var history [32]types.BlockID
+	cs.mu.RLock()
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		history = cs.headerHistory(tx)
+		return nil
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if err := encoding.WriteObject(conn, history); err != nil {
+		return err
+	}
+
+	// Read headers off of the wire and add them to the header chain until
+	// there are no more headers available.
+	moreAvailable := true
+	for moreAvailable {
+		var headers []types.BlockHeader
+		if err := encoding.ReadObject(conn, &headers, uint64(MaxCatchUpHeaders)*types.BlockHeaderSize+8); err != nil {
+			return err
+		}
+		if err := encoding.ReadObject(conn, &moreAvailable, 1); err != nil {
+			return err
+		}
+		if len(headers) == 0 {
+			continue
+		}
+		stalled = false
+
+		if _, err := cs.managedAcceptHeaders(headers); err != nil {
+			if isInvalidBlockErr(err) {
+				cs.gateway.PenalizePeer(conn.RPCAddr(), modules.MisbehaviorInvalidBlock)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// threadedReceiveHeaders is the calling end of the SendHeaders RPC.
+func (cs *ConsensusSet) threadedReceiveHeaders(conn modules.PeerConn) error {
+	err := cs.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+	return cs.managedReceiveHeaders(conn)
+}
+
+// rpcSendHeaders is the receiving end of the SendHeaders RPC. It works like
+// rpcSendBlocks, but only sends block headers, in batches of up to
+// 'MaxCatchUpHeaders'. Full nodes serve headers from their block chain, and
+// consensus sets in SPV mode serve headers from their header chain.
+func (cs *ConsensusSet) rpcSendHeaders(conn modules.PeerConn) error {
+	err := conn.SetDeadline(time.Now().Add(sendBlocksTimeout))
+	if err != nil {
+		return err
+	}
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+	go func() {
+		select {
+		case <-cs.tg.StopChan():
+		case <-finishedChan:
+		}
+		conn.Close()
+	}()
+	err = cs.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+
+	// Read a list of headers known to the requester and find the most recent
+	// one in the current path.
+	var knownBlocks [32]types.BlockID
+	err = encoding.ReadObject(conn, &knownBlocks, 32*crypto.HashSize)
+	if err != nil {
+		return err
+	}
+	found := false
+	var start types.BlockHeight
+	cs.mu.RLock()
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		height := cs.chainHeight(tx)
+		for _, id := range knownBlocks {
+			_, h, err := cs.chainHeader(tx, id)
+			if err != nil {
+				continue
+			}
+			pathID, err := cs.chainID(tx, h)
+			if err != nil || pathID != id {
+				continue
+			}
+			if h == height {
+				break
+			}
+			found = true
+			start = h + 1
+			break
+		}
+		return nil
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if !found {
+		if err = encoding.WriteObject(conn, []types.BlockHeader{}); err != nil {
+			return err
+		}
+		return encoding.WriteObject(conn, false)
+	}
+
+	// Send the caller all of the headers that they are missing.
+	moreAvailable := true
+	for moreAvailable {
+		var headers []types.BlockHeader
+		cs.mu.RLock()
+		err = cs.db.View(func(tx *bolt.Tx) error {
+			height := cs.chainHeight(tx)
+			for i := start; i <= height && i < start+MaxCatchUpHeaders; i++ {
+				id, err := cs.chainID(tx, i)
+				if err != nil {
+					return err
+				}
+				h, _, err := cs.chainHeader(tx, id)
+				if err != nil {
+					return err
+				}
+				headers = append(headers, h)
+			}
+			moreAvailable = start+MaxCatchUpHeaders <= height
+			start += MaxCatchUpHeaders
+			return nil
+		})
+		cs.mu.RUnlock()
+		if err != nil {
+			return err
+		}
+		if err = encoding.WriteObject(conn, headers); err != nil {
+			return err
+		}
+		if err = encoding.WriteObject(conn, moreAvailable); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // threadedRPCRelayHeader is an RPC that accepts a block header from a peer.
 func (cs *ConsensusSet) threadedRPCRelayHeader(conn modules.PeerConn) error {
 	err := conn.SetDeadline(time.Now().Add(relayHeaderTimeout))
@@ -419,6 +629,27 @@ func (cs *ConsensusSet) threadedRPCRelayHeader(conn modules.PeerConn) error {
 		return err
 	}
 
+	// Consensus sets in SPV mode add the header to the header chain, and
+	// request the missing headers from the peer if the header is an orphan.
+	if cs.spv {
+		_, err = cs.managedAcceptHeaders([]types.BlockHeader{h})
+		if err == errOrphan {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := cs.gateway.RPC(conn.RPCAddr(), "SendHeaders", cs.managedReceiveHeaders)
+				if err != nil {
+					cs.log.Debugln("WARN: failed to get parents of orphan header:", err)
+				}
+			}()
+			return nil
+		}
+		if isInvalidBlockErr(err) {
+			cs.gateway.PenalizePeer(conn.RPCAddr(), modules.MisbehaviorInvalidBlock)
+		}
+		return err
+	}
+
 	// Start verification inside of a bolt View tx.
 	cs.mu.RLock()
 	err = cs.db.View(func(tx *bolt.Tx) error {
@@ -449,6 +680,9 @@ func (cs *ConsensusSet) threadedRPCRelayHeader(conn modules.PeerConn) error {
 		}()
 		return nil
 	} else if err != nil {
+		if isInvalidBlockErr(err) {
+			cs.gateway.PenalizePeer(conn.RPCAddr(), modules.MisbehaviorInvalidBlock)
+		}
 		return err
 	}
 
@@ -530,6 +764,9 @@ func (cs *ConsensusSet) managedReceiveBlock(id types.BlockID) modules.RPCFunc {
 		if chainExtended {
 			cs.managedBroadcastBlock(block)
 		}
+		if isInvalidBlockErr(err) {
+			cs.gateway.PenalizePeer(conn.RPCAddr(), modules.MisbehaviorInvalidBlock)
+		}
 		if err != nil {
 			return err
 		}
@@ -554,6 +791,10 @@ func (cs *ConsensusSet) threadedInitialBlockchainDownload() error {
 	// within the local network at least one peer is connected to the braod
 	// network).
 	deadline := time.Now().Add(minIBDWaitTime)
+	rpcName, receive := "SendBlocks", cs.managedReceiveBlocks
+	if cs.spv {
+		rpcName, receive = "SendHeaders", cs.managedReceiveHeaders
+	}
 	numOutboundSynced := 0
 	numOutboundNotSynced := 0
 	for {
@@ -577,7 +818,7 @@ func (cs *ConsensusSet) threadedInitialBlockchainDownload() error {
 
 				// Request blocks from the peer. The error returned will only be
 				// 'nil' if there are no more blocks to receive.
-				err = cs.gateway.RPC(p.NetAddress, "SendBlocks", cs.managedReceiveBlocks)
+				err = cs.gateway.RPC(p.NetAddress, rpcName, receive)
 				if err == nil {
 					numOutboundSynced++
 					// In this case, 'return nil' is equivalent to skipping to
@@ -592,9 +833,12 @@ func (cs *ConsensusSet) threadedInitialBlockchainDownload() error {
 					//
 					// We disconnect so that these peers are removed from gateway.Peers() and
 					// do not prevent us from marking ourselves as fully synced.
-					err := cs.gateway.Disconnect(p.NetAddress)
-					if err != nil {
-						cs.log.Printf("WARN: disconnecting from peer %v failed: %v", p.NetAddress, err)
+					disconnectErr := cs.gateway.Disconnect(p.NetAddress)
+					if disconnectErr != nil {
+						cs.log.Printf("WARN: disconnecting from peer %v failed: %v", p.NetAddress, disconnectErr)
+					}
+					if err == errSendBlocksStalled || err == errSendHeadersStalled {
+						cs.gateway.PenalizePeer(p.NetAddress, modules.MisbehaviorRPCTimeout)
 					}
 				}
 				return nil
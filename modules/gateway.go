@@ -2,8 +2,10 @@ package modules
 
 import (
 	"net"
+	"time"
 
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/types"
 )
 
 const (
@@ -12,6 +14,33 @@ const (
 	GatewayDir = "gateway"
 )
 
+const (
+	// MisbehaviorInvalidBlock is reported when a peer relays an invalid block
+	// or block header.
+	MisbehaviorInvalidBlock PeerMisbehavior = iota
+
+	// MisbehaviorRPCTimeout is reported when a peer does not respond to an
+	// RPC in time.
+	MisbehaviorRPCTimeout
+
+	// MisbehaviorMalformedMessage is reported when a peer sends a message
+	// that cannot be understood.
+	MisbehaviorMalformedMessage
+)
+
+// String returns a description of the misbehavior.
+func (m PeerMisbehavior) String() string {
+	switch m {
+	case MisbehaviorInvalidBlock:
+		return "invalid block"
+	case MisbehaviorRPCTimeout:
+		return "RPC timeout"
+	case MisbehaviorMalformedMessage:
+		return "malformed message"
+	}
+	return "unknown misbehavior"
+}
+
 var (
 	// BootstrapPeers is a list of peers that can be used to find other peers -
 	// when a client first connects to the network, the only options for
@@ -129,12 +158,55 @@ var (
 )
 
 type (
-	// Peer contains all the info necessary to Broadcast to a peer.
+	// Peer contains all the info necessary to Broadcast to a peer. If the
+	// connection to the peer is encrypted, PublicKey is the identity key that
+	// the peer proved to own.
 	Peer struct {
-		Inbound    bool       `json:"inbound"`
-		Local      bool       `json:"local"`
-		NetAddress NetAddress `json:"netaddress"`
-		Version    string     `json:"version"`
+		Inbound    bool               `json:"inbound"`
+		Local      bool               `json:"local"`
+		NetAddress NetAddress         `json:"netaddress"`
+		Version    string             `json:"version"`
+		Encrypted  bool               `json:"encrypted"`
+		PublicKey  types.SiaPublicKey `json:"publickey"`
+	}
+
+	// GatewaySettings control the number of peers the gateway connects to
+	// and the bandwidth it may use. Speeds are in bytes per second; a speed
+	// of 0 means unlimited.
+	GatewaySettings struct {
+		MaxInboundPeers  int   `json:"maxinboundpeers"`
+		MaxOutboundPeers int   `json:"maxoutboundpeers"`
+		MaxDownloadSpeed int64 `json:"maxdownloadspeed"`
+		MaxUploadSpeed   int64 `json:"maxuploadspeed"`
+	}
+
+	// PeerTraffic is the number of bytes exchanged with a peer during RPCs,
+	// in total and per RPC.
+	PeerTraffic struct {
+		NetAddress NetAddress   `json:"netaddress"`
+		Download   uint64       `json:"download"`
+		Upload     uint64       `json:"upload"`
+		RPCs       []RPCTraffic `json:"rpcs"`
+	}
+
+	// RPCTraffic is the number of calls of an RPC and the bytes exchanged
+	// during them. Calls made by either side of the connection are counted.
+	RPCTraffic struct {
+		Name     string `json:"name"`
+		Calls    uint64 `json:"calls"`
+		Download uint64 `json:"download"`
+		Upload   uint64 `json:"upload"`
+	}
+
+	// PeerMisbehavior is a kind of misbehavior that lowers the score of the
+	// peer that commits it.
+	PeerMisbehavior int
+
+	// PeerBan is a temporary ban of a peer whose score dropped too low. Host
+	// is the banned IP address, or the full address for local peers.
+	PeerBan struct {
+		Host    string    `json:"host"`
+		Expires time.Time `json:"expires"`
 	}
 
 	// A PeerConn is the connection type used when communicating with peers during
@@ -165,12 +237,20 @@ type (
 		// Disconnect terminates a connection to a peer.
 		Disconnect(NetAddress) error
 
+		// Unpin removes the identity key pinned for a node, so that the node
+		// is accepted with the next key that it presents.
+		Unpin(NetAddress) error
+
 		// Address returns the Gateway's address.
 		Address() NetAddress
 
 		// Peers returns the addresses that the Gateway is currently connected to.
 		Peers() []Peer
 
+		// PublicKey returns the identity key with which the Gateway
+		// authenticates its encrypted connections.
+		PublicKey() types.SiaPublicKey
+
 		// RegisterRPC registers a function to handle incoming connections that
 		// supply the given RPC ID.
 		RegisterRPC(string, RPCFunc)
@@ -202,6 +282,32 @@ type (
 		// Online returns true if the gateway is connected to remote hosts
 		Online() bool
 
+		// PenalizePeer lowers the score of the peer at the given address.
+		// Peers whose score drops too low are banned temporarily.
+		PenalizePeer(NetAddress, PeerMisbehavior)
+
+		// Ban adds an IP address, or the IP address of a NetAddress, to the
+		// persistent blocklist and disconnects from the peers using it.
+		Ban(addr string) error
+
+		// Unban removes an address from the blocklist and lifts any temporary
+		// ban of it.
+		Unban(addr string) error
+
+		// Blocklist returns the addresses on the blocklist and the active
+		// temporary bans.
+		Blocklist() ([]string, []PeerBan)
+
+		// Settings returns the Gateway's peer and bandwidth limits.
+		Settings() GatewaySettings
+
+		// SetSettings changes the Gateway's peer and bandwidth limits.
+		SetSettings(GatewaySettings) error
+
+		// Traffic returns the bytes exchanged with each connected peer,
+		// noisiest peer first.
+		Traffic() []PeerTraffic
+
 		// Close safely stops the Gateway's listener process.
 		Close() error
 	}
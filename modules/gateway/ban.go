@@ -0,0 +1,246 @@
+package gateway
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
+)
+
+// The gateway keeps a score for every peer address. Misbehavior, such as
+// relaying invalid blocks, lowers the score, and the score slowly recovers
+// over time. Peers whose score drops below banThreshold are banned for
+// banDuration. The user can also ban addresses permanently by adding them to
+// the blocklist, which is persisted.
+//
+// Bans apply to IP addresses, so that a banned node cannot reconnect from a
+// different port. Local peers are banned by their full address instead,
+// because many nodes may share a local IP address.
+
+const (
+	// blocklistFile is the name of the file that contains the blocklist.
+	blocklistFile = "blocklist.json"
+)
+
+var (
+	errInvalidBanAddress = errors.New("address must be an IP address or an IP address and port")
+	errNotBanned         = errors.New("address is not banned")
+	errPeerBanned        = errors.New("peer is banned")
+
+	// blocklistMetadata contains the header and version strings that
+	// identify the blocklist file.
+	blocklistMetadata = persist.Metadata{
+		Header:  "Sia Gateway Blocklist",
+		Version: "1.3.2",
+	}
+)
+
+// peerScore is the score of a peer address.
+type peerScore struct {
+	score   int
+	updated time.Time
+}
+
+// banKey returns the key under which the score and bans of addr are stored.
+func banKey(addr modules.NetAddress) string {
+	if addr.IsLocal() {
+		return string(addr)
+	}
+	return addr.Host()
+}
+
+// parseBanAddress returns the ban key of an IP address or NetAddress.
+func parseBanAddress(addr string) (string, error) {
+	if net.ParseIP(addr) != nil {
+		return addr, nil
+	}
+	na := modules.NetAddress(addr)
+	if net.ParseIP(na.Host()) == nil {
+		return "", errInvalidBanAddress
+	}
+	return banKey(na), nil
+}
+
+// penalty returns the score penalty for a misbehavior.
+func penalty(m modules.PeerMisbehavior) int {
+	switch m {
+	case modules.MisbehaviorInvalidBlock:
+		return penaltyInvalidBlock
+	case modules.MisbehaviorMalformedMessage:
+		return penaltyMalformedMessage
+	case modules.MisbehaviorRPCTimeout:
+		return penaltyRPCTimeout
+	}
+	return 0
+}
+
+// isBanned returns true if addr is on the blocklist or temporarily banned.
+func (g *Gateway) isBanned(addr modules.NetAddress) bool {
+	for _, key := range []string{addr.Host(), string(addr)} {
+		if _, blocked := g.blocklist[key]; blocked {
+			return true
+		}
+		if expires, banned := g.bans[key]; banned && time.Now().Before(expires) {
+			return true
+		}
+	}
+	return false
+}
+
+// disconnectBanned disconnects from the peers and removes the nodes that are
+// banned.
+func (g *Gateway) disconnectBanned() {
+	for addr, p := range g.peers {
+		if g.isBanned(addr) {
+			p.sess.Close()
+			delete(g.peers, addr)
+			g.log.Println("INFO: disconnected from banned peer", addr)
+		}
+	}
+	for addr := range g.nodes {
+		if g.isBanned(addr) {
+			delete(g.nodes, addr)
+		}
+	}
+}
+
+// penalizePeer lowers the score of addr, banning it if the score drops below
+// banThreshold.
+func (g *Gateway) penalizePeer(addr modules.NetAddress, m modules.PeerMisbehavior) {
+	key := banKey(addr)
+	if key == "" {
+		return
+	}
+	ps, exists := g.scores[key]
+	if exists {
+		// Recover the score for the time since the last penalty.
+		ps.score += int(time.Since(ps.updated) / scoreRecoveryInterval)
+		if ps.score > 0 {
+			ps.score = 0
+		}
+	}
+	ps.score -= penalty(m)
+	ps.updated = time.Now()
+	g.log.Debugf("INFO: peer %v misbehaved (%v), score is now %v", addr, m, ps.score)
+	if ps.score > banThreshold {
+		g.scores[key] = ps
+		return
+	}
+
+	delete(g.scores, key)
+	g.bans[key] = time.Now().Add(banDuration)
+	g.log.Printf("INFO: banning %v for %v because its score dropped to %v", key, banDuration, ps.score)
+	g.disconnectBanned()
+}
+
+// loadBlocklist loads the blocklist from disk.
+func (g *Gateway) loadBlocklist() error {
+	var blocklist []string
+	err := persist.LoadJSON(blocklistMetadata, &blocklist, filepath.Join(g.persistDir, blocklistFile))
+	if err != nil {
+		return err
+	}
+	for _, key := range blocklist {
+		g.blocklist[key] = struct{}{}
+	}
+	return nil
+}
+
+// saveBlocklist stores the blocklist on disk.
+func (g *Gateway) saveBlocklist() error {
+	blocklist, _ := g.blocklistAndBans()
+	return persist.SaveJSON(blocklistMetadata, blocklist, filepath.Join(g.persistDir, blocklistFile))
+}
+
+// blocklistAndBans returns the sorted blocklist and the active bans, removing
+// the expired bans.
+func (g *Gateway) blocklistAndBans() ([]string, []modules.PeerBan) {
+	blocklist := make([]string, 0, len(g.blocklist))
+	for key := range g.blocklist {
+		blocklist = append(blocklist, key)
+	}
+	sort.Strings(blocklist)
+	bans := make([]modules.PeerBan, 0, len(g.bans))
+	for key, expires := range g.bans {
+		if time.Now().After(expires) {
+			delete(g.bans, key)
+			continue
+		}
+		bans = append(bans, modules.PeerBan{Host: key, Expires: expires})
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].Host < bans[j].Host
+	})
+	return blocklist, bans
+}
+
+// PenalizePeer lowers the score of the peer at addr because of the given
+// misbehavior. Peers whose score drops below banThreshold are disconnected
+// and banned for banDuration.
+func (g *Gateway) PenalizePeer(addr modules.NetAddress, m modules.PeerMisbehavior) {
+	if g.threads.Add() != nil {
+		return
+	}
+	defer g.threads.Done()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.penalizePeer(addr, m)
+}
+
+// Ban adds an IP address, or the IP address of a NetAddress, to the blocklist
+// and disconnects from the peers that use it.
+func (g *Gateway) Ban(addr string) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+	key, err := parseBanAddress(addr)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.blocklist[key] = struct{}{}
+	g.disconnectBanned()
+	g.log.Println("INFO: added", key, "to the blocklist")
+	return g.saveBlocklist()
+}
+
+// Unban removes an address from the blocklist and lifts any temporary ban of
+// it.
+func (g *Gateway) Unban(addr string) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+	key, err := parseBanAddress(addr)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	_, blocked := g.blocklist[key]
+	_, banned := g.bans[key]
+	if !blocked && !banned {
+		return errNotBanned
+	}
+	delete(g.blocklist, key)
+	delete(g.bans, key)
+	delete(g.scores, key)
+	g.log.Println("INFO: removed", key, "from the blocklist")
+	return g.saveBlocklist()
+}
+
+// Blocklist returns the addresses on the blocklist and the active temporary
+// bans.
+func (g *Gateway) Blocklist() ([]string, []modules.PeerBan) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.blocklistAndBans()
+}
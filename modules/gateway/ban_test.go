@@ -0,0 +1,123 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// TestPenalizePeer checks that a peer whose score drops too low is
+// disconnected and banned until the ban expires.
+func TestPenalizePeer(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newNamedTestingGateway(t, "1")
+	defer g1.Close()
+	g2 := newNamedTestingGateway(t, "2")
+	defer g2.Close()
+
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+	// A single timeout should not get the peer banned.
+	g1.PenalizePeer(g2.Address(), modules.MisbehaviorRPCTimeout)
+	if len(g1.Peers()) != 1 {
+		t.Fatal("peer was disconnected after a single timeout")
+	}
+	// Two invalid blocks should.
+	g1.PenalizePeer(g2.Address(), modules.MisbehaviorInvalidBlock)
+	g1.PenalizePeer(g2.Address(), modules.MisbehaviorInvalidBlock)
+	if len(g1.Peers()) != 0 {
+		t.Fatal("banned peer was not disconnected")
+	}
+	if _, bans := g1.Blocklist(); len(bans) != 1 || bans[0].Host != string(g2.Address()) {
+		t.Fatal("expected a ban of the peer, got", bans)
+	}
+	if err := g1.Connect(g2.Address()); err != errPeerBanned {
+		t.Fatal("expected errPeerBanned, got", err)
+	}
+
+	// The peer can connect again after the ban expires. g2 may reconnect
+	// to g1 on its own in the meantime.
+	err := build.Retry(50, banDuration/10, func() error {
+		if err := g1.Connect(g2.Address()); err != nil && err != errPeerExists {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("could not connect after the ban expired:", err)
+	}
+	if _, bans := g1.Blocklist(); len(bans) != 0 {
+		t.Fatal("expired ban was not removed:", bans)
+	}
+}
+
+// TestBlocklist checks that addresses on the blocklist are disconnected and
+// that the blocklist is persisted.
+func TestBlocklist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newNamedTestingGateway(t, "1")
+	defer g1.Close()
+	g2 := newNamedTestingGateway(t, "2")
+	defer g2.Close()
+
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+	if err := g1.Ban("not an address"); err != errInvalidBanAddress {
+		t.Fatal("expected errInvalidBanAddress, got", err)
+	}
+	if err := g1.Ban(string(g2.Address())); err != nil {
+		t.Fatal(err)
+	}
+	if len(g1.Peers()) != 0 {
+		t.Fatal("banned peer was not disconnected")
+	}
+	if err := g1.Connect(g2.Address()); err != errPeerBanned {
+		t.Fatal("expected errPeerBanned, got", err)
+	}
+
+	// g2 should not be able to connect to g1 either. The connection is
+	// closed after the handshake, so wait for g2 to notice.
+	_ = g2.Connect(g1.Address())
+	err := build.Retry(50, 100*time.Millisecond, func() error {
+		if len(g1.Peers()) != 0 || len(g2.Peers()) != 0 {
+			return errPeerBanned
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("banned peer was able to connect")
+	}
+
+	// The blocklist should survive a restart.
+	if err := g1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	g1, err = New("localhost:0", false, g1.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g1.Close()
+	if blocklist, _ := g1.Blocklist(); len(blocklist) != 1 || blocklist[0] != string(g2.Address()) {
+		t.Fatal("blocklist was not persisted:", blocklist)
+	}
+
+	if err := g1.Unban(string(g2.Address())); err != nil {
+		t.Fatal(err)
+	}
+	if err := g1.Unban(string(g2.Address())); err != errNotBanned {
+		t.Fatal("expected errNotBanned, got", err)
+	}
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -8,6 +8,10 @@ import (
 )
 
 const (
+	// encryptedTransportVersion is the version from which peers encrypt and
+	// authenticate their connections.
+	encryptedTransportVersion = "1.3.3"
+
 	// handshakeUpgradeVersion is the version where the gateway handshake RPC
 	// was altered to include additional information transfer.
 	handshakeUpgradeVersion = "1.0.0"
@@ -25,12 +29,34 @@ const (
 	// connect to itself, this number can be reduced.
 	maxLocalOutboundPeers = 3
 
+	// maxEncryptedFrameSize is the maximum number of bytes of data sent in a
+	// single frame over an encrypted connection.
+	maxEncryptedFrameSize = 1 << 16
+
 	// minAcceptableVersion is the version below which the gateway will refuse to
 	// connect to peers and reject connection attempts.
 	//
 	// Reject peers < v1.3.0 due to hardfork.
 	minAcceptableVersion = "1.3.0"
 
+	// banThreshold is the score below which a peer is banned.
+	banThreshold = -100
+
+	// penaltyInvalidBlock is the score penalty for relaying an invalid block.
+	penaltyInvalidBlock = 50
+
+	// penaltyMalformedMessage is the score penalty for sending a message that
+	// cannot be understood.
+	penaltyMalformedMessage = 25
+
+	// penaltyRPCTimeout is the score penalty for not responding to an RPC in
+	// time.
+	penaltyRPCTimeout = 10
+
+	// rateLimitPacketSize is the size of the chunks in which rate limited
+	// peer connections are read and written.
+	rateLimitPacketSize = 4 * 4096
+
 	// saveFrequency defines how often the gateway saves its persistence.
 	saveFrequency = time.Minute * 2
 
@@ -107,6 +133,16 @@ var (
 		Dev:      int(40),
 		Testing:  int(20),
 	}).(int)
+
+	// unconfirmedPinTimeout defines how long the identity key pinned for a
+	// node is enforced before the node has authenticated with it a second
+	// time. Once the timeout expires, the next key that the node presents is
+	// pinned instead.
+	unconfirmedPinTimeout = build.Select(build.Var{
+		Standard: 48 * time.Hour,
+		Dev:      1 * time.Hour,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
 )
 
 var (
@@ -133,8 +169,9 @@ var (
 		Testing:  500 * time.Millisecond,
 	}).(time.Duration)
 
-	// fullyConnectedThreshold defines the number of peers that the gateway can
-	// have before it stops accepting inbound connections.
+	// fullyConnectedThreshold defines the number of inbound peers that the
+	// gateway can have before it starts kicking inbound peers to make room for
+	// new ones. It is the default value of the MaxInboundPeers setting.
 	fullyConnectedThreshold = build.Select(build.Var{
 		Standard: 128,
 		Dev:      20,
@@ -180,7 +217,8 @@ var (
 	}).(time.Duration)
 
 	// wellConnectedThreshold is the number of outbound connections at which
-	// the gateway will not attempt to make new outbound connections.
+	// the gateway will not attempt to make new outbound connections. It is the
+	// default value of the MaxOutboundPeers setting.
 	wellConnectedThreshold = build.Select(build.Var{
 		Standard: 8,
 		Dev:      5,
@@ -188,6 +226,24 @@ var (
 	}).(int)
 )
 
+var (
+	// banDuration defines how long a peer is banned after its score drops
+	// below banThreshold.
+	banDuration = build.Select(build.Var{
+		Standard: 24 * time.Hour,
+		Dev:      10 * time.Minute,
+		Testing:  3 * time.Second,
+	}).(time.Duration)
+
+	// scoreRecoveryInterval defines how long it takes the score of a peer to
+	// recover by one point.
+	scoreRecoveryInterval = build.Select(build.Var{
+		Standard: time.Minute,
+		Dev:      10 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
+)
+
 var (
 	// connStdDeadline defines the standard deadline that should be used for
 	// all temporary connections to the gateway.
@@ -0,0 +1,337 @@
+package gateway
+
+import (
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// Peers of version encryptedTransportVersion or higher encrypt and
+// authenticate their connections. After the version handshake, both sides
+// send an ephemeral X25519 public key and derive a pair of Twofish-GCM keys,
+// one per direction, from the shared secret. Each side then proves, over the
+// encrypted connection, that it owns its node identity key by signing the
+// hash of both ephemeral keys. The identity key is an ed25519 key that is
+// generated on first startup and persisted, so that peers can recognize the
+// node across connections.
+//
+// All data that follows the handshake, including the session header, is sent
+// in length-prefixed frames that are encrypted with a counter nonce. A
+// tampered, reordered or replayed frame fails to decrypt and breaks the
+// connection.
+
+const (
+	// identityFile is the name of the file that contains the gateway's
+	// identity key.
+	identityFile = "identity.json"
+)
+
+var (
+	errBadEphemeralKey   = errors.New("peer sent an invalid ephemeral key")
+	errFrameDecryption   = errors.New("could not decrypt frame from peer")
+	errBadIdentityProof  = errors.New("peer's identity proof is invalid")
+	errIdentityChanged   = errors.New("peer's identity key does not match the key pinned for its address")
+	errIdentityDowngrade = errors.New("peer connected over an encrypted connection before, but now claims a version without encryption")
+	errNotPinned         = errors.New("no identity key is pinned for that node")
+
+	// identityMetadata contains the header and version strings that identify
+	// the identity file.
+	identityMetadata = persist.Metadata{
+		Header:  "Sia Gateway Identity",
+		Version: "1.3.3",
+	}
+
+	// specifiers used to derive the keys of the encrypted transport and to
+	// separate the identity proofs of the two sides.
+	specifierDialerKey     = types.Specifier{'d', 'i', 'a', 'l', 'e', 'r', ' ', 'k', 'e', 'y'}
+	specifierListenerKey   = types.Specifier{'l', 'i', 's', 't', 'e', 'n', 'e', 'r', ' ', 'k', 'e', 'y'}
+	specifierDialerProof   = types.Specifier{'d', 'i', 'a', 'l', 'e', 'r', ' ', 'p', 'r', 'o', 'o', 'f'}
+	specifierListenerProof = types.Specifier{'l', 'i', 's', 't', 'e', 'n', 'e', 'r', ' ', 'p', 'r', 'o', 'o', 'f'}
+)
+
+type (
+	// identityPersist is the persisted identity key of the gateway.
+	identityPersist struct {
+		SecretKey crypto.SecretKey `json:"secretkey"`
+	}
+
+	// identityProof proves that the sender of the proof owns PublicKey, and
+	// binds that key to the connection it is sent over.
+	identityProof struct {
+		PublicKey crypto.PublicKey
+		Signature crypto.Signature
+	}
+
+	// encryptedConn is a net.Conn that encrypts and authenticates all data
+	// sent over the underlying conn. remoteKey is the identity key that the
+	// peer proved to own during the handshake.
+	encryptedConn struct {
+		net.Conn
+		remoteKey crypto.PublicKey
+
+		readAEAD  cipher.AEAD
+		readNonce uint64
+		readBuf   []byte
+		readMu    sync.Mutex
+
+		writeAEAD  cipher.AEAD
+		writeNonce uint64
+		writeMu    sync.Mutex
+	}
+)
+
+// frameNonce returns the nonce of the frame with the given sequence number.
+func frameNonce(aead cipher.AEAD, seq uint64) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	copy(nonce, encoding.EncUint64(seq))
+	return nonce
+}
+
+// newTransportAEAD returns a Twofish-GCM cipher keyed by the hash of the
+// given values.
+func newTransportAEAD(vals ...interface{}) cipher.AEAD {
+	key := crypto.TwofishKey(crypto.HashAll(vals...))
+	// NOTE: NewGCM only returns an error if twofishCipher.BlockSize != 16.
+	aead, _ := cipher.NewGCM(key.NewCipher())
+	return aead
+}
+
+// Read implements io.Reader, decrypting one frame at a time.
+func (ec *encryptedConn) Read(b []byte) (int, error) {
+	ec.readMu.Lock()
+	defer ec.readMu.Unlock()
+	if len(ec.readBuf) == 0 {
+		frame, err := encoding.ReadPrefix(ec.Conn, maxEncryptedFrameSize+uint64(ec.readAEAD.Overhead()))
+		if err != nil {
+			return 0, err
+		}
+		plaintext, err := ec.readAEAD.Open(frame[:0], frameNonce(ec.readAEAD, ec.readNonce), frame, nil)
+		if err != nil {
+			return 0, errFrameDecryption
+		}
+		ec.readNonce++
+		ec.readBuf = plaintext
+	}
+	n := copy(b, ec.readBuf)
+	ec.readBuf = ec.readBuf[n:]
+	return n, nil
+}
+
+// Write implements io.Writer, encrypting b in frames of at most
+// maxEncryptedFrameSize bytes.
+func (ec *encryptedConn) Write(b []byte) (int, error) {
+	ec.writeMu.Lock()
+	defer ec.writeMu.Unlock()
+	var n int
+	for len(b) > 0 {
+		chunk := b
+		if len(chunk) > maxEncryptedFrameSize {
+			chunk = chunk[:maxEncryptedFrameSize]
+		}
+		frame := ec.writeAEAD.Seal(nil, frameNonce(ec.writeAEAD, ec.writeNonce), chunk, nil)
+		ec.writeNonce++
+		if err := encoding.WritePrefix(ec.Conn, frame); err != nil {
+			return n, err
+		}
+		n += len(chunk)
+		b = b[len(chunk):]
+	}
+	return n, nil
+}
+
+// encryptionHandshake performs the encryption handshake on conn. dialer
+// indicates whether this side initiated the connection. The returned conn
+// encrypts all further communication, and identifies the peer by the identity
+// key it proved to own.
+func encryptionHandshake(conn net.Conn, sk crypto.SecretKey, dialer bool) (*encryptedConn, error) {
+	// Exchange ephemeral keys. The dialer sends its key first.
+	var ourSecret, ourEphemeral, theirEphemeral [32]byte
+	fastrand.Read(ourSecret[:])
+	curve25519.ScalarBaseMult(&ourEphemeral, &ourSecret)
+	if dialer {
+		if err := encoding.WriteObject(conn, ourEphemeral); err != nil {
+			return nil, err
+		}
+	}
+	if err := encoding.ReadObject(conn, &theirEphemeral, 32); err != nil {
+		return nil, err
+	}
+	if !dialer {
+		if err := encoding.WriteObject(conn, ourEphemeral); err != nil {
+			return nil, err
+		}
+	}
+
+	// Derive the keys of the transport. A peer that sends a low-order point
+	// would force an all-zero shared secret.
+	var shared [32]byte
+	curve25519.ScalarMult(&shared, &ourSecret, &theirEphemeral)
+	if shared == ([32]byte{}) {
+		return nil, errBadEphemeralKey
+	}
+	dialerEphemeral, listenerEphemeral := ourEphemeral, theirEphemeral
+	ourKey, theirKey := specifierDialerKey, specifierListenerKey
+	ourProof, theirProof := specifierDialerProof, specifierListenerProof
+	if !dialer {
+		dialerEphemeral, listenerEphemeral = theirEphemeral, ourEphemeral
+		ourKey, theirKey = theirKey, ourKey
+		ourProof, theirProof = theirProof, ourProof
+	}
+	transcript := crypto.HashAll(dialerEphemeral, listenerEphemeral)
+	ec := &encryptedConn{
+		Conn:      conn,
+		writeAEAD: newTransportAEAD(ourKey, shared, transcript),
+		readAEAD:  newTransportAEAD(theirKey, shared, transcript),
+	}
+
+	// Prove our identity and verify the peer's. The dialer sends its proof
+	// first.
+	proof := identityProof{
+		PublicKey: sk.PublicKey(),
+		Signature: crypto.SignHash(crypto.HashAll(ourProof, transcript), sk),
+	}
+	var remoteProof identityProof
+	if dialer {
+		if err := encoding.WriteObject(ec, proof); err != nil {
+			return nil, err
+		}
+	}
+	if err := encoding.ReadObject(ec, &remoteProof, uint64(len(encoding.Marshal(proof)))); err != nil {
+		return nil, err
+	}
+	if crypto.VerifyHash(crypto.HashAll(theirProof, transcript), remoteProof.PublicKey, remoteProof.Signature) != nil {
+		return nil, errBadIdentityProof
+	}
+	if !dialer {
+		if err := encoding.WriteObject(ec, proof); err != nil {
+			return nil, err
+		}
+	}
+	ec.remoteKey = remoteProof.PublicKey
+	return ec, nil
+}
+
+// loadIdentity loads the gateway's identity key from disk, generating and
+// saving a new key if none exists yet.
+func (g *Gateway) loadIdentity() error {
+	var ip identityPersist
+	path := filepath.Join(g.persistDir, identityFile)
+	err := persist.LoadJSON(identityMetadata, &ip, path)
+	if err == nil {
+		g.identity = ip.SecretKey
+		return nil
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	g.identity, _ = crypto.GenerateKeyPair()
+	return persist.SaveJSON(identityMetadata, identityPersist{g.identity}, path)
+}
+
+// encryptConn performs the encryption handshake on conn if the peer's version
+// supports it, and returns the conn to use for the rest of the connection.
+func (g *Gateway) encryptConn(conn net.Conn, remoteVersion string, dialer bool) (net.Conn, error) {
+	if build.VersionCmp(remoteVersion, encryptedTransportVersion) < 0 {
+		return conn, nil
+	}
+	ec, err := encryptionHandshake(conn, g.identity, dialer)
+	if err != nil {
+		return nil, fmt.Errorf("encryption handshake failed: %v", err)
+	}
+	return ec, nil
+}
+
+// connIdentity returns whether conn is encrypted and, if so, the identity key
+// of the peer.
+func connIdentity(conn net.Conn) (bool, types.SiaPublicKey) {
+	ec, ok := conn.(*encryptedConn)
+	if !ok {
+		return false, types.SiaPublicKey{}
+	}
+	return true, types.Ed25519PublicKey(ec.remoteKey)
+}
+
+// hasActivePin returns true if an identity key is pinned for n and the pin has
+// not expired. Pins that were not confirmed by a second authenticated
+// connection expire after unconfirmedPinTimeout, so that a node whose first
+// connection was impersonated, or that changed its key before reconnecting,
+// is not locked out permanently.
+func (n *node) hasActivePin() bool {
+	if len(n.PublicKey.Key) == 0 {
+		return false
+	}
+	return n.PinConfirmed || time.Since(n.PinTime) < unconfirmedPinTimeout
+}
+
+// checkPinnedIdentity checks a peer at addr against the identity key pinned
+// for addr. The first identity key that a node presents over an encrypted
+// connection is pinned to its address (trust on first use). Later connections
+// with that address must be encrypted and authenticated with the same key, so
+// that another node cannot take over the address, and a peer cannot avoid
+// authentication by claiming a version below encryptedTransportVersion.
+// checkPinnedIdentity must be called while holding g.mu.
+func (g *Gateway) checkPinnedIdentity(addr modules.NetAddress, encrypted bool, key types.SiaPublicKey) error {
+	n, exists := g.nodes[addr]
+	if !exists || !n.hasActivePin() {
+		return nil
+	} else if !encrypted {
+		return errIdentityDowngrade
+	} else if n.PublicKey.String() != key.String() {
+		return errIdentityChanged
+	}
+	return nil
+}
+
+// pinIdentity pins key to the node at addr if the key was authenticated over
+// an encrypted connection and the node has no active pin. If key matches an
+// unconfirmed pin from an earlier connection, the pin is confirmed and no
+// longer expires. pinIdentity must be called while holding g.mu.
+func (g *Gateway) pinIdentity(addr modules.NetAddress, encrypted bool, key types.SiaPublicKey) {
+	n, exists := g.nodes[addr]
+	if !exists || !encrypted {
+		return
+	}
+	if !n.hasActivePin() {
+		n.PublicKey = key
+		n.PinTime = time.Now()
+		n.PinConfirmed = false
+	} else if !n.PinConfirmed && n.PublicKey.String() == key.String() {
+		n.PinConfirmed = true
+	}
+}
+
+// Unpin removes the identity key pinned for the node at addr, so that the
+// node is accepted with the next key that it presents. This is needed when a
+// node legitimately changes its identity key.
+func (g *Gateway) Unpin(addr modules.NetAddress) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	n, exists := g.nodes[addr]
+	if !exists || len(n.PublicKey.Key) == 0 {
+		return errNotPinned
+	}
+	n.PublicKey = types.SiaPublicKey{}
+	n.PinTime = time.Time{}
+	n.PinConfirmed = false
+	g.log.Println("INFO: removed the identity key pinned for", addr)
+	return g.saveSync()
+}
@@ -0,0 +1,253 @@
+package gateway
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// TestEncryptionHandshake checks that the encryption handshake authenticates
+// both sides and that data sent over the encrypted conn arrives intact.
+func TestEncryptionHandshake(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	sk1, pk1 := crypto.GenerateKeyPair()
+	sk2, pk2 := crypto.GenerateKeyPair()
+
+	errChan := make(chan error, 1)
+	var ec2 *encryptedConn
+	go func() {
+		var err error
+		ec2, err = encryptionHandshake(c2, sk2, false)
+		errChan <- err
+	}()
+	ec1, err := encryptionHandshake(c1, sk1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	if ec1.remoteKey != pk2 || ec2.remoteKey != pk1 {
+		t.Fatal("handshake did not exchange the identity keys")
+	}
+
+	// Send more data than fits in a single frame.
+	data := fastrand.Bytes(3*maxEncryptedFrameSize + 100)
+	go func() {
+		errChan <- encoding.WritePrefix(ec1, data)
+	}()
+	received, err := encoding.ReadPrefix(ec2, uint64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(received, data) {
+		t.Fatal("data was corrupted in transit")
+	}
+
+	// A frame that was not encrypted with the session key should be
+	// rejected.
+	go func() {
+		errChan <- encoding.WritePrefix(c1, fastrand.Bytes(100))
+	}()
+	if _, err := ec2.Read(make([]byte, 100)); err != errFrameDecryption {
+		t.Fatal("expected errFrameDecryption, got", err)
+	}
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestEncryptionHandshakeBadProof checks that a peer that signs the wrong
+// transcript is rejected.
+func TestEncryptionHandshakeBadProof(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	sk, _ := crypto.GenerateKeyPair()
+
+	// Act as a dialer that signs the listener's proof instead of its own.
+	go func() {
+		var secret, ephemeral, theirEphemeral [32]byte
+		fastrand.Read(secret[:])
+		curve25519.ScalarBaseMult(&ephemeral, &secret)
+		encoding.WriteObject(c1, ephemeral)
+		encoding.ReadObject(c1, &theirEphemeral, 32)
+		var shared [32]byte
+		curve25519.ScalarMult(&shared, &secret, &theirEphemeral)
+		transcript := crypto.HashAll(ephemeral, theirEphemeral)
+		ec := &encryptedConn{
+			Conn:      c1,
+			writeAEAD: newTransportAEAD(specifierDialerKey, shared, transcript),
+			readAEAD:  newTransportAEAD(specifierListenerKey, shared, transcript),
+		}
+		encoding.WriteObject(ec, identityProof{
+			PublicKey: sk.PublicKey(),
+			Signature: crypto.SignHash(crypto.HashAll(specifierListenerProof, transcript), sk),
+		})
+	}()
+	if _, err := encryptionHandshake(c2, sk, false); err != errBadIdentityProof {
+		t.Fatal("expected errBadIdentityProof, got", err)
+	}
+}
+
+// TestEncryptedPeers checks that gateways encrypt their connections and
+// report each other's identity keys, and that the identity key is persisted.
+func TestEncryptedPeers(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newNamedTestingGateway(t, "1")
+	defer g1.Close()
+	g2 := newNamedTestingGateway(t, "2")
+	defer g2.Close()
+
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+	// The accepting gateway adds the peer asynchronously.
+	err := build.Retry(50, 100*time.Millisecond, func() error {
+		for _, pair := range []struct{ g, remote *Gateway }{{g1, g2}, {g2, g1}} {
+			peers := pair.g.Peers()
+			if len(peers) != 1 {
+				return errors.New("expected a single peer")
+			}
+			pk := pair.remote.PublicKey()
+			if !peers[0].Encrypted || peers[0].PublicKey.String() != pk.String() {
+				return errors.New("peer is not encrypted with the remote's identity key")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The identity key should survive a restart.
+	pk := g1.PublicKey()
+	if err := g1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	g1, err = New("localhost:0", false, g1.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g1.Close()
+	if newPK := g1.PublicKey(); newPK.String() != pk.String() {
+		t.Fatal("identity key was not persisted")
+	}
+}
+
+// TestPinnedIdentity checks that the identity key of a node is pinned on the
+// first encrypted connection, and that connections with a different key or
+// without encryption are refused afterwards.
+func TestPinnedIdentity(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newNamedTestingGateway(t, "1")
+	defer g1.Close()
+	g2 := newNamedTestingGateway(t, "2")
+	defer g2.Close()
+
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+	g1.mu.RLock()
+	pinned := g1.nodes[g2.Address()].PublicKey
+	g1.mu.RUnlock()
+	if pk := g2.PublicKey(); pinned.String() != pk.String() {
+		t.Fatal("identity key of the peer was not pinned")
+	}
+
+	_, pk := crypto.GenerateKeyPair()
+	otherKey := types.Ed25519PublicKey(pk)
+	g1.mu.RLock()
+	defer g1.mu.RUnlock()
+	if err := g1.checkPinnedIdentity(g2.Address(), true, g2.PublicKey()); err != nil {
+		t.Fatal(err)
+	}
+	if err := g1.checkPinnedIdentity(g2.Address(), true, otherKey); err != errIdentityChanged {
+		t.Fatal("expected errIdentityChanged, got", err)
+	}
+	if err := g1.checkPinnedIdentity(g2.Address(), false, types.SiaPublicKey{}); err != errIdentityDowngrade {
+		t.Fatal("expected errIdentityDowngrade, got", err)
+	}
+	// Unknown nodes and nodes without a pinned key are accepted.
+	if err := g1.checkPinnedIdentity("1.2.3.4:5678", false, types.SiaPublicKey{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestPinExpiry checks that unconfirmed pins expire, that a second connection
+// with the pinned key confirms the pin, and that Unpin removes a pin.
+func TestPinExpiry(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g := newTestingGateway(t)
+	defer g.Close()
+
+	addr := modules.NetAddress("1.2.3.4:5678")
+	_, pk1 := crypto.GenerateKeyPair()
+	_, pk2 := crypto.GenerateKeyPair()
+	key1, key2 := types.Ed25519PublicKey(pk1), types.Ed25519PublicKey(pk2)
+
+	g.mu.Lock()
+	g.nodes[addr] = &node{NetAddress: addr}
+	g.pinIdentity(addr, true, key1)
+	if err := g.checkPinnedIdentity(addr, true, key2); err != errIdentityChanged {
+		t.Fatal("expected errIdentityChanged, got", err)
+	}
+	// Once an unconfirmed pin expires, the next key is pinned instead.
+	g.nodes[addr].PinTime = time.Now().Add(-unconfirmedPinTimeout)
+	if err := g.checkPinnedIdentity(addr, true, key2); err != nil {
+		t.Fatal(err)
+	}
+	g.pinIdentity(addr, true, key2)
+	if n := g.nodes[addr]; n.PublicKey.String() != key2.String() || n.PinConfirmed {
+		t.Fatal("expired pin was not replaced")
+	}
+	// A second connection with the pinned key confirms the pin, which then
+	// no longer expires.
+	g.pinIdentity(addr, true, key2)
+	if !g.nodes[addr].PinConfirmed {
+		t.Fatal("pin was not confirmed")
+	}
+	g.nodes[addr].PinTime = time.Now().Add(-unconfirmedPinTimeout)
+	if err := g.checkPinnedIdentity(addr, true, key1); err != errIdentityChanged {
+		t.Fatal("expected errIdentityChanged, got", err)
+	}
+	g.mu.Unlock()
+
+	if err := g.Unpin(addr); err != nil {
+		t.Fatal(err)
+	}
+	g.mu.RLock()
+	err := g.checkPinnedIdentity(addr, true, key1)
+	g.mu.RUnlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Unpin(addr); err != errNotPinned {
+		t.Fatal("expected errNotPinned, got", err)
+	}
+}
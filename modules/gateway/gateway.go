@@ -74,9 +74,6 @@ import (
 // peers of the same IP address, it should favor kicking peers of the same ip
 // address range.
 //
-// TODO: There is no public key exchange, so communications cannot be
-// effectively encrypted or authenticated.
-//
 // TODO: Gateway hostname discovery currently has significant centralization,
 // namely the fallback is a single third-party website that can easily form any
 // response it wants. Instead, multiple TLS-protected third party websites
@@ -89,11 +86,13 @@ import (
 // hostname, which means they will not be able to dial you back, which means
 // they will not add you to their node list.
 //
-// TODO: The gateway should encrypt and authenticate all communications. Though
-// the gateway participates in a flood network, practical attacks have been
-// demonstrated which have been able to confuse nodes by manipulating messages
-// from their peers. Encryption + authentication would have made the attack
-// more difficult.
+// TODO: Connections to peers older than encryptedTransportVersion are neither
+// encrypted nor authenticated. Though the gateway participates in a flood
+// network, practical attacks have been demonstrated which have been able to
+// confuse nodes by manipulating messages from their peers. Once enough of the
+// network has upgraded, unencrypted connections should be refused. Until
+// then, only nodes whose identity key has been pinned are required to
+// encrypt.
 
 import (
 	"errors"
@@ -103,10 +102,13 @@ import (
 	"path/filepath"
 	"sync"
 
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/persist"
 	siasync "github.com/NebulousLabs/Sia/sync"
+	"github.com/NebulousLabs/Sia/types"
 	"github.com/NebulousLabs/fastrand"
+	"github.com/NebulousLabs/ratelimit"
 )
 
 var (
@@ -145,6 +147,23 @@ type Gateway struct {
 	peers  map[modules.NetAddress]*peer
 	peerTG siasync.ThreadGroup
 
+	// scores are the misbehavior scores of peer addresses, and bans are the
+	// addresses that are banned until the given time because their score
+	// dropped too low. blocklist contains the addresses banned by the user.
+	// All three are keyed by banKey.
+	scores    map[string]peerScore
+	bans      map[string]time.Time
+	blocklist map[string]struct{}
+
+	// settings are the peer and bandwidth limits set by the user. rl limits
+	// the bandwidth of all peer connections together.
+	settings modules.GatewaySettings
+	rl       *ratelimit.RateLimit
+
+	// identity is the key with which the gateway authenticates its encrypted
+	// connections.
+	identity crypto.SecretKey
+
 	// Utilities.
 	log        *persist.Logger
 	mu         sync.RWMutex
@@ -176,6 +195,12 @@ func (g *Gateway) Address() modules.NetAddress {
 	return g.myAddr
 }
 
+// PublicKey returns the identity key with which the Gateway authenticates its
+// encrypted connections.
+func (g *Gateway) PublicKey() types.SiaPublicKey {
+	return types.Ed25519PublicKey(g.identity.PublicKey())
+}
+
 // Close saves the state of the Gateway and stops its listener process.
 func (g *Gateway) Close() error {
 	if err := g.threads.Stop(); err != nil {
@@ -201,6 +226,13 @@ func New(addr string, bootstrap bool, persistDir string) (*Gateway, error) {
 		nodes: make(map[modules.NetAddress]*node),
 		peers: make(map[modules.NetAddress]*peer),
 
+		scores:    make(map[string]peerScore),
+		bans:      make(map[string]time.Time),
+		blocklist: make(map[string]struct{}),
+
+		settings: defaultSettings(),
+		rl:       ratelimit.NewRateLimit(0, 0, 0),
+
 		persistDir: persistDir,
 	}
 
@@ -247,6 +279,15 @@ func New(addr string, bootstrap bool, persistDir string) (*Gateway, error) {
 	if loadErr := g.load(); loadErr != nil && !os.IsNotExist(loadErr) {
 		return nil, loadErr
 	}
+	if loadErr := g.loadBlocklist(); loadErr != nil && !os.IsNotExist(loadErr) {
+		return nil, loadErr
+	}
+	if loadErr := g.loadSettings(); loadErr != nil && !os.IsNotExist(loadErr) {
+		return nil, loadErr
+	}
+	if err := g.loadIdentity(); err != nil {
+		return nil, err
+	}
 	// Spawn the thread to periodically save the gateway.
 	go g.threadedSaveLoop()
 	// Make sure that the gateway saves after shutdown.
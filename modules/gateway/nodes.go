@@ -20,10 +20,16 @@ var (
 	errPeerGenesisID = errors.New("peer has different genesis ID")
 )
 
-// A node represents a potential peer on the Sia network.
+// A node represents a potential peer on the Sia network. PublicKey is the
+// identity key pinned for the node, PinTime is when it was pinned, and
+// PinConfirmed is set once the node authenticated with the pinned key again.
+// See checkPinnedIdentity.
 type node struct {
 	NetAddress      modules.NetAddress `json:"netaddress"`
 	WasOutboundPeer bool               `json:"wasoutboundpeer"`
+	PublicKey       types.SiaPublicKey `json:"publickey"`
+	PinTime         time.Time          `json:"pintime"`
+	PinConfirmed    bool               `json:"pinconfirmed"`
 }
 
 // addNode adds an address to the set of nodes on the network.
@@ -36,6 +42,8 @@ func (g *Gateway) addNode(addr modules.NetAddress) error {
 		return errors.New("address is not valid: " + string(addr))
 	} else if net.ParseIP(addr.Host()) == nil {
 		return errors.New("address must be an IP address: " + string(addr))
+	} else if g.isBanned(addr) {
+		return errPeerBanned
 	}
 	g.nodes[addr] = &node{
 		NetAddress:      addr,
@@ -64,6 +72,10 @@ func (g *Gateway) pingNode(addr modules.NetAddress) error {
 	if build.VersionCmp(remoteVersion, minimumAcceptablePeerVersion) < 0 {
 		return nil // for older versions, this is where pinging ends
 	}
+	conn, err = g.encryptConn(conn, remoteVersion, true)
+	if err != nil {
+		return err
+	}
 
 	// Send our header.
 	// NOTE: since we don't intend to complete the connection, we can send an
@@ -174,15 +186,20 @@ func (g *Gateway) requestNodes(conn modules.PeerConn) error {
 
 	g.mu.Lock()
 	changed := false
+	malformed := false
 	for _, node := range nodes {
 		err := g.addNode(node)
-		if err != nil && err != errNodeExists && err != errOurAddress {
+		if err != nil && err != errNodeExists && err != errOurAddress && err != errPeerBanned {
 			g.log.Printf("WARN: peer '%v' sent the invalid addr '%v'", conn.RPCAddr(), node)
+			malformed = true
 		}
 		if err == nil {
 			changed = true
 		}
 	}
+	if malformed {
+		g.penalizePeer(conn.RPCAddr(), modules.MisbehaviorMalformedMessage)
+	}
 	if changed {
 		err := g.saveSync()
 		if err != nil {
@@ -11,6 +11,7 @@ import (
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
 	"github.com/NebulousLabs/fastrand"
+	"github.com/NebulousLabs/ratelimit"
 )
 
 var (
@@ -36,7 +37,8 @@ func (s invalidVersionError) Error() string {
 
 type peer struct {
 	modules.Peer
-	sess streamSession
+	sess    streamSession
+	traffic *peerTraffic
 }
 
 // sessionHeader is sent after the initial version exchange. It prevents peers
@@ -67,6 +69,9 @@ func (p *peer) accept() (modules.PeerConn, error) {
 // addPeer adds a peer to the Gateway's peer list, spawns a listener thread to
 // handle its requests and increments the remotePeers accordingly
 func (g *Gateway) addPeer(p *peer) {
+	if p.traffic == nil {
+		p.traffic = newPeerTraffic()
+	}
 	g.peers[p.NetAddress] = p
 	go g.threadedListenPeer(p)
 }
@@ -128,6 +133,20 @@ func (g *Gateway) threadedAcceptConn(conn net.Conn) {
 	addr := modules.NetAddress(conn.RemoteAddr().String())
 	g.log.Debugf("INFO: %v wants to connect", addr)
 
+	g.mu.RLock()
+	banned := g.isBanned(addr)
+	inboundDisabled := g.settings.MaxInboundPeers == 0
+	g.mu.RUnlock()
+	if banned {
+		g.log.Debugf("INFO: %v wanted to connect but is banned", addr)
+		conn.Close()
+		return
+	} else if inboundDisabled {
+		g.log.Debugf("INFO: %v wanted to connect but inbound connections are disabled", addr)
+		conn.Close()
+		return
+	}
+
 	remoteVersion, err := acceptVersionHandshake(conn, build.Version)
 	if err != nil {
 		g.log.Debugf("INFO: %v wanted to connect but version handshake failed: %v", addr, err)
@@ -169,6 +188,12 @@ func acceptableSessionHeader(ourHeader, remoteHeader sessionHeader, remoteAddr s
 // The requesting peer is added as a node and a peer. The peer is only added if
 // a nil error is returned.
 func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string) error {
+	// Encrypt the connection if the peer supports it.
+	conn, err := g.encryptConn(conn, remoteVersion, false)
+	if err != nil {
+		return err
+	}
+
 	g.log.Debugln("Sending sessionHeader with address", g.myAddr, g.myAddr.IsLocal())
 	// Perform header handshake.
 	g.mu.RLock()
@@ -193,6 +218,16 @@ func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string) err
 	remoteIP := modules.NetAddress(conn.RemoteAddr().String()).Host()
 	remotePort := remoteHeader.NetAddress.Port()
 	remoteAddr := modules.NetAddress(net.JoinHostPort(remoteIP, remotePort))
+	encrypted, remoteKey := connIdentity(conn)
+	g.mu.RLock()
+	banned := g.isBanned(remoteAddr)
+	pinErr := g.checkPinnedIdentity(remoteAddr, encrypted, remoteKey)
+	g.mu.RUnlock()
+	if banned {
+		return errPeerBanned
+	} else if pinErr != nil {
+		return pinErr
+	}
 
 	// Accept the peer.
 	peer := &peer{
@@ -205,8 +240,10 @@ func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string) err
 			// by the host but keeping note of the port number so we can call back
 			NetAddress: remoteAddr,
 			Version:    remoteVersion,
+			Encrypted:  encrypted,
+			PublicKey:  remoteKey,
 		},
-		sess: newServerStream(conn, remoteVersion),
+		sess: newServerStream(ratelimit.NewRLConn(conn, g.rl, g.threads.StopChan()), remoteVersion),
 	}
 	g.mu.Lock()
 	g.acceptPeer(peer)
@@ -221,6 +258,7 @@ func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string) err
 		if err == nil {
 			g.mu.Lock()
 			g.addNode(remoteAddr)
+			g.pinIdentity(remoteAddr, encrypted, remoteKey)
 			g.mu.Unlock()
 		}
 	}()
@@ -228,11 +266,23 @@ func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string) err
 	return nil
 }
 
+// numInboundPeers returns the number of inbound peers in the gateway.
+func (g *Gateway) numInboundPeers() int {
+	n := 0
+	for _, p := range g.peers {
+		if p.Inbound {
+			n++
+		}
+	}
+	return n
+}
+
 // acceptPeer makes room for the peer if necessary by kicking out existing
-// peers, then adds the peer to the peer list.
+// inbound peers, then adds the peer to the peer list.
 func (g *Gateway) acceptPeer(p *peer) {
-	// If we are not fully connected, add the peer without kicking any out.
-	if len(g.peers) < fullyConnectedThreshold {
+	// If we have fewer inbound peers than allowed, add the peer without
+	// kicking any out.
+	if g.numInboundPeers() < g.settings.MaxInboundPeers {
 		g.addPeer(p)
 		return
 	}
@@ -401,9 +451,12 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 	}
 	g.mu.RLock()
 	_, exists := g.peers[addr]
+	banned := g.isBanned(addr)
 	g.mu.RUnlock()
 	if exists {
 		return errPeerExists
+	} else if banned {
+		return errPeerBanned
 	}
 
 	// Dial the peer and perform peer initialization.
@@ -420,7 +473,13 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 	}
 
 	if build.VersionCmp(remoteVersion, minimumAcceptablePeerVersion) >= 0 {
-		err = g.managedConnectPeer(conn, remoteVersion, addr)
+		// Encrypt the connection if the peer supports it.
+		var encConn net.Conn
+		encConn, err = g.encryptConn(conn, remoteVersion, true)
+		if err == nil {
+			conn = encConn
+			err = g.managedConnectPeer(conn, remoteVersion, addr)
+		}
 	} else {
 		err = errors.New("version number is below threshold")
 	}
@@ -437,17 +496,25 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	encrypted, remoteKey := connIdentity(conn)
+	if err := g.checkPinnedIdentity(addr, encrypted, remoteKey); err != nil {
+		conn.Close()
+		return err
+	}
 	g.addPeer(&peer{
 		Peer: modules.Peer{
 			Inbound:    false,
 			Local:      addr.IsLocal(),
 			NetAddress: addr,
 			Version:    remoteVersion,
+			Encrypted:  encrypted,
+			PublicKey:  remoteKey,
 		},
-		sess: newClientStream(conn, remoteVersion),
+		sess: newClientStream(ratelimit.NewRLConn(conn, g.rl, g.threads.StopChan()), remoteVersion),
 	})
 	g.addNode(addr)
 	g.nodes[addr].WasOutboundPeer = true
+	g.pinIdentity(addr, encrypted, remoteKey)
 
 	if err := g.saveSync(); err != nil {
 		g.log.Println("ERROR: Unable to save new outbound peer to gateway:", err)
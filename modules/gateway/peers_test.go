@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
@@ -209,13 +210,19 @@ func TestListen(t *testing.T) {
 		t.Fatal("gateway should have given ack")
 	}
 
+	sk, _ := crypto.GenerateKeyPair()
+	ec, err := encryptionHandshake(conn, sk, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	header := sessionHeader{
 		GenesisID:  types.GenesisID,
 		UniqueID:   gatewayID{},
 		NetAddress: "fake",
 	}
 
-	err = exchangeOurHeader(conn, header)
+	err = exchangeOurHeader(ec, header)
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -235,12 +242,16 @@ func TestListen(t *testing.T) {
 		t.Fatal("gateway should have given ack")
 	}
 
+	ec, err = encryptionHandshake(conn, sk, true)
+	if err != nil {
+		t.Fatal(err)
+	}
 	header.NetAddress = modules.NetAddress(conn.LocalAddr().String())
-	err = exchangeOurHeader(conn, header)
+	err = exchangeOurHeader(ec, header)
 	if err != nil {
 		t.Fatal(err)
 	}
-	_, err = exchangeRemoteHeader(conn, header)
+	_, err = exchangeRemoteHeader(ec, header)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -261,7 +272,7 @@ func TestListen(t *testing.T) {
 
 	// Disconnect. Now that connection has been established, need to shutdown
 	// via the stream multiplexer.
-	newClientStream(conn, build.Version).Close()
+	newClientStream(ec, build.Version).Close()
 
 	// g should remove the peer
 	err = build.Retry(50, 100*time.Millisecond, func() error {
@@ -94,9 +94,10 @@ func (g *Gateway) permanentPeerManager(closedChan chan struct{}) {
 			// Break as soon as we have enough outbound peers.
 			g.mu.RLock()
 			numOutboundPeers := g.numOutboundPeers()
+			maxOutboundPeers := g.settings.MaxOutboundPeers
 			isOutboundPeer := g.peers[addr] != nil && !g.peers[addr].Inbound
 			g.mu.RUnlock()
-			if numOutboundPeers >= wellConnectedThreshold {
+			if numOutboundPeers >= maxOutboundPeers {
 				g.log.Debugln("INFO: [PPM] Gateway has enough peers, sleeping.")
 				if !g.managedSleep(wellConnectedDelay) {
 					return
@@ -2,6 +2,7 @@ package gateway
 
 import (
 	"errors"
+	"net"
 	"sync"
 	"time"
 
@@ -62,8 +63,8 @@ func (g *Gateway) managedRPC(addr modules.NetAddress, name string, fn modules.RP
 		return err
 	}
 	conn.SetDeadline(time.Time{})
-	// call fn
-	return fn(conn)
+	// call fn, counting its traffic
+	return fn(peer.traffic.wrap(conn, handlerName(name)))
 }
 
 // RPC calls an RPC on the given address. RPC cannot be called on an address
@@ -204,17 +205,24 @@ func (g *Gateway) threadedHandleConn(conn modules.PeerConn) {
 		return
 	}
 	if err := encoding.ReadObject(conn, &id, 8); err != nil {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			g.PenalizePeer(conn.RPCAddr(), modules.MisbehaviorRPCTimeout)
+		}
 		return
 	}
 	// call registered handler for this ID
 	g.mu.RLock()
 	fn, ok := g.handlers[id]
+	p, connected := g.peers[conn.RPCAddr()]
 	g.mu.RUnlock()
 	if !ok {
 		g.log.Debugf("WARN: incoming conn %v requested unknown RPC \"%v\"", conn.RPCAddr(), id)
 		return
 	}
 	g.log.Debugf("INFO: incoming conn %v requested RPC \"%v\"", conn.RPCAddr(), id)
+	if connected {
+		conn = p.traffic.wrap(conn, id)
+	}
 
 	// call fn
 	err = fn(conn)
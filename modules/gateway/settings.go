@@ -0,0 +1,91 @@
+package gateway
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
+)
+
+const (
+	// settingsFile is the name of the file that contains the gateway's
+	// settings.
+	settingsFile = "settings.json"
+)
+
+var (
+	errNegativeSetting = errors.New("peer limits and bandwidth limits can't be below 0")
+
+	// settingsMetadata contains the header and version strings that identify
+	// the settings file.
+	settingsMetadata = persist.Metadata{
+		Header:  "Sia Gateway Settings",
+		Version: "1.3.2",
+	}
+)
+
+// defaultSettings returns the settings of a new gateway. Bandwidth is not
+// limited by default.
+func defaultSettings() modules.GatewaySettings {
+	return modules.GatewaySettings{
+		MaxInboundPeers:  fullyConnectedThreshold,
+		MaxOutboundPeers: wellConnectedThreshold,
+	}
+}
+
+// applyRateLimits sets the global bandwidth limits of the peer connections
+// according to the gateway's settings.
+func (g *Gateway) applyRateLimits() {
+	if g.settings.MaxDownloadSpeed == 0 && g.settings.MaxUploadSpeed == 0 {
+		g.rl.SetLimits(0, 0, 0)
+		return
+	}
+	g.rl.SetLimits(g.settings.MaxDownloadSpeed, g.settings.MaxUploadSpeed, rateLimitPacketSize)
+}
+
+// loadSettings loads the gateway's settings from disk.
+func (g *Gateway) loadSettings() error {
+	settings := defaultSettings()
+	err := persist.LoadJSON(settingsMetadata, &settings, filepath.Join(g.persistDir, settingsFile))
+	if err != nil {
+		return err
+	}
+	g.settings = settings
+	g.applyRateLimits()
+	return nil
+}
+
+// saveSettings stores the gateway's settings on disk.
+func (g *Gateway) saveSettings() error {
+	return persist.SaveJSON(settingsMetadata, g.settings, filepath.Join(g.persistDir, settingsFile))
+}
+
+// Settings returns the gateway's peer and bandwidth limits.
+func (g *Gateway) Settings() modules.GatewaySettings {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.settings
+}
+
+// SetSettings changes the gateway's peer and bandwidth limits. The peer
+// limits apply to new connections; existing peers are not disconnected. A
+// MaxInboundPeers of 0 disables inbound connections, and a MaxOutboundPeers
+// of 0 stops the gateway from looking for outbound peers on its own.
+func (g *Gateway) SetSettings(settings modules.GatewaySettings) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+	if settings.MaxInboundPeers < 0 || settings.MaxOutboundPeers < 0 ||
+		settings.MaxDownloadSpeed < 0 || settings.MaxUploadSpeed < 0 {
+		return errNegativeSetting
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.settings = settings
+	g.applyRateLimits()
+	g.log.Printf("INFO: settings changed to %+v", settings)
+	return g.saveSettings()
+}
@@ -0,0 +1,61 @@
+package gateway
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// TestSettings checks that the gateway's settings are validated, persisted
+// and enforced.
+func TestSettings(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newNamedTestingGateway(t, "1")
+	defer g1.Close()
+	g2 := newNamedTestingGateway(t, "2")
+	defer g2.Close()
+
+	if g1.Settings() != defaultSettings() {
+		t.Fatal("new gateway does not use the default settings:", g1.Settings())
+	}
+	if err := g1.SetSettings(modules.GatewaySettings{MaxUploadSpeed: -1}); err != errNegativeSetting {
+		t.Fatal("expected errNegativeSetting, got", err)
+	}
+
+	// Disable inbound connections.
+	settings := modules.GatewaySettings{
+		MaxInboundPeers:  0,
+		MaxOutboundPeers: 2,
+		MaxDownloadSpeed: 1e6,
+		MaxUploadSpeed:   1e6,
+	}
+	if err := g1.SetSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	if err := g2.Connect(g1.Address()); err == nil {
+		t.Fatal("connected to a gateway that does not accept inbound peers")
+	}
+	if len(g1.Peers()) != 0 {
+		t.Fatal("gateway accepted an inbound peer")
+	}
+	// Outbound connections are still allowed.
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The settings should survive a restart.
+	if err := g1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	g1, err := New("localhost:0", false, g1.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g1.Close()
+	if g1.Settings() != settings {
+		t.Fatal("settings were not persisted:", g1.Settings())
+	}
+}
@@ -0,0 +1,109 @@
+package gateway
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// peerTraffic counts the bytes exchanged with a peer during RPCs, per RPC.
+type peerTraffic struct {
+	rpcs map[rpcID]*modules.RPCTraffic
+	mu   sync.Mutex
+}
+
+// trafficConn is a PeerConn that adds the bytes read and written to the
+// traffic of an RPC.
+type trafficConn struct {
+	modules.PeerConn
+	rpc     *modules.RPCTraffic
+	traffic *peerTraffic
+}
+
+// newPeerTraffic returns an empty peerTraffic.
+func newPeerTraffic() *peerTraffic {
+	return &peerTraffic{
+		rpcs: make(map[rpcID]*modules.RPCTraffic),
+	}
+}
+
+// Read implements io.Reader, counting the bytes read as downloaded.
+func (tc *trafficConn) Read(b []byte) (int, error) {
+	n, err := tc.PeerConn.Read(b)
+	tc.traffic.mu.Lock()
+	tc.rpc.Download += uint64(n)
+	tc.traffic.mu.Unlock()
+	return n, err
+}
+
+// Write implements io.Writer, counting the bytes written as uploaded.
+func (tc *trafficConn) Write(b []byte) (int, error) {
+	n, err := tc.PeerConn.Write(b)
+	tc.traffic.mu.Lock()
+	tc.rpc.Upload += uint64(n)
+	tc.traffic.mu.Unlock()
+	return n, err
+}
+
+// wrap records a call of the RPC with the given id and returns a conn that
+// counts the traffic of the call. The RPC id that precedes the call is not
+// counted.
+func (pt *peerTraffic) wrap(conn modules.PeerConn, id rpcID) *trafficConn {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	rpc, exists := pt.rpcs[id]
+	if !exists {
+		rpc = &modules.RPCTraffic{Name: strings.TrimSpace(id.String())}
+		pt.rpcs[id] = rpc
+	}
+	rpc.Calls++
+	return &trafficConn{
+		PeerConn: conn,
+		rpc:      rpc,
+		traffic:  pt,
+	}
+}
+
+// stats returns the traffic of the peer at addr, with the RPCs sorted by
+// name.
+func (pt *peerTraffic) stats(addr modules.NetAddress) modules.PeerTraffic {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	traffic := modules.PeerTraffic{
+		NetAddress: addr,
+		RPCs:       make([]modules.RPCTraffic, 0, len(pt.rpcs)),
+	}
+	for _, rpc := range pt.rpcs {
+		traffic.Download += rpc.Download
+		traffic.Upload += rpc.Upload
+		traffic.RPCs = append(traffic.RPCs, *rpc)
+	}
+	sort.Slice(traffic.RPCs, func(i, j int) bool {
+		return traffic.RPCs[i].Name < traffic.RPCs[j].Name
+	})
+	return traffic
+}
+
+// Traffic returns the bytes exchanged during RPCs with each connected peer,
+// sorted by total traffic with the noisiest peer first. The counters are
+// reset when a peer disconnects.
+func (g *Gateway) Traffic() []modules.PeerTraffic {
+	g.mu.RLock()
+	traffic := make([]modules.PeerTraffic, 0, len(g.peers))
+	for addr, p := range g.peers {
+		traffic = append(traffic, p.traffic.stats(addr))
+	}
+	g.mu.RUnlock()
+
+	sort.Slice(traffic, func(i, j int) bool {
+		ti := traffic[i].Download + traffic[i].Upload
+		tj := traffic[j].Download + traffic[j].Upload
+		if ti != tj {
+			return ti > tj
+		}
+		return traffic[i].NetAddress < traffic[j].NetAddress
+	})
+	return traffic
+}
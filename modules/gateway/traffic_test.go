@@ -0,0 +1,91 @@
+package gateway
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// TestTraffic checks that the bytes exchanged during an RPC are counted for
+// the RPC on both sides of the connection.
+func TestTraffic(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newNamedTestingGateway(t, "1")
+	defer g1.Close()
+	g2 := newNamedTestingGateway(t, "2")
+	defer g2.Close()
+
+	// The RPC reads 100 bytes and responds with 1000 bytes. With the length
+	// prefixes added by the encoding, 116 bytes are sent and 1016 bytes are
+	// received.
+	g2.RegisterRPC("Traffic", func(conn modules.PeerConn) error {
+		var b []byte
+		if err := encoding.ReadObject(conn, &b, 100+8); err != nil {
+			return err
+		}
+		return encoding.WriteObject(conn, make([]byte, 1000))
+	})
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		err := g1.RPC(g2.Address(), "Traffic", func(conn modules.PeerConn) error {
+			if err := encoding.WriteObject(conn, make([]byte, 100)); err != nil {
+				return err
+			}
+			var b []byte
+			return encoding.ReadObject(conn, &b, 1000+8)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// findRPC returns the traffic of the Traffic RPC of the only peer of g.
+	findRPC := func(g *Gateway) (modules.RPCTraffic, error) {
+		traffic := g.Traffic()
+		if len(traffic) != 1 {
+			return modules.RPCTraffic{}, errors.New("expected traffic of a single peer")
+		}
+		for _, rpc := range traffic[0].RPCs {
+			if rpc.Name == "Traffic" {
+				return rpc, nil
+			}
+		}
+		return modules.RPCTraffic{}, errors.New("traffic of the RPC was not counted")
+	}
+	rpc, err := findRPC(g1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rpc.Calls != 2 || rpc.Upload != 2*116 || rpc.Download != 2*1016 {
+		t.Fatalf("wrong traffic for the caller: %+v", rpc)
+	}
+	// The handler may still be running after the caller returns.
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		rpc, err := findRPC(g2)
+		if err != nil {
+			return err
+		}
+		if rpc.Calls != 2 || rpc.Upload != 2*1016 || rpc.Download != 2*116 {
+			return errors.New("wrong traffic for the handler")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The totals should include the traffic of every RPC.
+	traffic := g1.Traffic()[0]
+	if traffic.NetAddress != g2.Address() || traffic.Upload < 2*116 || traffic.Download < 2*1016 {
+		t.Fatalf("wrong total traffic: %+v", traffic)
+	}
+}
@@ -44,6 +44,26 @@ var (
 	// received more than workingThreshold settings calls over the duration of
 	// workingStatusFrequency.
 	HostWorkingStatusWorking = HostWorkingStatus("working")
+
+	// ObligationHealthUnknown is reported for a storage obligation that has
+	// not yet been checked by the host.
+	ObligationHealthUnknown = ObligationHealth("unknown")
+
+	// ObligationHealthHealthy is reported for a storage obligation for which
+	// the host was able to build and verify a storage proof during its most
+	// recent check.
+	ObligationHealthHealthy = ObligationHealth("healthy")
+
+	// ObligationHealthProofFailing is reported for a storage obligation for
+	// which the host was unable to build a valid storage proof, typically
+	// because a sector is missing or corrupted.
+	ObligationHealthProofFailing = ObligationHealth("proof failing")
+
+	// ObligationHealthRevisionUnconfirmed is reported for a storage
+	// obligation whose most recent file contract revision has not been
+	// confirmed on the blockchain even though the host has attempted to
+	// submit it.
+	ObligationHealthRevisionUnconfirmed = ObligationHealth("revision unconfirmed")
 )
 
 type (
@@ -80,6 +100,7 @@ type (
 	// HostInternalSettings contains a list of settings that can be changed.
 	HostInternalSettings struct {
 		AcceptingContracts   bool              `json:"acceptingcontracts"`
+		MaintenanceMode      bool              `json:"maintenancemode"`
 		MaxDownloadBatchSize uint64            `json:"maxdownloadbatchsize"`
 		MaxDuration          types.BlockHeight `json:"maxduration"`
 		MaxReviseBatchSize   uint64            `json:"maxrevisebatchsize"`
@@ -96,6 +117,19 @@ type (
 		MinUploadBandwidthPrice   types.Currency `json:"minuploadbandwidthprice"`
 	}
 
+	// HostMaintenanceStatus reports how far along the host is in draining its
+	// storage obligations. While in maintenance mode the host refuses new
+	// contracts and renewals and stops announcing, so the host can be retired
+	// once the last obligation has been resolved. The expiry time is an
+	// estimate based on the target block frequency.
+	HostMaintenanceStatus struct {
+		MaintenanceMode   bool              `json:"maintenancemode"`
+		ActiveObligations uint64            `json:"activeobligations"`
+		LastExpiryHeight  types.BlockHeight `json:"lastexpiryheight"`
+		LastExpiryTime    types.Timestamp   `json:"lastexpirytime"`
+		RemainingBlocks   types.BlockHeight `json:"remainingblocks"`
+	}
+
 	// HostNetworkMetrics reports the quantity of each type of RPC call that
 	// has been made to the host.
 	HostNetworkMetrics struct {
@@ -111,7 +145,8 @@ type (
 	// StorageObligation contains information about a storage obligation that
 	// the host has accepted.
 	StorageObligation struct {
-		NegotiationHeight types.BlockHeight `json:"negotiationheight"`
+		ObligationID      types.FileContractID `json:"obligationid"`
+		NegotiationHeight types.BlockHeight    `json:"negotiationheight"`
 
 		OriginConfirmed     bool   `json:"originconfirmed"`
 		RevisionConstructed bool   `json:"revisionconstructed"`
@@ -119,8 +154,28 @@ type (
 		ProofConstructed    bool   `json:"proofconstructed"`
 		ProofConfirmed      bool   `json:"proofconfirmed"`
 		ObligationStatus    uint64 `json:"obligationstatus"`
+
+		// Storage usage of the obligation. LogicalBytes is the amount of data
+		// protected by the obligation. PhysicalBytes is the amount of disk
+		// space consumed by the distinct sectors of the obligation, and
+		// SharedBytes is the portion of PhysicalBytes that is also referenced
+		// by other obligations.
+		LogicalBytes  uint64 `json:"logicalbytes"`
+		PhysicalBytes uint64 `json:"physicalbytes"`
+		SharedBytes   uint64 `json:"sharedbytes"`
+
+		// The result of the most recent storage proof dry run performed by
+		// the host on the obligation.
+		Health            ObligationHealth  `json:"health"`
+		HealthError       string            `json:"healtherror"`
+		HealthCheckHeight types.BlockHeight `json:"healthcheckheight"`
 	}
 
+	// ObligationHealth reports whether the host expects to be able to fulfill
+	// a storage obligation. Can be one of "unknown", "healthy", "proof
+	// failing", or "revision unconfirmed".
+	ObligationHealth string
+
 	// HostWorkingStatus reports the working state of a host. Can be one of
 	// "checking", "working", or "not working".
 	HostWorkingStatus string
@@ -139,6 +194,10 @@ type (
 		// AnnounceAddress submits an announcement using the given address.
 		AnnounceAddress(NetAddress) error
 
+		// AnnounceAddresses submits an announcement listing several addresses
+		// of the host, in order of preference.
+		AnnounceAddresses([]NetAddress) error
+
 		// ExternalSettings returns the settings of the host as seen by an
 		// untrusted node querying the host for settings.
 		ExternalSettings() HostExternalSettings
@@ -150,6 +209,10 @@ type (
 		// potentially private or sensitive information.
 		InternalSettings() HostInternalSettings
 
+		// MaintenanceStatus reports the progress of the host in draining its
+		// storage obligations.
+		MaintenanceStatus() HostMaintenanceStatus
+
 		// NetworkMetrics returns information on the types of RPC calls that
 		// have been made to the host.
 		NetworkMetrics() HostNetworkMetrics
@@ -17,8 +17,9 @@ var (
 	errUnknownAddress = errors.New("host cannot announce, does not seem to have a valid address.")
 )
 
-// managedAnnounce creates an announcement transaction and submits it to the network.
-func (h *Host) managedAnnounce(addr modules.NetAddress) error {
+// managedAnnounce creates an announcement transaction and submits it to the
+// network. The addresses are announced in order of preference.
+func (h *Host) managedAnnounce(addrs ...modules.NetAddress) error {
 	// The wallet needs to be unlocked to add fees to the transaction, and the
 	// host needs to have an active unlock hash that renters can make payment
 	// to.
@@ -29,15 +30,19 @@ func (h *Host) managedAnnounce(addr modules.NetAddress) error {
 	h.mu.Lock()
 	pubKey := h.publicKey
 	secKey := h.secretKey
+	maintenanceMode := h.settings.MaintenanceMode
 	err := h.checkUnlockHash()
 	h.mu.Unlock()
+	if maintenanceMode {
+		return errMaintenanceMode
+	}
 	if err != nil {
 		return err
 	}
 
 	// Create the announcement that's going to be added to the arbitrary data
 	// field of the transaction.
-	signedAnnouncement, err := modules.CreateAnnouncement(addr, pubKey, secKey)
+	signedAnnouncement, err := modules.CreateMultiAddressAnnouncement(addrs, pubKey, secKey)
 	if err != nil {
 		return err
 	}
@@ -45,7 +50,7 @@ func (h *Host) managedAnnounce(addr modules.NetAddress) error {
 	// Create a transaction, with a fee, that contains the full announcement.
 	txnBuilder := h.wallet.StartTransaction()
 	_, fee := h.tpool.FeeEstimation()
-	fee = fee.Mul64(600) // Estimated txn size (in bytes) of a host announcement.
+	fee = fee.Mul64(600 + 400*uint64(len(addrs)-1)) // Estimated txn size (in bytes) of a host announcement.
 	err = txnBuilder.FundSiacoins(fee)
 	if err != nil {
 		txnBuilder.Drop()
@@ -69,7 +74,7 @@ func (h *Host) managedAnnounce(addr modules.NetAddress) error {
 	h.mu.Lock()
 	h.announced = true
 	h.mu.Unlock()
-	h.log.Printf("INFO: Successfully announced as %v", addr)
+	h.log.Printf("INFO: Successfully announced as %v", addrs)
 	return nil
 }
 
@@ -118,31 +123,45 @@ func (h *Host) Announce() error {
 // specific address. If there is no error, the host's address will be updated
 // to the supplied address.
 func (h *Host) AnnounceAddress(addr modules.NetAddress) error {
+	return h.AnnounceAddresses([]modules.NetAddress{addr})
+}
+
+// AnnounceAddresses submits a host announcement to the blockchain listing
+// several addresses for the host, such as an IPv4 address, an IPv6 address
+// and an onion address, in order of preference. If there is no error, the
+// host's address will be updated to the first supplied address.
+func (h *Host) AnnounceAddresses(addrs []modules.NetAddress) error {
 	err := h.tg.Add()
 	if err != nil {
 		return err
 	}
 	defer h.tg.Done()
 
-	// Check that the address is sane, and that the address is also not local.
-	err = addr.IsStdValid()
-	if err != nil {
-		return build.ExtendErr("announcement requested with bad net address", err)
+	// Check that the addresses are sane, and that the addresses are also not
+	// local.
+	if len(addrs) == 0 {
+		return errors.New("announcement requested without a net address")
 	}
-	if addr.IsLocal() {
-		return errors.New("announcement requested with local net address")
+	for _, addr := range addrs {
+		err = addr.IsStdValid()
+		if err != nil {
+			return build.ExtendErr("announcement requested with bad net address", err)
+		}
+		if addr.IsLocal() {
+			return errors.New("announcement requested with local net address")
+		}
 	}
 
 	// Attempt the actual announcement.
-	err = h.managedAnnounce(addr)
+	err = h.managedAnnounce(addrs...)
 	if err != nil {
 		return build.ExtendErr("unable to perform manual host announcement", err)
 	}
 
-	// Address is valid, update the host's internal net address to match the
-	// specified addr.
+	// Addresses are valid, update the host's internal net address to match
+	// the preferred address.
 	h.mu.Lock()
-	h.settings.NetAddress = addr
+	h.settings.NetAddress = addrs[0]
 	h.mu.Unlock()
 	return nil
 }
@@ -13,8 +13,9 @@ import (
 type announcementFinder struct {
 	cs modules.ConsensusSet
 
-	// Announcements that have been seen. The two slices are wedded.
+	// Announcements that have been seen. The slices are wedded.
 	netAddresses []modules.NetAddress
+	allAddresses [][]modules.NetAddress
 	publicKeys   []types.SiaPublicKey
 }
 
@@ -24,9 +25,10 @@ func (af *announcementFinder) ProcessConsensusChange(cc modules.ConsensusChange)
 	for _, block := range cc.AppliedBlocks {
 		for _, txn := range block.Transactions {
 			for _, arb := range txn.ArbitraryData {
-				addr, pubKey, err := modules.DecodeAnnouncement(arb)
+				addrs, pubKey, err := modules.DecodeMultiAddressAnnouncement(arb)
 				if err == nil {
-					af.netAddresses = append(af.netAddresses, addr)
+					af.netAddresses = append(af.netAddresses, addrs[0])
+					af.allAddresses = append(af.allAddresses, addrs)
 					af.publicKeys = append(af.publicKeys, pubKey)
 				}
 			}
@@ -137,6 +139,51 @@ func TestHostAnnounceAddress(t *testing.T) {
 	}
 }
 
+// TestHostAnnounceAddresses checks that the host can announce several
+// addresses at once.
+func TestHostAnnounceAddresses(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	af, err := newAnnouncementFinder(ht.cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer af.Close()
+
+	// Announce an IPv4 address, an IPv6 address and an onion address.
+	addrs := []modules.NetAddress{"1.2.3.4:1234", "[2001:db8::1]:1234", "abcdefghijklmnop.onion:1234"}
+	err = ht.host.AnnounceAddresses(addrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ht.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(af.allAddresses) != 1 {
+		t.Fatal("could not find host announcement in blockchain")
+	}
+	if len(af.allAddresses[0]) != len(addrs) {
+		t.Fatal("announcement has the wrong number of addresses:", af.allAddresses[0])
+	}
+	for i := range addrs {
+		if af.allAddresses[0][i] != addrs[i] {
+			t.Error("announcement has wrong address at index", i, af.allAddresses[0][i])
+		}
+	}
+	if ht.host.InternalSettings().NetAddress != addrs[0] {
+		t.Error("host net address should be set to the preferred address")
+	}
+}
+
 // TestHostAnnounceCheckUnlockHash verifies that the host's unlock hash is
 // checked when an announcement is performed.
 func TestHostAnnounceCheckUnlockHash(t *testing.T) {
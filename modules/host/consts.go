@@ -170,6 +170,16 @@ var (
 		Testing:  uint64(5),
 	}).(uint64)
 
+	// obligationHealthCheckFrequency defines how often the host performs a
+	// storage proof dry run on each of its storage obligations. Each check
+	// reads one full sector per obligation from disk, so the checks should
+	// not happen too frequently.
+	obligationHealthCheckFrequency = build.Select(build.Var{
+		Dev:      time.Minute * 10,
+		Standard: time.Hour * 6,
+		Testing:  time.Second * 5,
+	}).(time.Duration)
+
 	// obligationLockTimeout defines how long a thread will wait to get a lock
 	// on a storage obligation before timing out and reporting an error to the
 	// renter.
@@ -110,6 +110,27 @@ var (
 	}).(uint64)
 )
 
+var (
+	// storageFolderMigrationBandwidth is the maximum number of bytes per
+	// second that are copied while migrating a storage folder to a new path.
+	// The cap leaves disk throughput available for serving renters while the
+	// migration is in progress.
+	storageFolderMigrationBandwidth = build.Select(build.Var{
+		Dev:      uint64(1 << 26), // 64 MiB/s
+		Standard: uint64(1 << 25), // 32 MiB/s
+		Testing:  uint64(1 << 24), // 16 MiB/s
+	}).(uint64)
+
+	// storageFolderMigrationCheckpoint is the number of sectors that are
+	// copied between each time the progress of a storage folder migration is
+	// saved to the WAL.
+	storageFolderMigrationCheckpoint = build.Select(build.Var{
+		Dev:      uint32(1 << 6),
+		Standard: uint32(1 << 8),
+		Testing:  uint32(1 << 4),
+	}).(uint32)
+)
+
 var (
 	// folderRecheckInitialInterval specifies the amount of time that the
 	// contract manager will initially wait when checking to see if an
@@ -185,6 +185,10 @@ func newContractManager(dependencies modules.Dependencies, persistDir string) (*
 			if err != nil {
 				cm.log.Println("Error closing the storage folder file handle", err)
 			}
+			err = sf.closeMigrationFiles()
+			if err != nil {
+				cm.log.Println("Error closing the storage folder migration file handles", err)
+			}
 		}
 	})
 
@@ -212,6 +216,14 @@ func newContractManager(dependencies modules.Dependencies, persistDir string) (*
 	// and adds them if they are discovered.
 	go cm.threadedFolderRecheck()
 
+	// Resume any storage folder migrations that were interrupted by the
+	// previous shutdown.
+	for _, sf := range cm.storageFolders {
+		if sf.migrationPath != "" {
+			go cm.wal.threadedMigrateStorageFolder(sf)
+		}
+	}
+
 	// Simulate an error to make sure the cleanup code is triggered correctly.
 	if cm.dependencies.Disrupt("erroredStartup") {
 		err = errors.New("startup disrupted")
@@ -19,6 +19,9 @@ type (
 		Index uint16
 		Path  string
 		Usage []uint64
+
+		MigrationPath     string
+		MigrationProgress uint32
 	}
 
 	// savedSettings contains fields that are saved atomically to disk inside
@@ -35,6 +38,9 @@ func (sf *storageFolder) savedStorageFolder() savedStorageFolder {
 		Index: sf.index,
 		Path:  sf.path,
 		Usage: make([]uint64, len(sf.usage)),
+
+		MigrationPath:     sf.migrationPath,
+		MigrationProgress: sf.migrationProgress,
 	}
 	copy(ssf.Usage, sf.usage)
 	return ssf
@@ -92,6 +98,19 @@ func (cm *ContractManager) loadSettings() error {
 			}
 		}
 		sf.availableSectors = make(map[sectorID]uint32)
+
+		// Open the files at the new path of a storage folder that was being
+		// migrated, so that the migration can resume.
+		if ss.StorageFolders[i].MigrationPath != "" && atomic.LoadUint64(&sf.atomicUnavailable) == 0 {
+			sf.migrationPath = ss.StorageFolders[i].MigrationPath
+			sf.migrationProgress = ss.StorageFolders[i].MigrationProgress
+			err = cm.openMigrationFiles(sf)
+			if err != nil {
+				cm.log.Printf("ERROR: unable to resume migration of %v to %v: %v\n", sf.path, sf.migrationPath, err)
+				sf.migrationPath = ""
+				sf.migrationProgress = 0
+			}
+		}
 		cm.storageFolders[sf.index] = sf
 	}
 	return nil
@@ -153,7 +153,9 @@ func (cm *ContractManager) ReadSector(root crypto.Hash) ([]byte, error) {
 	}
 
 	// Read the sector.
+	sf.fileMu.RLock()
 	sectorData, err := readSector(sf.sectorFile, sl.index)
+	sf.fileMu.RUnlock()
 	if err != nil {
 		atomic.AddUint64(&sf.atomicFailedReads, 1)
 		return nil, build.ExtendErr("unable to fetch sector", err)
@@ -162,6 +164,30 @@ func (cm *ContractManager) ReadSector(root crypto.Hash) ([]byte, error) {
 	return sectorData, nil
 }
 
+// SectorReferenceCounts returns the number of virtual sectors that are being
+// tracked for each of the provided sector roots. Identical sectors are only
+// stored on disk once, the reference count indicates how many times the data
+// has been added to the contract manager.
+func (cm *ContractManager) SectorReferenceCounts(roots []crypto.Hash) ([]uint64, error) {
+	err := cm.tg.Add()
+	if err != nil {
+		return nil, err
+	}
+	defer cm.tg.Done()
+
+	ids := make([]sectorID, len(roots))
+	for i, root := range roots {
+		ids[i] = cm.managedSectorID(root)
+	}
+	counts := make([]uint64, len(roots))
+	cm.wal.mu.Lock()
+	for i, id := range ids {
+		counts[i] = uint64(cm.sectorLocations[id].count)
+	}
+	cm.wal.mu.Unlock()
+	return counts, nil
+}
+
 // managedLockSector grabs a sector lock.
 func (wal *writeAheadLog) managedLockSector(id sectorID) {
 	wal.mu.Lock()
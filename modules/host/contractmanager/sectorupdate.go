@@ -356,6 +356,9 @@ func (wal *writeAheadLog) managedRemoveSector(id sectorID) error {
 // writeSectorMetadata will take a sector update and write the related metadata
 // to disk.
 func (wal *writeAheadLog) writeSectorMetadata(sf *storageFolder, su sectorUpdate) error {
+	sf.fileMu.RLock()
+	defer sf.fileMu.RUnlock()
+
 	err := writeSectorMetadata(sf.metadataFile, su.Index, su.ID, su.Count)
 	if err != nil {
 		wal.cm.log.Printf("ERROR: unable to write sector metadata to folder %v when adding sector: %v\n", su.Folder, err)
@@ -363,6 +366,16 @@ func (wal *writeAheadLog) writeSectorMetadata(sf *storageFolder, su sectorUpdate
 		return err
 	}
 	atomic.AddUint64(&sf.atomicSuccessfulWrites, 1)
+
+	// If the storage folder is being migrated, the metadata at the new path
+	// also needs to be updated.
+	if sf.migrationMetadataFile != nil {
+		err = writeSectorMetadata(sf.migrationMetadataFile, su.Index, su.ID, su.Count)
+		if err != nil {
+			wal.cm.log.Printf("ERROR: unable to write sector metadata to the new path of folder %v: %v\n", su.Folder, err)
+			return err
+		}
+	}
 	return nil
 }
 
@@ -1906,3 +1906,59 @@ func TestFailingStorageFolder(t *testing.T) {
 		}
 	}
 }
+
+// TestSectorReferenceCounts checks that the contract manager correctly reports
+// the number of virtual sectors held for each sector root.
+func TestSectorReferenceCounts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add one sector three times and another sector once.
+	root1, data1 := randSector()
+	root2, data2 := randSector()
+	for i := 0; i < 3; i++ {
+		err = cmt.cm.AddSector(root1, data1)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = cmt.cm.AddSector(root2, data2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root3, _ := randSector()
+	counts, err := cmt.cm.SectorReferenceCounts([]crypto.Hash{root1, root2, root3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts[0] != 3 || counts[1] != 1 || counts[2] != 0 {
+		t.Error("wrong reference counts:", counts)
+	}
+
+	// The storage folder should report the deduplicated usage.
+	sfs := cmt.cm.StorageFolders()
+	if sfs[0].LogicalBytes != 4*modules.SectorSize {
+		t.Error("wrong number of logical bytes:", sfs[0].LogicalBytes)
+	}
+	if sfs[0].PhysicalBytes != 2*modules.SectorSize {
+		t.Error("wrong number of physical bytes:", sfs[0].PhysicalBytes)
+	}
+}
@@ -113,11 +113,28 @@ type storageFolder struct {
 	availableSectors map[sectorID]uint32
 	sectors          uint64
 
+	// migrationPath is the path that the storage folder is being migrated to,
+	// and is empty if there is no migration in progress. migrationProgress is
+	// the index of the next sector that needs to be copied to the new path.
+	// While a migration is in progress, all sector metadata updates are
+	// written to both the old and the new metadata file.
+	migrationPath         string
+	migrationProgress     uint32
+	migrationMetadataFile modules.File
+	migrationSectorFile   modules.File
+
 	// mu needs to be RLocked to safetly write new sectors into the storage
 	// folder. mu needs to be Locked when the folder is being added, removed,
 	// or resized.
 	mu sync.TryRWMutex
 
+	// fileMu needs to be RLocked while the file handles are used outside of
+	// the storage folder lock, and Locked while the file handles are swapped
+	// at the end of a migration. This keeps the old files open until all
+	// in-flight reads and writes have finished. If the WAL lock is also
+	// needed, it must be grabbed before fileMu.
+	fileMu sync.TryRWMutex
+
 	// An open file handle is kept so that writes can easily be made to the
 	// storage folder without needing to grab a new file handle. This also
 	// makes it easy to do delayed-syncing.
@@ -363,10 +380,14 @@ func (cm *ContractManager) ResizeStorageFolder(index uint16, newSize uint64, for
 
 	cm.wal.mu.Lock()
 	sf, exists := cm.storageFolders[index]
+	migrating := exists && sf.migrationPath != ""
 	cm.wal.mu.Unlock()
 	if !exists || atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
 		return errStorageFolderNotFound
 	}
+	if migrating {
+		return errStorageFolderMigrating
+	}
 
 	if newSize/modules.SectorSize < MinimumSectorsPerStorageFolder {
 		return ErrSmallStorageFolder
@@ -398,6 +419,12 @@ func (cm *ContractManager) StorageFolders() []modules.StorageFolderMetadata {
 	cm.wal.mu.Lock()
 	defer cm.wal.mu.Unlock()
 
+	// Tally up the number of virtual sectors held by each storage folder.
+	virtualSectors := make(map[uint16]uint64)
+	for _, sl := range cm.sectorLocations {
+		virtualSectors[sl.storageFolder] += uint64(sl.count)
+	}
+
 	// Iterate over the storage folders that are in memory first, and then
 	// suppliment them with the storage folders that are not in memory.
 	var smfs []modules.StorageFolderMetadata
@@ -416,6 +443,10 @@ func (cm *ContractManager) StorageFolders() []modules.StorageFolderMetadata {
 			CapacityRemaining: ((64 * uint64(len(sf.usage))) - sf.sectors) * modules.SectorSize,
 			Index:             sf.index,
 			Path:              sf.path,
+			MigrationPath:     sf.migrationPath,
+
+			LogicalBytes:  virtualSectors[sf.index] * modules.SectorSize,
+			PhysicalBytes: sf.sectors * modules.SectorSize,
 		}
 
 		// Set some of the values to extreme numbers if the storage folder is
@@ -0,0 +1,520 @@
+package contractmanager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+var (
+	// errStorageFolderMigrating is returned if an operation is attempted on a
+	// storage folder that is in the middle of being migrated to a new path.
+	errStorageFolderMigrating = errors.New("storage folder is being migrated to a new path")
+)
+
+type (
+	// storageFolderMigration is the data saved to the WAL to track the
+	// progress of a storage folder that is being migrated to a new path.
+	// Progress is the index of the next sector that needs to be copied.
+	storageFolderMigration struct {
+		Index    uint16
+		OldPath  string
+		NewPath  string
+		Progress uint32
+	}
+)
+
+// findUnfinishedStorageFolderMigrations will scroll through a set of state
+// changes and pull out the most recent progress of every storage folder
+// migration which has not yet completed.
+func findUnfinishedStorageFolderMigrations(scs []stateChange) []storageFolderMigration {
+	// Use a map to figure out what unfinished storage folder migrations exist
+	// and use it to remove the ones that have terminated.
+	usfmMap := make(map[uint16]storageFolderMigration)
+	for _, sc := range scs {
+		for _, usfm := range sc.UnfinishedStorageFolderMigrations {
+			usfmMap[usfm.Index] = usfm
+		}
+		for _, sfm := range sc.StorageFolderMigrations {
+			delete(usfmMap, sfm.Index)
+		}
+		for _, index := range sc.ErroredStorageFolderMigrations {
+			delete(usfmMap, index)
+		}
+		for _, sfr := range sc.StorageFolderRemovals {
+			delete(usfmMap, sfr.Index)
+		}
+	}
+
+	// Return the active unfinished storage folder migrations as a slice.
+	usfms := make([]storageFolderMigration, 0, len(usfmMap))
+	for _, usfm := range usfmMap {
+		usfms = append(usfms, usfm)
+	}
+	return usfms
+}
+
+// openMigrationFiles opens the metadata and sector files at the migration
+// path of the storage folder.
+func (cm *ContractManager) openMigrationFiles(sf *storageFolder) error {
+	var err error
+	sf.migrationMetadataFile, err = cm.dependencies.OpenFile(filepath.Join(sf.migrationPath, metadataFile), os.O_RDWR, 0700)
+	if err != nil {
+		return build.ExtendErr("unable to open migration metadata file", err)
+	}
+	sf.migrationSectorFile, err = cm.dependencies.OpenFile(filepath.Join(sf.migrationPath, sectorFile), os.O_RDWR, 0700)
+	if err != nil {
+		err = build.ComposeErrors(err, sf.migrationMetadataFile.Close())
+		sf.migrationMetadataFile = nil
+		return build.ExtendErr("unable to open migration sector file", err)
+	}
+	return nil
+}
+
+// closeMigrationFiles closes the metadata and sector files at the migration
+// path of the storage folder.
+func (sf *storageFolder) closeMigrationFiles() error {
+	var err error
+	if sf.migrationMetadataFile != nil {
+		err = build.ComposeErrors(err, sf.migrationMetadataFile.Close())
+	}
+	if sf.migrationSectorFile != nil {
+		err = build.ComposeErrors(err, sf.migrationSectorFile.Close())
+	}
+	sf.migrationMetadataFile = nil
+	sf.migrationSectorFile = nil
+	return err
+}
+
+// commitStorageFolderMigrationProgress restores the progress of a storage
+// folder migration. commitStorageFolderMigrationProgress should only be called
+// during WAL recovery.
+func (wal *writeAheadLog) commitStorageFolderMigrationProgress(sfm storageFolderMigration) {
+	sf, exists := wal.cm.storageFolders[sfm.Index]
+	if !exists || atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+		wal.cm.log.Printf("ERROR: unable to locate storage folder for migration to %v\n", sfm.NewPath)
+		return
+	}
+	if sf.path != sfm.OldPath {
+		// The migration has already completed.
+		return
+	}
+	if sf.migrationPath != sfm.NewPath || sf.migrationMetadataFile == nil {
+		err := sf.closeMigrationFiles()
+		if err != nil {
+			wal.cm.log.Println("ERROR: unable to close migration files:", err)
+		}
+		sf.migrationPath = sfm.NewPath
+		err = wal.cm.openMigrationFiles(sf)
+		if err != nil {
+			wal.cm.log.Printf("ERROR: unable to resume migration of %v to %v: %v\n", sf.path, sf.migrationPath, err)
+			sf.migrationPath = ""
+			sf.migrationProgress = 0
+			return
+		}
+	}
+	sf.migrationProgress = sfm.Progress
+}
+
+// commitStorageFolderMigration switches a storage folder over to the path that
+// it has been migrated to and deletes the files at the old path.
+// commitStorageFolderMigration should only be called during WAL recovery.
+func (wal *writeAheadLog) commitStorageFolderMigration(sfm storageFolderMigration) {
+	sf, exists := wal.cm.storageFolders[sfm.Index]
+	if !exists {
+		wal.cm.log.Printf("ERROR: unable to locate storage folder for migration to %v\n", sfm.NewPath)
+		return
+	}
+	if sf.path == sfm.OldPath {
+		// Make sure that the files at the new path are open.
+		if sf.migrationPath != sfm.NewPath || sf.migrationMetadataFile == nil {
+			err := sf.closeMigrationFiles()
+			if err != nil {
+				wal.cm.log.Println("ERROR: unable to close migration files:", err)
+			}
+			sf.migrationPath = sfm.NewPath
+			err = wal.cm.openMigrationFiles(sf)
+			if err != nil {
+				wal.cm.log.Printf("ERROR: unable to complete migration of %v to %v: %v\n", sf.path, sf.migrationPath, err)
+				return
+			}
+		}
+
+		// Close the old files and switch to the new ones.
+		if sf.metadataFile != nil {
+			sf.metadataFile.Close()
+		}
+		if sf.sectorFile != nil {
+			sf.sectorFile.Close()
+		}
+		sf.metadataFile = sf.migrationMetadataFile
+		sf.sectorFile = sf.migrationSectorFile
+		sf.path = sf.migrationPath
+		sf.migrationMetadataFile = nil
+		sf.migrationSectorFile = nil
+		sf.migrationPath = ""
+		sf.migrationProgress = 0
+		atomic.StoreUint64(&sf.atomicUnavailable, 0)
+	}
+
+	// Delete the files at the old path. The files may have already been
+	// deleted before the WAL was cleaned up.
+	err := wal.cm.dependencies.RemoveFile(filepath.Join(sfm.OldPath, metadataFile))
+	if err != nil && !os.IsNotExist(err) {
+		wal.cm.log.Printf("Error: unable to remove metadata file as storage folder %v is migrated\n", sfm.OldPath)
+	}
+	err = wal.cm.dependencies.RemoveFile(filepath.Join(sfm.OldPath, sectorFile))
+	if err != nil && !os.IsNotExist(err) {
+		wal.cm.log.Printf("Error: unable to remove sector file as storage folder %v is migrated\n", sfm.OldPath)
+	}
+}
+
+// commitErroredStorageFolderMigration abandons the migration of a storage
+// folder, deleting any files that were created at the new path.
+func (wal *writeAheadLog) commitErroredStorageFolderMigration(index uint16) {
+	sf, exists := wal.cm.storageFolders[index]
+	if !exists || sf.migrationPath == "" {
+		return
+	}
+	err := sf.closeMigrationFiles()
+	if err != nil {
+		wal.cm.log.Println("ERROR: unable to close migration files:", err)
+	}
+	err = wal.cm.dependencies.RemoveFile(filepath.Join(sf.migrationPath, metadataFile))
+	if err != nil && !os.IsNotExist(err) {
+		wal.cm.log.Printf("Error: unable to remove metadata file of abandoned migration to %v\n", sf.migrationPath)
+	}
+	err = wal.cm.dependencies.RemoveFile(filepath.Join(sf.migrationPath, sectorFile))
+	if err != nil && !os.IsNotExist(err) {
+		wal.cm.log.Printf("Error: unable to remove sector file of abandoned migration to %v\n", sf.migrationPath)
+	}
+	sf.migrationPath = ""
+	sf.migrationProgress = 0
+	atomic.StoreUint64(&sf.atomicProgressNumerator, 0)
+	atomic.StoreUint64(&sf.atomicProgressDenominator, 0)
+}
+
+// managedCopySector copies the sector at the provided index of a storage
+// folder to the same index in the new location of the storage folder. The
+// sector metadata is copied while holding the sector lock, any later updates
+// to the metadata are mirrored into the new location by writeSectorMetadata.
+func (wal *writeAheadLog) managedCopySector(sf *storageFolder, sectorIndex uint32) error {
+	// Fetch the id of the sector in this location. The id can not change
+	// during the migration, because no new sectors can be added to a storage
+	// folder that is locked.
+	metadata := make([]byte, sectorMetadataDiskSize)
+	_, err := sf.metadataFile.ReadAt(metadata, int64(sectorIndex)*sectorMetadataDiskSize)
+	if err != nil {
+		atomic.AddUint64(&sf.atomicFailedReads, 1)
+		return build.ExtendErr("unable to read sector metadata", err)
+	}
+	var id sectorID
+	copy(id[:], metadata)
+
+	wal.managedLockSector(id)
+	defer wal.managedUnlockSector(id)
+
+	// Re-read the metadata now that the sector is locked, the count may have
+	// changed in the meantime.
+	_, err = sf.metadataFile.ReadAt(metadata, int64(sectorIndex)*sectorMetadataDiskSize)
+	if err != nil {
+		atomic.AddUint64(&sf.atomicFailedReads, 1)
+		return build.ExtendErr("unable to read sector metadata", err)
+	}
+	sectorData, err := readSector(sf.sectorFile, sectorIndex)
+	if err != nil {
+		atomic.AddUint64(&sf.atomicFailedReads, 1)
+		return build.ExtendErr("unable to read sector selected for migration", err)
+	}
+	atomic.AddUint64(&sf.atomicSuccessfulReads, 1)
+
+	err = writeSector(sf.migrationSectorFile, sectorIndex, sectorData)
+	if err != nil {
+		return build.ExtendErr("unable to write sector to new location", err)
+	}
+	_, err = sf.migrationMetadataFile.WriteAt(metadata, int64(sectorIndex)*sectorMetadataDiskSize)
+	if err != nil {
+		return build.ExtendErr("unable to write sector metadata to new location", err)
+	}
+	return nil
+}
+
+// managedSyncMigrationFiles syncs the files at the new location of a storage
+// folder that is being migrated.
+func (sf *storageFolder) managedSyncMigrationFiles() error {
+	var wg sync.WaitGroup
+	var err1, err2 error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		err1 = sf.migrationMetadataFile.Sync()
+	}()
+	go func() {
+		defer wg.Done()
+		err2 = sf.migrationSectorFile.Sync()
+	}()
+	wg.Wait()
+	return build.ComposeErrors(err1, err2)
+}
+
+// managedMigrateStorageFolder copies every sector in a storage folder to the
+// new location of the storage folder, starting from the last saved progress.
+// Sectors keep the same index in the new location, which means that the
+// sector locations of the contract manager remain valid and the switch to the
+// new location is a single atomic update to the WAL.
+//
+// The storage folder needs to be locked so that no new sectors are placed
+// into it while the migration is in progress.
+func (wal *writeAheadLog) managedMigrateStorageFolder(sf *storageFolder) error {
+	wal.mu.Lock()
+	numSectors := uint32(len(sf.usage)) * storageFolderGranularity
+	progress := sf.migrationProgress
+	oldPath := sf.path
+	newPath := sf.migrationPath
+	wal.mu.Unlock()
+	atomic.StoreUint64(&sf.atomicProgressNumerator, uint64(progress)*modules.SectorSize)
+	atomic.StoreUint64(&sf.atomicProgressDenominator, uint64(numSectors)*modules.SectorSize)
+
+	// Copy the sectors one at a time, waiting between copies so that the
+	// bandwidth cap is not exceeded.
+	copyTime := time.Duration(modules.SectorSize * uint64(time.Second) / storageFolderMigrationBandwidth)
+	nextCopy := time.Now()
+	for progress < numSectors {
+		select {
+		case <-wal.cm.tg.StopChan():
+			// The migration will resume from the most recent checkpoint when
+			// the contract manager is restarted.
+			return nil
+		case <-time.After(time.Until(nextCopy)):
+		}
+		if atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+			return errStorageFolderNotFound
+		}
+
+		// Only sectors which are in use need to be copied.
+		wal.mu.Lock()
+		usageElement := sf.usage[progress/storageFolderGranularity]
+		wal.mu.Unlock()
+		usageMask := uint64(1) << (progress % storageFolderGranularity)
+		if usageElement&usageMask == usageMask {
+			err := wal.managedCopySector(sf, progress)
+			if err != nil {
+				return err
+			}
+			nextCopy = nextCopy.Add(copyTime)
+			if now := time.Now(); nextCopy.Before(now) {
+				nextCopy = now
+			}
+		}
+		progress++
+		atomic.StoreUint64(&sf.atomicProgressNumerator, uint64(progress)*modules.SectorSize)
+
+		// Periodically save the progress to the WAL. The copied sectors need
+		// to be synced before the progress is saved.
+		if progress%storageFolderMigrationCheckpoint == 0 && progress < numSectors {
+			err := sf.managedSyncMigrationFiles()
+			if err != nil {
+				return build.ExtendErr("unable to sync migrated sectors", err)
+			}
+			wal.mu.Lock()
+			sf.migrationProgress = progress
+			wal.appendChange(stateChange{
+				UnfinishedStorageFolderMigrations: []storageFolderMigration{{
+					Index:    sf.index,
+					OldPath:  oldPath,
+					NewPath:  newPath,
+					Progress: progress,
+				}},
+			})
+			wal.mu.Unlock()
+		}
+	}
+
+	// Simulate an interruption between copying the last sector and switching
+	// the storage folder over to the new location.
+	if wal.cm.dependencies.Disrupt("storageFolderMigrationFinish") {
+		return nil
+	}
+
+	// All of the sectors have been copied. Sync the new files and then switch
+	// the storage folder over to them.
+	err := sf.managedSyncMigrationFiles()
+	if err != nil {
+		return build.ExtendErr("unable to sync migrated sectors", err)
+	}
+
+	// Reads and metadata writes can happen concurrently while holding a sector
+	// lock rather than the storage folder lock. The file lock waits for them
+	// to finish, after which they will only use the new files.
+	wal.mu.Lock()
+	sf.fileMu.Lock()
+	oldMetadataFile := sf.metadataFile
+	oldSectorFile := sf.sectorFile
+	sf.metadataFile = sf.migrationMetadataFile
+	sf.sectorFile = sf.migrationSectorFile
+	sf.migrationMetadataFile = nil
+	sf.migrationSectorFile = nil
+	sf.fileMu.Unlock()
+	sf.path = newPath
+	sf.migrationPath = ""
+	sf.migrationProgress = 0
+	atomic.StoreUint64(&sf.atomicProgressNumerator, 0)
+	atomic.StoreUint64(&sf.atomicProgressDenominator, 0)
+	wal.appendChange(stateChange{
+		StorageFolderMigrations: []storageFolderMigration{{
+			Index:    sf.index,
+			OldPath:  oldPath,
+			NewPath:  newPath,
+			Progress: numSectors,
+		}},
+	})
+	syncChan := wal.syncChan
+	wal.mu.Unlock()
+	<-syncChan
+
+	// The switch has been committed, the files at the old path can be
+	// removed.
+	err = build.ComposeErrors(oldMetadataFile.Close(), oldSectorFile.Close())
+	if err != nil {
+		wal.cm.log.Printf("Error: unable to close old files as storage folder %v is migrated: %v\n", oldPath, err)
+	}
+	err = wal.cm.dependencies.RemoveFile(filepath.Join(oldPath, metadataFile))
+	if err != nil {
+		wal.cm.log.Printf("Error: unable to remove metadata file as storage folder %v is migrated\n", oldPath)
+	}
+	err = wal.cm.dependencies.RemoveFile(filepath.Join(oldPath, sectorFile))
+	if err != nil {
+		wal.cm.log.Printf("Error: unable to remove sector file as storage folder %v is migrated\n", oldPath)
+	}
+	return nil
+}
+
+// threadedMigrateStorageFolder migrates a storage folder to its new path in
+// the background. If the migration fails, it is abandoned and the storage
+// folder remains at its old path.
+func (wal *writeAheadLog) threadedMigrateStorageFolder(sf *storageFolder) {
+	err := wal.cm.tg.Add()
+	if err != nil {
+		return
+	}
+	defer wal.cm.tg.Done()
+
+	// Lock the storage folder for the duration of the migration, preventing
+	// new sectors from being added to it.
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	err = wal.managedMigrateStorageFolder(sf)
+	if err == nil {
+		return
+	}
+	wal.cm.log.Printf("ERROR: unable to migrate storage folder %v: %v\n", sf.path, err)
+	wal.mu.Lock()
+	wal.commitErroredStorageFolderMigration(sf.index)
+	wal.appendChange(stateChange{
+		ErroredStorageFolderMigrations: []uint16{sf.index},
+	})
+	wal.mu.Unlock()
+}
+
+// MigrateStorageFolder moves a storage folder to a new path. The sectors in
+// the storage folder are copied to the new path in the background, at a rate
+// which leaves disk throughput available for renters. The storage folder
+// remains available for reads during the migration, and the progress is
+// reported through StorageFolders. An interrupted migration resumes when the
+// contract manager is restarted.
+func (cm *ContractManager) MigrateStorageFolder(index uint16, newPath string) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+
+	// Check that the path is an absolute path.
+	if !filepath.IsAbs(newPath) {
+		return errRelativePath
+	}
+	// Check that the folder being linked to both exists and is a folder.
+	pathInfo, err := os.Stat(newPath)
+	if err != nil {
+		return err
+	}
+	if !pathInfo.Mode().IsDir() {
+		return errStorageFolderNotFolder
+	}
+
+	// Create the files at the new path and record the start of the migration
+	// in the WAL.
+	var sf *storageFolder
+	var syncChan chan struct{}
+	err = func() error {
+		cm.wal.mu.Lock()
+		defer cm.wal.mu.Unlock()
+
+		var exists bool
+		sf, exists = cm.storageFolders[index]
+		if !exists || atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+			return errStorageFolderNotFound
+		}
+		if sf.migrationPath != "" {
+			return errStorageFolderMigrating
+		}
+		for _, csf := range cm.storageFolders {
+			if csf.path == newPath || csf.migrationPath == newPath {
+				return ErrRepeatFolder
+			}
+		}
+
+		// Create the files at the new path. The sparse files are allocated to
+		// the full size of the storage folder.
+		numSectors := uint64(len(sf.usage)) * storageFolderGranularity
+		sectorLookupName := filepath.Join(newPath, metadataFile)
+		sectorHousingName := filepath.Join(newPath, sectorFile)
+		var err error
+		sf.migrationMetadataFile, err = cm.dependencies.CreateFile(sectorLookupName)
+		if err != nil {
+			return build.ExtendErr("could not create storage folder file", err)
+		}
+		sf.migrationSectorFile, err = cm.dependencies.CreateFile(sectorHousingName)
+		if err == nil {
+			err = sf.migrationMetadataFile.Truncate(int64(numSectors * sectorMetadataDiskSize))
+		}
+		if err == nil {
+			err = sf.migrationSectorFile.Truncate(int64(numSectors * modules.SectorSize))
+		}
+		if err != nil {
+			err = build.ComposeErrors(err, sf.closeMigrationFiles())
+			err = build.ComposeErrors(err, cm.dependencies.RemoveFile(sectorLookupName))
+			err = build.ComposeErrors(err, cm.dependencies.RemoveFile(sectorHousingName))
+			return build.ExtendErr("could not allocate storage folder files", err)
+		}
+
+		sf.migrationPath = newPath
+		sf.migrationProgress = 0
+		atomic.StoreUint64(&sf.atomicProgressNumerator, 0)
+		atomic.StoreUint64(&sf.atomicProgressDenominator, numSectors*modules.SectorSize)
+		cm.wal.appendChange(stateChange{
+			UnfinishedStorageFolderMigrations: []storageFolderMigration{{
+				Index:   index,
+				OldPath: sf.path,
+				NewPath: newPath,
+			}},
+		})
+		syncChan = cm.wal.syncChan
+		return nil
+	}()
+	if err != nil {
+		return err
+	}
+	<-syncChan
+
+	// Hand the copying off to a background thread.
+	go cm.wal.threadedMigrateStorageFolder(sf)
+	return nil
+}
@@ -0,0 +1,347 @@
+package contractmanager
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// dependencyInterruptMigration is a mocked dependency that will stop a storage
+// folder migration right before the storage folder is switched to the new
+// path.
+type dependencyInterruptMigration struct {
+	modules.ProductionDependencies
+}
+
+// Disrupt will interrupt the storage folder migration.
+func (d *dependencyInterruptMigration) Disrupt(s string) bool {
+	return s == "storageFolderMigrationFinish"
+}
+
+// waitForMigration blocks until the storage folder at the provided index has
+// finished migrating to the new path.
+func waitForMigration(cm *ContractManager, index uint16, newPath string) error {
+	return build.Retry(100, 100*time.Millisecond, func() error {
+		for _, sf := range cm.StorageFolders() {
+			if sf.Index != index {
+				continue
+			}
+			if sf.Path != newPath || sf.MigrationPath != "" {
+				return errStorageFolderMigrating
+			}
+			return nil
+		}
+		return errStorageFolderNotFound
+	})
+}
+
+// TestMigrateStorageFolder checks that a storage folder can be moved to a new
+// path, and that all of the sectors remain available after the move.
+func TestMigrateStorageFolder(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// Add a storage folder to the contract manager tester.
+	storageFolderOne := filepath.Join(cmt.persistDir, "storageFolderOne")
+	storageFolderTwo := filepath.Join(cmt.persistDir, "storageFolderTwo")
+	err = os.MkdirAll(storageFolderOne, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(storageFolderTwo, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderOne, modules.SectorSize*storageFolderGranularity*2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fill the storage folder with sectors, adding one of them twice.
+	var roots []crypto.Hash
+	var datas [][]byte
+	for i := 0; i < 10; i++ {
+		root, data := randSector()
+		err = cmt.cm.AddSector(root, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+		datas = append(datas, data)
+	}
+	err = cmt.cm.AddSector(roots[0], datas[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Migration should fail for relative paths and for paths that are already
+	// in use.
+	sfs := cmt.cm.StorageFolders()
+	if len(sfs) != 1 {
+		t.Fatal("there should be one storage folder in the contract manager")
+	}
+	index := sfs[0].Index
+	err = cmt.cm.MigrateStorageFolder(index, "storageFolderTwo")
+	if err != errRelativePath {
+		t.Fatal("expecting errRelativePath, got", err)
+	}
+	err = cmt.cm.MigrateStorageFolder(index, storageFolderOne)
+	if err != ErrRepeatFolder {
+		t.Fatal("expecting ErrRepeatFolder, got", err)
+	}
+
+	// Migrate the storage folder and wait for the migration to complete.
+	err = cmt.cm.MigrateStorageFolder(index, storageFolderTwo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = waitForMigration(cmt.cm, index, storageFolderTwo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sfs = cmt.cm.StorageFolders()
+	if sfs[0].PhysicalBytes != 10*modules.SectorSize || sfs[0].LogicalBytes != 11*modules.SectorSize {
+		t.Error("storage folder usage changed during migration:", sfs[0].PhysicalBytes, sfs[0].LogicalBytes)
+	}
+	if sfs[0].ProgressDenominator != 0 {
+		t.Error("progress should be reset after the migration completes")
+	}
+
+	// The files at the old path should be removed once the switch to the new
+	// path has been committed.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		_, err := os.Stat(filepath.Join(storageFolderOne, metadataFile))
+		if !os.IsNotExist(err) {
+			return errors.New("metadata file should have been removed")
+		}
+		_, err = os.Stat(filepath.Join(storageFolderOne, sectorFile))
+		if !os.IsNotExist(err) {
+			return errors.New("sector file should have been removed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check that all of the sectors can still be read, including after a
+	// restart.
+	for i := 0; i < 2; i++ {
+		for j, root := range roots {
+			data, err := cmt.cm.ReadSector(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, datas[j]) {
+				t.Fatal("sector data does not match after migration")
+			}
+		}
+		err = cmt.cm.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmt.cm, err = New(filepath.Join(cmt.persistDir, modules.ContractManagerDir))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Removing the duplicate sector twice should leave no sectors behind,
+	// meaning that the virtual sector count survived the migration.
+	err = cmt.cm.RemoveSector(roots[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.RemoveSector(roots[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cmt.cm.ReadSector(roots[0])
+	if err != ErrSectorNotFound {
+		t.Fatal("expecting ErrSectorNotFound, got", err)
+	}
+}
+
+// TestMigrateStorageFolderConcurrentReads checks that sectors can be read
+// while a storage folder is migrated, including while the storage folder is
+// switched over to the new path.
+func TestMigrateStorageFolderConcurrentReads(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	storageFolderOne := filepath.Join(cmt.persistDir, "storageFolderOne")
+	storageFolderTwo := filepath.Join(cmt.persistDir, "storageFolderTwo")
+	err = os.MkdirAll(storageFolderOne, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(storageFolderTwo, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderOne, modules.SectorSize*storageFolderGranularity*2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var roots []crypto.Hash
+	var datas [][]byte
+	for i := 0; i < 5; i++ {
+		root, data := randSector()
+		err = cmt.cm.AddSector(root, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+		datas = append(datas, data)
+	}
+
+	// Keep reading the sectors until the migration has completed.
+	index := cmt.cm.StorageFolders()[0].Index
+	err = cmt.cm.MigrateStorageFolder(index, storageFolderTwo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	readErrs := make(chan error, 1)
+	go func() {
+		defer close(readErrs)
+		for {
+			for i, root := range roots {
+				data, err := cmt.cm.ReadSector(root)
+				if err != nil {
+					readErrs <- err
+					return
+				}
+				if !bytes.Equal(data, datas[i]) {
+					readErrs <- errors.New("sector data does not match during migration")
+					return
+				}
+			}
+			select {
+			case <-done:
+				return
+			default:
+			}
+		}
+	}()
+	err = waitForMigration(cmt.cm, index, storageFolderTwo)
+	close(done)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-readErrs; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestMigrateStorageFolderRestart checks that a storage folder migration which
+// is interrupted by a shutdown resumes after a restart.
+func TestMigrateStorageFolderRestart(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	d := new(dependencyInterruptMigration)
+	cmt, err := newMockedContractManagerTester(d, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	storageFolderOne := filepath.Join(cmt.persistDir, "storageFolderOne")
+	storageFolderTwo := filepath.Join(cmt.persistDir, "storageFolderTwo")
+	err = os.MkdirAll(storageFolderOne, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(storageFolderTwo, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderOne, modules.SectorSize*storageFolderGranularity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var roots []crypto.Hash
+	var datas [][]byte
+	for i := 0; i < 5; i++ {
+		root, data := randSector()
+		err = cmt.cm.AddSector(root, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+		datas = append(datas, data)
+	}
+
+	// Start the migration. The dependency stops the migration once all of the
+	// sectors have been copied, but before the switch.
+	sfs := cmt.cm.StorageFolders()
+	index := sfs[0].Index
+	err = cmt.cm.MigrateStorageFolder(index, storageFolderTwo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		sfs := cmt.cm.StorageFolders()
+		if sfs[0].ProgressNumerator != sfs[0].ProgressDenominator {
+			return errStorageFolderMigrating
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sfs = cmt.cm.StorageFolders()
+	if sfs[0].Path != storageFolderOne || sfs[0].MigrationPath != storageFolderTwo {
+		t.Fatal("storage folder should still be migrating:", sfs[0].Path, sfs[0].MigrationPath)
+	}
+	err = cmt.cm.RemoveStorageFolder(index, false)
+	if err != errStorageFolderMigrating {
+		t.Fatal("expecting errStorageFolderMigrating, got", err)
+	}
+
+	// Restart the contract manager, the migration should resume and complete.
+	err = cmt.cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm, err = New(filepath.Join(cmt.persistDir, modules.ContractManagerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = waitForMigration(cmt.cm, index, storageFolderTwo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for j, root := range roots {
+		data, err := cmt.cm.ReadSector(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, datas[j]) {
+			t.Fatal("sector data does not match after migration")
+		}
+	}
+}
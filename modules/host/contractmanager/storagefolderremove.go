@@ -58,6 +58,10 @@ func (cm *ContractManager) RemoveStorageFolder(index uint16, force bool) error {
 		cm.wal.mu.Unlock()
 		return errStorageFolderNotFound
 	}
+	if sf.migrationPath != "" {
+		cm.wal.mu.Unlock()
+		return errStorageFolderMigrating
+	}
 	cm.wal.mu.Unlock()
 
 	// Lock the storage folder for the duration of the operation.
@@ -57,6 +57,17 @@ type (
 		UnfinishedStorageFolderAdditions  []savedStorageFolder
 		UnfinishedStorageFolderExtensions []unfinishedStorageFolderExtension
 
+		// These fields relate to migrating a storage folder to a new path.
+		// Migration is a long running operation which copies the sectors of
+		// the folder one at a time. Progress is recorded periodically as an
+		// 'UnfinishedStorageFolderMigration' so that the copy can resume
+		// after a restart. A 'StorageFolderMigration' indicates that all of
+		// the sectors have been copied and that the storage folder now lives
+		// at the new path.
+		ErroredStorageFolderMigrations    []uint16
+		StorageFolderMigrations           []storageFolderMigration
+		UnfinishedStorageFolderMigrations []storageFolderMigration
+
 		// Updates to the sector metadata. Careful ordering of events ensures
 		// that a sector update will not make it into the synced WAL unless the
 		// sector data is already on-disk and synced.
@@ -187,6 +198,21 @@ func (wal *writeAheadLog) commitChange(sc stateChange) {
 			wal.commitStorageFolderRemoval(sfr)
 		}
 	}
+	for _, usfm := range sc.UnfinishedStorageFolderMigrations {
+		for i := uint64(0); i < wal.cm.dependencies.AtLeastOne(); i++ {
+			wal.commitStorageFolderMigrationProgress(usfm)
+		}
+	}
+	for _, sfm := range sc.StorageFolderMigrations {
+		for i := uint64(0); i < wal.cm.dependencies.AtLeastOne(); i++ {
+			wal.commitStorageFolderMigration(sfm)
+		}
+	}
+	for _, index := range sc.ErroredStorageFolderMigrations {
+		for i := uint64(0); i < wal.cm.dependencies.AtLeastOne(); i++ {
+			wal.commitErroredStorageFolderMigration(index)
+		}
+	}
 	for _, su := range sc.SectorUpdates {
 		for i := uint64(0); i < wal.cm.dependencies.AtLeastOne(); i++ {
 			wal.commitUpdateSector(su)
@@ -79,6 +79,19 @@ func (wal *writeAheadLog) syncResources() {
 				wal.cm.log.Severe("ERROR: unable to sync a storage folder:", err)
 			}
 		}(sf)
+
+		// Metadata updates are mirrored into the new location of a storage
+		// folder that is being migrated, and need to be synced as well.
+		if sf.migrationMetadataFile != nil {
+			wg.Add(1)
+			go func(sf *storageFolder) {
+				defer wg.Done()
+				err := sf.migrationMetadataFile.Sync()
+				if err != nil {
+					wal.cm.log.Severe("ERROR: unable to sync a migrating storage folder:", err)
+				}
+			}(sf)
+		}
 	}
 
 	// Sync the temp WAL file, but do not perform the atmoic rename - the
@@ -206,6 +219,7 @@ func (wal *writeAheadLog) commit() {
 		// Extract any unfinished long-running jobs from the list of WAL items.
 		unfinishedAdditions := findUnfinishedStorageFolderAdditions(wal.uncommittedChanges)
 		unfinishedExtensions := findUnfinishedStorageFolderExtensions(wal.uncommittedChanges)
+		unfinishedMigrations := findUnfinishedStorageFolderMigrations(wal.uncommittedChanges)
 
 		// Recreate the wal file so that it can receive new updates.
 		var err error
@@ -224,6 +238,7 @@ func (wal *writeAheadLog) commit() {
 		wal.appendChange(stateChange{
 			UnfinishedStorageFolderAdditions:  unfinishedAdditions,
 			UnfinishedStorageFolderExtensions: unfinishedExtensions,
+			UnfinishedStorageFolderMigrations: unfinishedMigrations,
 		})
 
 		// Clear the set of uncommitted changes.
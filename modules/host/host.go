@@ -160,6 +160,10 @@ type Host struct {
 	workingStatus        modules.HostWorkingStatus
 	connectabilityStatus modules.HostConnectabilityStatus
 
+	// The results of the most recent storage proof dry run for each of the
+	// unresolved storage obligations. These values are not persistent.
+	obligationHealth map[types.FileContractID]obligationHealth
+
 	// A map of storage obligations that are currently being modified. Locks on
 	// storage obligations can be long-running, and each storage obligation can
 	// be locked separately.
@@ -189,7 +193,7 @@ func (h *Host) checkUnlockHash() error {
 		}
 	}
 	if !hasAddr || h.unlockHash == (types.UnlockHash{}) {
-		uc, err := h.wallet.NextAddress()
+		uc, err := h.wallet.NextAddressForPurpose(modules.PurposeHost)
 		if err != nil {
 			return err
 		}
@@ -231,6 +235,7 @@ func newHost(dependencies modules.Dependencies, cs modules.ConsensusSet, tpool m
 		dependencies: dependencies,
 
 		lockedStorageObligations: make(map[types.FileContractID]*siasync.TryMutex),
+		obligationHealth:         make(map[types.FileContractID]obligationHealth),
 
 		persistDir: persistDir,
 	}
@@ -297,6 +302,14 @@ func newHost(dependencies modules.Dependencies, cs modules.ConsensusSet, tpool m
 		h.log.Println("Could not initialize host networking:", err)
 		return nil, err
 	}
+
+	// Periodically check that the host is able to fulfill its storage
+	// obligations.
+	threadedCheckObligationHealthClosedChan := make(chan struct{})
+	go h.threadedCheckObligationHealth(threadedCheckObligationHealthClosedChan)
+	h.tg.OnStop(func() {
+		<-threadedCheckObligationHealthClosedChan
+	})
 	return h, nil
 }
 
@@ -395,6 +408,14 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 		h.announced = false
 	}
 
+	if settings.MaintenanceMode != h.settings.MaintenanceMode {
+		if settings.MaintenanceMode {
+			h.log.Println("INFO: host entering maintenance mode, contracts will no longer be formed or renewed")
+		} else {
+			h.log.Println("INFO: host leaving maintenance mode")
+		}
+	}
+
 	h.settings = settings
 	h.revisionNumber++
 
@@ -0,0 +1,63 @@
+package host
+
+// maintenance.go reports on the progress of a host that is in maintenance
+// mode. A host in maintenance mode refuses new contracts and renewals and
+// stops announcing itself, but keeps serving the obligations it already has
+// until the final proof window closes. Once every obligation has been
+// resolved the host can be shut down without losing any collateral.
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+var (
+	// errMaintenanceMode is returned if the host is asked to perform an
+	// action which is not allowed while the host is in maintenance mode.
+	errMaintenanceMode = errors.New("host is in maintenance mode")
+)
+
+// MaintenanceStatus returns the number of unresolved storage obligations held
+// by the host, along with the height and an estimated time at which the last
+// of them will be resolved.
+func (h *Host) MaintenanceStatus() modules.HostMaintenanceStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	status := modules.HostMaintenanceStatus{
+		MaintenanceMode: h.settings.MaintenanceMode,
+	}
+	err := h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			err := json.Unmarshal(soBytes, &so)
+			if err != nil {
+				return err
+			}
+			if so.ObligationStatus != obligationUnresolved {
+				return nil
+			}
+			status.ActiveObligations++
+			if so.proofDeadline() > status.LastExpiryHeight {
+				status.LastExpiryHeight = so.proofDeadline()
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		h.log.Println("ERROR: unable to load storage obligations for maintenance status:", err)
+	}
+
+	if status.LastExpiryHeight > h.blockHeight {
+		status.RemainingBlocks = status.LastExpiryHeight - h.blockHeight
+	}
+	drainTime := time.Duration(status.RemainingBlocks*types.BlockFrequency) * time.Second
+	status.LastExpiryTime = types.Timestamp(time.Now().Add(drainTime).Unix())
+	return status
+}
@@ -0,0 +1,80 @@
+package host
+
+import (
+	"testing"
+)
+
+// TestMaintenanceMode checks that a host in maintenance mode refuses to
+// announce, advertises that it is not accepting contracts, and reports the
+// expiry of its last storage obligation.
+func TestMaintenanceMode(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// Add a storage obligation to the host.
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.managedAddStorageObligation(so)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedUnlockStorageObligation(so.id())
+
+	// Outside of maintenance mode the status should still report the
+	// obligation.
+	ms := ht.host.MaintenanceStatus()
+	if ms.MaintenanceMode {
+		t.Error("host should not start in maintenance mode")
+	}
+	if ms.ActiveObligations != 1 {
+		t.Error("expecting one active obligation, got", ms.ActiveObligations)
+	}
+
+	// Put the host into maintenance mode.
+	settings := ht.host.InternalSettings()
+	settings.AcceptingContracts = true
+	settings.MaintenanceMode = true
+	err = ht.host.SetInternalSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ht.host.ExternalSettings().AcceptingContracts {
+		t.Error("host in maintenance mode should not advertise that it is accepting contracts")
+	}
+	err = ht.host.Announce()
+	if err != errMaintenanceMode {
+		t.Error("expecting errMaintenanceMode, got", err)
+	}
+
+	ms = ht.host.MaintenanceStatus()
+	if !ms.MaintenanceMode {
+		t.Error("host should report that it is in maintenance mode")
+	}
+	if ms.LastExpiryHeight != so.proofDeadline() {
+		t.Error("wrong last expiry height:", ms.LastExpiryHeight, so.proofDeadline())
+	}
+	if ms.RemainingBlocks != so.proofDeadline()-ht.host.blockHeight {
+		t.Error("wrong number of remaining blocks:", ms.RemainingBlocks)
+	}
+
+	// Leaving maintenance mode should allow the host to accept contracts
+	// again.
+	settings.MaintenanceMode = false
+	err = ht.host.SetInternalSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ht.host.ExternalSettings().AcceptingContracts {
+		t.Error("host should be accepting contracts after leaving maintenance mode")
+	}
+}
@@ -92,6 +92,17 @@ func (h *Host) managedRPCRenewContract(conn net.Conn) error {
 	if err != nil {
 		return extendErr("RPCSettings failed: ", err)
 	}
+	// A host in maintenance mode does not renew contracts. The renter has
+	// been told that the host is not accepting contracts through the host
+	// settings, and will understand that the connection is going to be
+	// closed.
+	h.mu.RLock()
+	maintenanceMode := h.settings.MaintenanceMode
+	h.mu.RUnlock()
+	if maintenanceMode {
+		h.log.Debugln("Turning down contract renewal because the host is in maintenance mode.")
+		return nil
+	}
 
 	// Set the renewal deadline.
 	conn.SetDeadline(time.Now().Add(modules.NegotiateRenewContractTime))
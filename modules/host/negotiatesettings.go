@@ -44,7 +44,7 @@ func (h *Host) externalSettings() modules.HostExternalSettings {
 	}
 
 	return modules.HostExternalSettings{
-		AcceptingContracts:   h.settings.AcceptingContracts,
+		AcceptingContracts:   h.settings.AcceptingContracts && !h.settings.MaintenanceMode,
 		MaxDownloadBatchSize: h.settings.MaxDownloadBatchSize,
 		MaxDuration:          h.settings.MaxDuration,
 		MaxReviseBatchSize:   h.settings.MaxReviseBatchSize,
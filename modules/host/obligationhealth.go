@@ -0,0 +1,157 @@
+package host
+
+// obligationhealth.go periodically performs a dry run of the storage proof for
+// every unresolved storage obligation. The host only builds a real storage
+// proof once the proof window has opened, which is far too late to learn that
+// a sector has gone missing. By building and verifying a proof for a random
+// segment ahead of time, the host can alert the user while there is still
+// time to do something about the problem.
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
+
+	"github.com/coreos/bbolt"
+)
+
+var (
+	// errProofVerificationFailed is returned if the host was able to build a
+	// storage proof for an obligation, but the proof does not match the
+	// Merkle root of the file contract.
+	errProofVerificationFailed = errors.New("storage proof does not verify against the file contract Merkle root")
+
+	// errRevisionUnconfirmed is returned if the most recent file contract
+	// revision of an obligation has not made it onto the blockchain despite
+	// the host attempting to submit it.
+	errRevisionUnconfirmed = errors.New("file contract revision was submitted but has not been confirmed")
+)
+
+// obligationHealth contains the result of a storage proof dry run.
+type obligationHealth struct {
+	status modules.ObligationHealth
+	err    string
+	height types.BlockHeight
+}
+
+// managedCheckObligationHealth performs a storage proof dry run on the
+// storage obligation with the provided id, and records the result in the
+// host. Unhealthy obligations are logged so that the user is alerted.
+func (h *Host) managedCheckObligationHealth(soid types.FileContractID) error {
+	// Obligations that are currently locked are being modified or are having
+	// their proofs submitted. They will be checked next time around.
+	err := h.managedTryLockStorageObligation(soid)
+	if err != nil {
+		return err
+	}
+	defer h.managedUnlockStorageObligation(soid)
+
+	var so storageObligation
+	h.mu.RLock()
+	blockHeight := h.blockHeight
+	err = h.db.View(func(tx *bolt.Tx) error {
+		so, err = getStorageObligation(tx, soid)
+		return err
+	})
+	h.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if so.ObligationStatus != obligationUnresolved {
+		return nil
+	}
+
+	health := obligationHealth{
+		status: modules.ObligationHealthHealthy,
+		height: blockHeight,
+	}
+	if err := h.managedDryRunStorageProof(so); err != nil {
+		health.status = modules.ObligationHealthProofFailing
+		health.err = err.Error()
+	} else if len(so.RevisionTransactionSet) > 0 && !so.RevisionConfirmed && blockHeight > so.expiration()-revisionSubmissionBuffer+resubmissionTimeout {
+		// The revision should have been submitted at least one resubmission
+		// timeout ago. If it is still not confirmed, the host is at risk of
+		// having the storage proof checked against an outdated revision.
+		health.status = modules.ObligationHealthRevisionUnconfirmed
+		health.err = errRevisionUnconfirmed.Error()
+	}
+	if health.status != modules.ObligationHealthHealthy {
+		h.log.Printf("WARN: storage obligation %v is unhealthy (%v): %v\n", soid, health.status, health.err)
+	}
+
+	h.mu.Lock()
+	h.obligationHealth[soid] = health
+	h.mu.Unlock()
+	return nil
+}
+
+// managedDryRunStorageProof builds a storage proof for a random segment of the
+// data protected by the storage obligation and verifies it against the Merkle
+// root of the most recent file contract revision.
+func (h *Host) managedDryRunStorageProof(so storageObligation) error {
+	// An obligation without any data cannot have a storage proof.
+	if so.fileSize() == 0 {
+		return nil
+	}
+	numSegments := crypto.CalculateLeaves(so.fileSize())
+	segmentIndex := fastrand.Uint64n(numSegments)
+	sp, err := h.managedBuildStorageProof(so, segmentIndex)
+	if err != nil {
+		return err
+	}
+	if !crypto.VerifySegment(sp.Segment[:], sp.HashSet, numSegments, segmentIndex, so.merkleRoot()) {
+		return errProofVerificationFailed
+	}
+	return nil
+}
+
+// threadedCheckObligationHealth periodically performs a storage proof dry run
+// on every unresolved storage obligation held by the host.
+func (h *Host) threadedCheckObligationHealth(closeChan chan struct{}) {
+	defer close(closeChan)
+	for {
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(obligationHealthCheckFrequency):
+		}
+
+		// Grab the ids of all of the unresolved storage obligations.
+		var soids []types.FileContractID
+		h.mu.RLock()
+		err := h.db.View(func(tx *bolt.Tx) error {
+			return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+				var so storageObligation
+				err := json.Unmarshal(soBytes, &so)
+				if err != nil {
+					return err
+				}
+				if so.ObligationStatus == obligationUnresolved {
+					soids = append(soids, so.id())
+				}
+				return nil
+			})
+		})
+		h.mu.RUnlock()
+		if err != nil {
+			h.log.Println("ERROR: unable to load storage obligations for health check:", err)
+			continue
+		}
+
+		for _, soid := range soids {
+			if err := h.tg.Add(); err != nil {
+				return
+			}
+			err := h.managedCheckObligationHealth(soid)
+			h.tg.Done()
+			if err != nil && err != errObligationLocked {
+				h.log.Debugln("Unable to check health of storage obligation", soid, err)
+			}
+		}
+	}
+}
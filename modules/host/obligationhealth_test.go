@@ -0,0 +1,101 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestObligationHealth checks that the storage proof dry run correctly
+// reports the health of a storage obligation, both when the host has all of
+// the data and after a sector has gone missing.
+func TestObligationHealth(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// Add a storage obligation to the host.
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.managedAddStorageObligation(so)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedUnlockStorageObligation(so.id())
+
+	// Before any check, the health of the obligation should be unknown.
+	sos := ht.host.StorageObligations()
+	if len(sos) != 1 {
+		t.Fatal("expecting one storage obligation, got", len(sos))
+	}
+	if sos[0].ObligationID != so.id() {
+		t.Error("storage obligation has the wrong id")
+	}
+	if sos[0].Health != modules.ObligationHealthUnknown {
+		t.Error("unchecked obligation should have unknown health:", sos[0].Health)
+	}
+
+	// Add a sector to the obligation through a revision.
+	sectorRoot, sectorData := randSector()
+	so.SectorRoots = []crypto.Hash{sectorRoot}
+	validPayouts, missedPayouts := so.payouts()
+	so.RevisionTransactionSet = []types.Transaction{{
+		FileContractRevisions: []types.FileContractRevision{{
+			ParentID:          so.id(),
+			UnlockConditions:  types.UnlockConditions{},
+			NewRevisionNumber: 1,
+
+			NewFileSize:           uint64(len(sectorData)),
+			NewFileMerkleRoot:     sectorRoot,
+			NewWindowStart:        so.expiration(),
+			NewWindowEnd:          so.proofDeadline(),
+			NewValidProofOutputs:  validPayouts,
+			NewMissedProofOutputs: missedPayouts,
+			NewUnlockHash:         types.UnlockConditions{}.UnlockHash(),
+		}},
+	}}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.modifyStorageObligation(so, nil, []crypto.Hash{sectorRoot}, [][]byte{sectorData})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedUnlockStorageObligation(so.id())
+
+	// The host has the data, the obligation should be healthy.
+	err = ht.host.managedCheckObligationHealth(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sos = ht.host.StorageObligations()
+	if sos[0].Health != modules.ObligationHealthHealthy {
+		t.Fatal("obligation should be healthy:", sos[0].Health, sos[0].HealthError)
+	}
+
+	// Delete the sector, the storage proof should now fail.
+	err = ht.host.DeleteSector(sectorRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ht.host.managedCheckObligationHealth(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sos = ht.host.StorageObligations()
+	if sos[0].Health != modules.ObligationHealthProofFailing {
+		t.Fatal("obligation should be failing its storage proof:", sos[0].Health)
+	}
+	if sos[0].HealthError == "" {
+		t.Error("failing obligation should report an error")
+	}
+}
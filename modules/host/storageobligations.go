@@ -98,6 +98,11 @@ var (
 	// is not found in the database.
 	errNoStorageObligation = errors.New("storage obligation not found in database")
 
+	// errProofSegmentOutOfBounds is returned if a storage proof is requested
+	// for a segment that is not covered by the sector roots of the storage
+	// obligation.
+	errProofSegmentOutOfBounds = errors.New("storage proof segment is outside of the sectors held by the storage obligation")
+
 	// errObligationUnlocked is returned when a storage obligation is being
 	// removed from lock, but is already unlocked.
 	errObligationUnlocked = errors.New("storage obligation is unlocked, and should not be getting unlocked")
@@ -589,6 +594,7 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 	// ended up, and the sector roots are removed because they are large
 	// objects with little purpose once storage proofs are no longer needed.
 	h.financialMetrics.ContractCount--
+	delete(h.obligationHealth, so.id())
 	so.ObligationStatus = sos
 	so.SectorRoots = nil
 	return h.db.Update(func(tx *bolt.Tx) error {
@@ -596,6 +602,45 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 	})
 }
 
+// managedBuildStorageProof builds a storage proof for the segment at
+// 'segmentIndex' of the data protected by the storage obligation. Only the
+// sector containing the segment is read from disk, the rest of the proof is
+// built from the sector roots of the obligation.
+func (h *Host) managedBuildStorageProof(so storageObligation, segmentIndex uint64) (types.StorageProof, error) {
+	sectorIndex := segmentIndex / (modules.SectorSize / crypto.SegmentSize)
+	if sectorIndex >= uint64(len(so.SectorRoots)) {
+		return types.StorageProof{}, errProofSegmentOutOfBounds
+	}
+	// Pull the corresponding sector into memory.
+	sectorRoot := so.SectorRoots[sectorIndex]
+	sectorBytes, err := h.ReadSector(sectorRoot)
+	if err != nil {
+		return types.StorageProof{}, err
+	}
+
+	// Build the storage proof for just the sector.
+	sectorSegment := segmentIndex % (modules.SectorSize / crypto.SegmentSize)
+	base, cachedHashSet := crypto.MerkleProof(sectorBytes, sectorSegment)
+
+	// Using the sector, build a cached root.
+	log2SectorSize := uint64(0)
+	for 1<<log2SectorSize < (modules.SectorSize / crypto.SegmentSize) {
+		log2SectorSize++
+	}
+	ct := crypto.NewCachedTree(log2SectorSize)
+	ct.SetIndex(segmentIndex)
+	for _, root := range so.SectorRoots {
+		ct.Push(root)
+	}
+	hashSet := ct.Prove(base, cachedHashSet)
+	sp := types.StorageProof{
+		ParentID: so.id(),
+		HashSet:  hashSet,
+	}
+	copy(sp.Segment[:], base)
+	return sp, nil
+}
+
 // threadedHandleActionItem will look at a storage obligation and determine
 // which action is necessary for the storage obligation to succeed.
 func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
@@ -763,36 +808,12 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 			h.log.Debugln("Host got an error when fetching a storage proof segment:", err)
 			return
 		}
-		sectorIndex := segmentIndex / (modules.SectorSize / crypto.SegmentSize)
-		// Pull the corresponding sector into memory.
-		sectorRoot := so.SectorRoots[sectorIndex]
-		sectorBytes, err := h.ReadSector(sectorRoot)
+		sp, err := h.managedBuildStorageProof(so, segmentIndex)
 		if err != nil {
 			h.log.Debugln(err)
 			return
 		}
 
-		// Build the storage proof for just the sector.
-		sectorSegment := segmentIndex % (modules.SectorSize / crypto.SegmentSize)
-		base, cachedHashSet := crypto.MerkleProof(sectorBytes, sectorSegment)
-
-		// Using the sector, build a cached root.
-		log2SectorSize := uint64(0)
-		for 1<<log2SectorSize < (modules.SectorSize / crypto.SegmentSize) {
-			log2SectorSize++
-		}
-		ct := crypto.NewCachedTree(log2SectorSize)
-		ct.SetIndex(segmentIndex)
-		for _, root := range so.SectorRoots {
-			ct.Push(root)
-		}
-		hashSet := ct.Prove(base, cachedHashSet)
-		sp := types.StorageProof{
-			ParentID: so.id(),
-			HashSet:  hashSet,
-		}
-		copy(sp.Segment[:], base)
-
 		// Create and build the transaction with the storage proof.
 		builder := h.wallet.StartTransaction()
 		_, feeRecommendation := h.tpool.FeeEstimation()
@@ -855,6 +876,39 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 	}
 }
 
+// obligationStorage returns the number of logical bytes protected by a
+// storage obligation, the number of physical bytes consumed by the distinct
+// sectors of the obligation, and the number of those physical bytes that are
+// shared with other obligations.
+func (h *Host) obligationStorage(so storageObligation) (logical, physical, shared uint64, err error) {
+	// Count the number of times that each sector appears in the obligation.
+	occurrences := make(map[crypto.Hash]uint64)
+	var roots []crypto.Hash
+	for _, root := range so.SectorRoots {
+		if occurrences[root] == 0 {
+			roots = append(roots, root)
+		}
+		occurrences[root]++
+	}
+	counts, err := h.SectorReferenceCounts(roots)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	logical = uint64(len(so.SectorRoots)) * modules.SectorSize
+	for i, root := range roots {
+		// Sectors that have gone missing do not consume any physical space.
+		if counts[i] == 0 {
+			continue
+		}
+		physical += modules.SectorSize
+		if counts[i] > occurrences[root] {
+			shared += modules.SectorSize
+		}
+	}
+	return logical, physical, shared, nil
+}
+
 // StorageObligations fetches the set of storage obligations in the host and
 // returns metadata on them.
 func (h *Host) StorageObligations() (sos []modules.StorageObligation) {
@@ -869,7 +923,16 @@ func (h *Host) StorageObligations() (sos []modules.StorageObligation) {
 			if err != nil {
 				return build.ExtendErr("unable to unmarshal storage obligation:", err)
 			}
+			logical, physical, shared, err := h.obligationStorage(so)
+			if err != nil {
+				return build.ExtendErr("unable to get storage usage of storage obligation:", err)
+			}
+			health, exists := h.obligationHealth[so.id()]
+			if !exists {
+				health.status = modules.ObligationHealthUnknown
+			}
 			mso := modules.StorageObligation{
+				ObligationID:      so.id(),
 				NegotiationHeight: so.NegotiationHeight,
 
 				OriginConfirmed:     so.OriginConfirmed,
@@ -878,6 +941,14 @@ func (h *Host) StorageObligations() (sos []modules.StorageObligation) {
 				ProofConstructed:    so.ProofConstructed,
 				ProofConfirmed:      so.ProofConfirmed,
 				ObligationStatus:    uint64(so.ObligationStatus),
+
+				LogicalBytes:  logical,
+				PhysicalBytes: physical,
+				SharedBytes:   shared,
+
+				Health:            health.status,
+				HealthError:       health.err,
+				HealthCheckHeight: health.height,
 			}
 			sos = append(sos, mso)
 			return nil
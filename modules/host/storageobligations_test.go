@@ -3,6 +3,8 @@ package host
 import (
 	"testing"
 
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -80,3 +82,62 @@ func TestStorageObligationID(t *testing.T) {
 		t.Error("id function of storage obligation incorrect for file contracts with dependencies")
 	}
 }
+
+// TestObligationStorage checks that the physical, logical, and shared storage
+// usage of a storage obligation are computed correctly when sectors are
+// deduplicated by the storage manager.
+func TestObligationStorage(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// Create an obligation that holds sector 'a' twice and sector 'b' once.
+	// Sector 'b' is also held by a second obligation.
+	rootA, dataA := randSector()
+	rootB, dataB := randSector()
+	for _, sector := range []struct {
+		root crypto.Hash
+		data []byte
+	}{{rootA, dataA}, {rootA, dataA}, {rootB, dataB}, {rootB, dataB}} {
+		err = ht.host.AddSector(sector.root, sector.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	so := storageObligation{
+		SectorRoots: []crypto.Hash{rootA, rootB, rootA},
+	}
+	logical, physical, shared, err := ht.host.obligationStorage(so)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logical != 3*modules.SectorSize {
+		t.Error("wrong number of logical bytes:", logical)
+	}
+	if physical != 2*modules.SectorSize {
+		t.Error("wrong number of physical bytes:", physical)
+	}
+	if shared != modules.SectorSize {
+		t.Error("wrong number of shared bytes:", shared)
+	}
+
+	// The storage folders should report four logical sectors stored in two
+	// physical sectors.
+	var totalLogical, totalPhysical uint64
+	for _, sf := range ht.host.StorageFolders() {
+		totalLogical += sf.LogicalBytes
+		totalPhysical += sf.PhysicalBytes
+	}
+	if totalLogical != 4*modules.SectorSize {
+		t.Error("storage folders report wrong number of logical bytes:", totalLogical)
+	}
+	if totalPhysical != 2*modules.SectorSize {
+		t.Error("storage folders report wrong number of physical bytes:", totalPhysical)
+	}
+}
@@ -32,6 +32,7 @@ func (h *Host) managedLearnHostname() {
 	hostAutoAddress := h.autoAddress
 	hostAnnounced := h.announced
 	hostAcceptingContracts := h.settings.AcceptingContracts
+	hostMaintenanceMode := h.settings.MaintenanceMode
 	hostContractCount := h.financialMetrics.ContractCount
 	h.mu.RUnlock()
 
@@ -78,8 +79,8 @@ func (h *Host) managedLearnHostname() {
 	// Announce the host, but only if the host is either accepting contracts or
 	// has a storage obligation. If the host is not accepting contracts and has
 	// no open contracts, there is no reason to notify anyone that the host's
-	// address has changed.
-	if hostAcceptingContracts || hostContractCount > 0 {
+	// address has changed. A host in maintenance mode does not announce.
+	if (hostAcceptingContracts || hostContractCount > 0) && !hostMaintenanceMode {
 		h.log.Println("Host external IP address changed from", hostAutoAddress, "to", autoAddress, "- performing host announcement.")
 		err = h.managedAnnounce(autoAddress)
 		if err != nil {
@@ -260,7 +260,7 @@ func (m *Miner) checkAddress() error {
 	if m.persist.Address != (types.UnlockHash{}) && hasAddr {
 		return nil
 	}
-	uc, err := m.wallet.NextAddress()
+	uc, err := m.wallet.NextAddressForPurpose(modules.PurposeMiner)
 	if err != nil {
 		return err
 	}
@@ -24,6 +24,10 @@ const (
 )
 
 const (
+	// MaxAnnouncementAddresses is the maximum number of addresses that can
+	// be listed in a single host announcement.
+	MaxAnnouncementAddresses = 8
+
 	// NegotiateDownloadTime defines the amount of time that the renter and
 	// host have to negotiate a download request batch. The time is set high
 	// enough that two nodes behind Tor have a reasonable chance of completing
@@ -122,6 +126,10 @@ var (
 	// announcement or it's not a recognized version of a host announcement.
 	ErrAnnNotAnnouncement = errors.New("provided data does not form a recognized host announcement")
 
+	// ErrAnnTooManyAddresses is returned when creating a host announcement
+	// with more than MaxAnnouncementAddresses addresses.
+	ErrAnnTooManyAddresses = errors.New("host announcement contains too many addresses")
+
 	// ErrAnnUnrecognizedSignature is returned when the signature in a host
 	// announcement is not a type of signature that is recognized.
 	ErrAnnUnrecognizedSignature = errors.New("the signature provided in the host announcement is not recognized")
@@ -145,6 +153,11 @@ var (
 	// announcement will follow this prefix.
 	PrefixHostAnnouncement = types.Specifier{'H', 'o', 's', 't', 'A', 'n', 'n', 'o', 'u', 'n', 'c', 'e', 'm', 'e', 'n', 't'}
 
+	// PrefixHostAnnouncementAddresses is used to indicate that a host
+	// announcement is followed by a list of additional addresses that the
+	// host can be reached at.
+	PrefixHostAnnouncementAddresses = types.Specifier{'H', 'o', 's', 't', 'A', 'd', 'd', 'r', 'e', 's', 's', 'e', 's'}
+
 	// RPCDownload is the specifier for downloading a file from a host.
 	RPCDownload = types.Specifier{'D', 'o', 'w', 'n', 'l', 'o', 'a', 'd', 2}
 
@@ -195,6 +208,19 @@ type (
 		PublicKey  types.SiaPublicKey
 	}
 
+	// HostAnnouncementAddresses is an extension to a HostAnnouncement that
+	// lists additional addresses of the host, such as an IPv6 address or an
+	// onion address, in order of preference. The extension is appended after
+	// the signature of the HostAnnouncement, which means that nodes that do
+	// not know about the extension will still recognize the announcement.
+	// 'Specifier' is always 'PrefixHostAnnouncementAddresses'. The extension
+	// is followed by a signature from the public key of the host covering
+	// both the announcement and the extension.
+	HostAnnouncementAddresses struct {
+		Specifier    types.Specifier
+		NetAddresses []NetAddress
+	}
+
 	// HostExternalSettings are the parameters advertised by the host. These
 	// are the values that the renter will request from the host in order to
 	// build its database.
@@ -345,44 +371,119 @@ func CreateAnnouncement(addr NetAddress, pk types.SiaPublicKey, sk crypto.Secret
 	return append(annBytes, sig[:]...), nil
 }
 
-// DecodeAnnouncement decodes announcement bytes into a host announcement,
-// verifying the prefix and the signature.
-func DecodeAnnouncement(fullAnnouncement []byte) (na NetAddress, spk types.SiaPublicKey, err error) {
+// CreateMultiAddressAnnouncement creates a host announcement that lists
+// several addresses for the host, in order of preference. The first address
+// is placed in the HostAnnouncement itself so that nodes which do not
+// understand the additional addresses still learn about the host.
+func CreateMultiAddressAnnouncement(addrs []NetAddress, pk types.SiaPublicKey, sk crypto.SecretKey) (signedAnnouncement []byte, err error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("host announcement requires at least one address")
+	}
+	if len(addrs) > MaxAnnouncementAddresses {
+		return nil, ErrAnnTooManyAddresses
+	}
+	annBytes, err := CreateAnnouncement(addrs[0], pk, sk)
+	if err != nil || len(addrs) == 1 {
+		return annBytes, err
+	}
+	for _, addr := range addrs[1:] {
+		if err := addr.IsValid(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Append the additional addresses, signed together with the original
+	// announcement.
+	ha := HostAnnouncement{
+		Specifier:  PrefixHostAnnouncement,
+		NetAddress: addrs[0],
+		PublicKey:  pk,
+	}
+	haa := HostAnnouncementAddresses{
+		Specifier:    PrefixHostAnnouncementAddresses,
+		NetAddresses: addrs[1:],
+	}
+	sig := crypto.SignHash(crypto.HashAll(ha, haa), sk)
+	annBytes = append(annBytes, encoding.Marshal(haa)...)
+	return append(annBytes, sig[:]...), nil
+}
+
+// decodeAnnouncement reads a host announcement from the decoder, verifying the
+// prefix and the signature.
+func decodeAnnouncement(dec *encoding.Decoder) (ha HostAnnouncement, err error) {
 	// Read the first part of the announcement to get the intended host
 	// announcement.
-	var ha HostAnnouncement
-	dec := encoding.NewDecoder(bytes.NewReader(fullAnnouncement))
 	err = dec.Decode(&ha)
 	if err != nil {
-		return "", types.SiaPublicKey{}, err
+		return HostAnnouncement{}, err
 	}
 
 	// Check that the announcement was registered as a host announcement.
 	if ha.Specifier != PrefixHostAnnouncement {
-		return "", types.SiaPublicKey{}, ErrAnnNotAnnouncement
+		return HostAnnouncement{}, ErrAnnNotAnnouncement
 	}
 	// Check that the public key is a recognized type of public key.
 	if ha.PublicKey.Algorithm != types.SignatureEd25519 {
-		return "", types.SiaPublicKey{}, ErrAnnUnrecognizedSignature
+		return HostAnnouncement{}, ErrAnnUnrecognizedSignature
 	}
 
 	// Read the signature out of the reader.
 	var sig crypto.Signature
 	err = dec.Decode(&sig)
 	if err != nil {
-		return "", types.SiaPublicKey{}, err
+		return HostAnnouncement{}, err
 	}
 	// Verify the signature.
 	var pk crypto.PublicKey
 	copy(pk[:], ha.PublicKey.Key)
 	annHash := crypto.HashObject(ha)
 	err = crypto.VerifyHash(annHash, pk, sig)
+	if err != nil {
+		return HostAnnouncement{}, err
+	}
+	return ha, nil
+}
+
+// DecodeAnnouncement decodes announcement bytes into a host announcement,
+// verifying the prefix and the signature.
+func DecodeAnnouncement(fullAnnouncement []byte) (na NetAddress, spk types.SiaPublicKey, err error) {
+	ha, err := decodeAnnouncement(encoding.NewDecoder(bytes.NewReader(fullAnnouncement)))
 	if err != nil {
 		return "", types.SiaPublicKey{}, err
 	}
 	return ha.NetAddress, ha.PublicKey, nil
 }
 
+// DecodeMultiAddressAnnouncement decodes announcement bytes into the list of
+// addresses of the host, in order of preference, verifying the prefix and the
+// signatures. Additional addresses which are malformed or which do not carry
+// a valid signature are ignored, leaving only the address of the original
+// announcement.
+func DecodeMultiAddressAnnouncement(fullAnnouncement []byte) (addrs []NetAddress, spk types.SiaPublicKey, err error) {
+	dec := encoding.NewDecoder(bytes.NewReader(fullAnnouncement))
+	ha, err := decodeAnnouncement(dec)
+	if err != nil {
+		return nil, types.SiaPublicKey{}, err
+	}
+	addrs = []NetAddress{ha.NetAddress}
+
+	// Read the additional addresses, if there are any.
+	var haa HostAnnouncementAddresses
+	var sig crypto.Signature
+	if dec.DecodeAll(&haa, &sig) != nil {
+		return addrs, ha.PublicKey, nil
+	}
+	if haa.Specifier != PrefixHostAnnouncementAddresses || len(haa.NetAddresses)+1 > MaxAnnouncementAddresses {
+		return addrs, ha.PublicKey, nil
+	}
+	var pk crypto.PublicKey
+	copy(pk[:], ha.PublicKey.Key)
+	if crypto.VerifyHash(crypto.HashAll(ha, haa), pk, sig) != nil {
+		return addrs, ha.PublicKey, nil
+	}
+	return append(addrs, haa.NetAddresses...), ha.PublicKey, nil
+}
+
 // VerifyFileContractRevisionTransactionSignatures checks that the signatures
 // on a file contract revision are valid and cover the right fields.
 func VerifyFileContractRevisionTransactionSignatures(fcr types.FileContractRevision, tsigs []types.TransactionSignature, height types.BlockHeight) error {
@@ -88,6 +88,84 @@ func TestAnnouncementHandling(t *testing.T) {
 	}
 }
 
+// TestMultiAddressAnnouncement checks that CreateMultiAddressAnnouncement and
+// DecodeMultiAddressAnnouncement work together, and that the announcement is
+// still understood by DecodeAnnouncement.
+func TestMultiAddressAnnouncement(t *testing.T) {
+	t.Parallel()
+
+	sk, pk := crypto.GenerateKeyPair()
+	spk := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       pk[:],
+	}
+	addrs := []NetAddress{"f.o:1234", "[::2]:1234", "abcdefghijklmnop.onion:1234"}
+
+	annBytes, err := CreateMultiAddressAnnouncement(addrs, spk, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decAddrs, decPubKey, err := DecodeMultiAddressAnnouncement(annBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decPubKey.Key, spk.Key) {
+		t.Error("decoded announcement has the wrong public key")
+	}
+	if len(decAddrs) != len(addrs) {
+		t.Fatal("decoded announcement has the wrong number of addresses:", decAddrs)
+	}
+	for i := range addrs {
+		if decAddrs[i] != addrs[i] {
+			t.Error("decoded announcement has the wrong address at index", i, decAddrs[i])
+		}
+	}
+
+	// Nodes that only understand single address announcements should see the
+	// first address.
+	decAddr, _, err := DecodeAnnouncement(annBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decAddr != addrs[0] {
+		t.Error("legacy decoding returned the wrong address:", decAddr)
+	}
+
+	// Corrupt the signature of the additional addresses. Only the first
+	// address should be returned.
+	annBytes[len(annBytes)-1]++
+	decAddrs, _, err = DecodeMultiAddressAnnouncement(annBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decAddrs) != 1 || decAddrs[0] != addrs[0] {
+		t.Error("additional addresses with a bad signature should be ignored:", decAddrs)
+	}
+
+	// A single address announcement should decode to a single address.
+	annBytes, err = CreateAnnouncement(addrs[0], spk, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decAddrs, _, err = DecodeMultiAddressAnnouncement(annBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decAddrs) != 1 || decAddrs[0] != addrs[0] {
+		t.Error("wrong addresses for single address announcement:", decAddrs)
+	}
+
+	// Announcements with too many addresses should be rejected.
+	tooMany := make([]NetAddress, MaxAnnouncementAddresses+1)
+	for i := range tooMany {
+		tooMany[i] = addrs[0]
+	}
+	_, err = CreateMultiAddressAnnouncement(tooMany, spk, sk)
+	if err != ErrAnnTooManyAddresses {
+		t.Error("expecting ErrAnnTooManyAddresses, got", err)
+	}
+}
+
 // TestNegotiationResponses tests the WriteNegotiationAcceptance,
 // WriteNegotiationRejection, and ReadNegotiationAcceptance functions.
 func TestNegotiationResponses(t *testing.T) {
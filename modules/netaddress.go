@@ -92,6 +92,18 @@ func (na NetAddress) IsLocal() bool {
 	return false
 }
 
+// IsOnion returns true if the NetAddress points to a Tor onion service. Onion
+// addresses can only be reached when the connection is routed through Tor.
+func (na NetAddress) IsOnion() bool {
+	return strings.HasSuffix(strings.ToLower(strings.TrimSuffix(na.Host(), ".")), ".onion")
+}
+
+// IsIPv6 returns true if the host of the NetAddress is an IPv6 address.
+func (na NetAddress) IsIPv6() bool {
+	ip := net.ParseIP(na.Host())
+	return ip != nil && ip.To4() == nil
+}
+
 // IsValid is an extension to IsStdValid that also forbids the loopback
 // address. IsValid is being phased out in favor of allowing the loopback
 // address but verifying through other means that the connection is not to
@@ -164,6 +176,20 @@ func (na NetAddress) IsStdValid() error {
 				}
 			}
 		}
+
+		// Onion addresses are derived from the key of the onion service, and
+		// are either 16 (v2) or 56 (v3) base32 characters long.
+		if strings.ToLower(labels[len(labels)-1]) == "onion" {
+			service := strings.ToLower(labels[len(labels)-2])
+			if len(service) != 16 && len(service) != 56 {
+				return errors.New("onion address has invalid length")
+			}
+			for _, r := range service {
+				if !('a' <= r && r <= 'z' || '2' <= r && r <= '7') {
+					return errors.New("onion address contains invalid characters")
+				}
+			}
+		}
 	}
 
 	return nil
@@ -64,6 +64,8 @@ var (
 		"foo-bar.baz-:123",
 		"foo.-bar.baz:123",
 		"foo.bar-.baz:123",
+		"foo.onion:123",                         // onion address too short
+		strings.Repeat("a", 15) + "1.onion:123", // invalid base32 character
 		".:123",
 		".foo.com:123",
 		"foo.com..:123",
@@ -97,6 +99,10 @@ var (
 		"[::2]:65535",
 		"111.111.111.111:111",
 		"12.34.45.64:7777",
+		// Onion addresses.
+		strings.Repeat("a", 16) + ".onion:9982",
+		strings.Repeat("b", 56) + ".onion:9982",
+		"www." + strings.Repeat("2", 16) + ".ONION:9982",
 	}
 )
 
@@ -317,3 +323,32 @@ func TestIsLocal(t *testing.T) {
 		}
 	}
 }
+
+// TestAddressKinds checks that IsOnion and IsIPv6 correctly identify the kind
+// of a NetAddress.
+func TestAddressKinds(t *testing.T) {
+	t.Parallel()
+
+	testSet := []struct {
+		query NetAddress
+		onion bool
+		ipv6  bool
+	}{
+		{"foo.com:1234", false, false},
+		{"12.34.45.64:7777", false, false},
+		{"[::2]:1234", false, true},
+		{"[2001:db8::1]:9982", false, true},
+		{NetAddress(strings.Repeat("a", 16) + ".onion:9982"), true, false},
+		{NetAddress(strings.Repeat("a", 56) + ".ONION.:9982"), true, false},
+		{"onion.com:9982", false, false},
+		{"garbage", false, false},
+	}
+	for _, test := range testSet {
+		if test.query.IsOnion() != test.onion {
+			t.Error("IsOnion failed:", test.query, test.query.IsOnion())
+		}
+		if test.query.IsIPv6() != test.ipv6 {
+			t.Error("IsIPv6 failed:", test.query, test.query.IsIPv6())
+		}
+	}
+}
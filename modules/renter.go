@@ -98,6 +98,11 @@ type HostDBEntry struct {
 	// FirstSeen is the last block height at which this host was announced.
 	FirstSeen types.BlockHeight `json:"firstseen"`
 
+	// NetAddresses contains every address that the host listed in its most
+	// recent announcement, in the host's order of preference. The embedded
+	// NetAddress is the address that is currently used to contact the host.
+	NetAddresses []NetAddress `json:"netaddresses"`
+
 	// Measurements that have been taken on the host. The most recent
 	// measurements are kept in full detail, historic ones are compressed into
 	// the historic values.
@@ -24,8 +24,10 @@ func (newStub) Synced() bool                               { return true }
 func (newStub) Unsubscribe(modules.ConsensusSetSubscriber) { return }
 
 // wallet stubs
-func (newStub) NextAddress() (uc types.UnlockConditions, err error) { return }
-func (newStub) StartTransaction() modules.TransactionBuilder        { return nil }
+func (newStub) NextAddressForPurpose(modules.AddressPurpose) (uc types.UnlockConditions, err error) {
+	return
+}
+func (newStub) StartTransaction() modules.TransactionBuilder { return nil }
 
 // transaction pool stubs
 func (newStub) AcceptTransactionSet([]types.Transaction) error      { return nil }
@@ -510,7 +512,7 @@ type testWalletShim struct {
 
 // These stub implementations for the walletShim interface set their respective
 // booleans to true, allowing tests to verify that they have been called.
-func (ws *testWalletShim) NextAddress() (types.UnlockConditions, error) {
+func (ws *testWalletShim) NextAddressForPurpose(modules.AddressPurpose) (types.UnlockConditions, error) {
 	ws.nextAddressCalled = true
 	return types.UnlockConditions{}, nil
 }
@@ -523,9 +525,9 @@ func (ws *testWalletShim) StartTransaction() modules.TransactionBuilder {
 func TestWalletBridge(t *testing.T) {
 	shim := new(testWalletShim)
 	bridge := WalletBridge{shim}
-	bridge.NextAddress()
+	bridge.NextAddressForPurpose(modules.PurposeRenter)
 	if !shim.nextAddressCalled {
-		t.Error("NextAddress was not called on the shim")
+		t.Error("NextAddressForPurpose was not called on the shim")
 	}
 	bridge.StartTransaction()
 	if !shim.startTxnCalled {
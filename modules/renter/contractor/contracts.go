@@ -149,7 +149,7 @@ func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFundin
 	}
 
 	// get an address to use for negotiation
-	uc, err := c.wallet.NextAddress()
+	uc, err := c.wallet.NextAddressForPurpose(modules.PurposeRenter)
 	if err != nil {
 		return modules.RenterContract{}, err
 	}
@@ -206,7 +206,7 @@ func (c *Contractor) managedRenew(sc *proto.SafeContract, contractFunding types.
 	}
 
 	// get an address to use for negotiation
-	uc, err := c.wallet.NextAddress()
+	uc, err := c.wallet.NextAddressForPurpose(modules.PurposeRenter)
 	if err != nil {
 		return modules.RenterContract{}, err
 	}
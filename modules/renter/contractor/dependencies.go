@@ -20,11 +20,11 @@ type (
 	// provide a shim to bridge the gap between modules.Wallet and
 	// transactionBuilder.
 	walletShim interface {
-		NextAddress() (types.UnlockConditions, error)
+		NextAddressForPurpose(modules.AddressPurpose) (types.UnlockConditions, error)
 		StartTransaction() modules.TransactionBuilder
 	}
 	wallet interface {
-		NextAddress() (types.UnlockConditions, error)
+		NextAddressForPurpose(modules.AddressPurpose) (types.UnlockConditions, error)
 		StartTransaction() transactionBuilder
 	}
 	transactionBuilder interface {
@@ -69,8 +69,11 @@ type WalletBridge struct {
 	W walletShim
 }
 
-// NextAddress computes and returns the next address of the wallet.
-func (ws *WalletBridge) NextAddress() (types.UnlockConditions, error) { return ws.W.NextAddress() }
+// NextAddressForPurpose computes and returns the next address of the wallet in
+// the derivation namespace of purpose.
+func (ws *WalletBridge) NextAddressForPurpose(purpose modules.AddressPurpose) (types.UnlockConditions, error) {
+	return ws.W.NextAddressForPurpose(purpose)
+}
 
 // StartTransaction creates a new transactionBuilder that can be used to create
 // and sign a transaction.
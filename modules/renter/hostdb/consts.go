@@ -26,6 +26,11 @@ const (
 	// allowed to be offline while still being in the hostdb.
 	maxHostDowntime = 10 * 24 * time.Hour
 
+	// maxScanAddresses is the maximum number of a host's announced addresses
+	// that are tried during a single scan. Each address that does not respond
+	// can take up to hostRequestTimeout.
+	maxScanAddresses = 3
+
 	// maxSettingsLen indicates how long in bytes the host settings field is
 	// allowed to be before being ignored as a DoS attempt.
 	maxSettingsLen = 10e3
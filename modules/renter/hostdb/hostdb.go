@@ -21,6 +21,10 @@ import (
 var (
 	errNilCS      = errors.New("cannot create hostdb with nil consensus set")
 	errNilGateway = errors.New("cannot create hostdb with nil gateway")
+
+	// errNoScanAddresses is returned when a host only announced addresses
+	// that cannot be reached without a Tor proxy.
+	errNoScanAddresses = errors.New("host has no addresses that can be reached without a Tor proxy")
 )
 
 // The HostDB is a database of potential hosts. It assigns a weight to each
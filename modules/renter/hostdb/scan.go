@@ -12,6 +12,7 @@ import (
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
 	"github.com/NebulousLabs/fastrand"
 )
 
@@ -233,54 +234,57 @@ func (hdb *HostDB) updateEntry(entry modules.HostDBEntry, netErr error) {
 	}
 }
 
+// scanAddresses returns the addresses of a host in the order that they should
+// be tried when scanning. The address currently used to contact the host is
+// tried first, followed by the remaining announced addresses in the host's
+// order of preference. At most maxScanAddresses addresses are returned. Onion
+// addresses are skipped, as the hostdb dials hosts directly and cannot reach
+// them without a Tor proxy.
+func scanAddresses(entry modules.HostDBEntry) []modules.NetAddress {
+	var addrs []modules.NetAddress
+	if !entry.NetAddress.IsOnion() {
+		addrs = append(addrs, entry.NetAddress)
+	}
+	for _, addr := range entry.NetAddresses {
+		if len(addrs) == maxScanAddresses {
+			break
+		}
+		if addr == entry.NetAddress || addr.IsOnion() {
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
 // managedScanHost will connect to a host and grab the settings, verifying
-// uptime and updating to the host's preferences.
+// uptime and updating to the host's preferences. Each of the host's addresses
+// is tried in turn until one of them succeeds.
 func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
-	// Request settings from the queued host entry.
-	netAddr := entry.NetAddress
-	pubKey := entry.PublicKey
-	hdb.log.Debugf("Scanning host %v at %v", pubKey, netAddr)
-
 	// Update historic interactions of entry if necessary
 	hdb.mu.RLock()
 	updateHostHistoricInteractions(&entry, hdb.blockHeight)
 	hdb.mu.RUnlock()
 
-	var settings modules.HostExternalSettings
-	err := func() error {
-		dialer := &net.Dialer{
-			Cancel:  hdb.tg.StopChan(),
-			Timeout: hostRequestTimeout,
-		}
-		conn, err := dialer.Dial("tcp", string(netAddr))
+	err := errNoScanAddresses
+	addrs := scanAddresses(entry)
+	for i, netAddr := range addrs {
+		var settings modules.HostExternalSettings
+		err = hdb.managedRequestSettings(netAddr, entry.PublicKey, &settings)
 		if err != nil {
-			return err
+			hdb.log.Debugf("Scan of host at %v failed: %v", netAddr, err)
+			continue
 		}
-		connCloseChan := make(chan struct{})
-		go func() {
-			select {
-			case <-hdb.tg.StopChan():
-			case <-connCloseChan:
-			}
-			conn.Close()
-		}()
-		defer close(connCloseChan)
-		conn.SetDeadline(time.Now().Add(hostScanDeadline))
-
-		err = encoding.WriteObject(conn, modules.RPCSettings)
-		if err != nil {
-			return err
-		}
-		var pubkey crypto.PublicKey
-		copy(pubkey[:], pubKey.Key)
-		return crypto.ReadSignedObject(conn, &settings, maxSettingsLen, pubkey)
-	}()
-	if err != nil {
-		hdb.log.Debugf("Scan of host at %v failed: %v", netAddr, err)
-
-	} else {
 		hdb.log.Debugf("Scan of host at %v succeeded.", netAddr)
 		entry.HostExternalSettings = settings
+		// If the host could only be reached on one of its other addresses,
+		// switch to that address for future interactions. Hosts with several
+		// announced addresses keep the address that worked, as the address
+		// reported in their settings may not be reachable from this renter.
+		if i > 0 || len(entry.NetAddresses) > 1 {
+			entry.NetAddress = netAddr
+		}
+		break
 	}
 
 	// Update the host tree to have a new entry, including the new error. Then
@@ -290,6 +294,39 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 	hdb.mu.Unlock()
 }
 
+// managedRequestSettings connects to a host at the provided address and
+// requests the host's settings, verifying that they are signed by the host's
+// public key.
+func (hdb *HostDB) managedRequestSettings(netAddr modules.NetAddress, pubKey types.SiaPublicKey, settings *modules.HostExternalSettings) error {
+	hdb.log.Debugf("Scanning host %v at %v", pubKey, netAddr)
+	dialer := &net.Dialer{
+		Cancel:  hdb.tg.StopChan(),
+		Timeout: hostRequestTimeout,
+	}
+	conn, err := dialer.Dial("tcp", string(netAddr))
+	if err != nil {
+		return err
+	}
+	connCloseChan := make(chan struct{})
+	go func() {
+		select {
+		case <-hdb.tg.StopChan():
+		case <-connCloseChan:
+		}
+		conn.Close()
+	}()
+	defer close(connCloseChan)
+	conn.SetDeadline(time.Now().Add(hostScanDeadline))
+
+	err = encoding.WriteObject(conn, modules.RPCSettings)
+	if err != nil {
+		return err
+	}
+	var pubkey crypto.PublicKey
+	copy(pubkey[:], pubKey.Key)
+	return crypto.ReadSignedObject(conn, settings, maxSettingsLen, pubkey)
+}
+
 // threadedProbeHosts pulls hosts from the thread pool and runs a scan on them.
 func (hdb *HostDB) threadedProbeHosts(scanPool <-chan modules.HostDBEntry) {
 	err := hdb.tg.Add()
@@ -182,3 +182,49 @@ func TestUpdateEntry(t *testing.T) {
 		t.Error("host not reporting historic uptime?")
 	}
 }
+
+// TestScanAddresses checks that the addresses of a host are tried in the
+// right order during a scan.
+func TestScanAddresses(t *testing.T) {
+	onion := modules.NetAddress("abcdefghijklmnop.onion:9982")
+	entry := modules.HostDBEntry{
+		NetAddresses: []modules.NetAddress{onion, "foo.com:9982", "[::2]:9982"},
+	}
+	entry.NetAddress = "[::2]:9982"
+
+	// The current address should come first, followed by the remaining
+	// clearnet addresses. The onion address is skipped.
+	addrs := scanAddresses(entry)
+	expected := []modules.NetAddress{"[::2]:9982", "foo.com:9982"}
+	if len(addrs) != len(expected) {
+		t.Fatal("wrong number of scan addresses:", addrs)
+	}
+	for i := range expected {
+		if addrs[i] != expected[i] {
+			t.Error("wrong scan address at index", i, addrs[i])
+		}
+	}
+
+	// No more than maxScanAddresses addresses should be tried.
+	entry.NetAddresses = append(entry.NetAddresses, "bar.com:9982", "baz.com:9982")
+	addrs = scanAddresses(entry)
+	if len(addrs) != maxScanAddresses || addrs[0] != entry.NetAddress {
+		t.Error("wrong scan addresses for host with many addresses:", addrs)
+	}
+
+	// A host that is only reachable over Tor has no scan addresses.
+	entry = modules.HostDBEntry{NetAddresses: []modules.NetAddress{onion}}
+	entry.NetAddress = onion
+	if addrs = scanAddresses(entry); len(addrs) != 0 {
+		t.Error("onion only host should have no scan addresses:", addrs)
+	}
+
+	// A host that announced a single address should be scanned on that
+	// address alone.
+	entry = modules.HostDBEntry{NetAddresses: []modules.NetAddress{"foo.com:9982"}}
+	entry.NetAddress = "foo.com:9982"
+	addrs = scanAddresses(entry)
+	if len(addrs) != 1 || addrs[0] != "foo.com:9982" {
+		t.Error("wrong scan addresses for single address host:", addrs)
+	}
+}
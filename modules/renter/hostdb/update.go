@@ -14,14 +14,15 @@ func findHostAnnouncements(b types.Block) (announcements []modules.HostDBEntry)
 		// the HostAnnouncement must be prefaced by the standard host
 		// announcement string
 		for _, arb := range t.ArbitraryData {
-			addr, pubKey, err := modules.DecodeAnnouncement(arb)
+			addrs, pubKey, err := modules.DecodeMultiAddressAnnouncement(arb)
 			if err != nil {
 				continue
 			}
 
 			// Add the announcement to the slice being returned.
 			var host modules.HostDBEntry
-			host.NetAddress = addr
+			host.NetAddress = addrs[0]
+			host.NetAddresses = addrs
 			host.PublicKey = pubKey
 			announcements = append(announcements, host)
 		}
@@ -33,15 +34,29 @@ func findHostAnnouncements(b types.Block) (announcements []modules.HostDBEntry)
 // into the set of all hosts, and if it is online and responding to requests it
 // will be put into the list of active hosts.
 func (hdb *HostDB) insertBlockchainHost(host modules.HostDBEntry) {
-	// Remove garbage hosts and local hosts (but allow local hosts in testing).
-	if err := host.NetAddress.IsValid(); err != nil {
-		hdb.log.Debugf("WARN: host '%v' has an invalid NetAddress: %v", host.NetAddress, err)
-		return
+	// Remove garbage addresses and local addresses (but allow local addresses
+	// in testing). The host is only dropped if none of its addresses are
+	// usable.
+	if len(host.NetAddresses) == 0 {
+		host.NetAddresses = []modules.NetAddress{host.NetAddress}
+	}
+	var addrs []modules.NetAddress
+	for _, addr := range host.NetAddresses {
+		if err := addr.IsValid(); err != nil {
+			hdb.log.Debugf("WARN: host '%v' has an invalid NetAddress: %v", addr, err)
+			continue
+		}
+		// Ignore all local addresses announced through the blockchain.
+		if build.Release == "standard" && addr.IsLocal() {
+			continue
+		}
+		addrs = append(addrs, addr)
 	}
-	// Ignore all local hosts announced through the blockchain.
-	if build.Release == "standard" && host.NetAddress.IsLocal() {
+	if len(addrs) == 0 {
 		return
 	}
+	host.NetAddress = addrs[0]
+	host.NetAddresses = addrs
 
 	// Make sure the host gets into the host tree so it does not get dropped if
 	// shutdown occurs before a scan can be performed.
@@ -53,6 +68,7 @@ func (hdb *HostDB) insertBlockchainHost(host modules.HostDBEntry) {
 		// first seen height of zero, but due to rescans hosts can end up with
 		// a zero-value FirstSeen field.
 		oldEntry.NetAddress = host.NetAddress
+		oldEntry.NetAddresses = host.NetAddresses
 		if oldEntry.FirstSeen == 0 {
 			oldEntry.FirstSeen = hdb.blockHeight
 		}
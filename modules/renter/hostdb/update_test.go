@@ -52,3 +52,35 @@ func TestFindHostAnnouncements(t *testing.T) {
 		t.Error("host announcement found when there was an invalid encoding of a host announcement")
 	}
 }
+
+// TestFindMultiAddressHostAnnouncements checks that findHostAnnouncements
+// returns every address listed in a multi-address host announcement.
+func TestFindMultiAddressHostAnnouncements(t *testing.T) {
+	sk, pk := crypto.GenerateKeyPair()
+	spk := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       pk[:],
+	}
+	addrs := []modules.NetAddress{"foo.com:1234", "[::2]:1234"}
+	annBytes, err := modules.CreateMultiAddressAnnouncement(addrs, spk, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := types.Block{
+		Transactions: []types.Transaction{
+			{
+				ArbitraryData: [][]byte{annBytes},
+			},
+		},
+	}
+	announcements := findHostAnnouncements(b)
+	if len(announcements) != 1 {
+		t.Fatal("host announcement not found in block")
+	}
+	if announcements[0].NetAddress != addrs[0] {
+		t.Error("announcement has the wrong primary address:", announcements[0].NetAddress)
+	}
+	if len(announcements[0].NetAddresses) != 2 || announcements[0].NetAddresses[1] != addrs[1] {
+		t.Error("announcement has the wrong addresses:", announcements[0].NetAddresses)
+	}
+}
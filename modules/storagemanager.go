@@ -34,12 +34,23 @@ type (
 		SuccessfulReads  uint64 `json:"successfulreads"`
 		SuccessfulWrites uint64 `json:"successfulwrites"`
 
+		// Deduplication statistics. PhysicalBytes is the amount of disk space
+		// consumed by the sectors in the folder. LogicalBytes counts every
+		// virtual sector separately, and is the amount of data that renters
+		// believe is being stored in the folder.
+		LogicalBytes  uint64 `json:"logicalbytes"`
+		PhysicalBytes uint64 `json:"physicalbytes"`
+
 		// Certain operations on a storage folder can take a long time (Add,
-		// Remove, and Resize). The fields below indicate the progress of any
-		// long running operations that might be under way in the storage
-		// folder. Progress is always reported in bytes.
+		// Remove, Resize, and Migrate). The fields below indicate the progress
+		// of any long running operations that might be under way in the
+		// storage folder. Progress is always reported in bytes.
 		ProgressNumerator   uint64
 		ProgressDenominator uint64
+
+		// MigrationPath is the path that the storage folder is being migrated
+		// to. It is empty if the storage folder is not being migrated.
+		MigrationPath string `json:"migrationpath"`
 	}
 
 	// A StorageManager is responsible for managing storage folders and
@@ -80,10 +91,23 @@ type (
 		// requests to remove data.
 		DeleteSector(sectorRoot crypto.Hash) error
 
+		// MigrateStorageFolder will move a storage folder to a new path. The
+		// sectors are copied to the new path in the background, and the
+		// storage folder remains usable while the migration is in progress.
+		// The migration resumes if the manager is restarted before it
+		// completes.
+		MigrateStorageFolder(index uint16, newPath string) error
+
 		// ReadSector will read a sector from the storage manager, returning the
 		// bytes that match the input sector root.
 		ReadSector(sectorRoot crypto.Hash) ([]byte, error)
 
+		// SectorReferenceCounts returns the number of virtual sectors that
+		// the storage manager is tracking for each of the provided sector
+		// roots. A sector that is not stored by the manager has a reference
+		// count of zero.
+		SectorReferenceCounts(sectorRoots []crypto.Hash) ([]uint64, error)
+
 		// RemoveSector will remove a sector from the storage manager. The
 		// height at which the sector expires should be provided, so that the
 		// auto-expiry information for that sector can be properly updated.
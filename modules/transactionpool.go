@@ -83,6 +83,32 @@ type (
 		Sizes        []uint64
 		Transactions []types.Transaction
 	}
+
+	// PoolTransactionSet describes a transaction set in the transaction pool.
+	// Size is in bytes, and Age is the number of blocks since the oldest
+	// transaction of the set entered the pool.
+	PoolTransactionSet struct {
+		ID           TransactionSetID  `json:"id"`
+		Size         uint64            `json:"size"`
+		Fees         types.Currency    `json:"fees"`
+		FeePerByte   types.Currency    `json:"feeperbyte"`
+		Age          types.BlockHeight `json:"age"`
+		Transactions []PoolTransaction `json:"transactions"`
+	}
+
+	// PoolTransaction describes a transaction of a set in the transaction
+	// pool. Parents are the transactions of the set that create objects the
+	// transaction spends or revises, and Children are the transactions of the
+	// set that spend or revise objects the transaction creates.
+	PoolTransaction struct {
+		ID          types.TransactionID   `json:"id"`
+		Size        uint64                `json:"size"`
+		Fees        types.Currency        `json:"fees"`
+		Age         types.BlockHeight     `json:"age"`
+		Parents     []types.TransactionID `json:"parents"`
+		Children    []types.TransactionID `json:"children"`
+		Transaction types.Transaction     `json:"transaction"`
+	}
 )
 
 type (
@@ -117,6 +143,12 @@ type (
 		// within 10 blocks.
 		FeeEstimation() (minimumRecommended, maximumRecommended types.Currency)
 
+		// FeeEstimate returns an estimation for how high the transaction fee
+		// needs to be per byte for a transaction set to be confirmed within
+		// targetBlocks blocks, based on how long recently confirmed sets took
+		// to confirm at the fees they paid.
+		FeeEstimate(targetBlocks types.BlockHeight) types.Currency
+
 		// PurgeTransactionPool is a temporary function available to the miner. In
 		// the event that a miner mines an unacceptable block, the transaction pool
 		// will be purged to clear out the transaction pool and get rid of the
@@ -135,9 +167,14 @@ type (
 
 		// TransactionList returns a list of all transactions in the transaction
 		// pool. The transactions are provided in an order that can acceptably be
-		// put into a block.
+		// put into a block, with the sets paying the highest fee per byte
+		// first.
 		TransactionList() []types.Transaction
 
+		// TransactionSets returns the transaction sets in the transaction
+		// pool, with the sets paying the highest fee per byte first.
+		TransactionSets() []PoolTransactionSet
+
 		// TransactionPoolSubscribe adds a subscriber to the transaction pool.
 		// Subscribers will receive all consensus set changes as well as
 		// transaction pool changes, and should not subscribe to both.
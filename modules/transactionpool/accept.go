@@ -4,9 +4,11 @@ package transactionpool
 // between a file contract revision and a file contract.
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"time"
 
 	"github.com/NebulousLabs/Sia/build"
@@ -20,6 +22,7 @@ var (
 	errEmptySet            = errors.New("transaction set is empty")
 	errFullTransactionPool = errors.New("transaction pool cannot accept more transactions")
 	errLowMinerFees        = errors.New("transaction set needs more miner fees to be accepted")
+	errLowReplacementFees  = errors.New("replacement transaction set must pay sufficiently more miner fees than the sets it replaces")
 	errObjectConflict      = errors.New("transaction set conflicts with an existing transaction set")
 )
 
@@ -57,22 +60,222 @@ func relatedObjectIDs(ts []types.Transaction) []ObjectID {
 	return oids
 }
 
-// requiredFeesToExtendTpool returns the amount of fees required to extend the
-// transaction pool to fit another transaction set. The amount returned has the
-// unit 'currency per byte'.
-func (tp *TransactionPool) requiredFeesToExtendTpool() types.Currency {
+// spentObjectIDs determines the ids of all of the objects that are spent or
+// revised by a transaction set.
+func spentObjectIDs(ts []types.Transaction) map[ObjectID]struct{} {
+	oidMap := make(map[ObjectID]struct{})
+	for _, t := range ts {
+		for _, sci := range t.SiacoinInputs {
+			oidMap[ObjectID(sci.ParentID)] = struct{}{}
+		}
+		for _, fcr := range t.FileContractRevisions {
+			oidMap[ObjectID(fcr.ParentID)] = struct{}{}
+		}
+		for _, sp := range t.StorageProofs {
+			oidMap[ObjectID(sp.ParentID)] = struct{}{}
+		}
+		for _, sfi := range t.SiafundInputs {
+			oidMap[ObjectID(sfi.ParentID)] = struct{}{}
+		}
+	}
+	return oidMap
+}
+
+// createdObjectIDs determines the ids of all of the objects that are created
+// by a transaction.
+func createdObjectIDs(t types.Transaction) []ObjectID {
+	var oids []ObjectID
+	for i := range t.SiacoinOutputs {
+		oids = append(oids, ObjectID(t.SiacoinOutputID(uint64(i))))
+	}
+	for i := range t.FileContracts {
+		oids = append(oids, ObjectID(t.FileContractID(uint64(i))))
+	}
+	for i := range t.SiafundOutputs {
+		oids = append(oids, ObjectID(t.SiafundOutputID(uint64(i))))
+	}
+	return oids
+}
+
+// transactionSetFees returns the total miner fees of a transaction set.
+func transactionSetFees(ts []types.Transaction) (fees types.Currency) {
+	for _, txn := range ts {
+		for _, fee := range txn.MinerFees {
+			fees = fees.Add(fee)
+		}
+	}
+	return fees
+}
+
+// requiredFeesForSize returns the amount of fees required to extend a
+// transaction pool of the given size to fit another transaction set. The
+// amount returned has the unit 'currency per byte'.
+func requiredFeesForSize(size int) types.Currency {
 	// If the transaction pool is nearly empty, it can be extended even if there
 	// are no fees.
-	if tp.transactionListSize < TransactionPoolSizeForFee {
+	if size < TransactionPoolSizeForFee {
 		return types.ZeroCurrency
 	}
 
 	// Calculate the fee required to bump out the size of the transaction pool.
-	ratioToTarget := float64(tp.transactionListSize) / TransactionPoolSizeTarget
+	ratioToTarget := float64(size) / TransactionPoolSizeTarget
 	feeFactor := math.Pow(ratioToTarget, TransactionPoolExponentiation)
 	return types.SiacoinPrecision.MulFloat(feeFactor).Div64(1000) // Divide by 1000 to get SC / kb
 }
 
+// requiredFeesToExtendTpool returns the amount of fees required to extend the
+// transaction pool to fit another transaction set. The amount returned has the
+// unit 'currency per byte'.
+func (tp *TransactionPool) requiredFeesToExtendTpool() types.Currency {
+	return requiredFeesForSize(tp.transactionListSize)
+}
+
+// higherFeeRate returns whether fees1 per size1 bytes is a higher fee rate
+// than fees2 per size2 bytes.
+func higherFeeRate(fees1 types.Currency, size1 uint64, fees2 types.Currency, size2 uint64) bool {
+	return fees1.Mul64(size2).Cmp(fees2.Mul64(size1)) > 0
+}
+
+// newFeeRateSet returns the fee rate entry of a transaction set.
+func newFeeRateSet(id TransactionSetID, set []types.Transaction) feeRateSet {
+	return feeRateSet{
+		id:   id,
+		fees: transactionSetFees(set),
+		size: uint64(len(encoding.Marshal(set))),
+	}
+}
+
+// feeRateBefore returns whether fs1 is ordered before fs2 in the fee index.
+// Sets with a higher fee per byte come first, and sets with the same fee rate
+// are ordered by id.
+func feeRateBefore(fs1, fs2 feeRateSet) bool {
+	if higherFeeRate(fs1.fees, fs1.size, fs2.fees, fs2.size) {
+		return true
+	} else if higherFeeRate(fs2.fees, fs2.size, fs1.fees, fs1.size) {
+		return false
+	}
+	return bytes.Compare(fs1.id[:], fs2.id[:]) < 0
+}
+
+// feeIndexPosition returns the position of fs in the fee index, or the
+// position at which it would be inserted.
+func (tp *TransactionPool) feeIndexPosition(fs feeRateSet) int {
+	return sort.Search(len(tp.feeIndex), func(i int) bool {
+		return !feeRateBefore(tp.feeIndex[i], fs)
+	})
+}
+
+// addToFeeIndex inserts a transaction set into the fee index.
+func (tp *TransactionPool) addToFeeIndex(fs feeRateSet) {
+	i := tp.feeIndexPosition(fs)
+	tp.feeIndex = append(tp.feeIndex, feeRateSet{})
+	copy(tp.feeIndex[i+1:], tp.feeIndex[i:])
+	tp.feeIndex[i] = fs
+}
+
+// removeFromFeeIndex removes a transaction set from the fee index.
+func (tp *TransactionPool) removeFromFeeIndex(fs feeRateSet) {
+	i := tp.feeIndexPosition(fs)
+	if i == len(tp.feeIndex) || tp.feeIndex[i].id != fs.id {
+		tp.log.Critical("transaction set is missing from the fee index")
+		return
+	}
+	tp.feeIndex = append(tp.feeIndex[:i], tp.feeIndex[i+1:]...)
+}
+
+// feeOrderedSets returns the transaction sets of the pool, sorted by fee per
+// byte with the highest paying set first. Sets with the same fee rate are
+// sorted by id. The returned slice is the fee index of the pool and must not
+// be modified.
+func (tp *TransactionPool) feeOrderedSets() []feeRateSet {
+	return tp.feeIndex
+}
+
+// setsToEvict returns the sets that need to be evicted from the pool to make
+// room for a set of setSize bytes that pays setFees. Only sets whose fee per
+// byte is lower than that of the new set by at least minEvictionFeeIncrement
+// are evicted, starting with the lowest paying set. Sets in exclude are never
+// evicted. If the new set pays enough fees without evicting anything, no sets
+// are returned. If evicting every eligible set would still not make enough
+// room, errLowMinerFees is returned.
+func (tp *TransactionPool) setsToEvict(setSize uint64, setFees types.Currency, exclude map[TransactionSetID]struct{}) ([]TransactionSetID, error) {
+	poolSize := tp.transactionListSize
+	if requiredFeesForSize(poolSize).Mul64(setSize).Cmp(setFees) <= 0 {
+		return nil, nil
+	}
+
+	var evict []TransactionSetID
+	sets := tp.feeOrderedSets()
+	for i := len(sets) - 1; i >= 0; i-- {
+		minFees := sets[i].fees.Add(minEvictionFeeIncrement.Mul64(sets[i].size))
+		if higherFeeRate(minFees, sets[i].size, setFees, setSize) {
+			break
+		}
+		if _, excluded := exclude[sets[i].id]; excluded {
+			continue
+		}
+		evict = append(evict, sets[i].id)
+		poolSize -= int(sets[i].size)
+		if requiredFeesForSize(poolSize).Mul64(setSize).Cmp(setFees) <= 0 {
+			return evict, nil
+		}
+	}
+	return nil, errLowMinerFees
+}
+
+// addTransactionSet adds a transaction set and its diff to the transaction
+// pool. The caller is responsible for registering the objects of the set in
+// knownObjects. The size of the set is returned.
+func (tp *TransactionPool) addTransactionSet(id TransactionSetID, set []types.Transaction, cc *modules.ConsensusChange) int {
+	fs := newFeeRateSet(id, set)
+	tp.transactionSets[id] = set
+	tp.transactionSetDiffs[id] = cc
+	tp.transactionListSize += int(fs.size)
+	tp.addToFeeIndex(fs)
+	return int(fs.size)
+}
+
+// removeTransactionSet removes a transaction set and the objects it owns from
+// the transaction pool.
+func (tp *TransactionPool) removeTransactionSet(id TransactionSetID) {
+	set := tp.transactionSets[id]
+	for _, oid := range relatedObjectIDs(set) {
+		if tp.knownObjects[oid] == id {
+			delete(tp.knownObjects, oid)
+		}
+	}
+	fs := newFeeRateSet(id, set)
+	tp.transactionListSize -= int(fs.size)
+	tp.removeFromFeeIndex(fs)
+	delete(tp.transactionSets, id)
+	delete(tp.transactionSetDiffs, id)
+}
+
+// recordTransactionHeights records the current height as the height at which
+// the transactions of ts were first seen. Transactions that are already known,
+// such as the parents of a set that was merged with its children, keep the
+// height they were first seen at.
+func (tp *TransactionPool) recordTransactionHeights(ts []types.Transaction) {
+	for _, txn := range ts {
+		if _, exists := tp.transactionHeights[txn.ID()]; !exists {
+			tp.transactionHeights[txn.ID()] = tp.blockHeight
+		}
+	}
+}
+
+// evictTransactionSets evicts the given transaction sets from the transaction
+// pool to make room for sets that pay higher fees. Subscribers learn about the
+// evictions with the next update.
+func (tp *TransactionPool) evictTransactionSets(ids []TransactionSetID) {
+	for _, id := range ids {
+		for _, txn := range tp.transactionSets[id] {
+			delete(tp.transactionHeights, txn.ID())
+		}
+		tp.removeTransactionSet(id)
+		tp.log.Debugf("evicted transaction set %v, tpool size is %vB after eviction\n", id, tp.transactionListSize)
+	}
+}
+
 // checkTransactionSetComposition checks if the transaction set is valid given
 // the state of the pool. It does not check that each individual transaction
 // would be legal in the next block, but does check things like miner fees and
@@ -169,40 +372,49 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []T
 	}
 
 	// Check that the transaction set has enough fees to justify adding it to
-	// the transaction list.
-	requiredFees := tp.requiredFeesToExtendTpool().Mul64(setSize)
+	// the transaction list, possibly by evicting sets that pay lower fees.
+	// The conflicts are merged into the new set and are never evicted.
+	evict, err := tp.setsToEvict(setSize, transactionSetFees(superset), supersetMap)
 	if err != nil {
 		return err
 	}
-	var setFees types.Currency
-	for _, txn := range superset {
-		for _, fee := range txn.MinerFees {
-			setFees = setFees.Add(fee)
-		}
-	}
-	if requiredFees.Cmp(setFees) > 0 {
-		// TODO: check if there is an existing set with lower fees that we can
-		// kick out.
-		return errLowMinerFees
-	}
 
-	// Check that the transaction set is valid.
+	// Check that the transaction set is valid. If it is not, it may be
+	// replacing conflicts that spend the same objects.
 	cc, err := txnFn(superset)
 	if err != nil {
-		return modules.NewConsensusConflict("provided transaction set has prereqs, but is still invalid: " + err.Error())
+		var replaceErr error
+		superset, cc, replaceErr = tp.replaceConflicts(dedupSet, supersetMap, txnFn)
+		if replaceErr == errObjectConflict {
+			return modules.NewConsensusConflict("provided transaction set has prereqs, but is still invalid: " + err.Error())
+		} else if replaceErr != nil {
+			return replaceErr
+		}
+		// The replacement has paid enough fees to be added without evicting
+		// other sets.
+		evict = nil
 	}
 
-	// Remove the conflicts from the transaction pool.
+	// Remove the conflicts from the transaction pool. Objects that are part
+	// of the new set are added back below; the objects and heights of
+	// replaced transactions are forgotten.
+	supersetTxns := make(map[types.TransactionID]struct{})
+	for _, txn := range superset {
+		supersetTxns[txn.ID()] = struct{}{}
+	}
 	for conflict := range supersetMap {
-		conflictSet := tp.transactionSets[conflict]
-		tp.transactionListSize -= len(encoding.Marshal(conflictSet))
-		delete(tp.transactionSets, conflict)
-		delete(tp.transactionSetDiffs, conflict)
+		for _, txn := range tp.transactionSets[conflict] {
+			if _, ok := supersetTxns[txn.ID()]; !ok {
+				delete(tp.transactionHeights, txn.ID())
+			}
+		}
+		tp.removeTransactionSet(conflict)
 	}
+	tp.evictTransactionSets(evict)
 
 	// Add the transaction set to the pool.
 	setID := TransactionSetID(crypto.HashObject(superset))
-	tp.transactionSets[setID] = superset
+	tsetSize := tp.addTransactionSet(setID, superset, &cc)
 	for _, diff := range cc.SiacoinOutputDiffs {
 		tp.knownObjects[ObjectID(diff.ID)] = setID
 	}
@@ -212,9 +424,7 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []T
 	for _, diff := range cc.SiafundOutputDiffs {
 		tp.knownObjects[ObjectID(diff.ID)] = setID
 	}
-	tp.transactionSetDiffs[setID] = &cc
-	tsetSize := len(encoding.Marshal(superset))
-	tp.transactionListSize += tsetSize
+	tp.recordTransactionHeights(superset)
 
 	// debug logging
 	if build.DEBUG {
@@ -228,6 +438,76 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []T
 	return nil
 }
 
+// minReplacementFees returns the fees that a replacement of replacementSize
+// bytes has to pay to replace transactions that pay replacedFees. On top of
+// the replaced fees, the replacement pays the fee per byte required to extend
+// the pool plus minEvictionFeeIncrement for each of its bytes, so that
+// replacing the same inputs over and over gets more expensive every time.
+func (tp *TransactionPool) minReplacementFees(replacedFees types.Currency, replacementSize uint64) types.Currency {
+	feePerByte := tp.requiredFeesToExtendTpool().Add(minEvictionFeeIncrement)
+	return replacedFees.Add(feePerByte.Mul64(replacementSize))
+}
+
+// replaceConflicts handles a transaction set that spends some of the same
+// objects as the conflicting sets in supersetMap. Such a set is a
+// replacement, for example one that pays a higher fee than the original
+// transaction. The transactions of the conflicts that it double spends, and
+// every transaction that depends on them, are evicted if the replacement pays
+// at least minReplacementFees. The remaining transactions of the
+// conflicts are merged with the replacement as usual. errObjectConflict is
+// returned if ts does not double spend any of its conflicts.
+func (tp *TransactionPool) replaceConflicts(ts []types.Transaction, supersetMap map[TransactionSetID]struct{}, txnFn func([]types.Transaction) (modules.ConsensusChange, error)) ([]types.Transaction, modules.ConsensusChange, error) {
+	// Transaction sets are ordered so that parents come before their
+	// children, so the descendants of an evicted transaction are found by
+	// tracking the objects it creates.
+	evicted := spentObjectIDs(ts)
+	var superset, replaced []types.Transaction
+	for conflict := range supersetMap {
+		for _, txn := range tp.transactionSets[conflict] {
+			doubleSpend := false
+			for oid := range spentObjectIDs([]types.Transaction{txn}) {
+				if _, ok := evicted[oid]; ok {
+					doubleSpend = true
+					break
+				}
+			}
+			if !doubleSpend {
+				superset = append(superset, txn)
+				continue
+			}
+			replaced = append(replaced, txn)
+			for _, oid := range createdObjectIDs(txn) {
+				evicted[oid] = struct{}{}
+			}
+		}
+	}
+	if len(replaced) == 0 {
+		return nil, modules.ConsensusChange{}, errObjectConflict
+	}
+	superset = append(superset, ts...)
+
+	// The replacement must pay for the transactions it evicts and for its
+	// own relay, and the new set must still pay enough fees to be added to
+	// the pool.
+	minFees := tp.minReplacementFees(transactionSetFees(replaced), uint64(len(encoding.Marshal(ts))))
+	if transactionSetFees(ts).Cmp(minFees) < 0 {
+		return nil, modules.ConsensusChange{}, errLowReplacementFees
+	}
+	setSize, err := tp.checkTransactionSetComposition(superset)
+	if err != nil {
+		return nil, modules.ConsensusChange{}, err
+	}
+	if tp.requiredFeesToExtendTpool().Mul64(setSize).Cmp(transactionSetFees(superset)) > 0 {
+		return nil, modules.ConsensusChange{}, errLowMinerFees
+	}
+
+	cc, err := txnFn(superset)
+	if err != nil {
+		return nil, modules.ConsensusChange{}, modules.NewConsensusConflict("provided replacement transaction set is invalid: " + err.Error())
+	}
+	return superset, cc, nil
+}
+
 // acceptTransactionSet verifies that a transaction set is allowed to be in the
 // transaction pool, and then adds it to the transaction pool.
 func (tp *TransactionPool) acceptTransactionSet(ts []types.Transaction, txnFn func([]types.Transaction) (modules.ConsensusChange, error)) error {
@@ -254,35 +534,26 @@ func (tp *TransactionPool) acceptTransactionSet(ts []types.Transaction, txnFn fu
 		return err
 	}
 
-	// Check that the transaction set has enough fees to justify adding it to
-	// the transaction list.
-	requiredFees := tp.requiredFeesToExtendTpool().Mul64(setSize)
-	if err != nil {
-		return err
-	}
-	var setFees types.Currency
-	for _, txn := range ts {
-		for _, fee := range txn.MinerFees {
-			setFees = setFees.Add(fee)
-		}
-	}
-	if requiredFees.Cmp(setFees) > 0 {
-		// TODO: check if there is an existing set with lower fees that we can
-		// kick out.
-		return errLowMinerFees
-	}
-
 	// Check for conflicts with other transactions, which would indicate a
 	// double-spend. Legal children of a transaction set will also trigger the
 	// conflict-detector.
 	oids := relatedObjectIDs(ts)
 	var conflicts []TransactionSetID
+	conflictMap := make(map[TransactionSetID]struct{})
 	for _, oid := range oids {
 		conflict, exists := tp.knownObjects[oid]
 		if exists {
 			conflicts = append(conflicts, conflict)
+			conflictMap[conflict] = struct{}{}
 		}
 	}
+
+	// Check that the transaction set has enough fees to justify adding it to
+	// the transaction list, possibly by evicting sets that pay lower fees.
+	evict, err := tp.setsToEvict(setSize, transactionSetFees(ts), conflictMap)
+	if err != nil {
+		return err
+	}
 	if len(conflicts) > 0 {
 		return tp.handleConflicts(ts, conflicts, txnFn)
 	}
@@ -291,20 +562,14 @@ func (tp *TransactionPool) acceptTransactionSet(ts []types.Transaction, txnFn fu
 		return modules.NewConsensusConflict("provided transaction set is standalone and invalid: " + err.Error())
 	}
 
-	// Add the transaction set to the pool.
+	// Make room for the transaction set and add it to the pool.
+	tp.evictTransactionSets(evict)
 	setID := TransactionSetID(crypto.HashObject(ts))
-	tp.transactionSets[setID] = ts
+	tsetSize := tp.addTransactionSet(setID, ts, &cc)
 	for _, oid := range oids {
 		tp.knownObjects[oid] = setID
 	}
-	tp.transactionSetDiffs[setID] = &cc
-	tsetSize := len(encoding.Marshal(ts))
-	tp.transactionListSize += tsetSize
-	for _, txn := range ts {
-		if _, exists := tp.transactionHeights[txn.ID()]; !exists {
-			tp.transactionHeights[txn.ID()] = tp.blockHeight
-		}
-	}
+	tp.recordTransactionHeights(ts)
 
 	// debug logging
 	if build.DEBUG {
@@ -3,6 +3,8 @@ package transactionpool
 import (
 	"testing"
 
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
 	"github.com/NebulousLabs/fastrand"
@@ -60,7 +62,7 @@ func TestAcceptTransactionSet(t *testing.T) {
 
 // TestConflictingTransactionSets tries to add two transaction sets
 // to the transaction pool that are each legal individually, but double spend
-// an output.
+// an output. Only a set that pays more fees may replace the other.
 func TestConflictingTransactionSets(t *testing.T) {
 	if testing.Short() {
 		t.SkipNow()
@@ -73,7 +75,7 @@ func TestConflictingTransactionSets(t *testing.T) {
 	defer tpt.Close()
 
 	// Fund a partial transaction.
-	fund := types.NewCurrency64(30e6)
+	fund := types.SiacoinPrecision
 	txnBuilder := tpt.wallet.StartTransaction()
 	err = txnBuilder.FundSiacoins(fund)
 	if err != nil {
@@ -87,6 +89,8 @@ func TestConflictingTransactionSets(t *testing.T) {
 	}
 	txnSetDoubleSpend := make([]types.Transaction, len(txnSet))
 	copy(txnSetDoubleSpend, txnSet)
+	txnSetLowFee := make([]types.Transaction, len(txnSet))
+	copy(txnSetLowFee, txnSet)
 
 	// There are now two sets of transactions that are signed and ready to
 	// spend the same output. Have one spend the money in a miner fee, and the
@@ -94,6 +98,10 @@ func TestConflictingTransactionSets(t *testing.T) {
 	txnIndex := len(txnSet) - 1
 	txnSet[txnIndex].MinerFees = append(txnSet[txnIndex].MinerFees, fund)
 	txnSetDoubleSpend[txnIndex].SiacoinOutputs = append(txnSetDoubleSpend[txnIndex].SiacoinOutputs, types.SiacoinOutput{Value: fund})
+	// The low fee set pays a single hasting more than the double spend.
+	lowFee := types.NewCurrency64(1)
+	txnSetLowFee[txnIndex].MinerFees = append(txnSetLowFee[txnIndex].MinerFees, lowFee)
+	txnSetLowFee[txnIndex].SiacoinOutputs = append(txnSetLowFee[txnIndex].SiacoinOutputs, types.SiacoinOutput{Value: fund.Sub(lowFee)})
 
 	// Add the first and then the second txn set.
 	err = tpt.tpool.AcceptTransactionSet(txnSet)
@@ -105,15 +113,44 @@ func TestConflictingTransactionSets(t *testing.T) {
 		t.Error("transaction should not have passed inspection")
 	}
 
-	// Purge and try the sets in the reverse order.
+	// Purge and try the sets in the reverse order. The set that spends the
+	// money in a miner fee pays more fees, so it replaces the double spend.
 	tpt.tpool.PurgeTransactionPool()
 	err = tpt.tpool.AcceptTransactionSet(txnSetDoubleSpend)
 	if err != nil {
 		t.Error(err)
 	}
+	// A set that pays barely more than the double spend cannot replace it.
+	err = tpt.tpool.AcceptTransactionSet(txnSetLowFee)
+	if err != errLowReplacementFees {
+		t.Error("expected errLowReplacementFees, got", err)
+	}
 	err = tpt.tpool.AcceptTransactionSet(txnSet)
-	if err == nil {
-		t.Error("transaction should not have passed inspection")
+	if err != nil {
+		t.Error(err)
+	}
+	txnList := tpt.tpool.TransactionList()
+	if len(txnList) != len(txnSet) {
+		t.Fatal("expected the double spend to be replaced, got", len(txnList), "transactions")
+	}
+	for _, txn := range txnList {
+		if txn.ID() == txnSetDoubleSpend[txnIndex].ID() {
+			t.Error("double spend was not evicted")
+		}
+	}
+	for _, txn := range txnSet {
+		if _, exists := tpt.tpool.transactionHeights[txn.ID()]; !exists {
+			t.Error("height of the replacement was not recorded")
+		}
+	}
+	if _, exists := tpt.tpool.transactionHeights[txnSetDoubleSpend[txnIndex].ID()]; exists {
+		t.Error("height of the double spend was not forgotten")
+	}
+
+	// The double spend cannot replace the set, because it pays less fees.
+	err = tpt.tpool.AcceptTransactionSet(txnSetDoubleSpend)
+	if err != errLowReplacementFees {
+		t.Error("expected errLowReplacementFees, got", err)
 	}
 }
 
@@ -467,10 +504,23 @@ func TestTransactionChild(t *testing.T) {
 	if err != nil {
 		t.Fatal("first transaction in the transaction set was not valid?")
 	}
+	// Pretend the parent was seen a block earlier. The child is merged with
+	// the parent; the child should get the current height and the parent
+	// should keep its own.
+	parentHeight := tpt.tpool.transactionHeights[txnSet[0].ID()] - 1
+	tpt.tpool.transactionHeights[txnSet[0].ID()] = parentHeight
 	err = tpt.tpool.AcceptTransactionSet(txnSet[1:])
 	if err != nil {
 		t.Fatal("child transaction not seen as valid")
 	}
+	if height := tpt.tpool.transactionHeights[txnSet[0].ID()]; height != parentHeight {
+		t.Fatalf("expected parent height %v, got %v", parentHeight, height)
+	}
+	for _, txn := range txnSet[1:] {
+		if height, exists := tpt.tpool.transactionHeights[txn.ID()]; !exists || height != tpt.tpool.blockHeight {
+			t.Fatal("height of the child transaction was not recorded")
+		}
+	}
 }
 
 // TestNilAccept tries submitting a nil transaction set and a 0-len
@@ -752,3 +802,209 @@ func TestPartialConfirmationWeave(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestEvictLowFeeSets checks that a full transaction pool evicts the sets
+// paying the lowest fees to make room for a set that pays more, and that
+// subscribers are told about the eviction.
+func TestEvictLowFeeSets(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tpt.Close()
+
+	// Create an output that can fund a fee paying set once the pool is full.
+	fund := types.SiacoinPrecision.Mul64(1000)
+	txns, err := tpt.wallet.SendSiacoinsMulti([]types.SiacoinOutput{{
+		UnlockHash: types.UnlockConditions{}.UnlockHash(),
+		Value:      fund,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tpt.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms := mockSubscriber{
+		txnMap: make(map[modules.TransactionSetID][]types.Transaction),
+	}
+	tpt.tpool.TransactionPoolSubscribe(&ms)
+
+	// Fill the transaction pool to the fee limit with sets that pay no fees.
+	for i := 0; i < TransactionPoolSizeForFee/10e3; i++ {
+		arbData := make([]byte, 10e3)
+		copy(arbData, modules.PrefixNonSia[:])
+		fastrand.Read(arbData[100:116]) // prevents collisions with other transacitons in the loop.
+		txn := types.Transaction{ArbitraryData: [][]byte{arbData}}
+		err := tpt.tpool.AcceptTransactionSet([]types.Transaction{txn})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = tpt.tpool.AcceptTransactionSet([]types.Transaction{{}})
+	if err != errLowMinerFees {
+		t.Fatal("expected errLowMinerFees, got", err)
+	}
+	numSets := len(tpt.tpool.transactionSets)
+
+	// Add a set that pays too little to extend the pool, but more than the
+	// sets in the pool. The lowest paying set should be evicted to make room.
+	lowest := tpt.tpool.feeOrderedSets()[numSets-1].id
+	fee := types.SiacoinPrecision.Div64(1e4)
+	graph, err := types.TransactionGraph(txns[len(txns)-1].SiacoinOutputID(0), []types.TransactionGraphEdge{{
+		Dest:   1,
+		Fee:    fee,
+		Source: 0,
+		Value:  fund.Sub(fee),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tpt.tpool.requiredFeesToExtendTpool().Mul64(uint64(len(encoding.Marshal(graph)))).Cmp(fee) <= 0 {
+		t.Fatal("fee paying set does not need to evict other sets")
+	}
+	err = tpt.tpool.AcceptTransactionSet(graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tpt.tpool.transactionSets) != numSets {
+		t.Fatalf("expected %v sets in the pool, got %v", numSets, len(tpt.tpool.transactionSets))
+	}
+	if _, exists := tpt.tpool.transactionSets[lowest]; exists {
+		t.Fatal("lowest paying set was not evicted")
+	}
+	if _, exists := tpt.tpool.transactionSetDiffs[lowest]; exists {
+		t.Fatal("diff of the evicted set was not removed")
+	}
+	for _, id := range tpt.tpool.knownObjects {
+		if id == lowest {
+			t.Fatal("objects of the evicted set are still known")
+		}
+	}
+	if _, exists := ms.txnMap[modules.TransactionSetID(lowest)]; exists {
+		t.Fatal("subscriber was not told about the eviction")
+	}
+	if len(ms.txnMap) != numSets {
+		t.Fatalf("expected subscriber to know %v sets, got %v", numSets, len(ms.txnMap))
+	}
+
+	// The fee paying set should be listed first.
+	txnList := tpt.tpool.TransactionList()
+	if len(txnList) < len(graph) || txnList[0].ID() != graph[0].ID() {
+		t.Fatal("transaction list is not ordered by fee")
+	}
+}
+
+// TestFeeOrderedSets checks that feeOrderedSets sorts the sets of the pool by
+// fee per byte, and that the order is kept as sets are removed.
+func TestFeeOrderedSets(t *testing.T) {
+	tp := &TransactionPool{
+		knownObjects:        make(map[ObjectID]TransactionSetID),
+		transactionSets:     make(map[TransactionSetID][]types.Transaction),
+		transactionSetDiffs: make(map[TransactionSetID]*modules.ConsensusChange),
+	}
+	fees := []types.Currency{
+		types.NewCurrency64(10),
+		types.NewCurrency64(1000),
+		types.ZeroCurrency,
+		types.NewCurrency64(100),
+	}
+	for i, fee := range fees {
+		txn := types.Transaction{
+			MinerFees:     []types.Currency{fee},
+			ArbitraryData: [][]byte{fastrand.Bytes(100 * (i + 1))},
+		}
+		tp.addTransactionSet(TransactionSetID(crypto.HashObject(txn)), []types.Transaction{txn}, &modules.ConsensusChange{})
+	}
+	sets := tp.feeOrderedSets()
+	if len(sets) != len(fees) {
+		t.Fatalf("expected %v sets, got %v", len(fees), len(sets))
+	}
+	for i := 1; i < len(sets); i++ {
+		if higherFeeRate(sets[i].fees, sets[i].size, sets[i-1].fees, sets[i-1].size) {
+			t.Fatal("sets are not sorted by fee rate:", sets)
+		}
+	}
+	if !sets[0].fees.Equals(fees[1]) || !sets[len(sets)-1].fees.IsZero() {
+		t.Fatal("sets are not sorted by fee rate:", sets)
+	}
+
+	// Remove the highest paying set. The remaining sets should keep their
+	// order.
+	remaining := append([]feeRateSet(nil), sets[1:]...)
+	tp.removeTransactionSet(sets[0].id)
+	sets = tp.feeOrderedSets()
+	if len(sets) != len(remaining) {
+		t.Fatalf("expected %v sets, got %v", len(remaining), len(sets))
+	}
+	for i := range sets {
+		if sets[i].id != remaining[i].id {
+			t.Fatal("fee index is out of order after removing a set:", sets)
+		}
+	}
+	var size uint64
+	for _, fs := range sets {
+		size += fs.size
+	}
+	if uint64(tp.transactionListSize) != size {
+		t.Fatalf("expected pool size %v, got %v", size, tp.transactionListSize)
+	}
+}
+
+// TestMinEvictionFeeIncrement checks that a set is only evicted by a set that
+// pays at least minEvictionFeeIncrement more per byte.
+func TestMinEvictionFeeIncrement(t *testing.T) {
+	tp := &TransactionPool{
+		knownObjects:        make(map[ObjectID]TransactionSetID),
+		transactionSets:     make(map[TransactionSetID][]types.Transaction),
+		transactionSetDiffs: make(map[TransactionSetID]*modules.ConsensusChange),
+	}
+	// Fill the pool past the fee limit with a single set.
+	txn := types.Transaction{
+		MinerFees:     []types.Currency{types.NewCurrency64(1)},
+		ArbitraryData: [][]byte{make([]byte, 2*TransactionPoolSizeForFee)},
+	}
+	id := TransactionSetID(crypto.HashObject(txn))
+	size := tp.addTransactionSet(id, []types.Transaction{txn}, &modules.ConsensusChange{})
+
+	// A set paying a marginally higher fee rate must not evict it.
+	setSize := uint64(1000)
+	setFees := types.NewCurrency64(1).Mul64(setSize).Div64(uint64(size)).Add(types.NewCurrency64(setSize))
+	if _, err := tp.setsToEvict(setSize, setFees, nil); err != errLowMinerFees {
+		t.Fatal("expected errLowMinerFees, got", err)
+	}
+
+	// A set that pays the increment on top of the fee rate of the set evicts
+	// it.
+	setFees = minEvictionFeeIncrement.Mul64(setSize).Add(setFees)
+	evict, err := tp.setsToEvict(setSize, setFees, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evict) != 1 || evict[0] != id {
+		t.Fatal("expected the set to be evicted, got", evict)
+	}
+}
+
+// TestMinReplacementFees checks that a replacement has to pay the replaced
+// fees plus the pool's fee rate and minEvictionFeeIncrement for its size.
+func TestMinReplacementFees(t *testing.T) {
+	tp := &TransactionPool{}
+	replaced := types.NewCurrency64(1000)
+	minFees := tp.minReplacementFees(replaced, 100)
+	if !minFees.Equals(replaced.Add(minEvictionFeeIncrement.Mul64(100))) {
+		t.Fatal("wrong minimum replacement fees for an empty pool:", minFees)
+	}
+
+	// Once the pool requires fees, the replacement has to pay them as well.
+	tp.transactionListSize = 2 * TransactionPoolSizeForFee
+	fullFees := tp.minReplacementFees(replaced, 100)
+	expected := minFees.Add(tp.requiredFeesToExtendTpool().Mul64(100))
+	if !fullFees.Equals(expected) {
+		t.Fatalf("expected minimum replacement fees %v, got %v", expected, fullFees)
+	}
+}
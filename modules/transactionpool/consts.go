@@ -54,6 +54,25 @@ const (
 	// amount required to extend the fee pool when coming up with a min fee
 	// recommendation.
 	minExtendMultiplier = 1.2
+
+	// confirmationEstimationDepth defines how far backwards in the blockchain
+	// the fee estimator looks when using the confirmation times of
+	// transaction sets to estimate the fee needed to confirm within a target
+	// number of blocks.
+	confirmationEstimationDepth = types.BlockHeight(144)
+
+	// confirmationSuccessRate is the fraction of the transaction sets in a
+	// fee bucket that must have been confirmed within the target number of
+	// blocks for the fee rate of the bucket to be recommended.
+	confirmationSuccessRate = 0.85
+
+	// maxConfirmationSamples is the maximum number of confirmed transaction
+	// sets that the fee estimator remembers.
+	maxConfirmationSamples = 2000
+
+	// minConfirmationSamples is the number of confirmed transaction sets that
+	// the fee estimator needs to see before it trusts its estimate.
+	minConfirmationSamples = 20
 )
 
 // Variables related to the persisting structures of the transaction pool.
@@ -69,6 +88,12 @@ var (
 	// minEstimation defines a sane minimum fee per byte for transactions.  This
 	// will typically be only suggested as a fee in the absence of congestion.
 	minEstimation = types.SiacoinPrecision.Div64(100).Div64(1e3)
+
+	// minEvictionFeeIncrement defines how much more fee per byte a
+	// transaction set needs to pay than a set in the pool in order to evict
+	// it. Without it, a set could be evicted by a set that pays a negligibly
+	// higher fee, letting peers churn the pool for almost no cost.
+	minEvictionFeeIncrement = types.SiacoinPrecision.Div64(1e5).Div64(1e3)
 )
 
 // Variables related to propagating transactions through the network.
@@ -74,8 +74,18 @@ type (
 	// medianPersist is the json object that gets stored in the database so that
 	// the transaction pool can persist its block based fee estimations.
 	medianPersist struct {
-		RecentMedians   []types.Currency
-		RecentMedianFee types.Currency
+		RecentMedians       []types.Currency
+		RecentMedianFee     types.Currency
+		RecentConfirmations []confirmationSample
+	}
+
+	// confirmationSample records that a transaction set paying FeeRate per
+	// byte was confirmed at Height, Blocks blocks after it entered the
+	// transaction pool.
+	confirmationSample struct {
+		Height  types.BlockHeight
+		FeeRate types.Currency
+		Blocks  types.BlockHeight
 	}
 )
 
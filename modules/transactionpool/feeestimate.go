@@ -0,0 +1,154 @@
+package transactionpool
+
+import (
+	"sort"
+
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// feeestimate.go estimates the fee needed for a transaction set to be
+// confirmed within a target number of blocks. Whenever a block confirms a set
+// from the pool, the pool records the fee per byte the set paid and how many
+// blocks it waited, using the heights in transactionHeights. The estimate for
+// a target is the lowest fee rate at which most of the recently confirmed
+// sets paying a similar rate were confirmed within the target. Sets that
+// leave the pool without being confirmed are not recorded.
+
+// poolSet is a transaction set of the pool that may get confirmed by the
+// blocks of a consensus change.
+type poolSet struct {
+	feeRate  types.Currency
+	recorded bool
+}
+
+// poolFeeRates returns the sets of the transaction pool by the ids of their
+// transactions.
+func (tp *TransactionPool) poolFeeRates() map[types.TransactionID]*poolSet {
+	sets := make(map[types.TransactionID]*poolSet)
+	for _, set := range tp.transactionSets {
+		ps := &poolSet{
+			feeRate: transactionSetFees(set).Div64(uint64(len(encoding.Marshal(set)))),
+		}
+		for _, txn := range set {
+			sets[txn.ID()] = ps
+		}
+	}
+	return sets
+}
+
+// recordConfirmations records the confirmation time of the pool sets that
+// got confirmed by block, which is at the current height of the pool. A set is
+// only recorded once, even if its transactions are spread over multiple
+// blocks.
+func (tp *TransactionPool) recordConfirmations(block types.Block, sets map[types.TransactionID]*poolSet) {
+	for _, txn := range block.Transactions {
+		ps, exists := sets[txn.ID()]
+		if !exists || ps.recorded {
+			continue
+		}
+		seenHeight, seen := tp.transactionHeights[txn.ID()]
+		if !seen || seenHeight >= tp.blockHeight {
+			continue
+		}
+		ps.recorded = true
+		tp.recentConfirmations = append(tp.recentConfirmations, confirmationSample{
+			Height:  tp.blockHeight,
+			FeeRate: ps.feeRate,
+			Blocks:  tp.blockHeight - seenHeight,
+		})
+	}
+
+	// Forget the samples that are too old, and the oldest samples if there
+	// are too many.
+	var i int
+	for i < len(tp.recentConfirmations) && tp.recentConfirmations[i].Height+confirmationEstimationDepth < tp.blockHeight {
+		i++
+	}
+	if len(tp.recentConfirmations)-i > maxConfirmationSamples {
+		i = len(tp.recentConfirmations) - maxConfirmationSamples
+	}
+	tp.recentConfirmations = tp.recentConfirmations[i:]
+}
+
+// revertConfirmations forgets the samples of blocks above the current height
+// of the pool, which have been reverted.
+func (tp *TransactionPool) revertConfirmations() {
+	i := len(tp.recentConfirmations)
+	for i > 0 && tp.recentConfirmations[i-1].Height > tp.blockHeight {
+		i--
+	}
+	tp.recentConfirmations = tp.recentConfirmations[:i]
+}
+
+// feeBucket returns the bucket of a fee rate. Each bucket covers a doubling
+// of the fee rate.
+func feeBucket(feeRate types.Currency) int {
+	return feeRate.Big().BitLen()
+}
+
+// confirmationFeeEstimate returns the lowest fee per byte at which at least
+// confirmationSuccessRate of the recently confirmed sets were confirmed within
+// targetBlocks blocks. The sets are grouped by fee bucket, from the highest
+// fee rate down, and adjacent buckets are merged until each group has enough
+// samples to be judged. false is returned if there are not enough samples to
+// make an estimate.
+func (tp *TransactionPool) confirmationFeeEstimate(targetBlocks types.BlockHeight) (types.Currency, bool) {
+	if len(tp.recentConfirmations) < minConfirmationSamples {
+		return types.ZeroCurrency, false
+	}
+	samples := make([]confirmationSample, len(tp.recentConfirmations))
+	copy(samples, tp.recentConfirmations)
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].FeeRate.Cmp(samples[j].FeeRate) > 0
+	})
+
+	var estimate types.Currency
+	var found bool
+	var total, confirmed int
+	for i, sample := range samples {
+		total++
+		if sample.Blocks <= targetBlocks {
+			confirmed++
+		}
+		// Judge the group once it is large enough and the next sample falls
+		// into a lower bucket.
+		lastInBucket := i == len(samples)-1 || feeBucket(samples[i+1].FeeRate) != feeBucket(sample.FeeRate)
+		if !lastInBucket || total < minConfirmationSamples {
+			continue
+		}
+		if float64(confirmed)/float64(total) < confirmationSuccessRate {
+			break
+		}
+		estimate, found = sample.FeeRate, true
+		total, confirmed = 0, 0
+	}
+	return estimate, found
+}
+
+// FeeEstimate returns the fee per byte that a transaction set should pay to be
+// confirmed within targetBlocks blocks. A target of 0 is treated as 1. The
+// estimate is never lower than the minimum returned by FeeEstimation. Until
+// enough transaction sets have been confirmed to estimate from, the maximum
+// returned by FeeEstimation is divided by the target instead.
+func (tp *TransactionPool) FeeEstimate(targetBlocks types.BlockHeight) types.Currency {
+	if err := tp.tg.Add(); err != nil {
+		return types.ZeroCurrency
+	}
+	defer tp.tg.Done()
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+
+	if targetBlocks == 0 {
+		targetBlocks = 1
+	}
+	min, max := tp.feeEstimation()
+	fee, ok := tp.confirmationFeeEstimate(targetBlocks)
+	if !ok {
+		fee = max.Div64(uint64(targetBlocks))
+	}
+	if fee.Cmp(min) < 0 {
+		fee = min
+	}
+	return fee
+}
@@ -0,0 +1,161 @@
+package transactionpool
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestConfirmationFeeEstimate checks that the fee estimate for a target is the
+// lowest fee rate at which most sets were confirmed within the target.
+func TestConfirmationFeeEstimate(t *testing.T) {
+	tp := new(TransactionPool)
+
+	// Without enough samples there is no estimate.
+	for i := 0; i < minConfirmationSamples-1; i++ {
+		tp.recentConfirmations = append(tp.recentConfirmations, confirmationSample{
+			FeeRate: types.NewCurrency64(1000),
+			Blocks:  1,
+		})
+	}
+	if _, ok := tp.confirmationFeeEstimate(1); ok {
+		t.Fatal("expected no estimate with too few samples")
+	}
+
+	// Sets paying 1000 or more per byte confirm in the next block, sets paying
+	// 100 within 3 blocks, and sets paying 10 take 10 blocks.
+	tp.recentConfirmations = nil
+	for i := 0; i < minConfirmationSamples; i++ {
+		tp.recentConfirmations = append(tp.recentConfirmations,
+			confirmationSample{FeeRate: types.NewCurrency64(10), Blocks: 10},
+			confirmationSample{FeeRate: types.NewCurrency64(100), Blocks: 3},
+			confirmationSample{FeeRate: types.NewCurrency64(1000), Blocks: 1},
+			confirmationSample{FeeRate: types.NewCurrency64(5000), Blocks: 1},
+		)
+	}
+	tests := []struct {
+		target types.BlockHeight
+		fee    uint64
+	}{
+		{1, 1000},
+		{2, 1000},
+		{3, 100},
+		{9, 100},
+		{10, 10},
+		{100, 10},
+	}
+	for _, test := range tests {
+		fee, ok := tp.confirmationFeeEstimate(test.target)
+		if !ok {
+			t.Fatal("no estimate for target", test.target)
+		}
+		if !fee.Equals64(test.fee) {
+			t.Errorf("expected estimate of %v for target %v, got %v", test.fee, test.target, fee)
+		}
+	}
+}
+
+// TestRecordConfirmations checks that the transaction pool records how long
+// its sets took to be confirmed, and uses the samples to estimate fees.
+func TestRecordConfirmations(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tpt.Close()
+
+	// Without any samples, the estimate falls back to the fee recommendations.
+	min, max := tpt.tpool.FeeEstimation()
+	if !tpt.tpool.FeeEstimate(0).Equals(max) || !tpt.tpool.FeeEstimate(1).Equals(max) {
+		t.Fatal("estimate for the next block should be the maximum recommendation")
+	}
+	if !tpt.tpool.FeeEstimate(100).Equals(min) {
+		t.Fatal("estimate for a distant target should be the minimum recommendation")
+	}
+
+	// Create two independent sets paying fees.
+	fund := types.SiacoinPrecision.Mul64(1000)
+	txns, err := tpt.wallet.SendSiacoinsMulti([]types.SiacoinOutput{
+		{UnlockHash: types.UnlockConditions{}.UnlockHash(), Value: fund},
+		{UnlockHash: types.UnlockConditions{}.UnlockHash(), Value: fund},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tpt.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var graphs [][]types.Transaction
+	for i := 0; i < 2; i++ {
+		fee := types.SiacoinPrecision.Mul64(uint64(i + 1))
+		graph, err := types.TransactionGraph(txns[len(txns)-1].SiacoinOutputID(uint64(i)), []types.TransactionGraphEdge{{
+			Dest:   1,
+			Fee:    fee,
+			Source: 0,
+			Value:  fund.Sub(fee),
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		graphs = append(graphs, graph)
+	}
+
+	// Confirm a set that waited for a block, and a set that got confirmed in
+	// the next block.
+	err = tpt.tpool.AcceptTransactionSet(graphs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpt.tpool.mu.Lock()
+	tpt.tpool.transactionHeights[graphs[0][0].ID()]--
+	tpt.tpool.mu.Unlock()
+	err = tpt.tpool.AcceptTransactionSet(graphs[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	height := tpt.cs.Height()
+	tpt.tpool.mu.Lock()
+	numSamples := len(tpt.tpool.recentConfirmations)
+	tpt.tpool.mu.Unlock()
+	_, err = tpt.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpt.tpool.mu.Lock()
+	samples := append([]confirmationSample(nil), tpt.tpool.recentConfirmations...)
+	tpt.tpool.mu.Unlock()
+	if len(samples) != numSamples+2 {
+		t.Fatalf("expected %v samples, got %v", numSamples+2, len(samples))
+	}
+	blocks := map[types.BlockHeight]bool{}
+	for _, sample := range samples[numSamples:] {
+		if sample.Height != height+1 {
+			t.Error("sample has wrong height:", sample.Height)
+		}
+		if sample.FeeRate.IsZero() {
+			t.Error("sample has no fee rate")
+		}
+		blocks[sample.Blocks] = true
+	}
+	if !blocks[1] || !blocks[2] {
+		t.Fatal("samples have wrong confirmation times:", samples)
+	}
+
+	// The samples should survive a restart of the transaction pool.
+	persistDir := tpt.tpool.persistDir
+	err = tpt.tpool.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpt.tpool, err = New(tpt.cs, tpt.gateway, persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tpt.tpool.recentConfirmations) != len(samples) {
+		t.Fatal("samples were not persisted")
+	}
+}
@@ -174,6 +174,7 @@ func (tp *TransactionPool) initPersist() error {
 	if err != errNilFeeMedian {
 		tp.recentMedians = mp.RecentMedians
 		tp.recentMedianFee = mp.RecentMedianFee
+		tp.recentConfirmations = mp.RecentConfirmations
 	}
 
 	// Subscribe to the consensus set using the most recent consensus change.
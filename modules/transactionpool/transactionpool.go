@@ -7,6 +7,7 @@ import (
 	"github.com/coreos/bbolt"
 
 	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/persist"
 	"github.com/NebulousLabs/Sia/sync"
@@ -52,11 +53,22 @@ type (
 		transactionSetDiffs map[TransactionSetID]*modules.ConsensusChange
 		transactionListSize int
 
+		// feeIndex holds the transaction sets of the pool sorted by fee per
+		// byte, with the highest paying set first. It is updated as sets are
+		// added to and removed from the pool, so that eviction and
+		// TransactionList do not need to sort the pool.
+		feeIndex []feeRateSet
+
 		// Variables related to the blockchain.
 		blockHeight     types.BlockHeight
 		recentMedians   []types.Currency
 		recentMedianFee types.Currency // SC per byte
 
+		// recentConfirmations records how many blocks it took for recently
+		// confirmed transaction sets to be confirmed, and the fee per byte
+		// they paid.
+		recentConfirmations []confirmationSample
+
 		// The consensus change index tracks how many consensus changes have
 		// been sent to the transaction pool. When a new subscriber joins the
 		// transaction pool, all prior consensus changes are sent to the new
@@ -71,6 +83,14 @@ type (
 		tg         sync.ThreadGroup
 		persistDir string
 	}
+
+	// feeRateSet is a transaction set of the pool together with its fees and
+	// size, used to order the sets by fee per byte.
+	feeRateSet struct {
+		id   TransactionSetID
+		fees types.Currency
+		size uint64
+	}
 )
 
 // New creates a transaction pool that is ready to receive transactions.
@@ -128,7 +148,12 @@ func (tp *TransactionPool) FeeEstimation() (min, max types.Currency) {
 	defer tp.tg.Done()
 	tp.mu.Lock()
 	defer tp.mu.Unlock()
+	return tp.feeEstimation()
+}
 
+// feeEstimation returns the minimum and maximum estimated fee per transaction
+// byte.
+func (tp *TransactionPool) feeEstimation() (min, max types.Currency) {
 	// Use three methods to determine an acceptable fee, and then take the
 	// largest result of the two methods. The first method checks the historic
 	// blocks, to make sure that we don't under-estimate the number of fees
@@ -171,18 +196,65 @@ func (tp *TransactionPool) FeeEstimation() (min, max types.Currency) {
 
 // TransactionList returns a list of all transactions in the transaction pool.
 // The transactions are provided in an order that can acceptably be put into a
-// block.
+// block, with the sets that pay the most fees per byte first.
 func (tp *TransactionPool) TransactionList() []types.Transaction {
 	tp.mu.Lock()
 	defer tp.mu.Unlock()
 
 	var txns []types.Transaction
-	for _, tSet := range tp.transactionSets {
-		txns = append(txns, tSet...)
+	for _, set := range tp.feeOrderedSets() {
+		txns = append(txns, tp.transactionSets[set.id]...)
 	}
 	return txns
 }
 
+// TransactionSets returns the transaction sets in the transaction pool, with
+// the sets that pay the most fees per byte first.
+func (tp *TransactionPool) TransactionSets() []modules.PoolTransactionSet {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+
+	sets := make([]modules.PoolTransactionSet, 0, len(tp.transactionSets))
+	for _, fs := range tp.feeOrderedSets() {
+		set := tp.transactionSets[fs.id]
+		pts := modules.PoolTransactionSet{
+			ID:           modules.TransactionSetID(fs.id),
+			Size:         fs.size,
+			Fees:         fs.fees,
+			FeePerByte:   fs.fees.Div64(fs.size),
+			Transactions: make([]modules.PoolTransaction, 0, len(set)),
+		}
+		parents := setDependencies(set)
+		children := make(map[types.TransactionID][]types.TransactionID)
+		for _, txn := range set {
+			for _, parent := range parents[txn.ID()] {
+				children[parent] = append(children[parent], txn.ID())
+			}
+		}
+		for _, txn := range set {
+			id := txn.ID()
+			var age types.BlockHeight
+			if seenHeight, seen := tp.transactionHeights[id]; seen && seenHeight < tp.blockHeight {
+				age = tp.blockHeight - seenHeight
+			}
+			if age > pts.Age {
+				pts.Age = age
+			}
+			pts.Transactions = append(pts.Transactions, modules.PoolTransaction{
+				ID:          id,
+				Size:        uint64(len(encoding.Marshal(txn))),
+				Fees:        transactionSetFees([]types.Transaction{txn}),
+				Age:         age,
+				Parents:     parents[id],
+				Children:    children[id],
+				Transaction: txn,
+			})
+		}
+		sets = append(sets, pts)
+	}
+	return sets
+}
+
 // Transaction returns the transaction with the provided txid, its parents, and
 // a bool indicating if it exists in the transaction pool.
 func (tp *TransactionPool) Transaction(id types.TransactionID) (types.Transaction, []types.Transaction, bool) {
@@ -6,6 +6,7 @@ import (
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/modules/consensus"
 	"github.com/NebulousLabs/Sia/modules/gateway"
@@ -210,6 +211,88 @@ func TestGetTransaction(t *testing.T) {
 	}
 }
 
+// TestTransactionSets checks that TransactionSets describes the sets in the
+// transaction pool.
+func TestTransactionSets(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tpt.Close()
+
+	// Create a graph where the second transaction spends an output of the
+	// first, and an arbitrary data transaction that pays no fees.
+	txns, err := tpt.wallet.SendSiacoinsMulti([]types.SiacoinOutput{{
+		UnlockHash: types.UnlockConditions{}.UnlockHash(),
+		Value:      types.SiacoinPrecision.Mul64(1000),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = tpt.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fee := types.SiacoinPrecision
+	graph, err := types.TransactionGraph(txns[len(txns)-1].SiacoinOutputID(0), []types.TransactionGraphEdge{
+		{Dest: 1, Fee: fee, Source: 0, Value: types.SiacoinPrecision.Mul64(999)},
+		{Dest: 2, Fee: fee, Source: 1, Value: types.SiacoinPrecision.Mul64(998)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tpt.tpool.AcceptTransactionSet(graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	arbData := append(modules.PrefixNonSia[:], fastrand.Bytes(16)...)
+	arbTxn := types.Transaction{ArbitraryData: [][]byte{arbData}}
+	err = tpt.tpool.AcceptTransactionSet([]types.Transaction{arbTxn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpt.tpool.mu.Lock()
+	tpt.tpool.transactionHeights[graph[0].ID()] -= 2
+	tpt.tpool.mu.Unlock()
+
+	sets := tpt.tpool.TransactionSets()
+	if len(sets) != 2 {
+		t.Fatal("expected 2 sets, got", len(sets))
+	}
+	set := sets[0]
+	if len(set.Transactions) != 2 || set.Transactions[0].ID != graph[0].ID() || set.Transactions[1].ID != graph[1].ID() {
+		t.Fatal("the graph should be listed first")
+	}
+	if set.ID != modules.TransactionSetID(crypto.HashObject(graph)) {
+		t.Error("wrong set id")
+	}
+	if set.Size != uint64(len(encoding.Marshal(graph))) {
+		t.Error("wrong set size:", set.Size)
+	}
+	if !set.Fees.Equals(fee.Mul64(2)) || !set.FeePerByte.Equals(set.Fees.Div64(set.Size)) {
+		t.Error("wrong set fees:", set.Fees, set.FeePerByte)
+	}
+	if set.Age != 2 || set.Transactions[0].Age != 2 || set.Transactions[1].Age != 0 {
+		t.Error("wrong ages:", set.Age, set.Transactions[0].Age, set.Transactions[1].Age)
+	}
+	parent, child := set.Transactions[0], set.Transactions[1]
+	if len(parent.Parents) != 0 || len(parent.Children) != 1 || parent.Children[0] != child.ID {
+		t.Error("wrong dependencies of the parent:", parent.Parents, parent.Children)
+	}
+	if len(child.Parents) != 1 || child.Parents[0] != parent.ID || len(child.Children) != 0 {
+		t.Error("wrong dependencies of the child:", child.Parents, child.Children)
+	}
+	if !parent.Fees.Equals(fee) || parent.Transaction.ID() != parent.ID {
+		t.Error("wrong transaction details")
+	}
+	if sets[1].Transactions[0].ID != arbTxn.ID() || !sets[1].FeePerByte.IsZero() {
+		t.Error("the arbitrary data transaction should be listed last")
+	}
+}
+
 // TestBlockFeeEstimation checks that the fee estimation algorithm is reasonably
 // on target when the tpool is relying on blockchain based fee estimation.
 func TestFeeEstimation(t *testing.T) {
@@ -13,16 +13,14 @@ import (
 // all of the separate transaction sets within it. Set does not check for
 // conflicts.
 //
-// The algorithm goes through one transaction at a time. All of the outputs of
-// that transaction are added to the objMap, pointing to the transaction to
-// indicate that the transaction contains those outputs. The transaction is
-// assigned an integer id (each transaction will have a unique id) and added to
-// the txMap.
+// The algorithm goes through one transaction at a time. The parents of each
+// transaction are found with transactionParents. The transaction is assigned
+// an integer id (each transaction will have a unique id) and added to the
+// txMap.
 //
-// The transaction's inputs are then checked against the objMap to see if there
-// are any parents of the transaction in the graph. If there are, the
-// transaction is added to the parent set instead of its own set. If not, the
-// transaction is added as its own set.
+// If the transaction has any parents in the graph, the transaction is added to
+// the parent set instead of its own set. If not, the transaction is added as
+// its own set.
 //
 // The forwards map contains a list of ints indicating when a transaction has
 // been merged with a set. When a transaction gets merged with a parent set, its
@@ -50,8 +48,8 @@ func findSets(ts []types.Transaction) [][]types.Transaction {
 	// will help to discover which sets have been combined.
 	txMap := make(map[types.TransactionID]int)
 	setMap := make(map[int][]types.Transaction)
-	objMap := make(map[ObjectID]types.TransactionID)
 	forwards := make(map[int]int)
+	parents := transactionParents(ts)
 
 	// Define a function to follow and collapse any update chain.
 	forward := func(prev int) (ret int) {
@@ -71,33 +69,8 @@ func findSets(ts []types.Transaction) [][]types.Transaction {
 		// Check if the inputs depend on any previous transaction outputs.
 		tid := t.ID()
 		parentSets := make(map[int]struct{})
-		for _, obj := range t.SiacoinInputs {
-			txid, exists := objMap[ObjectID(obj.ParentID)]
-			if exists {
-				parentSet := forward(txMap[txid])
-				parentSets[parentSet] = struct{}{}
-			}
-		}
-		for _, obj := range t.FileContractRevisions {
-			txid, exists := objMap[ObjectID(obj.ParentID)]
-			if exists {
-				parentSet := forward(txMap[txid])
-				parentSets[parentSet] = struct{}{}
-			}
-		}
-		for _, obj := range t.StorageProofs {
-			txid, exists := objMap[ObjectID(obj.ParentID)]
-			if exists {
-				parentSet := forward(txMap[txid])
-				parentSets[parentSet] = struct{}{}
-			}
-		}
-		for _, obj := range t.SiafundInputs {
-			txid, exists := objMap[ObjectID(obj.ParentID)]
-			if exists {
-				parentSet := forward(txMap[txid])
-				parentSets[parentSet] = struct{}{}
-			}
+		for _, parent := range parents[i] {
+			parentSets[forward(txMap[parent])] = struct{}{}
 		}
 
 		// Determine the new counter for this transaction.
@@ -105,9 +78,6 @@ func findSets(ts []types.Transaction) [][]types.Transaction {
 			// No parent sets. Make a new set for this transaction.
 			txMap[tid] = i
 			setMap[i] = []types.Transaction{t}
-			// Don't need to add anything for the file contract outputs, storage
-			// proof outputs, siafund claim outputs; these outputs are not
-			// allowed to be spent until 50 confirmations.
 		} else {
 			// There are parent sets, pick one as the base and then merge the
 			// rest into it.
@@ -130,25 +100,6 @@ func findSets(ts []types.Transaction) [][]types.Transaction {
 			// Add this transaction to the base set.
 			setMap[base] = append(setMap[base], t)
 		}
-
-		// Mark this transaction's outputs as potential inputs to future
-		// transactions.
-		for j := range t.SiacoinOutputs {
-			scoid := t.SiacoinOutputID(uint64(j))
-			objMap[ObjectID(scoid)] = tid
-		}
-		for j := range t.FileContracts {
-			fcid := t.FileContractID(uint64(j))
-			objMap[ObjectID(fcid)] = tid
-		}
-		for j := range t.FileContractRevisions {
-			fcid := t.FileContractRevisions[j].ParentID
-			objMap[ObjectID(fcid)] = tid
-		}
-		for j := range t.SiafundOutputs {
-			sfoid := t.SiafundOutputID(uint64(j))
-			objMap[ObjectID(sfoid)] = tid
-		}
 	}
 
 	// Compile the final group of sets.
@@ -159,12 +110,78 @@ func findSets(ts []types.Transaction) [][]types.Transaction {
 	return ret
 }
 
+// transactionParents returns the parents of each transaction in a transaction
+// set, indexed by the position of the transaction in the set. A transaction is
+// a parent of another if it creates an object that the other spends or
+// revises. The parents of a transaction are listed in set order.
+//
+// An objMap points each object created or revised by the transactions seen so
+// far to the transaction that created it, so a transaction can only have
+// parents that come before it in the set.
+func transactionParents(ts []types.Transaction) [][]types.TransactionID {
+	parents := make([][]types.TransactionID, len(ts))
+	objMap := make(map[ObjectID]types.TransactionID)
+	for i, t := range ts {
+		tid := t.ID()
+		seen := make(map[types.TransactionID]struct{})
+		addParent := func(oid ObjectID) {
+			parent, exists := objMap[oid]
+			if !exists || parent == tid {
+				return
+			}
+			if _, exists := seen[parent]; exists {
+				return
+			}
+			seen[parent] = struct{}{}
+			parents[i] = append(parents[i], parent)
+		}
+		for _, obj := range t.SiacoinInputs {
+			addParent(ObjectID(obj.ParentID))
+		}
+		for _, obj := range t.FileContractRevisions {
+			addParent(ObjectID(obj.ParentID))
+		}
+		for _, obj := range t.StorageProofs {
+			addParent(ObjectID(obj.ParentID))
+		}
+		for _, obj := range t.SiafundInputs {
+			addParent(ObjectID(obj.ParentID))
+		}
+
+		// Mark this transaction's outputs as potential inputs to future
+		// transactions. Don't need to add anything for the file contract
+		// outputs, storage proof outputs, siafund claim outputs; these outputs
+		// are not allowed to be spent until 50 confirmations.
+		for _, oid := range createdObjectIDs(t) {
+			objMap[oid] = tid
+		}
+		for _, fcr := range t.FileContractRevisions {
+			objMap[ObjectID(fcr.ParentID)] = tid
+		}
+	}
+	return parents
+}
+
+// setDependencies returns the parents of each transaction in a transaction
+// set, following the same relationships that findSets uses to group
+// transactions. Transactions without parents are omitted.
+func setDependencies(ts []types.Transaction) map[types.TransactionID][]types.TransactionID {
+	deps := make(map[types.TransactionID][]types.TransactionID)
+	for i, parents := range transactionParents(ts) {
+		if len(parents) > 0 {
+			deps[ts[i].ID()] = parents
+		}
+	}
+	return deps
+}
+
 // purge removes all transactions from the transaction pool.
 func (tp *TransactionPool) purge() {
 	tp.knownObjects = make(map[ObjectID]TransactionSetID)
 	tp.transactionSets = make(map[TransactionSetID][]types.Transaction)
 	tp.transactionSetDiffs = make(map[TransactionSetID]*modules.ConsensusChange)
 	tp.transactionListSize = 0
+	tp.feeIndex = nil
 }
 
 // ProcessConsensusChange gets called to inform the transaction pool of changes
@@ -215,7 +232,9 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 			// Strip out all of the transactions in this block.
 			tp.recentMedians = tp.recentMedians[:len(tp.recentMedians)-1]
 		}
+		tp.revertConfirmations()
 	}
+	setFeeRates := tp.poolFeeRates()
 	for _, block := range cc.AppliedBlocks {
 		// Sanity check - the parent id of each block should match the current
 		// block id.
@@ -233,6 +252,7 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 				tp.log.Println("ERROR: could not add a transaction:", err)
 			}
 		}
+		tp.recordConfirmations(block, setFeeRates)
 
 		// Find the median transaction fee for this block.
 		type feeSummary struct {
@@ -313,8 +333,9 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 		tp.log.Println("ERROR: could not update the block height:", err)
 	}
 	err = tp.putFeeMedian(tp.dbTx, medianPersist{
-		RecentMedians:   tp.recentMedians,
-		RecentMedianFee: tp.recentMedianFee,
+		RecentMedians:       tp.recentMedians,
+		RecentMedianFee:     tp.recentMedianFee,
+		RecentConfirmations: tp.recentConfirmations,
 	})
 	if err != nil {
 		tp.log.Println("ERROR: could not update the transaction pool median fee information:", err)
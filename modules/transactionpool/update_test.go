@@ -130,6 +130,43 @@ func TestFindSets(t *testing.T) {
 	}
 }
 
+// TestSetDependencies checks that setDependencies finds the parents of the
+// transactions in a set.
+func TestSetDependencies(t *testing.T) {
+	// t1 creates two outputs, t2 spends the first, t3 spends the second as
+	// well as an output of t2, and t4 is independent.
+	t1 := types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{{Value: types.NewCurrency64(1)}, {Value: types.NewCurrency64(2)}},
+	}
+	t2 := types.Transaction{
+		SiacoinInputs:  []types.SiacoinInput{{ParentID: t1.SiacoinOutputID(0)}},
+		SiacoinOutputs: []types.SiacoinOutput{{Value: types.NewCurrency64(1)}},
+	}
+	t3 := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{
+			{ParentID: t1.SiacoinOutputID(1)},
+			{ParentID: t2.SiacoinOutputID(0)},
+		},
+	}
+	t4 := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{ParentID: types.SiacoinOutputID{1}}},
+	}
+
+	parents := setDependencies([]types.Transaction{t1, t2, t3, t4})
+	if len(parents) != 2 {
+		t.Fatal("expected 2 transactions with parents, got", len(parents))
+	}
+	if p := parents[t2.ID()]; len(p) != 1 || p[0] != t1.ID() {
+		t.Error("wrong parents for t2:", p)
+	}
+	if p := parents[t3.ID()]; len(p) != 2 || p[0] != t1.ID() || p[1] != t2.ID() {
+		t.Error("wrong parents for t3:", p)
+	}
+	if _, exists := parents[t4.ID()]; exists {
+		t.Error("t4 should not have parents")
+	}
+}
+
 // TestArbDataOnly tries submitting a transaction with only arbitrary data to
 // the transaction pool. Then a block is mined, putting the transaction on the
 // blockchain. The arb data transaction should no longer be in the transaction
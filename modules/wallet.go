@@ -23,7 +23,31 @@ const (
 	WalletDir = "wallet"
 )
 
+const (
+	// PurposeDefault is the derivation namespace of the addresses returned by
+	// NextAddress. It is the linear key sequence used by every wallet before
+	// namespaces were introduced.
+	PurposeDefault AddressPurpose = ""
+
+	// PurposeRenter is the derivation namespace of the addresses used by the
+	// renter, for example as the refund address of file contracts.
+	PurposeRenter AddressPurpose = "renter"
+
+	// PurposeHost is the derivation namespace of the addresses used by the
+	// host to receive contract payouts.
+	PurposeHost AddressPurpose = "host"
+
+	// PurposeMiner is the derivation namespace of the addresses used by the
+	// miner to receive block rewards.
+	PurposeMiner AddressPurpose = "miner"
+)
+
 var (
+	// AddressPurposes lists the derivation namespaces other than
+	// PurposeDefault. Each has its own index space derived from the wallet's
+	// seeds.
+	AddressPurposes = []AddressPurpose{PurposeRenter, PurposeHost, PurposeMiner}
+
 	// ErrBadEncryptionKey is returned if the incorrect encryption key to a
 	// file is provided.
 	ErrBadEncryptionKey = errors.New("provided encryption key is incorrect")
@@ -44,6 +68,11 @@ var (
 )
 
 type (
+	// AddressPurpose names a derivation namespace of a wallet seed. Keys in
+	// different namespaces are derived independently, so addresses handed out
+	// for one purpose do not advance the index of another.
+	AddressPurpose string
+
 	// Seed is cryptographic entropy that is used to derive spendable wallet
 	// addresses.
 	Seed [crypto.EntropySize]byte
@@ -100,6 +129,75 @@ type (
 		Outputs []ProcessedOutput `json:"outputs"`
 	}
 
+	// An AddressLabel is a label that the user has assigned to an address.
+	// The address does not need to belong to the wallet.
+	AddressLabel struct {
+		Address types.UnlockHash `json:"address"`
+		Label   string           `json:"label"`
+	}
+
+	// A TransactionLabel is a label that the user has assigned to a
+	// transaction in the wallet's history.
+	TransactionLabel struct {
+		TransactionID types.TransactionID `json:"transactionid"`
+		Label         string              `json:"label"`
+	}
+
+	// An UnspentOutput is a confirmed output that is owned or watched by the
+	// wallet. The FundType is either 'SiacoinOutput' or 'SiafundOutput'.
+	// WatchOnly indicates that the output belongs to a watch-only address and
+	// cannot be spent by the wallet itself. ConfirmationHeight is zero if the
+	// transaction that created the output is not in the wallet's history.
+	//
+	// Dust outputs are too small to be worth spending, Pending outputs are
+	// spent by a transaction that has not been confirmed yet, and Frozen
+	// outputs have been excluded from spending by the user. Spendable is true
+	// if the wallet would use the output to fund a transaction.
+	UnspentOutput struct {
+		ID                 types.OutputID    `json:"id"`
+		FundType           types.Specifier   `json:"fundtype"`
+		UnlockHash         types.UnlockHash  `json:"unlockhash"`
+		Value              types.Currency    `json:"value"`
+		WatchOnly          bool              `json:"watchonly"`
+		ConfirmationHeight types.BlockHeight `json:"confirmationheight"`
+		Dust               bool              `json:"dust"`
+		Pending            bool              `json:"pending"`
+		Frozen             bool              `json:"frozen"`
+		Spendable          bool              `json:"spendable"`
+	}
+
+	// SendOptions give the caller control over how a send is funded and
+	// priced. If Inputs is empty, inputs are selected automatically. Only one
+	// of Fee and FeePerByte may be set; if neither is, the fee is derived from
+	// the transaction pool's fee estimate. If ChangeAddress is empty, change
+	// is sent to a new wallet address. If DryRun is set, the signed
+	// transaction is returned without being broadcast. SendPassword is
+	// checked against the wallet's send password, if one is set.
+	SendOptions struct {
+		Fee           types.Currency
+		FeePerByte    types.Currency
+		Inputs        []types.SiacoinOutputID
+		ChangeAddress types.UnlockHash
+		DryRun        bool
+		SendPassword  string
+	}
+
+	// SpendingPolicy restricts the siacoins that the wallet will send. A zero
+	// TransactionLimit or DailyLimit means there is no such limit, and an
+	// empty Allowlist allows sends to any address. The limits apply to the
+	// coins sent to addresses outside of the wallet plus the miner fee; the
+	// daily limit covers the last 24 hours.
+	SpendingPolicy struct {
+		TransactionLimit types.Currency     `json:"transactionlimit"`
+		DailyLimit       types.Currency     `json:"dailylimit"`
+		Allowlist        []types.UnlockHash `json:"allowlist"`
+	}
+
+	// SpendingPolicyViolation implements the error interface, and indicates
+	// that a send was refused because it violates the wallet's spending
+	// policy.
+	SpendingPolicyViolation string
+
 	// TransactionBuilder is used to construct custom transactions. A transaction
 	// builder is initialized via 'RegisterTransaction' and then can be modified by
 	// adding funds or other fields. The transaction is completed by calling
@@ -284,10 +382,18 @@ type (
 		// filepath. The backup will have all seeds and keys.
 		CreateBackup(string) error
 
-		// LoadBackup will load a backup of the wallet from the provided
-		// address. The backup wallet will be added as an auxiliary seed, not
-		// as a primary seed.
-		// LoadBackup(masterKey, backupMasterKey crypto.TwofishKey, string) error
+		// ExportBackup will create a portable backup of the wallet at the
+		// provided filepath, encrypted with a key derived from the provided
+		// password. The backup contains the wallet's seeds and keys as well
+		// as its watch-only and multisig addresses, and can be loaded into a
+		// fresh node.
+		ExportBackup(backupPassword string, backupFilepath string) error
+
+		// LoadBackup will load a portable backup of the wallet from the
+		// provided filepath. If the wallet has not been initialized, it is
+		// initialized from the backup and encrypted with masterKey. Otherwise
+		// the seeds of the backup are added as auxiliary seeds.
+		LoadBackup(masterKey crypto.TwofishKey, backupPassword string, backupFilepath string) error
 
 		// Load033xWallet will load a version 0.3.3.x wallet from disk and add all of
 		// the keys in the wallet as unseeded keys.
@@ -312,6 +418,10 @@ type (
 		// seed.
 		NextAddresses(uint64) ([]types.UnlockConditions, error)
 
+		// NextAddressForPurpose returns a new coin address generated from the
+		// primary seed in the derivation namespace of the given purpose.
+		NextAddressForPurpose(AddressPurpose) (types.UnlockConditions, error)
+
 		// PrimarySeed returns the unencrypted primary seed of the wallet,
 		// along with a uint64 indicating how many addresses may be safely
 		// generated from the seed.
@@ -347,6 +457,66 @@ type (
 		// not considered in the unconfirmed balance.
 		UnconfirmedBalance() (outgoingSiacoins types.Currency, incomingSiacoins types.Currency)
 
+		// WatchOnlyBalance returns the confirmed balance of the wallet's
+		// watch-only addresses. These funds cannot be spent by the wallet.
+		WatchOnlyBalance() (siacoinBalance types.Currency, siafundBalance types.Currency)
+
+		// AddWatchAddresses instructs the wallet to track the outputs and
+		// transactions of the given addresses without being able to spend
+		// from them. If unused is true, the addresses are assumed to have
+		// never appeared in the blockchain and no rescan is performed.
+		AddWatchAddresses(addrs []types.UnlockHash, unused bool) error
+
+		// AddWatchUnlockConditions watches the addresses of the given
+		// UnlockConditions, like AddWatchAddresses. The UnlockConditions are
+		// kept so that the wallet can build unsigned transactions spending
+		// from the addresses.
+		AddWatchUnlockConditions(ucs []types.UnlockConditions, unused bool) error
+
+		// RemoveWatchAddresses instructs the wallet to stop tracking the
+		// given addresses. If unused is true, no rescan is performed.
+		RemoveWatchAddresses(addrs []types.UnlockHash, unused bool) error
+
+		// WatchAddresses returns the set of addresses that the wallet is
+		// watching.
+		WatchAddresses() ([]types.UnlockHash, error)
+
+		// AddMultisigAddress creates an M-of-N multisig address from a set
+		// of public keys. The wallet tracks the outputs of the address and
+		// adds its own signatures when signing transactions that spend from
+		// it. If unused is true, no rescan is performed.
+		AddMultisigAddress(pubkeys []types.SiaPublicKey, required uint64, unused bool) (types.UnlockConditions, error)
+
+		// MultisigAddresses returns the UnlockConditions of the multisig
+		// addresses tracked by the wallet.
+		MultisigAddresses() ([]types.UnlockConditions, error)
+
+		// UnspentOutputs returns the confirmed outputs that are owned or
+		// watched by the wallet.
+		UnspentOutputs() ([]UnspentOutput, error)
+
+		// UnlockConditions returns the UnlockConditions of a wallet or
+		// multisig address, or of a watched address that was added with its
+		// UnlockConditions.
+		UnlockConditions(addr types.UnlockHash) (types.UnlockConditions, error)
+
+		// FundWatchOnlyTransaction builds an unsigned transaction containing
+		// outputs, funded from the watch-only addresses whose
+		// UnlockConditions are known. If fee is zero, it is estimated. Change
+		// is sent to changeAddr, or back to the first input's address if
+		// changeAddr is empty. The transaction is meant to be signed offline.
+		FundWatchOnlyTransaction(outputs []types.SiacoinOutput, fee types.Currency, changeAddr types.UnlockHash) (types.Transaction, error)
+
+		// SignTransaction signs the inputs of txn identified by toSign using
+		// the wallet's keys. If toSign is empty, every input is signed. Any
+		// unsigned TransactionSignatures already present for an input are
+		// filled in; otherwise signatures covering the whole transaction are
+		// added. Multisig inputs are only partially signed if the wallet
+		// holds fewer keys than are required. An error is returned if an
+		// input can not be signed. Signing is subject to the spending
+		// policy.
+		SignTransaction(txn *types.Transaction, toSign []crypto.Hash, sendPassword string) error
+
 		// Height returns the wallet's internal processed consensus height
 		Height() types.BlockHeight
 
@@ -372,6 +542,22 @@ type (
 		// relative to the wallet.
 		UnconfirmedTransactions() []ProcessedTransaction
 
+		// SetAddressLabel assigns a label to an address. An empty label
+		// removes the address's label.
+		SetAddressLabel(addr types.UnlockHash, label string) error
+
+		// SetTransactionLabel assigns a label to a transaction in the
+		// wallet's history. An empty label removes the transaction's label.
+		SetTransactionLabel(txid types.TransactionID, label string) error
+
+		// AddressLabels returns the labels that have been assigned to
+		// addresses.
+		AddressLabels() ([]AddressLabel, error)
+
+		// TransactionLabels returns the labels that have been assigned to
+		// transactions.
+		TransactionLabels() ([]TransactionLabel, error)
+
 		// RegisterTransaction takes a transaction and its parents and returns
 		// a TransactionBuilder which can be used to expand the transaction.
 		RegisterTransaction(t types.Transaction, parents []types.Transaction) TransactionBuilder
@@ -399,11 +585,55 @@ type (
 		// SendSiacoinsMulti sends coins to multiple addresses.
 		SendSiacoinsMulti(outputs []types.SiacoinOutput) ([]types.Transaction, error)
 
+		// SendSiacoinsWithOptions sends coins to multiple addresses, using
+		// opts to control input selection, fees and change. The fee paid is
+		// returned along with the transactions.
+		SendSiacoinsWithOptions(outputs []types.SiacoinOutput, opts SendOptions) ([]types.Transaction, types.Currency, error)
+
+		// SpendingPolicy returns the wallet's spending policy, the amount
+		// sent in the last 24 hours that counts toward its daily limit, and
+		// whether sends require the send password.
+		SpendingPolicy() (policy SpendingPolicy, dailySpent types.Currency, sendPassword bool, err error)
+
+		// SetSpendingPolicy replaces the wallet's spending policy. If a send
+		// password is set, sendPassword must match it.
+		SetSpendingPolicy(policy SpendingPolicy, sendPassword string) error
+
+		// CheckSendPassword returns an error if sendPassword does not match
+		// the send password. It always succeeds if no send password is set.
+		CheckSendPassword(sendPassword string) error
+
+		// SetSendPassword changes the password required to send siacoins
+		// from sendPassword to newPassword. An empty newPassword removes the
+		// requirement.
+		SetSendPassword(sendPassword, newPassword string) error
+
+		// BumpFee raises the miner fee paid for an unconfirmed transaction
+		// funded by the wallet to newFee, either by spending one of its
+		// outputs in a child transaction or by replacing it with a
+		// transaction that spends the same inputs. The transaction set
+		// submitted to the transaction pool is returned. The fee increase
+		// is subject to the spending policy.
+		BumpFee(txid types.TransactionID, newFee types.Currency, sendPassword string) ([]types.Transaction, error)
+
+		// FreezeOutputs prevents the wallet from spending the given outputs
+		// when funding transactions or defragging.
+		FreezeOutputs(ids []types.OutputID) error
+
+		// UnfreezeOutputs allows the wallet to spend the given outputs again.
+		UnfreezeOutputs(ids []types.OutputID) error
+
+		// ConsolidateOutputs merges up to maxInputs of the wallet's smallest
+		// spendable siacoin outputs into a single output. If dryRun is set,
+		// the transaction is returned without being broadcast.
+		ConsolidateOutputs(maxInputs int, dryRun bool) ([]types.Transaction, types.Currency, error)
+
 		// SendSiafunds is a tool for sending siafunds from the wallet to an
 		// address. Sending money usually results in multiple transactions. The
 		// transactions are automatically given to the transaction pool, and
-		// are also returned to the caller.
-		SendSiafunds(amount types.Currency, dest types.UnlockHash) ([]types.Transaction, error)
+		// are also returned to the caller. The send is subject to the
+		// spending policy.
+		SendSiafunds(amount types.Currency, dest types.UnlockHash, sendPassword string) ([]types.Transaction, error)
 
 		// DustThreshold returns the quantity per byte below which a Currency is
 		// considered to be Dust.
@@ -416,6 +646,17 @@ type (
 	}
 )
 
+// NewSpendingPolicyViolation returns a spending policy violation, which
+// implements the error interface.
+func NewSpendingPolicyViolation(s string) SpendingPolicyViolation {
+	return SpendingPolicyViolation("spending policy violation: " + s)
+}
+
+// Error implements the error interface.
+func (spv SpendingPolicyViolation) Error() string {
+	return string(spv)
+}
+
 // CalculateWalletTransactionID is a helper function for determining the id of
 // a wallet transaction.
 func CalculateWalletTransactionID(tid types.TransactionID, oid types.OutputID) WalletTransactionID {
@@ -0,0 +1,461 @@
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// backupFileHeader is the header of portable wallet backup files.
+	backupFileHeader = "Sia Wallet Backup"
+
+	// backupFileVersion is the version of portable wallet backup files.
+	// Version 1.1 derives the backup key with scrypt; version 1.0 backups
+	// are no longer accepted.
+	backupFileVersion = "1.1"
+
+	// backupKDFR and backupKDFP are the scrypt block size and
+	// parallelization parameters used to derive backup keys.
+	backupKDFR = 8
+	backupKDFP = 1
+
+	// maxBackupKDFN is the largest scrypt cost parameter accepted when
+	// reading a backup, so that a crafted backup cannot make the node
+	// allocate an unbounded amount of memory.
+	maxBackupKDFN = 1 << 20
+)
+
+var (
+	// backupKDFN is the scrypt cost parameter used to derive backup keys.
+	backupKDFN = build.Select(build.Var{
+		Standard: uint64(1 << 16),
+		Dev:      uint64(1 << 14),
+		Testing:  uint64(1 << 10),
+	}).(uint64)
+
+	// errBadBackupKDF is returned when the key derivation parameters of a
+	// backup are invalid.
+	errBadBackupKDF = errors.New("backup has invalid key derivation parameters")
+)
+
+type (
+	// backupKDF holds the salt and the scrypt parameters used to derive the
+	// key of a portable backup from the backup password.
+	backupKDF struct {
+		Salt [32]byte
+		N    uint64
+		R    uint64
+		P    uint64
+	}
+
+	// walletBackupFile is the structure of a portable wallet backup on disk.
+	// The backup itself is encrypted with a key derived from the backup
+	// password with KDF.
+	walletBackupFile struct {
+		Header  string
+		Version string
+		KDF     backupKDF
+		Backup  crypto.Ciphertext
+	}
+
+	// walletBackup contains everything needed to recreate a wallet on another
+	// node. It is JSON-encoded before being encrypted so that fields can be
+	// added without breaking older backups.
+	walletBackup struct {
+		PrimarySeed         modules.Seed                      `json:"primaryseed"`
+		PrimarySeedProgress uint64                            `json:"primaryseedprogress"`
+		PurposeSeedProgress map[modules.AddressPurpose]uint64 `json:"purposeseedprogress,omitempty"`
+		AuxiliarySeeds      []modules.Seed                    `json:"auxiliaryseeds"`
+		UnseededKeys        []spendableKey                    `json:"unseededkeys"`
+		WatchedAddresses    []types.UnlockHash                `json:"watchedaddresses"`
+		MultisigConditions  []types.UnlockConditions          `json:"multisigconditions"`
+		WatchedConditions   []types.UnlockConditions          `json:"watchedconditions,omitempty"`
+		FrozenOutputs       []types.OutputID                  `json:"frozenoutputs"`
+		AddressLabels       []modules.AddressLabel            `json:"addresslabels"`
+		TransactionLabels   []modules.TransactionLabel        `json:"transactionlabels"`
+	}
+)
+
+// unseededKeys returns the keys that were not derived from the seeds of
+// backup, such as siag keys and keys loaded from v0.3.3.x wallets. Deriving
+// the seeded keys is expensive, so it should not be called under w.mu.
+func unseededKeys(backup walletBackup, keys []spendableKey) []spendableKey {
+	seeded := make(map[types.UnlockHash]struct{})
+	for _, seed := range append([]modules.Seed{backup.PrimarySeed}, backup.AuxiliarySeeds...) {
+		n := uint64(modules.PublicKeysPerSeed)
+		if seed == backup.PrimarySeed {
+			n = backup.PrimarySeedProgress
+		}
+		for _, sk := range generateKeys(seed, 0, n) {
+			seeded[sk.UnlockConditions.UnlockHash()] = struct{}{}
+		}
+		for _, purpose := range modules.AddressPurposes {
+			n := uint64(modules.PublicKeysPerSeed)
+			if seed == backup.PrimarySeed {
+				n = backup.PurposeSeedProgress[purpose]
+			}
+			for _, sk := range generatePurposeKeys(seed, purpose, 0, n) {
+				seeded[sk.UnlockConditions.UnlockHash()] = struct{}{}
+			}
+		}
+	}
+	var unseeded []spendableKey
+	for _, sk := range keys {
+		if _, ok := seeded[sk.UnlockConditions.UnlockHash()]; !ok {
+			unseeded = append(unseeded, sk)
+		}
+	}
+	return unseeded
+}
+
+// key derives the encryption key of a portable backup from the backup
+// password.
+func (kdf backupKDF) key(password string) (crypto.TwofishKey, error) {
+	if kdf.N < 2 || kdf.N&(kdf.N-1) != 0 || kdf.N > maxBackupKDFN || kdf.R != backupKDFR || kdf.P != backupKDFP {
+		return crypto.TwofishKey{}, errBadBackupKDF
+	}
+	var key crypto.TwofishKey
+	k, err := scrypt.Key([]byte(password), kdf.Salt[:], int(kdf.N), int(kdf.R), int(kdf.P), len(key))
+	if err != nil {
+		return crypto.TwofishKey{}, err
+	}
+	copy(key[:], k)
+	return key, nil
+}
+
+// managedBackupContents collects the contents of a portable backup of the
+// wallet along with all of the wallet's keys.
+func (w *Wallet) managedBackupContents() (walletBackup, []spendableKey, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return walletBackup{}, nil, modules.ErrLockedWallet
+	}
+	progress, err := dbGetPrimarySeedProgress(w.dbTx)
+	if err != nil {
+		return walletBackup{}, nil, err
+	}
+	purposeProgress := make(map[modules.AddressPurpose]uint64)
+	for _, purpose := range modules.AddressPurposes {
+		if purposeProgress[purpose], err = dbGetPurposeSeedProgress(w.dbTx, purpose); err != nil {
+			return walletBackup{}, nil, err
+		}
+	}
+	backup := walletBackup{
+		PrimarySeed:         w.primarySeed,
+		PrimarySeedProgress: progress,
+		PurposeSeedProgress: purposeProgress,
+		AuxiliarySeeds:      append([]modules.Seed(nil), w.seeds...),
+		WatchedAddresses:    w.watchedAddrList(),
+		MultisigConditions:  w.multisigCondsList(),
+		WatchedConditions:   w.watchedCondsList(),
+		FrozenOutputs:       w.frozenOutputList(),
+	}
+	// multisig addresses and watched addresses with known UnlockConditions
+	// are restored from their UnlockConditions
+	var watched []types.UnlockHash
+	for _, addr := range backup.WatchedAddresses {
+		if _, ok := w.watchedUnlockConditions(addr); !ok {
+			watched = append(watched, addr)
+		}
+	}
+	backup.WatchedAddresses = watched
+	err = dbForEachAddressLabel(w.dbTx, func(addr types.UnlockHash, label string) {
+		backup.AddressLabels = append(backup.AddressLabels, modules.AddressLabel{Address: addr, Label: label})
+	})
+	if err != nil {
+		return walletBackup{}, nil, err
+	}
+	err = dbForEachTransactionLabel(w.dbTx, func(txid types.TransactionID, label string) {
+		backup.TransactionLabels = append(backup.TransactionLabels, modules.TransactionLabel{TransactionID: txid, Label: label})
+	})
+	if err != nil {
+		return walletBackup{}, nil, err
+	}
+	keys := make([]spendableKey, 0, len(w.keys))
+	for _, sk := range w.keys {
+		keys = append(keys, sk)
+	}
+	return backup, keys, nil
+}
+
+// ExportBackup writes a portable backup of the wallet to backupFilepath,
+// encrypted with a key derived from backupPassword with a salted scrypt.
+// Unlike CreateBackup, which copies the wallet
+// database, the backup contains only the seeds, the primary seed progress of
+// each derivation namespace, unseeded keys, watch-only and multisig addresses,
+// frozen outputs and labels, and can be restored into a fresh node with
+// LoadBackup.
+func (w *Wallet) ExportBackup(backupPassword string, backupFilepath string) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+
+	backup, keys, err := w.managedBackupContents()
+	if err != nil {
+		return err
+	}
+	backup.UnseededKeys = unseededKeys(backup, keys)
+
+	plaintext, err := json.Marshal(backup)
+	if err != nil {
+		return err
+	}
+	kdf := backupKDF{N: backupKDFN, R: backupKDFR, P: backupKDFP}
+	fastrand.Read(kdf.Salt[:])
+	backupKey, err := kdf.key(backupPassword)
+	if err != nil {
+		return err
+	}
+	return encoding.WriteFile(backupFilepath, walletBackupFile{
+		Header:  backupFileHeader,
+		Version: backupFileVersion,
+		KDF:     kdf,
+		Backup:  backupKey.EncryptBytes(plaintext),
+	})
+}
+
+// readBackup reads and decrypts a portable wallet backup.
+func readBackup(backupPassword string, backupFilepath string) (walletBackup, error) {
+	f, err := os.Open(backupFilepath)
+	if err != nil {
+		return walletBackup{}, err
+	}
+	defer f.Close()
+
+	// Check the header and version before decoding the rest of the file, as
+	// the layout of the file depends on its version.
+	var bf walletBackupFile
+	dec := encoding.NewDecoder(f)
+	if err := dec.DecodeAll(&bf.Header, &bf.Version); err != nil {
+		return walletBackup{}, err
+	}
+	if bf.Header != backupFileHeader {
+		return walletBackup{}, ErrUnknownHeader
+	} else if bf.Version != backupFileVersion {
+		return walletBackup{}, ErrUnknownVersion
+	}
+	if err := dec.DecodeAll(&bf.KDF, &bf.Backup); err != nil {
+		return walletBackup{}, err
+	}
+	backupKey, err := bf.KDF.key(backupPassword)
+	if err != nil {
+		return walletBackup{}, err
+	}
+	plaintext, err := backupKey.DecryptBytes(bf.Backup)
+	if err != nil {
+		return walletBackup{}, modules.ErrBadEncryptionKey
+	}
+	var backup walletBackup
+	err = json.Unmarshal(plaintext, &backup)
+	return backup, err
+}
+
+// LoadBackup loads a portable backup created by ExportBackup, decrypting it
+// with backupPassword.
+//
+// If the wallet has not been initialized yet, it is initialized from the
+// backup and encrypted with masterKey, or with the hash of the primary seed if
+// masterKey is blank. Because the backup records the primary seed progress,
+// the blockchain does not need to be scanned for the seed's keys; the wallet
+// is synced when it is unlocked.
+//
+// Otherwise the wallet must be unlocked, and the seeds of the backup are
+// added as auxiliary seeds. The blockchain is only rescanned if the backup
+// contains keys or addresses that the wallet does not already track.
+func (w *Wallet) LoadBackup(masterKey crypto.TwofishKey, backupPassword string, backupFilepath string) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+
+	backup, err := readBackup(backupPassword, backupFilepath)
+	if err != nil {
+		return err
+	}
+
+	if !w.scanLock.TryLock() {
+		return errScanInProgress
+	}
+	defer w.scanLock.Unlock()
+
+	w.mu.RLock()
+	encrypted := w.encrypted
+	w.mu.RUnlock()
+	if !encrypted {
+		return w.managedInitFromBackup(masterKey, backup)
+	}
+
+	rescan, err := func() (bool, error) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.unlocked {
+			return false, modules.ErrLockedWallet
+		}
+		if err := checkMasterKey(w.dbTx, masterKey); err != nil {
+			return false, err
+		}
+
+		rescan := false
+		var current []seedFile
+		err := encoding.Unmarshal(w.dbTx.Bucket(bucketWallet).Get(keyAuxiliarySeedFiles), &current)
+		if err != nil {
+			return false, err
+		}
+		for _, seed := range append([]modules.Seed{backup.PrimarySeed}, backup.AuxiliarySeeds...) {
+			known := false
+			for _, wSeed := range append([]modules.Seed{w.primarySeed}, w.seeds...) {
+				known = known || seed == wSeed
+			}
+			if known {
+				continue
+			}
+			current = append(current, createSeedFile(masterKey, seed))
+			w.integrateSeed(seed, modules.PublicKeysPerSeed)
+			for _, purpose := range modules.AddressPurposes {
+				w.integratePurposeKeys(seed, purpose, modules.PublicKeysPerSeed)
+			}
+			w.seeds = append(w.seeds, seed)
+			rescan = true
+		}
+		err = w.dbTx.Bucket(bucketWallet).Put(keyAuxiliarySeedFiles, encoding.Marshal(current))
+		if err != nil {
+			return false, err
+		}
+
+		for _, sk := range backup.UnseededKeys {
+			err := w.loadSpendableKey(masterKey, sk)
+			if err == errDuplicateSpendableKey {
+				continue
+			} else if err != nil {
+				return false, err
+			}
+			w.integrateSpendableKey(masterKey, sk)
+			rescan = true
+		}
+
+		newAddrs, err := w.restoreTrackedAddresses(backup)
+		if err != nil {
+			return false, err
+		}
+		if !rescan && !newAddrs {
+			return false, nil
+		}
+		return true, w.resetHistory()
+	}()
+	if err != nil || !rescan {
+		return err
+	}
+	return w.managedRescan()
+}
+
+// managedInitFromBackup initializes an unencrypted wallet from a backup. The
+// wallet remains locked; it is synced when it is first unlocked.
+func (w *Wallet) managedInitFromBackup(masterKey crypto.TwofishKey, backup walletBackup) error {
+	// If masterKey is blank, use the hash of the seed.
+	if masterKey == (crypto.TwofishKey{}) {
+		masterKey = crypto.TwofishKey(crypto.HashObject(backup.PrimarySeed))
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.initEncryption(masterKey, backup.PrimarySeed, backup.PrimarySeedProgress); err != nil {
+		return err
+	}
+	for purpose, progress := range backup.PurposeSeedProgress {
+		if purpose == modules.PurposeDefault || !validPurpose(purpose) {
+			continue
+		}
+		if err := dbPutPurposeSeedProgress(w.dbTx, purpose, progress); err != nil {
+			return err
+		}
+	}
+
+	var auxiliarySeedFiles []seedFile
+	for _, seed := range backup.AuxiliarySeeds {
+		auxiliarySeedFiles = append(auxiliarySeedFiles, createSeedFile(masterKey, seed))
+	}
+	err := w.dbTx.Bucket(bucketWallet).Put(keyAuxiliarySeedFiles, encoding.Marshal(auxiliarySeedFiles))
+	if err != nil {
+		return err
+	}
+	for _, sk := range backup.UnseededKeys {
+		if err := w.saveSpendableKey(masterKey, sk); err != nil {
+			return err
+		}
+	}
+	_, err = w.restoreTrackedAddresses(backup)
+	return err
+}
+
+// restoreTrackedAddresses adds the watch-only and multisig addresses, the
+// frozen outputs and the labels of backup to the wallet. It returns true if
+// any addresses were not already being tracked. Existing labels are
+// overwritten by those in the backup.
+func (w *Wallet) restoreTrackedAddresses(backup walletBackup) (bool, error) {
+	newAddrs := false
+	for _, addr := range backup.WatchedAddresses {
+		if _, ok := w.watchedAddrs[addr]; ok || w.isWalletAddress(addr) {
+			continue
+		}
+		w.watchedAddrs[addr] = struct{}{}
+		newAddrs = true
+	}
+	for _, uc := range backup.MultisigConditions {
+		addr := uc.UnlockHash()
+		if _, ok := w.multisigConds[addr]; ok {
+			continue
+		}
+		w.multisigConds[addr] = uc
+		w.watchedAddrs[addr] = struct{}{}
+		newAddrs = true
+	}
+	for _, uc := range backup.WatchedConditions {
+		addr := uc.UnlockHash()
+		if _, ok := w.watchedConds[addr]; ok || w.isWalletAddress(addr) {
+			continue
+		}
+		w.watchedConds[addr] = uc
+		if _, ok := w.watchedAddrs[addr]; !ok {
+			w.watchedAddrs[addr] = struct{}{}
+			newAddrs = true
+		}
+	}
+	for _, id := range backup.FrozenOutputs {
+		w.frozenOutputs[id] = struct{}{}
+	}
+	if err := dbPutWatchedAddresses(w.dbTx, w.watchedAddrList()); err != nil {
+		return false, err
+	}
+	if err := dbPutMultisigConditions(w.dbTx, w.multisigCondsList()); err != nil {
+		return false, err
+	}
+	if err := dbPutWatchedConditions(w.dbTx, w.watchedCondsList()); err != nil {
+		return false, err
+	}
+	if err := dbPutFrozenOutputs(w.dbTx, w.frozenOutputList()); err != nil {
+		return false, err
+	}
+	for _, al := range backup.AddressLabels {
+		if err := dbPutAddressLabel(w.dbTx, al.Address, al.Label); err != nil {
+			return false, err
+		}
+	}
+	for _, tl := range backup.TransactionLabels {
+		if err := dbPutTransactionLabel(w.dbTx, tl.TransactionID, tl.Label); err != nil {
+			return false, err
+		}
+	}
+	return newAddrs, nil
+}
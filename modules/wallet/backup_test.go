@@ -0,0 +1,172 @@
+package wallet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
+)
+
+// TestExportLoadBackup tests that a portable backup restores the seeds, keys,
+// watch-only addresses and frozen outputs of a wallet into a fresh wallet.
+func TestExportLoadBackup(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Give the wallet an unseeded key, a watch-only address, a frozen output
+	// and a label.
+	var otherSeed modules.Seed
+	fastrand.Read(otherSeed[:])
+	unseeded := generateSpendableKey(otherSeed, 0)
+	wt.wallet.mu.Lock()
+	err = wt.wallet.loadSpendableKey(wt.walletMasterKey, unseeded)
+	wt.wallet.integrateSpendableKey(wt.walletMasterKey, unseeded)
+	wt.wallet.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	watchAddr := generateSpendableKey(otherSeed, 1).UnlockConditions.UnlockHash()
+	if err := wt.wallet.AddWatchAddresses([]types.UnlockHash{watchAddr}, true); err != nil {
+		t.Fatal(err)
+	}
+	outputs, err := wt.wallet.UnspentOutputs()
+	if err != nil || len(outputs) == 0 {
+		t.Fatal("wallet has no outputs:", err)
+	}
+	frozen := outputs[0].ID
+	if err := wt.wallet.FreezeOutputs([]types.OutputID{frozen}); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.SetAddressLabel(watchAddr, "cold storage"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Export the backup.
+	backupPassword := "backup password"
+	backupPath := filepath.Join(wt.persistDir, "wallet.backup")
+	if err := wt.wallet.ExportBackup(backupPassword, backupPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// Restore the backup into a fresh wallet.
+	w, err := New(wt.cs, wt.tpool, filepath.Join(wt.persistDir, "restored"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.LoadBackup(crypto.TwofishKey{}, "wrong password", backupPath); err != modules.ErrBadEncryptionKey {
+		t.Fatal("expected ErrBadEncryptionKey, got", err)
+	}
+	var masterKey crypto.TwofishKey
+	fastrand.Read(masterKey[:])
+	if err := w.LoadBackup(masterKey, backupPassword, backupPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Unlock(masterKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// The restored wallet should match the original.
+	seed, progress, err := wt.wallet.PrimarySeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restoredSeed, restoredProgress, err := w.PrimarySeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seed != restoredSeed || progress != restoredProgress {
+		t.Fatal("primary seed was not restored")
+	}
+	sc, _, _ := wt.wallet.ConfirmedBalance()
+	restoredSC, _, _ := w.ConfirmedBalance()
+	if !sc.Equals(restoredSC) {
+		t.Fatalf("restored balance %v does not match %v", restoredSC, sc)
+	}
+	if _, ok := w.keys[unseeded.UnlockConditions.UnlockHash()]; !ok {
+		t.Fatal("unseeded key was not restored")
+	}
+	addrs, _ := w.WatchAddresses()
+	if len(addrs) != 1 || addrs[0] != watchAddr {
+		t.Fatal("watch-only address was not restored:", addrs)
+	}
+	if _, ok := w.frozenOutputs[frozen]; !ok {
+		t.Fatal("frozen output was not restored")
+	}
+	labels, _ := w.AddressLabels()
+	if len(labels) != 1 || labels[0].Address != watchAddr || labels[0].Label != "cold storage" {
+		t.Fatal("address label was not restored:", labels)
+	}
+
+	// Loading the backup into the original wallet adds nothing.
+	if err := wt.wallet.LoadBackup(wt.walletMasterKey, backupPassword, backupPath); err != nil {
+		t.Fatal(err)
+	}
+	if len(wt.wallet.seeds) != 0 {
+		t.Fatal("known seeds should not be added again")
+	}
+}
+
+// TestBackupKDF checks that backup keys depend on the salt and that invalid
+// key derivation parameters are rejected.
+func TestBackupKDF(t *testing.T) {
+	kdf := backupKDF{N: backupKDFN, R: backupKDFR, P: backupKDFP}
+	fastrand.Read(kdf.Salt[:])
+	key1, err := kdf.key("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key2, err := kdf.key("password"); err != nil || key2 != key1 {
+		t.Fatal("key derivation is not deterministic:", err)
+	}
+	other := kdf
+	fastrand.Read(other.Salt[:])
+	if key2, err := other.key("password"); err != nil || key2 == key1 {
+		t.Fatal("key does not depend on the salt:", err)
+	}
+
+	for _, bad := range []backupKDF{
+		{N: 0, R: backupKDFR, P: backupKDFP},
+		{N: 1000, R: backupKDFR, P: backupKDFP},
+		{N: maxBackupKDFN * 2, R: backupKDFR, P: backupKDFP},
+		{N: backupKDFN, R: 1 << 20, P: backupKDFP},
+	} {
+		if _, err := bad.key("password"); err != errBadBackupKDF {
+			t.Error("expected errBadBackupKDF, got", err)
+		}
+	}
+}
+
+// TestReadBackupVersion checks that backups of an unknown version, such as
+// version 1.0 backups that used an unsalted key, are rejected.
+func TestReadBackupVersion(t *testing.T) {
+	dir := build.TempDir(modules.WalletDir, t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	backupPath := filepath.Join(dir, "wallet.backup")
+	key := crypto.TwofishKey(crypto.HashObject("password"))
+	err := encoding.WriteFile(backupPath, struct {
+		Header  string
+		Version string
+		Backup  crypto.Ciphertext
+	}{backupFileHeader, "1.0", key.EncryptBytes([]byte("{}"))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readBackup("password", backupPath); err != ErrUnknownVersion {
+		t.Fatal("expected ErrUnknownVersion, got", err)
+	}
+}
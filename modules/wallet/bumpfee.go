@@ -0,0 +1,261 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errCannotReplace is returned when the wallet cannot rebuild a
+	// transaction with a higher fee, because it spends or creates objects
+	// other than siacoins or spends outputs the wallet cannot sign for.
+	errCannotReplace = errors.New("transaction cannot be rebuilt by the wallet")
+
+	// errFeeNotHigher is returned when the new fee passed to BumpFee does not
+	// exceed the current fee of the transaction.
+	errFeeNotHigher = errors.New("new fee must be higher than the current fee of the transaction")
+
+	// errNotUnconfirmed is returned when BumpFee is called on a transaction
+	// that is not an unconfirmed transaction funded by the wallet.
+	errNotUnconfirmed = errors.New("transaction is not an unconfirmed transaction funded by the wallet")
+)
+
+// BumpFee raises the miner fee paid for an unconfirmed transaction funded by
+// the wallet to newFee, returning the transaction set that was submitted to
+// the transaction pool.
+//
+// If an output of the transaction belongs to the wallet and can cover the
+// difference, a child transaction that spends it pays the difference
+// (child-pays-for-parent). Otherwise the transaction is rebuilt with the same
+// inputs, and more inputs if needed, and replaces the original transaction in
+// the transaction pool. Outputs of the original transaction that belong to
+// the wallet are merged into the change output of the replacement.
+//
+// The fee increase counts toward the limits of the wallet's spending policy,
+// and sendPassword must match the send password if one is set.
+func (w *Wallet) BumpFee(txid types.TransactionID, newFee types.Currency, sendPassword string) ([]types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer w.tg.Done()
+
+	// dustThreshold has to be obtained separate from the lock
+	dustThreshold := w.DustThreshold()
+
+	var spend spendRecord
+	txnSet, spent, err := func() ([]types.Transaction, []types.OutputID, error) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.unlocked {
+			return nil, nil, modules.ErrLockedWallet
+		}
+		if err := w.checkSendPassword(sendPassword); err != nil {
+			return nil, nil, err
+		}
+		consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var pt modules.ProcessedTransaction
+		found := false
+		for _, upt := range w.unconfirmedProcessedTransactions {
+			if upt.TransactionID == txid {
+				pt, found = upt, true
+				break
+			}
+		}
+		funded := false
+		for _, input := range pt.Inputs {
+			funded = funded || (input.FundType == types.SpecifierSiacoinInput && input.WalletAddress)
+		}
+		if !found || !funded {
+			return nil, nil, errNotUnconfirmed
+		}
+		var oldFee types.Currency
+		for _, fee := range pt.Transaction.MinerFees {
+			oldFee = oldFee.Add(fee)
+		}
+		if newFee.Cmp(oldFee) <= 0 {
+			return nil, nil, errFeeNotHigher
+		}
+
+		// Prefer spending the largest usable wallet output of the
+		// transaction in a child transaction.
+		parent := pt.Transaction
+		child := -1
+		for i, sco := range parent.SiacoinOutputs {
+			if !w.isWalletAddress(sco.UnlockHash) || sco.Value.Cmp(newFee.Sub(oldFee)) <= 0 {
+				continue
+			} else if w.checkOutput(w.dbTx, consensusHeight, parent.SiacoinOutputID(uint64(i)), sco, types.ZeroCurrency) != nil {
+				continue
+			}
+			if child == -1 || sco.Value.Cmp(parent.SiacoinOutputs[child].Value) > 0 {
+				child = i
+			}
+		}
+		var txnSet []types.Transaction
+		var spent []types.OutputID
+		if child != -1 {
+			txn, err := w.childPaysForParent(parent, uint64(child), newFee.Sub(oldFee), dustThreshold)
+			if err != nil {
+				return nil, nil, err
+			}
+			txnSet = []types.Transaction{parent, txn}
+			spent = []types.OutputID{types.OutputID(parent.SiacoinOutputID(uint64(child)))}
+		} else {
+			txn, newInputs, err := w.replaceTransaction(pt, newFee, consensusHeight, dustThreshold)
+			if err != nil {
+				return nil, nil, err
+			}
+			txnSet = []types.Transaction{txn}
+			spent = newInputs
+		}
+		spend, err = w.authorizeSpend(nil, newFee.Sub(oldFee), true)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, id := range spent {
+			if err := dbPutSpentOutput(w.dbTx, id, consensusHeight); err != nil {
+				return nil, nil, err
+			}
+		}
+		return txnSet, spent, nil
+	}()
+	if err != nil {
+		w.log.Println("Attempt to bump transaction fee has failed - failed to build transaction:", err)
+		return nil, err
+	}
+
+	err = w.tpool.AcceptTransactionSet(txnSet)
+	if err != nil {
+		w.log.Println("Attempt to bump transaction fee has failed - transaction pool rejected transaction:", err)
+		// Release the outputs so that they can be spent again.
+		w.mu.Lock()
+		for _, id := range spent {
+			dbDeleteSpentOutput(w.dbTx, id)
+		}
+		w.undoSpend(spend)
+		w.mu.Unlock()
+		return nil, err
+	}
+	w.log.Println("Raised the fee of transaction", txid, "to", newFee.HumanString(), "ID:", txnSet[len(txnSet)-1].ID())
+	return txnSet, nil
+}
+
+// childPaysForParent creates a transaction that spends output index of
+// parent, paying fee and sending the remainder to a new wallet address.
+func (w *Wallet) childPaysForParent(parent types.Transaction, index uint64, fee, dustThreshold types.Currency) (types.Transaction, error) {
+	sco := parent.SiacoinOutputs[index]
+	txn := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{
+			ParentID:         parent.SiacoinOutputID(index),
+			UnlockConditions: w.keys[sco.UnlockHash].UnlockConditions,
+		}},
+		MinerFees: []types.Currency{fee},
+	}
+	// Change that is too small to be spent is added to the fee instead.
+	change := sco.Value.Sub(fee)
+	if change.Cmp(dustThreshold) <= 0 {
+		txn.MinerFees[0] = sco.Value
+	} else {
+		uc, err := w.nextPrimarySeedAddress(w.dbTx)
+		if err != nil {
+			return types.Transaction{}, err
+		}
+		txn.SiacoinOutputs = []types.SiacoinOutput{{Value: change, UnlockHash: uc.UnlockHash()}}
+	}
+	sci := txn.SiacoinInputs[0]
+	addSignatures(&txn, types.FullCoveredFields, sci.UnlockConditions, crypto.Hash(sci.ParentID), w.keys[sco.UnlockHash])
+	return txn, nil
+}
+
+// replaceTransaction rebuilds the transaction of pt so that it pays fee. The
+// replacement spends the same inputs, plus additional inputs if they do not
+// cover the fee, and sends the outputs that do not belong to the wallet to the
+// same addresses. The ids of the additional inputs are returned along with the
+// transaction.
+func (w *Wallet) replaceTransaction(pt modules.ProcessedTransaction, fee types.Currency, consensusHeight types.BlockHeight, dustThreshold types.Currency) (types.Transaction, []types.OutputID, error) {
+	orig := pt.Transaction
+	if len(orig.FileContracts) != 0 || len(orig.FileContractRevisions) != 0 || len(orig.StorageProofs) != 0 ||
+		len(orig.SiafundInputs) != 0 || len(orig.SiafundOutputs) != 0 {
+		return types.Transaction{}, nil, errCannotReplace
+	}
+
+	txn := types.Transaction{
+		ArbitraryData: orig.ArbitraryData,
+		MinerFees:     []types.Currency{fee},
+	}
+	used := make(map[types.SiacoinOutputID]struct{})
+	var fund types.Currency
+	for _, input := range pt.Inputs {
+		if !w.isWalletAddress(input.RelatedAddress) {
+			return types.Transaction{}, nil, errCannotReplace
+		}
+		fund = fund.Add(input.Value)
+	}
+	for _, sci := range orig.SiacoinInputs {
+		txn.SiacoinInputs = append(txn.SiacoinInputs, types.SiacoinInput{
+			ParentID:         sci.ParentID,
+			UnlockConditions: sci.UnlockConditions,
+		})
+		used[sci.ParentID] = struct{}{}
+	}
+	var payments types.Currency
+	for _, sco := range orig.SiacoinOutputs {
+		if !w.isWalletAddress(sco.UnlockHash) {
+			txn.SiacoinOutputs = append(txn.SiacoinOutputs, sco)
+			payments = payments.Add(sco.Value)
+		}
+	}
+
+	// Add the largest spendable outputs until the payments and fee are
+	// covered.
+	var newInputs []types.OutputID
+	if fund.Cmp(payments.Add(fee)) < 0 {
+		so, err := w.spendableOutputs()
+		if err != nil {
+			return types.Transaction{}, nil, err
+		}
+		for i := range so.ids {
+			if fund.Cmp(payments.Add(fee)) >= 0 {
+				break
+			}
+			if _, ok := used[so.ids[i]]; ok {
+				continue
+			}
+			if err := w.checkOutput(w.dbTx, consensusHeight, so.ids[i], so.outputs[i], dustThreshold); err != nil {
+				continue
+			}
+			txn.SiacoinInputs = append(txn.SiacoinInputs, types.SiacoinInput{
+				ParentID:         so.ids[i],
+				UnlockConditions: w.keys[so.outputs[i].UnlockHash].UnlockConditions,
+			})
+			newInputs = append(newInputs, types.OutputID(so.ids[i]))
+			fund = fund.Add(so.outputs[i].Value)
+		}
+		if fund.Cmp(payments.Add(fee)) < 0 {
+			return types.Transaction{}, nil, modules.ErrLowBalance
+		}
+	}
+
+	// Change that is too small to be spent is added to the fee instead.
+	change := fund.Sub(payments).Sub(fee)
+	if change.Cmp(dustThreshold) <= 0 {
+		txn.MinerFees[0] = fee.Add(change)
+	} else {
+		uc, err := w.nextPrimarySeedAddress(w.dbTx)
+		if err != nil {
+			return types.Transaction{}, nil, err
+		}
+		txn.SiacoinOutputs = append(txn.SiacoinOutputs, types.SiacoinOutput{Value: change, UnlockHash: uc.UnlockHash()})
+	}
+
+	for _, sci := range txn.SiacoinInputs {
+		addSignatures(&txn, types.FullCoveredFields, sci.UnlockConditions, crypto.Hash(sci.ParentID), w.keys[sci.UnlockConditions.UnlockHash()])
+	}
+	return txn, newInputs, nil
+}
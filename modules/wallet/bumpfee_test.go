@@ -0,0 +1,154 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// tpoolContains returns true if the transaction pool of wt contains txid.
+func tpoolContains(wt *walletTester, txid types.TransactionID) bool {
+	for _, txn := range wt.tpool.TransactionList() {
+		if txn.ID() == txid {
+			return true
+		}
+	}
+	return false
+}
+
+// TestBumpFeeChildPaysForParent tests raising the fee of a transaction with a
+// change output by spending the change in a child transaction.
+func TestBumpFeeChildPaysForParent(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	var dest types.UnlockHash
+	dest[0] = 1
+	fee := types.SiacoinPrecision
+	txns, _, err := wt.wallet.SendSiacoinsWithOptions([]types.SiacoinOutput{{Value: types.SiacoinPrecision.Mul64(100), UnlockHash: dest}}, modules.SendOptions{Fee: fee})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent := txns[0]
+
+	// The new fee must be higher, and the transaction must be known.
+	if _, err := wt.wallet.BumpFee(parent.ID(), fee, ""); err != errFeeNotHigher {
+		t.Fatal("expected errFeeNotHigher, got", err)
+	}
+	if _, err := wt.wallet.BumpFee(types.TransactionID{1}, fee.Mul64(2), ""); err != errNotUnconfirmed {
+		t.Fatal("expected errNotUnconfirmed, got", err)
+	}
+
+	txns, err = wt.wallet.BumpFee(parent.ID(), fee.Mul64(3), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) != 2 || txns[0].ID() != parent.ID() {
+		t.Fatal("expected the parent and a child transaction")
+	}
+	child := txns[1]
+	if len(child.SiacoinInputs) != 1 || child.SiacoinInputs[0].ParentID != parent.SiacoinOutputID(1) {
+		t.Fatal("child does not spend the change output of the parent")
+	}
+	if !child.MinerFees[0].Equals(fee.Mul64(2)) {
+		t.Fatal("child pays the wrong fee:", child.MinerFees[0])
+	}
+	if !tpoolContains(wt, parent.ID()) || !tpoolContains(wt, child.ID()) {
+		t.Fatal("parent and child should both be in the transaction pool")
+	}
+
+	// Both transactions are confirmed in the next block.
+	b, _ := wt.miner.FindBlock()
+	if err := wt.cs.AcceptBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := wt.wallet.Transaction(child.ID()); !ok {
+		t.Fatal("child was not confirmed")
+	}
+}
+
+// TestBumpFeeReplacement tests raising the fee of a transaction without a
+// change output by replacing it in the transaction pool.
+func TestBumpFeeReplacement(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Mine another block so that the wallet has a second output to add to
+	// the replacement.
+	b, _ := wt.miner.FindBlock()
+	if err := wt.cs.AcceptBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	spendable, err := spendableSiacoinOutputs(wt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var input modules.UnspentOutput
+	for _, uo := range spendable {
+		input = uo
+		break
+	}
+
+	// Spend the entire output so that there is no change.
+	var dest types.UnlockHash
+	dest[0] = 1
+	fee := types.SiacoinPrecision
+	sends := []types.SiacoinOutput{{Value: input.Value.Sub(fee), UnlockHash: dest}}
+	txns, _, err := wt.wallet.SendSiacoinsWithOptions(sends, modules.SendOptions{
+		Fee:    fee,
+		Inputs: []types.SiacoinOutputID{types.SiacoinOutputID(input.ID)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := txns[0]
+	if len(orig.SiacoinOutputs) != 1 {
+		t.Fatal("transaction should not have a change output")
+	}
+
+	txns, err = wt.wallet.BumpFee(orig.ID(), fee.Mul64(2), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) != 1 {
+		t.Fatal("expected a single replacement transaction")
+	}
+	replacement := txns[0]
+	if replacement.SiacoinInputs[0].ParentID != orig.SiacoinInputs[0].ParentID || len(replacement.SiacoinInputs) != 2 {
+		t.Fatal("replacement should spend the original input and one more")
+	}
+	if replacement.SiacoinOutputs[0].UnlockHash != dest || !replacement.SiacoinOutputs[0].Value.Equals(sends[0].Value) {
+		t.Fatal("replacement does not make the original payment")
+	}
+	if !replacement.MinerFees[0].Equals(fee.Mul64(2)) {
+		t.Fatal("replacement pays the wrong fee:", replacement.MinerFees[0])
+	}
+	if tpoolContains(wt, orig.ID()) || !tpoolContains(wt, replacement.ID()) {
+		t.Fatal("replacement did not replace the original transaction")
+	}
+
+	// The replacement can be bumped again, and only it is confirmed.
+	if _, err := wt.wallet.BumpFee(replacement.ID(), fee.Mul64(3), ""); err != nil {
+		t.Fatal(err)
+	}
+	b, _ = wt.miner.FindBlock()
+	if err := wt.cs.AcceptBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := wt.wallet.Transaction(orig.ID()); ok {
+		t.Fatal("original transaction should not have been confirmed")
+	}
+}
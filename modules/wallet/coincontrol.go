@@ -0,0 +1,247 @@
+package wallet
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errDuplicateInput is returned when the same output is selected as an
+	// input more than once.
+	errDuplicateInput = errors.New("output was selected more than once")
+
+	// errUnknownOutput is returned when a selected output does not belong to
+	// the wallet.
+	errUnknownOutput = errors.New("wallet does not own the requested output")
+
+	// errFeeAndFeePerByte is returned when both an explicit fee and a fee per
+	// byte are supplied.
+	errFeeAndFeePerByte = errors.New("cannot specify both a fee and a fee per byte")
+)
+
+// signatureSize is the encoded size of a TransactionSignature produced by the
+// wallet for a standard input. It is used to estimate the size of a
+// transaction before it is signed.
+var signatureSize = len(encoding.Marshal(types.TransactionSignature{
+	CoveredFields: types.FullCoveredFields,
+	Signature:     make([]byte, crypto.SignatureSize),
+}))
+
+// spendableOutputs returns the confirmed and unconfirmed siacoin outputs of
+// the wallet, sorted from largest to smallest.
+func (w *Wallet) spendableOutputs() (sortedOutputs, error) {
+	var so sortedOutputs
+	err := dbForEachSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
+		so.ids = append(so.ids, scoid)
+		so.outputs = append(so.outputs, sco)
+	})
+	if err != nil {
+		return sortedOutputs{}, err
+	}
+	for _, upt := range w.unconfirmedProcessedTransactions {
+		for i, sco := range upt.Transaction.SiacoinOutputs {
+			if !w.isWalletAddress(sco.UnlockHash) {
+				continue
+			}
+			so.ids = append(so.ids, upt.Transaction.SiacoinOutputID(uint64(i)))
+			so.outputs = append(so.outputs, sco)
+		}
+	}
+	sort.Sort(sort.Reverse(so))
+	return so, nil
+}
+
+// SendSiacoinsWithOptions creates a transaction containing outputs, funded
+// and priced according to opts. The fee that was paid is returned along with
+// the transaction. Unless opts.DryRun is set, the transaction is submitted to
+// the transaction pool. The send must comply with the wallet's spending
+// policy, and opts.SendPassword must match the send password if one is set.
+func (w *Wallet) SendSiacoinsWithOptions(outputs []types.SiacoinOutput, opts modules.SendOptions) (txns []types.Transaction, fee types.Currency, err error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, types.ZeroCurrency, err
+	}
+	defer w.tg.Done()
+	return w.managedSendSiacoinsWithOptions(outputs, opts, true)
+}
+
+// managedSendSiacoinsWithOptions implements SendSiacoinsWithOptions. If
+// enforcePolicy is set, the send must comply with the wallet's spending
+// policy.
+func (w *Wallet) managedSendSiacoinsWithOptions(outputs []types.SiacoinOutput, opts modules.SendOptions, enforcePolicy bool) ([]types.Transaction, types.Currency, error) {
+	if !opts.Fee.IsZero() && !opts.FeePerByte.IsZero() {
+		return nil, types.ZeroCurrency, errFeeAndFeePerByte
+	}
+
+	// dustThreshold and the fee estimate have to be obtained separate from
+	// the lock
+	dustThreshold := w.DustThreshold()
+	feePerByte := opts.FeePerByte
+	if feePerByte.IsZero() {
+		feePerByte = w.tpool.FeeEstimate(sendFeeTarget)
+	}
+
+	var totalOutput types.Currency
+	for _, sco := range outputs {
+		totalOutput = totalOutput.Add(sco.Value)
+	}
+
+	var spend spendRecord
+	txn, fee, err := func() (types.Transaction, types.Currency, error) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.unlocked {
+			return types.Transaction{}, types.ZeroCurrency, modules.ErrLockedWallet
+		}
+		consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+		if err != nil {
+			return types.Transaction{}, types.ZeroCurrency, err
+		}
+
+		// Determine where change is sent.
+		changeAddr := opts.ChangeAddress
+		if changeAddr == (types.UnlockHash{}) {
+			uc, err := w.nextPrimarySeedAddress(w.dbTx)
+			if err != nil {
+				return types.Transaction{}, types.ZeroCurrency, err
+			}
+			changeAddr = uc.UnlockHash()
+		}
+
+		// Start with a transaction containing the outputs, a change output
+		// and a fee, so that the size estimate accounts for all of them.
+		txn := types.Transaction{
+			SiacoinOutputs: append(append([]types.SiacoinOutput(nil), outputs...), types.SiacoinOutput{UnlockHash: changeAddr}),
+			MinerFees:      []types.Currency{types.ZeroCurrency},
+		}
+		var fund types.Currency
+		feeFor := func() types.Currency {
+			if !opts.Fee.IsZero() {
+				return opts.Fee
+			}
+			// Neither the fee nor the change can exceed the funds, so use the
+			// funds in their place to avoid underestimating their encoded
+			// size.
+			est := txn
+			est.SiacoinOutputs = append([]types.SiacoinOutput(nil), txn.SiacoinOutputs...)
+			est.SiacoinOutputs[len(est.SiacoinOutputs)-1].Value = fund
+			est.MinerFees = []types.Currency{fund}
+			size := len(encoding.Marshal(est)) + len(est.SiacoinInputs)*signatureSize
+			return feePerByte.Mul64(uint64(size))
+		}
+		addInput := func(id types.SiacoinOutputID, sco types.SiacoinOutput) {
+			txn.SiacoinInputs = append(txn.SiacoinInputs, types.SiacoinInput{
+				ParentID:         id,
+				UnlockConditions: w.keys[sco.UnlockHash].UnlockConditions,
+			})
+		}
+
+		so, err := w.spendableOutputs()
+		if err != nil {
+			return types.Transaction{}, types.ZeroCurrency, err
+		}
+		if len(opts.Inputs) > 0 {
+			// Spend exactly the requested outputs.
+			available := make(map[types.SiacoinOutputID]types.SiacoinOutput)
+			for i := range so.ids {
+				available[so.ids[i]] = so.outputs[i]
+			}
+			used := make(map[types.SiacoinOutputID]struct{})
+			for _, id := range opts.Inputs {
+				sco, ok := available[id]
+				if !ok {
+					return types.Transaction{}, types.ZeroCurrency, errUnknownOutput
+				}
+				if _, ok := used[id]; ok {
+					return types.Transaction{}, types.ZeroCurrency, errDuplicateInput
+				}
+				used[id] = struct{}{}
+				if err := w.checkOutput(w.dbTx, consensusHeight, id, sco, types.ZeroCurrency); err != nil {
+					return types.Transaction{}, types.ZeroCurrency, err
+				}
+				addInput(id, sco)
+				fund = fund.Add(sco.Value)
+			}
+		} else {
+			// Select the largest outputs until the outputs and fee are
+			// covered.
+			for i := range so.ids {
+				if fund.Cmp(totalOutput.Add(feeFor())) >= 0 {
+					break
+				}
+				if err := w.checkOutput(w.dbTx, consensusHeight, so.ids[i], so.outputs[i], dustThreshold); err != nil {
+					continue
+				}
+				addInput(so.ids[i], so.outputs[i])
+				fund = fund.Add(so.outputs[i].Value)
+			}
+		}
+		fee := feeFor()
+		if fund.Cmp(totalOutput.Add(fee)) < 0 {
+			return types.Transaction{}, types.ZeroCurrency, modules.ErrLowBalance
+		}
+
+		// Add the change to the change output. Change that is too small to be
+		// spent is added to the fee instead.
+		change := fund.Sub(totalOutput).Sub(fee)
+		if change.Cmp(dustThreshold) <= 0 {
+			fee = fee.Add(change)
+			txn.SiacoinOutputs = txn.SiacoinOutputs[:len(txn.SiacoinOutputs)-1]
+		} else {
+			txn.SiacoinOutputs[len(txn.SiacoinOutputs)-1].Value = change
+		}
+		txn.MinerFees[0] = fee
+
+		if enforcePolicy {
+			if err := w.checkSendPassword(opts.SendPassword); err != nil {
+				return types.Transaction{}, types.ZeroCurrency, err
+			}
+			spend, err = w.authorizeSpend(outputs, fee, !opts.DryRun)
+			if err != nil {
+				return types.Transaction{}, types.ZeroCurrency, err
+			}
+		}
+
+		for _, sci := range txn.SiacoinInputs {
+			addSignatures(&txn, types.FullCoveredFields, sci.UnlockConditions, crypto.Hash(sci.ParentID), w.keys[sci.UnlockConditions.UnlockHash()])
+		}
+		if opts.DryRun {
+			return txn, fee, nil
+		}
+		for _, sci := range txn.SiacoinInputs {
+			if err := dbPutSpentOutput(w.dbTx, types.OutputID(sci.ParentID), consensusHeight); err != nil {
+				return types.Transaction{}, types.ZeroCurrency, err
+			}
+		}
+		return txn, fee, nil
+	}()
+	if err != nil {
+		w.log.Println("Attempt to send coins has failed - failed to build transaction:", err)
+		return nil, types.ZeroCurrency, err
+	}
+	txnSet := []types.Transaction{txn}
+	if opts.DryRun {
+		return txnSet, fee, nil
+	}
+
+	err = w.tpool.AcceptTransactionSet(txnSet)
+	if err != nil {
+		w.log.Println("Attempt to send coins has failed - transaction pool rejected transaction:", err)
+		// Release the inputs so that they can be spent again.
+		w.mu.Lock()
+		for _, sci := range txn.SiacoinInputs {
+			dbDeleteSpentOutput(w.dbTx, types.OutputID(sci.ParentID))
+		}
+		if enforcePolicy {
+			w.undoSpend(spend)
+		}
+		w.mu.Unlock()
+		return nil, types.ZeroCurrency, err
+	}
+	w.log.Println("Submitted a siacoin transfer transaction with", len(txn.SiacoinInputs), "inputs and fees", fee.HumanString(), "ID:", txn.ID())
+	return txnSet, fee, nil
+}
@@ -0,0 +1,121 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestSendSiacoinsWithOptions tests sending siacoins with an explicit fee,
+// explicit inputs, a custom change address and as a dry run.
+func TestSendSiacoinsWithOptions(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Mine another block so that the wallet has two mature miner payouts.
+	b, _ := wt.miner.FindBlock()
+	if err := wt.cs.AcceptBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	outputs, err := wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var scos []modules.UnspentOutput
+	for _, uo := range outputs {
+		if uo.FundType == types.SpecifierSiacoinOutput && !uo.WatchOnly {
+			scos = append(scos, uo)
+		}
+	}
+	if len(scos) < 2 {
+		t.Fatal("expected at least two siacoin outputs, got", len(scos))
+	}
+	input := scos[0]
+
+	var dest, change types.UnlockHash
+	dest[0] = 1
+	change[0] = 2
+	sendAmount := types.SiacoinPrecision.Mul64(100)
+	fee := types.SiacoinPrecision.Mul64(3)
+	sends := []types.SiacoinOutput{{Value: sendAmount, UnlockHash: dest}}
+	opts := modules.SendOptions{
+		Fee:           fee,
+		Inputs:        []types.SiacoinOutputID{types.SiacoinOutputID(input.ID)},
+		ChangeAddress: change,
+		DryRun:        true,
+	}
+
+	// Invalid options should be rejected.
+	badOpts := opts
+	badOpts.FeePerByte = types.NewCurrency64(1)
+	if _, _, err := wt.wallet.SendSiacoinsWithOptions(sends, badOpts); err != errFeeAndFeePerByte {
+		t.Fatal("expected errFeeAndFeePerByte, got", err)
+	}
+	badOpts = opts
+	badOpts.Inputs = []types.SiacoinOutputID{{1}}
+	if _, _, err := wt.wallet.SendSiacoinsWithOptions(sends, badOpts); err != errUnknownOutput {
+		t.Fatal("expected errUnknownOutput, got", err)
+	}
+	badOpts = opts
+	badOpts.Inputs = append(badOpts.Inputs, opts.Inputs[0])
+	if _, _, err := wt.wallet.SendSiacoinsWithOptions(sends, badOpts); err != errDuplicateInput {
+		t.Fatal("expected errDuplicateInput, got", err)
+	}
+
+	// A dry run should return a valid transaction without broadcasting it.
+	txns, paid, err := wt.wallet.SendSiacoinsWithOptions(sends, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !paid.Equals(fee) {
+		t.Fatalf("expected fee %v, got %v", fee, paid)
+	}
+	if len(txns) != 1 {
+		t.Fatal("expected a single transaction, got", len(txns))
+	}
+	txn := txns[0]
+	if len(txn.SiacoinInputs) != 1 || txn.SiacoinInputs[0].ParentID != types.SiacoinOutputID(input.ID) {
+		t.Fatal("transaction does not spend the requested input")
+	}
+	if len(txn.SiacoinOutputs) != 2 || txn.SiacoinOutputs[1].UnlockHash != change {
+		t.Fatal("change was not sent to the change address")
+	}
+	if !txn.SiacoinOutputs[1].Value.Equals(input.Value.Sub(sendAmount).Sub(fee)) {
+		t.Fatal("wrong change value:", txn.SiacoinOutputs[1].Value)
+	}
+	if len(wt.tpool.TransactionList()) != 0 {
+		t.Fatal("dry run broadcast the transaction")
+	}
+
+	// Sending for real should spend the same input.
+	opts.DryRun = false
+	txns, _, err = wt.wallet.SendSiacoinsWithOptions(sends, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wt.tpool.TransactionList()) != 1 || txns[0].ID() != txn.ID() {
+		t.Fatal("transaction was not broadcast")
+	}
+
+	// The input is now spent and cannot be selected again.
+	if _, _, err := wt.wallet.SendSiacoinsWithOptions(sends, opts); err != errSpendHeightTooHigh {
+		t.Fatal("expected errSpendHeightTooHigh, got", err)
+	}
+
+	// Automatic input selection with a fee per byte should also succeed.
+	txns, paid, err = wt.wallet.SendSiacoinsWithOptions(sends, modules.SendOptions{FeePerByte: types.SiacoinPrecision.Div64(1000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paid.Cmp(types.SiacoinPrecision.Div64(1000).Mul64(uint64(len(encoding.Marshal(txns[0]))))) < 0 {
+		t.Fatal("fee does not cover the size of the transaction:", paid)
+	}
+}
@@ -15,6 +15,11 @@ const (
 	// defragThreshold is the number of outputs a wallet is allowed before it is
 	// defragmented.
 	defragThreshold = 50
+
+	// sendFeeTarget is the number of blocks within which the transactions
+	// sent by the wallet should be confirmed. It determines the fee the
+	// wallet pays when no fee is specified.
+	sendFeeTarget = 1
 )
 
 var (
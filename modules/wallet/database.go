@@ -26,6 +26,13 @@ var (
 	// bucketAddrTransactions maps an UnlockHash to the
 	// ProcessedTransactions that it appears in.
 	bucketAddrTransactions = []byte("bucketAddrTransactions")
+	// bucketAddressLabels maps an UnlockHash to the label that the user has
+	// assigned to it.
+	bucketAddressLabels = []byte("bucketAddressLabels")
+	// bucketOutputHeights maps an OutputID to the height of the block that
+	// created it. The outputs of every ProcessedTransaction are stored, and
+	// removed again when the transaction is reverted.
+	bucketOutputHeights = []byte("bucketOutputHeights")
 	// bucketSiacoinOutputs maps a SiacoinOutputID to its SiacoinOutput. Only
 	// outputs that the wallet controls are stored. The wallet uses these
 	// outputs to fund transactions.
@@ -39,18 +46,35 @@ var (
 	// these outputs so that it can reuse them if they are not confirmed on
 	// the blockchain.
 	bucketSpentOutputs = []byte("bucketSpentOutputs")
+	// bucketTransactionLabels maps a TransactionID to the label that the
+	// user has assigned to it. Unlike the transaction history, labels are
+	// kept when the wallet rescans the blockchain.
+	bucketTransactionLabels = []byte("bucketTransactionLabels")
 	// bucketWallet contains various fields needed by the wallet, such as its
 	// UID, EncryptionVerification, and PrimarySeedFile.
 	bucketWallet = []byte("bucketWallet")
+	// bucketWatchedSiacoinOutputs maps a SiacoinOutputID to its
+	// SiacoinOutput. Only outputs belonging to watch-only addresses are
+	// stored. The wallet cannot spend these outputs.
+	bucketWatchedSiacoinOutputs = []byte("bucketWatchedSiacoinOutputs")
+	// bucketWatchedSiafundOutputs maps a SiafundOutputID to its
+	// SiafundOutput. Only outputs belonging to watch-only addresses are
+	// stored. The wallet cannot spend these outputs.
+	bucketWatchedSiafundOutputs = []byte("bucketWatchedSiafundOutputs")
 
 	dbBuckets = [][]byte{
 		bucketProcessedTransactions,
 		bucketProcessedTxnIndex,
 		bucketAddrTransactions,
+		bucketAddressLabels,
+		bucketOutputHeights,
 		bucketSiacoinOutputs,
 		bucketSiafundOutputs,
 		bucketSpentOutputs,
+		bucketTransactionLabels,
 		bucketWallet,
+		bucketWatchedSiacoinOutputs,
+		bucketWatchedSiafundOutputs,
 	}
 
 	errNoKey = errors.New("key does not exist")
@@ -60,11 +84,18 @@ var (
 	keyConsensusChange        = []byte("keyConsensusChange")
 	keyConsensusHeight        = []byte("keyConsensusHeight")
 	keyEncryptionVerification = []byte("keyEncryptionVerification")
+	keyFrozenOutputs          = []byte("keyFrozenOutputs")
+	keyMultisigConditions     = []byte("keyMultisigConditions")
 	keyPrimarySeedFile        = []byte("keyPrimarySeedFile")
 	keyPrimarySeedProgress    = []byte("keyPrimarySeedProgress")
+	keyPurposeSeedProgress    = []byte("keyPurposeSeedProgress")
 	keySiafundPool            = []byte("keySiafundPool")
 	keySpendableKeyFiles      = []byte("keySpendableKeyFiles")
+	keySpendHistory           = []byte("keySpendHistory")
+	keySpendingPolicy         = []byte("keySpendingPolicy")
 	keyUID                    = []byte("keyUID")
+	keyWatchedAddresses       = []byte("keyWatchedAddresses")
+	keyWatchedConditions      = []byte("keyWatchedConditions")
 )
 
 // threadedDBUpdate commits the active database transaction and starts a new
@@ -141,6 +172,12 @@ func dbReset(tx *bolt.Tx) error {
 	wb.Put(keyConsensusHeight, encoding.Marshal(uint64(0)))
 	wb.Put(keyAuxiliarySeedFiles, encoding.Marshal([]seedFile{}))
 	wb.Put(keySpendableKeyFiles, encoding.Marshal([]spendableKeyFile{}))
+	wb.Put(keyWatchedAddresses, encoding.Marshal([]types.UnlockHash{}))
+	wb.Put(keyMultisigConditions, encoding.Marshal([]types.UnlockConditions{}))
+	wb.Put(keyWatchedConditions, encoding.Marshal([]types.UnlockConditions{}))
+	wb.Put(keyFrozenOutputs, encoding.Marshal([]types.OutputID{}))
+	wb.Put(keySpendingPolicy, encoding.Marshal(spendingPolicyFile{}))
+	wb.Put(keySpendHistory, encoding.Marshal([]spendRecord{}))
 	dbPutConsensusHeight(tx, 0)
 	dbPutConsensusChangeID(tx, modules.ConsensusChangeBeginning)
 	dbPutSiafundPool(tx, types.ZeroCurrency)
@@ -221,6 +258,26 @@ func dbForEachSiafundOutput(tx *bolt.Tx, fn func(types.SiafundOutputID, types.Si
 	return dbForEach(tx.Bucket(bucketSiafundOutputs), fn)
 }
 
+func dbPutWatchedSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID, output types.SiacoinOutput) error {
+	return dbPut(tx.Bucket(bucketWatchedSiacoinOutputs), id, output)
+}
+func dbDeleteWatchedSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) error {
+	return dbDelete(tx.Bucket(bucketWatchedSiacoinOutputs), id)
+}
+func dbForEachWatchedSiacoinOutput(tx *bolt.Tx, fn func(types.SiacoinOutputID, types.SiacoinOutput)) error {
+	return dbForEach(tx.Bucket(bucketWatchedSiacoinOutputs), fn)
+}
+
+func dbPutWatchedSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID, output types.SiafundOutput) error {
+	return dbPut(tx.Bucket(bucketWatchedSiafundOutputs), id, output)
+}
+func dbDeleteWatchedSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID) error {
+	return dbDelete(tx.Bucket(bucketWatchedSiafundOutputs), id)
+}
+func dbForEachWatchedSiafundOutput(tx *bolt.Tx, fn func(types.SiafundOutputID, types.SiafundOutput)) error {
+	return dbForEach(tx.Bucket(bucketWatchedSiafundOutputs), fn)
+}
+
 func dbPutSpentOutput(tx *bolt.Tx, id types.OutputID, height types.BlockHeight) error {
 	return dbPut(tx.Bucket(bucketSpentOutputs), id, height)
 }
@@ -232,6 +289,52 @@ func dbDeleteSpentOutput(tx *bolt.Tx, id types.OutputID) error {
 	return dbDelete(tx.Bucket(bucketSpentOutputs), id)
 }
 
+func dbPutOutputHeight(tx *bolt.Tx, id types.OutputID, height types.BlockHeight) error {
+	return dbPut(tx.Bucket(bucketOutputHeights), id, height)
+}
+func dbGetOutputHeight(tx *bolt.Tx, id types.OutputID) (height types.BlockHeight, err error) {
+	err = dbGet(tx.Bucket(bucketOutputHeights), id, &height)
+	return
+}
+func dbDeleteOutputHeight(tx *bolt.Tx, id types.OutputID) error {
+	return dbDelete(tx.Bucket(bucketOutputHeights), id)
+}
+
+// dbPutProcessedOutputHeights stores the confirmation height of the outputs
+// created by pt. Miner fees are not outputs that can be spent, so they are
+// skipped.
+func dbPutProcessedOutputHeights(tx *bolt.Tx, pt modules.ProcessedTransaction) error {
+	for _, po := range pt.Outputs {
+		if po.FundType == types.SpecifierMinerFee {
+			continue
+		}
+		if err := dbPutOutputHeight(tx, po.ID, pt.ConfirmationHeight); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dbPutAddressLabel(tx *bolt.Tx, addr types.UnlockHash, label string) error {
+	return dbPut(tx.Bucket(bucketAddressLabels), addr, label)
+}
+func dbDeleteAddressLabel(tx *bolt.Tx, addr types.UnlockHash) error {
+	return dbDelete(tx.Bucket(bucketAddressLabels), addr)
+}
+func dbForEachAddressLabel(tx *bolt.Tx, fn func(types.UnlockHash, string)) error {
+	return dbForEach(tx.Bucket(bucketAddressLabels), fn)
+}
+
+func dbPutTransactionLabel(tx *bolt.Tx, txid types.TransactionID, label string) error {
+	return dbPut(tx.Bucket(bucketTransactionLabels), txid, label)
+}
+func dbDeleteTransactionLabel(tx *bolt.Tx, txid types.TransactionID) error {
+	return dbDelete(tx.Bucket(bucketTransactionLabels), txid)
+}
+func dbForEachTransactionLabel(tx *bolt.Tx, fn func(types.TransactionID, string)) error {
+	return dbForEach(tx.Bucket(bucketTransactionLabels), fn)
+}
+
 func dbPutAddrTransactions(tx *bolt.Tx, addr types.UnlockHash, txns []uint64) error {
 	return dbPut(tx.Bucket(bucketAddrTransactions), addr, txns)
 }
@@ -342,6 +445,11 @@ func dbAppendProcessedTransaction(tx *bolt.Tx, pt modules.ProcessedTransaction)
 	if err = dbAddProcessedTransactionAddrs(tx, pt, key); err != nil {
 		return errors.AddContext(err, "failed to add processed transaction to addresses in database")
 	}
+
+	// record the confirmation height of the created outputs
+	if err = dbPutProcessedOutputHeights(tx, pt); err != nil {
+		return errors.AddContext(err, "failed to store output heights in database")
+	}
 	return nil
 }
 
@@ -356,7 +464,15 @@ func dbDeleteLastProcessedTransaction(tx *bolt.Tx) error {
 	// decrement the sequence integer; we only care that the next integer is
 	// larger than the previous one.
 	b := tx.Bucket(bucketProcessedTransactions)
-	key, _ := b.Cursor().Last()
+	key, val := b.Cursor().Last()
+	var pt modules.ProcessedTransaction
+	if err := decodeProcessedTransaction(val, &pt); err == nil {
+		for _, po := range pt.Outputs {
+			if err := dbDeleteOutputHeight(tx, po.ID); err != nil {
+				return err
+			}
+		}
+	}
 	return b.Delete(key)
 }
 
@@ -429,6 +545,35 @@ func dbPutPrimarySeedProgress(tx *bolt.Tx, progress uint64) error {
 	return tx.Bucket(bucketWallet).Put(keyPrimarySeedProgress, encoding.Marshal(progress))
 }
 
+// purposeSeedProgressKey returns the key in bucketWallet that stores the seed
+// progress of purpose.
+func purposeSeedProgressKey(purpose modules.AddressPurpose) []byte {
+	return []byte(string(keyPurposeSeedProgress) + string(purpose))
+}
+
+// dbGetPurposeSeedProgress returns the number of keys generated from the
+// primary seed in the derivation namespace of purpose. The progress of
+// PurposeDefault is the primary seed progress.
+func dbGetPurposeSeedProgress(tx *bolt.Tx, purpose modules.AddressPurpose) (progress uint64, err error) {
+	if purpose == modules.PurposeDefault {
+		return dbGetPrimarySeedProgress(tx)
+	}
+	progressBytes := tx.Bucket(bucketWallet).Get(purposeSeedProgressKey(purpose))
+	if progressBytes == nil {
+		return 0, nil
+	}
+	err = encoding.Unmarshal(progressBytes, &progress)
+	return
+}
+
+// dbPutPurposeSeedProgress sets the seed progress counter of purpose.
+func dbPutPurposeSeedProgress(tx *bolt.Tx, purpose modules.AddressPurpose, progress uint64) error {
+	if purpose == modules.PurposeDefault {
+		return dbPutPrimarySeedProgress(tx, progress)
+	}
+	return tx.Bucket(bucketWallet).Put(purposeSeedProgressKey(purpose), encoding.Marshal(progress))
+}
+
 // dbGetConsensusChangeID returns the ID of the last ConsensusChange processed by the wallet.
 func dbGetConsensusChangeID(tx *bolt.Tx) (cc modules.ConsensusChangeID) {
 	copy(cc[:], tx.Bucket(bucketWallet).Get(keyConsensusChange))
@@ -451,6 +596,80 @@ func dbPutConsensusHeight(tx *bolt.Tx, height types.BlockHeight) error {
 	return tx.Bucket(bucketWallet).Put(keyConsensusHeight, encoding.Marshal(height))
 }
 
+// dbGetWatchedAddresses returns the watch-only addresses of the wallet.
+func dbGetWatchedAddresses(tx *bolt.Tx) (addrs []types.UnlockHash, err error) {
+	err = encoding.Unmarshal(tx.Bucket(bucketWallet).Get(keyWatchedAddresses), &addrs)
+	return
+}
+
+// dbPutWatchedAddresses stores the watch-only addresses of the wallet.
+func dbPutWatchedAddresses(tx *bolt.Tx, addrs []types.UnlockHash) error {
+	return tx.Bucket(bucketWallet).Put(keyWatchedAddresses, encoding.Marshal(addrs))
+}
+
+// dbGetMultisigConditions returns the UnlockConditions of the multisig
+// addresses tracked by the wallet.
+func dbGetMultisigConditions(tx *bolt.Tx) (ucs []types.UnlockConditions, err error) {
+	err = encoding.Unmarshal(tx.Bucket(bucketWallet).Get(keyMultisigConditions), &ucs)
+	return
+}
+
+// dbPutMultisigConditions stores the UnlockConditions of the multisig
+// addresses tracked by the wallet.
+func dbPutMultisigConditions(tx *bolt.Tx, ucs []types.UnlockConditions) error {
+	return tx.Bucket(bucketWallet).Put(keyMultisigConditions, encoding.Marshal(ucs))
+}
+
+// dbGetWatchedConditions returns the UnlockConditions of the watch-only
+// addresses that were added along with them.
+func dbGetWatchedConditions(tx *bolt.Tx) (ucs []types.UnlockConditions, err error) {
+	err = encoding.Unmarshal(tx.Bucket(bucketWallet).Get(keyWatchedConditions), &ucs)
+	return
+}
+
+// dbPutWatchedConditions stores the UnlockConditions of the watch-only
+// addresses that were added along with them.
+func dbPutWatchedConditions(tx *bolt.Tx, ucs []types.UnlockConditions) error {
+	return tx.Bucket(bucketWallet).Put(keyWatchedConditions, encoding.Marshal(ucs))
+}
+
+// dbGetFrozenOutputs returns the ids of the outputs that the wallet will not
+// spend automatically.
+func dbGetFrozenOutputs(tx *bolt.Tx) (ids []types.OutputID, err error) {
+	err = encoding.Unmarshal(tx.Bucket(bucketWallet).Get(keyFrozenOutputs), &ids)
+	return
+}
+
+// dbPutFrozenOutputs stores the ids of the outputs that the wallet will not
+// spend automatically.
+func dbPutFrozenOutputs(tx *bolt.Tx, ids []types.OutputID) error {
+	return tx.Bucket(bucketWallet).Put(keyFrozenOutputs, encoding.Marshal(ids))
+}
+
+// dbGetSpendingPolicy returns the wallet's spending policy and send password.
+func dbGetSpendingPolicy(tx *bolt.Tx) (spf spendingPolicyFile, err error) {
+	err = encoding.Unmarshal(tx.Bucket(bucketWallet).Get(keySpendingPolicy), &spf)
+	return
+}
+
+// dbPutSpendingPolicy stores the wallet's spending policy and send password.
+func dbPutSpendingPolicy(tx *bolt.Tx, spf spendingPolicyFile) error {
+	return tx.Bucket(bucketWallet).Put(keySpendingPolicy, encoding.Marshal(spf))
+}
+
+// dbGetSpendHistory returns the recent sends that count toward the daily
+// limit of the spending policy.
+func dbGetSpendHistory(tx *bolt.Tx) (records []spendRecord, err error) {
+	err = encoding.Unmarshal(tx.Bucket(bucketWallet).Get(keySpendHistory), &records)
+	return
+}
+
+// dbPutSpendHistory stores the recent sends that count toward the daily
+// limit of the spending policy.
+func dbPutSpendHistory(tx *bolt.Tx, records []spendRecord) error {
+	return tx.Bucket(bucketWallet).Put(keySpendHistory, encoding.Marshal(records))
+}
+
 // dbGetSiafundPool returns the value of the siafund pool.
 func dbGetSiafundPool(tx *bolt.Tx) (pool types.Currency, err error) {
 	err = encoding.Unmarshal(tx.Bucket(bucketWallet).Get(keySiafundPool), &pool)
@@ -7,6 +7,7 @@ import (
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
 
 	"github.com/coreos/bbolt"
 )
@@ -39,3 +40,48 @@ func TestDBOpen(t *testing.T) {
 	})
 	w.db.Close()
 }
+
+// TestDBOutputHeights checks that the confirmation heights of the outputs of
+// processed transactions are stored and removed along with the transactions.
+func TestDBOutputHeights(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testdir := build.TempDir(modules.WalletDir, t.Name())
+	os.MkdirAll(testdir, 0700)
+	w := new(Wallet)
+	if err := w.openDB(filepath.Join(testdir, dbFile)); err != nil {
+		t.Fatal(err)
+	}
+	defer w.db.Close()
+
+	pt := modules.ProcessedTransaction{
+		TransactionID:      types.TransactionID{1},
+		ConfirmationHeight: 7,
+		Outputs: []modules.ProcessedOutput{
+			{ID: types.OutputID{2}, FundType: types.SpecifierSiacoinOutput},
+			{ID: types.OutputID{3}, FundType: types.SpecifierMinerFee},
+		},
+	}
+	err := w.db.Update(func(tx *bolt.Tx) error {
+		if err := dbAppendProcessedTransaction(tx, pt); err != nil {
+			return err
+		}
+		if height, err := dbGetOutputHeight(tx, types.OutputID{2}); err != nil || height != 7 {
+			t.Fatal("wrong output height:", height, err)
+		}
+		if _, err := dbGetOutputHeight(tx, types.OutputID{3}); err != errNoKey {
+			t.Fatal("miner fees should not have a height")
+		}
+		if err := dbDeleteLastProcessedTransaction(tx); err != nil {
+			return err
+		}
+		if _, err := dbGetOutputHeight(tx, types.OutputID{2}); err != errNoKey {
+			t.Fatal("output height should be removed with the transaction")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -107,6 +107,7 @@ func (w *Wallet) managedUnlock(masterKey crypto.TwofishKey) error {
 	var lastChange modules.ConsensusChangeID
 	var primarySeedFile seedFile
 	var primarySeedProgress uint64
+	purposeSeedProgress := make(map[modules.AddressPurpose]uint64)
 	var auxiliarySeedFiles []seedFile
 	var unseededKeyFiles []spendableKeyFile
 	err := func() error {
@@ -132,6 +133,12 @@ func (w *Wallet) managedUnlock(masterKey crypto.TwofishKey) error {
 		if err != nil {
 			return err
 		}
+		for _, purpose := range modules.AddressPurposes {
+			purposeSeedProgress[purpose], err = dbGetPurposeSeedProgress(w.dbTx, purpose)
+			if err != nil {
+				return err
+			}
+		}
 
 		// auxiliarySeedFiles
 		err = encoding.Unmarshal(wb.Get(keyAuxiliarySeedFiles), &auxiliarySeedFiles)
@@ -163,7 +170,11 @@ func (w *Wallet) managedUnlock(masterKey crypto.TwofishKey) error {
 		}
 		w.integrateSeed(primarySeed, primarySeedProgress)
 		w.primarySeed = primarySeed
-		w.regenerateLookahead(primarySeedProgress)
+		w.regenerateLookahead(modules.PurposeDefault, primarySeedProgress)
+		for purpose, progress := range purposeSeedProgress {
+			w.integratePurposeKeys(primarySeed, purpose, progress)
+			w.regenerateLookahead(purpose, progress)
+		}
 
 		// auxiliarySeedFiles
 		for _, sf := range auxiliarySeedFiles {
@@ -172,6 +183,9 @@ func (w *Wallet) managedUnlock(masterKey crypto.TwofishKey) error {
 				return err
 			}
 			w.integrateSeed(auxSeed, modules.PublicKeysPerSeed)
+			for _, purpose := range modules.AddressPurposes {
+				w.integratePurposeKeys(auxSeed, purpose, modules.PublicKeysPerSeed)
+			}
 			w.seeds = append(w.seeds, auxSeed)
 		}
 
@@ -337,6 +351,11 @@ func (w *Wallet) Reset() error {
 	w.wipeSecrets()
 	w.keys = make(map[types.UnlockHash]spendableKey)
 	w.lookahead = make(map[types.UnlockHash]uint64)
+	w.purposeLookahead = newPurposeLookahead()
+	w.watchedAddrs = make(map[types.UnlockHash]struct{})
+	w.multisigConds = make(map[types.UnlockHash]types.UnlockConditions)
+	w.watchedConds = make(map[types.UnlockHash]types.UnlockConditions)
+	w.frozenOutputs = make(map[types.OutputID]struct{})
 	w.seeds = []modules.Seed{}
 	w.unconfirmedProcessedTransactions = []modules.ProcessedTransaction{}
 	w.unlocked = false
@@ -387,7 +406,19 @@ func (w *Wallet) InitFromSeed(masterKey crypto.TwofishKey, seed modules.Seed) er
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	_, err := w.initEncryption(masterKey, seed, progress)
-	return err
+	if err != nil {
+		return err
+	}
+	// the other derivation namespaces are set up the same way
+	for purpose, index := range s.largestPurposeIndexSeen {
+		progress := index + 1
+		progress += progress / 10
+		w.log.Printf("INFO: found %v key index %v in blockchain. Setting its seed progress to %v", purpose, index, progress)
+		if err := dbPutPurposeSeedProgress(w.dbTx, purpose, progress); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Unlocked indicates whether the wallet is locked or unlocked.
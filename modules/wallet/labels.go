@@ -0,0 +1,110 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+const (
+	// maxLabelLen is the maximum length of an address or transaction label,
+	// in bytes.
+	maxLabelLen = 256
+)
+
+var (
+	// errLabelTooLong is returned when a label exceeds maxLabelLen.
+	errLabelTooLong = errors.New("label is too long")
+
+	// errUnknownTransaction is returned when labeling a transaction that is
+	// not in the wallet's history.
+	errUnknownTransaction = errors.New("transaction is not in the wallet's history")
+)
+
+// SetAddressLabel assigns a label to an address. An empty label removes the
+// address's label.
+func (w *Wallet) SetAddressLabel(addr types.UnlockHash, label string) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+	if len(label) > maxLabelLen {
+		return errLabelTooLong
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if label == "" {
+		return dbDeleteAddressLabel(w.dbTx, addr)
+	}
+	return dbPutAddressLabel(w.dbTx, addr, label)
+}
+
+// SetTransactionLabel assigns a label to a confirmed or unconfirmed
+// transaction in the wallet's history. An empty label removes the
+// transaction's label.
+func (w *Wallet) SetTransactionLabel(txid types.TransactionID, label string) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+	if len(label) > maxLabelLen {
+		return errLabelTooLong
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if label == "" {
+		return dbDeleteTransactionLabel(w.dbTx, txid)
+	}
+	if !w.isWalletTransaction(txid) {
+		return errUnknownTransaction
+	}
+	return dbPutTransactionLabel(w.dbTx, txid, label)
+}
+
+// AddressLabels returns the labels that have been assigned to addresses.
+func (w *Wallet) AddressLabels() (labels []modules.AddressLabel, err error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err = dbForEachAddressLabel(w.dbTx, func(addr types.UnlockHash, label string) {
+		labels = append(labels, modules.AddressLabel{Address: addr, Label: label})
+	})
+	return labels, err
+}
+
+// TransactionLabels returns the labels that have been assigned to
+// transactions.
+func (w *Wallet) TransactionLabels() (labels []modules.TransactionLabel, err error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err = dbForEachTransactionLabel(w.dbTx, func(txid types.TransactionID, label string) {
+		labels = append(labels, modules.TransactionLabel{TransactionID: txid, Label: label})
+	})
+	return labels, err
+}
+
+// isWalletTransaction returns true if txid is a confirmed or unconfirmed
+// transaction in the wallet's history.
+func (w *Wallet) isWalletTransaction(txid types.TransactionID) bool {
+	if _, err := dbGetTransactionIndex(w.dbTx, txid); err == nil {
+		return true
+	}
+	for _, upt := range w.unconfirmedProcessedTransactions {
+		if upt.TransactionID == txid {
+			return true
+		}
+	}
+	return false
+}
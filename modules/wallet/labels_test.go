@@ -0,0 +1,88 @@
+package wallet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestLabels tests assigning, listing and removing address and transaction
+// labels, and that labels survive a rescan.
+func TestLabels(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	txns, err := wt.wallet.Transactions(0, ^types.BlockHeight(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) == 0 {
+		t.Fatal("wallet has no transactions")
+	}
+	txid := txns[0].TransactionID
+	var addr types.UnlockHash
+	addr[0] = 1
+
+	// Invalid labels should be rejected.
+	if err := wt.wallet.SetAddressLabel(addr, strings.Repeat("a", maxLabelLen+1)); err != errLabelTooLong {
+		t.Fatal("expected errLabelTooLong, got", err)
+	}
+	if err := wt.wallet.SetTransactionLabel(types.TransactionID{1}, "unknown"); err != errUnknownTransaction {
+		t.Fatal("expected errUnknownTransaction, got", err)
+	}
+
+	// Assign labels and check that they are returned.
+	if err := wt.wallet.SetAddressLabel(addr, "exchange"); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.SetTransactionLabel(txid, "first payout"); err != nil {
+		t.Fatal(err)
+	}
+	addrLabels, err := wt.wallet.AddressLabels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrLabels) != 1 || addrLabels[0].Address != addr || addrLabels[0].Label != "exchange" {
+		t.Fatal("wrong address labels:", addrLabels)
+	}
+	txnLabels, err := wt.wallet.TransactionLabels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txnLabels) != 1 || txnLabels[0].TransactionID != txid || txnLabels[0].Label != "first payout" {
+		t.Fatal("wrong transaction labels:", txnLabels)
+	}
+
+	// Labels are kept when the transaction history is rebuilt.
+	if err := wt.wallet.AddWatchAddresses([]types.UnlockHash{{2}}, false); err != nil {
+		t.Fatal(err)
+	}
+	txnLabels, err = wt.wallet.TransactionLabels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txnLabels) != 1 {
+		t.Fatal("transaction label was lost during rescan")
+	}
+
+	// An empty label removes the label.
+	if err := wt.wallet.SetAddressLabel(addr, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.SetTransactionLabel(txid, ""); err != nil {
+		t.Fatal(err)
+	}
+	addrLabels, _ = wt.wallet.AddressLabels()
+	txnLabels, _ = wt.wallet.TransactionLabels()
+	if len(addrLabels) != 0 || len(txnLabels) != 0 {
+		t.Fatal("labels were not removed")
+	}
+}
@@ -82,8 +82,97 @@ func (w *Wallet) UnconfirmedBalance() (outgoingSiacoins types.Currency, incoming
 	return
 }
 
+// UnspentOutputs returns the confirmed siacoin and siafund outputs that are
+// owned or watched by the wallet. Outputs that belong to watch-only addresses
+// are marked as such.
+func (w *Wallet) UnspentOutputs() ([]modules.UnspentOutput, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer w.tg.Done()
+
+	// dustThreshold has to be obtained separate from the lock
+	dustThreshold := w.DustThreshold()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// ensure durability of reported outputs
+	w.syncDB()
+
+	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []modules.UnspentOutput
+	addSiacoinOutput := func(watchOnly bool) func(types.SiacoinOutputID, types.SiacoinOutput) {
+		return func(id types.SiacoinOutputID, sco types.SiacoinOutput) {
+			uo := modules.UnspentOutput{
+				ID:                 types.OutputID(id),
+				FundType:           types.SpecifierSiacoinOutput,
+				UnlockHash:         sco.UnlockHash,
+				Value:              sco.Value,
+				WatchOnly:          watchOnly,
+				ConfirmationHeight: w.outputHeight(types.OutputID(id)),
+			}
+			err := w.checkOutput(w.dbTx, consensusHeight, id, sco, dustThreshold)
+			uo.Dust = sco.Value.Cmp(dustThreshold) < 0
+			uo.Pending = w.isPendingOutput(types.OutputID(id), consensusHeight)
+			_, uo.Frozen = w.frozenOutputs[uo.ID]
+			uo.Spendable = err == nil && !watchOnly
+			outputs = append(outputs, uo)
+		}
+	}
+	addSiafundOutput := func(watchOnly bool) func(types.SiafundOutputID, types.SiafundOutput) {
+		return func(id types.SiafundOutputID, sfo types.SiafundOutput) {
+			uo := modules.UnspentOutput{
+				ID:                 types.OutputID(id),
+				FundType:           types.SpecifierSiafundOutput,
+				UnlockHash:         sfo.UnlockHash,
+				Value:              sfo.Value,
+				WatchOnly:          watchOnly,
+				ConfirmationHeight: w.outputHeight(types.OutputID(id)),
+			}
+			uo.Pending = w.isPendingOutput(types.OutputID(id), consensusHeight)
+			_, uo.Frozen = w.frozenOutputs[uo.ID]
+			timelocked := consensusHeight < w.keys[sfo.UnlockHash].UnlockConditions.Timelock
+			uo.Spendable = !watchOnly && !uo.Pending && !uo.Frozen && !timelocked
+			outputs = append(outputs, uo)
+		}
+	}
+	if err := dbForEachSiacoinOutput(w.dbTx, addSiacoinOutput(false)); err != nil {
+		return nil, err
+	}
+	if err := dbForEachWatchedSiacoinOutput(w.dbTx, addSiacoinOutput(true)); err != nil {
+		return nil, err
+	}
+	if err := dbForEachSiafundOutput(w.dbTx, addSiafundOutput(false)); err != nil {
+		return nil, err
+	}
+	if err := dbForEachWatchedSiafundOutput(w.dbTx, addSiafundOutput(true)); err != nil {
+		return nil, err
+	}
+	return outputs, nil
+}
+
+// outputHeight returns the height at which the output was confirmed, or zero
+// if it is unknown.
+func (w *Wallet) outputHeight(id types.OutputID) types.BlockHeight {
+	height, _ := dbGetOutputHeight(w.dbTx, id)
+	return height
+}
+
+// isPendingOutput returns true if the output was recently spent by the wallet
+// in a transaction that has not been confirmed yet.
+func (w *Wallet) isPendingOutput(id types.OutputID, consensusHeight types.BlockHeight) bool {
+	spendHeight, err := dbGetSpentOutput(w.dbTx, id)
+	return err == nil && spendHeight+RespendTimeout > consensusHeight
+}
+
 // SendSiacoins creates a transaction sending 'amount' to 'dest'. The transaction
-// is submitted to the transaction pool and is also returned.
+// is submitted to the transaction pool and is also returned. The send must
+// comply with the wallet's spending policy, and fails if a send password is
+// set.
 func (w *Wallet) SendSiacoins(amount types.Currency, dest types.UnlockHash) (txns []types.Transaction, err error) {
 	if err := w.tg.Add(); err != nil {
 		return nil, err
@@ -98,17 +187,23 @@ func (w *Wallet) SendSiacoins(amount types.Currency, dest types.UnlockHash) (txn
 		return nil, modules.ErrLockedWallet
 	}
 
-	_, tpoolFee := w.tpool.FeeEstimation()
+	tpoolFee := w.tpool.FeeEstimate(sendFeeTarget)
 	tpoolFee = tpoolFee.Mul64(750) // Estimated transaction size in bytes
 	output := types.SiacoinOutput{
 		Value:      amount,
 		UnlockHash: dest,
 	}
+	spend, err := w.managedAuthorizeSend([]types.SiacoinOutput{output}, tpoolFee, "")
+	if err != nil {
+		w.log.Println("Attempt to send coins has failed - refused by the spending policy:", err)
+		return nil, err
+	}
 
 	txnBuilder := w.StartTransaction()
 	defer func() {
 		if err != nil {
 			txnBuilder.Drop()
+			w.managedUndoSpend(spend)
 		}
 	}()
 	err = txnBuilder.FundSiacoins(amount.Add(tpoolFee))
@@ -140,7 +235,7 @@ func (w *Wallet) SendSiacoins(amount types.Currency, dest types.UnlockHash) (txn
 
 // SendSiacoinsMulti creates a transaction that includes the specified
 // outputs. The transaction is submitted to the transaction pool and is also
-// returned.
+// returned. Like SendSiacoins, it is subject to the wallet's spending policy.
 func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.Transaction, err error) {
 	w.log.Println("Beginning call to SendSiacoinsMulti")
 	if err := w.tg.Add(); err != nil {
@@ -155,17 +250,24 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 		return nil, modules.ErrLockedWallet
 	}
 
+	// Add estimated transaction fee.
+	tpoolFee := w.tpool.FeeEstimate(sendFeeTarget)
+	tpoolFee = tpoolFee.Mul64(2)                              // We don't want send-to-many transactions to fail.
+	tpoolFee = tpoolFee.Mul64(1000 + 60*uint64(len(outputs))) // Estimated transaction size in bytes
+
+	spend, err := w.managedAuthorizeSend(outputs, tpoolFee, "")
+	if err != nil {
+		w.log.Println("Attempt to send coins has failed - refused by the spending policy:", err)
+		return nil, err
+	}
+
 	txnBuilder := w.StartTransaction()
 	defer func() {
 		if err != nil {
 			txnBuilder.Drop()
+			w.managedUndoSpend(spend)
 		}
 	}()
-
-	// Add estimated transaction fee.
-	_, tpoolFee := w.tpool.FeeEstimation()
-	tpoolFee = tpoolFee.Mul64(2)                              // We don't want send-to-many transactions to fail.
-	tpoolFee = tpoolFee.Mul64(1000 + 60*uint64(len(outputs))) // Estimated transaction size in bytes
 	txnBuilder.AddMinerFee(tpoolFee)
 
 	// Calculate total cost to wallet.
@@ -211,8 +313,10 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 }
 
 // SendSiafunds creates a transaction sending 'amount' to 'dest'. The transaction
-// is submitted to the transaction pool and is also returned.
-func (w *Wallet) SendSiafunds(amount types.Currency, dest types.UnlockHash) ([]types.Transaction, error) {
+// is submitted to the transaction pool and is also returned. The send must
+// comply with the wallet's spending policy; the fee counts toward its limits
+// and dest must be in its allowlist.
+func (w *Wallet) SendSiafunds(amount types.Currency, dest types.UnlockHash, sendPassword string) ([]types.Transaction, error) {
 	if err := w.tg.Add(); err != nil {
 		return nil, err
 	}
@@ -224,16 +328,27 @@ func (w *Wallet) SendSiafunds(amount types.Currency, dest types.UnlockHash) ([]t
 		return nil, modules.ErrLockedWallet
 	}
 
-	_, tpoolFee := w.tpool.FeeEstimation()
+	tpoolFee := w.tpool.FeeEstimate(sendFeeTarget)
 	tpoolFee = tpoolFee.Mul64(750) // Estimated transaction size in bytes
 	tpoolFee = tpoolFee.Mul64(5)   // use large fee to ensure siafund transactions are selected by miners
 	output := types.SiafundOutput{
 		Value:      amount,
 		UnlockHash: dest,
 	}
+	spend, err := w.managedAuthorizeSend(siafundDestinations([]types.SiafundOutput{output}), tpoolFee, sendPassword)
+	if err != nil {
+		w.log.Println("Attempt to send siafunds has failed - refused by the spending policy:", err)
+		return nil, err
+	}
 
 	txnBuilder := w.StartTransaction()
-	err := txnBuilder.FundSiacoins(tpoolFee)
+	defer func() {
+		if err != nil {
+			txnBuilder.Drop()
+			w.managedUndoSpend(spend)
+		}
+	}()
+	err = txnBuilder.FundSiacoins(tpoolFee)
 	if err != nil {
 		return nil, err
 	}
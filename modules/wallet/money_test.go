@@ -37,7 +37,7 @@ func TestSendSiacoins(t *testing.T) {
 	// unconfirmed siacoins - incoming unconfirmed siacoins should equal 5000 +
 	// fee.
 	sendValue := types.SiacoinPrecision.Mul64(3)
-	_, tpoolFee := wt.wallet.tpool.FeeEstimation()
+	tpoolFee := wt.wallet.tpool.FeeEstimate(sendFeeTarget)
 	tpoolFee = tpoolFee.Mul64(750)
 	_, err = wt.wallet.SendSiacoins(sendValue, types.UnlockHash{})
 	if err != nil {
@@ -0,0 +1,124 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errMultisigRequired is returned when the number of required signatures
+	// of a multisig address is invalid.
+	errMultisigRequired = errors.New("required signatures must be between 1 and the number of public keys")
+
+	// errMultisigTooFewKeys is returned when creating a multisig address with
+	// fewer than two public keys.
+	errMultisigTooFewKeys = errors.New("a multisig address needs at least two public keys")
+)
+
+// standardUnlockHash returns the address of the standard 1-of-1
+// UnlockConditions for pk. Seed-derived wallet keys are indexed by this
+// address.
+func standardUnlockHash(pk types.SiaPublicKey) types.UnlockHash {
+	return types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{pk},
+		SignaturesRequired: 1,
+	}.UnlockHash()
+}
+
+// cosignerKey returns a spendableKey for a multisig input containing the
+// secret keys in keys that correspond to any of the public keys of uc. The
+// bool is false if none of the public keys are known.
+func cosignerKey(keys map[types.UnlockHash]spendableKey, uc types.UnlockConditions) (spendableKey, bool) {
+	sk := spendableKey{UnlockConditions: uc}
+	for _, pk := range uc.PublicKeys {
+		if key, ok := keys[standardUnlockHash(pk)]; ok {
+			sk.SecretKeys = append(sk.SecretKeys, key.SecretKeys...)
+		}
+	}
+	return sk, len(sk.SecretKeys) > 0
+}
+
+// AddMultisigAddress creates a multisig address from a set of public keys,
+// requiring that 'required' of them sign to spend from it. The wallet tracks
+// the outputs of the address like a watch-only address, and will add its own
+// signatures to transactions spending from it. If unused is true, the address
+// is assumed to have never appeared in the blockchain and no rescan is
+// performed.
+func (w *Wallet) AddMultisigAddress(pubkeys []types.SiaPublicKey, required uint64, unused bool) (types.UnlockConditions, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.UnlockConditions{}, err
+	}
+	defer w.tg.Done()
+
+	if len(pubkeys) < 2 {
+		return types.UnlockConditions{}, errMultisigTooFewKeys
+	}
+	if required == 0 || required > uint64(len(pubkeys)) {
+		return types.UnlockConditions{}, errMultisigRequired
+	}
+	uc := types.UnlockConditions{
+		PublicKeys:         pubkeys,
+		SignaturesRequired: required,
+	}
+
+	if !w.scanLock.TryLock() {
+		return types.UnlockConditions{}, errScanInProgress
+	}
+	defer w.scanLock.Unlock()
+
+	err := func() error {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.unlocked {
+			return modules.ErrLockedWallet
+		}
+
+		addr := uc.UnlockHash()
+		w.multisigConds[addr] = uc
+		w.watchedAddrs[addr] = struct{}{}
+		if err := dbPutMultisigConditions(w.dbTx, w.multisigCondsList()); err != nil {
+			return err
+		}
+		if err := dbPutWatchedAddresses(w.dbTx, w.watchedAddrList()); err != nil {
+			return err
+		}
+		if unused {
+			return nil
+		}
+		return w.resetHistory()
+	}()
+	if err != nil {
+		return types.UnlockConditions{}, err
+	}
+	if !unused {
+		if err := w.managedRescan(); err != nil {
+			return types.UnlockConditions{}, err
+		}
+	}
+	return uc, nil
+}
+
+// MultisigAddresses returns the UnlockConditions of the multisig addresses
+// tracked by the wallet.
+func (w *Wallet) MultisigAddresses() ([]types.UnlockConditions, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer w.tg.Done()
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.multisigCondsList(), nil
+}
+
+// multisigCondsList returns the UnlockConditions of the multisig addresses as
+// a slice.
+func (w *Wallet) multisigCondsList() []types.UnlockConditions {
+	ucs := make([]types.UnlockConditions, 0, len(w.multisigConds))
+	for _, uc := range w.multisigConds {
+		ucs = append(ucs, uc)
+	}
+	return ucs
+}
@@ -0,0 +1,124 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
+)
+
+// TestMultisigCosigning creates a 2-of-3 multisig address shared between the
+// wallet, an offline seed and a third party, and checks that a transaction
+// spending from it can be signed by the wallet and the seed in turn.
+func TestMultisigCosigning(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Collect the public keys of the three cosigners.
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cosignerSeed, thirdSeed modules.Seed
+	fastrand.Read(cosignerSeed[:])
+	fastrand.Read(thirdSeed[:])
+	pubkeys := []types.SiaPublicKey{
+		uc.PublicKeys[0],
+		generateSpendableKey(cosignerSeed, 0).UnlockConditions.PublicKeys[0],
+		generateSpendableKey(thirdSeed, 0).UnlockConditions.PublicKeys[0],
+	}
+
+	// Invalid multisig parameters should be rejected.
+	if _, err := wt.wallet.AddMultisigAddress(pubkeys, 4, true); err != errMultisigRequired {
+		t.Fatal("expected errMultisigRequired, got", err)
+	}
+	if _, err := wt.wallet.AddMultisigAddress(pubkeys[:1], 1, true); err != errMultisigTooFewKeys {
+		t.Fatal("expected errMultisigTooFewKeys, got", err)
+	}
+
+	msuc, err := wt.wallet.AddMultisigAddress(pubkeys, 2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msAddr := msuc.UnlockHash()
+	if ucs, _ := wt.wallet.MultisigAddresses(); len(ucs) != 1 || ucs[0].UnlockHash() != msAddr {
+		t.Fatal("multisig address is not tracked")
+	}
+	if found, err := wt.wallet.UnlockConditions(msAddr); err != nil || found.UnlockHash() != msAddr {
+		t.Fatal("wrong unlock conditions for multisig address:", err)
+	}
+
+	// Fund the multisig address.
+	sendAmount := types.SiacoinPrecision.Mul64(100)
+	if _, err := wt.wallet.SendSiacoins(sendAmount, msAddr); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := wt.miner.FindBlock()
+	if err := wt.cs.AcceptBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	outputs, err := wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msOutput modules.UnspentOutput
+	for _, uo := range outputs {
+		if uo.UnlockHash == msAddr {
+			msOutput = uo
+		}
+	}
+	if !msOutput.WatchOnly || !msOutput.Value.Equals(sendAmount) {
+		t.Fatal("multisig output not tracked correctly:", msOutput)
+	}
+
+	// Build a transaction spending the multisig output.
+	fee := types.SiacoinPrecision
+	txn := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{
+			ParentID:         types.SiacoinOutputID(msOutput.ID),
+			UnlockConditions: msuc,
+		}},
+		SiacoinOutputs: []types.SiacoinOutput{{
+			Value:      msOutput.Value.Sub(fee),
+			UnlockHash: types.UnlockHash{},
+		}},
+		MinerFees: []types.Currency{fee},
+	}
+
+	// The wallet adds one signature, which is not enough.
+	if err := wt.wallet.SignTransaction(&txn, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(txn.TransactionSignatures) != 1 {
+		t.Fatal("expected one signature, got", len(txn.TransactionSignatures))
+	}
+	if err := wt.tpool.AcceptTransactionSet([]types.Transaction{txn}); err == nil {
+		t.Fatal("half-signed transaction should not be accepted")
+	}
+
+	// Signing again with the wallet should not add a duplicate signature.
+	if err := wt.wallet.SignTransaction(&txn, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(txn.TransactionSignatures) != 1 {
+		t.Fatal("wallet signed the same input twice")
+	}
+
+	// The offline cosigner completes the transaction.
+	if err := SignTransaction(&txn, cosignerSeed, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(txn.TransactionSignatures) != 2 {
+		t.Fatal("expected two signatures, got", len(txn.TransactionSignatures))
+	}
+	if err := wt.tpool.AcceptTransactionSet([]types.Transaction{txn}); err != nil {
+		t.Fatal(err)
+	}
+}
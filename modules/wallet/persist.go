@@ -46,6 +46,8 @@ func (w *Wallet) openDB(filename string) (err error) {
 	err = w.db.Update(func(tx *bolt.Tx) error {
 		// check whether we need to init bucketAddrTransactions
 		buildAddrTxns := tx.Bucket(bucketAddrTransactions) == nil
+		// check whether we need to init bucketOutputHeights
+		buildOutputHeights := tx.Bucket(bucketOutputHeights) == nil
 		// ensure that all buckets exist
 		for _, b := range dbBuckets {
 			_, err := tx.CreateBucketIfNotExists(b)
@@ -73,6 +75,24 @@ func (w *Wallet) openDB(filename string) (err error) {
 		if wb.Get(keySiafundPool) == nil {
 			wb.Put(keySiafundPool, encoding.Marshal(types.ZeroCurrency))
 		}
+		if wb.Get(keyWatchedAddresses) == nil {
+			wb.Put(keyWatchedAddresses, encoding.Marshal([]types.UnlockHash{}))
+		}
+		if wb.Get(keyMultisigConditions) == nil {
+			wb.Put(keyMultisigConditions, encoding.Marshal([]types.UnlockConditions{}))
+		}
+		if wb.Get(keyWatchedConditions) == nil {
+			wb.Put(keyWatchedConditions, encoding.Marshal([]types.UnlockConditions{}))
+		}
+		if wb.Get(keyFrozenOutputs) == nil {
+			wb.Put(keyFrozenOutputs, encoding.Marshal([]types.OutputID{}))
+		}
+		if wb.Get(keySpendingPolicy) == nil {
+			wb.Put(keySpendingPolicy, encoding.Marshal(spendingPolicyFile{}))
+		}
+		if wb.Get(keySpendHistory) == nil {
+			wb.Put(keySpendHistory, encoding.Marshal([]spendRecord{}))
+		}
 
 		// build the bucketAddrTransactions bucket if necessary
 		if buildAddrTxns {
@@ -85,6 +105,16 @@ func (w *Wallet) openDB(filename string) (err error) {
 			}
 		}
 
+		// build the bucketOutputHeights bucket if necessary
+		if buildOutputHeights {
+			it := dbProcessedTransactionsIterator(tx)
+			for it.next() {
+				if err := dbPutProcessedOutputHeights(tx, it.value()); err != nil {
+					return err
+				}
+			}
+		}
+
 		// check whether wallet is encrypted
 		w.encrypted = tx.Bucket(bucketWallet).Get(keyEncryptionVerification) != nil
 		return nil
@@ -205,27 +235,3 @@ func (w *Wallet) convertPersistFrom112To120(dbFilename, compatFilename string) e
 	w.encrypted = true
 	return err
 }
-
-/*
-// LoadBackup loads a backup file from the provided filepath. The backup file
-// primary seed is loaded as an auxiliary seed.
-func (w *Wallet) LoadBackup(masterKey, backupMasterKey crypto.TwofishKey, backupFilepath string) error {
-	if err := w.tg.Add(); err != nil {
-		return err
-	}
-	defer w.tg.Done()
-
-	lockID := w.mu.Lock()
-	defer w.mu.Unlock(lockID)
-
-	// Load all of the seed files, check for duplicates, re-encrypt them (but
-	// keep the UID), and add them to the walletPersist object)
-	var backupPersist walletPersist
-	err := persist.LoadFile(settingsMetadata, &backupPersist, backupFilepath)
-	if err != nil {
-		return err
-	}
-	backupSeeds := append(backupPersist.AuxiliarySeedFiles, backupPersist.PrimarySeedFile)
-	TODO: more
-}
-*/
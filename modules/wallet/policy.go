@@ -0,0 +1,274 @@
+package wallet
+
+import (
+	"crypto/subtle"
+	"time"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
+)
+
+const (
+	// spendWindow is the period covered by the daily limit of the spending
+	// policy.
+	spendWindow = 24 * time.Hour
+)
+
+type (
+	// spendingPolicyFile is the persisted form of the wallet's spending
+	// policy. A zero PasswordHash means that sends do not require a send
+	// password.
+	spendingPolicyFile struct {
+		Policy       modules.SpendingPolicy
+		PasswordSalt [32]byte
+		PasswordHash crypto.Hash
+	}
+
+	// spendRecord records an amount sent by the wallet that counts toward the
+	// daily limit of the spending policy.
+	spendRecord struct {
+		Timestamp types.Timestamp
+		Amount    types.Currency
+	}
+)
+
+// hasPassword returns true if sends require a send password.
+func (spf spendingPolicyFile) hasPassword() bool {
+	return spf.PasswordHash != (crypto.Hash{})
+}
+
+// checkPassword returns an error if password does not match the send
+// password. It always succeeds if no send password is set.
+func (spf spendingPolicyFile) checkPassword(password string) error {
+	if !spf.hasPassword() {
+		return nil
+	}
+	if password == "" {
+		return modules.NewSpendingPolicyViolation("send password is required")
+	}
+	hash := crypto.HashAll(spf.PasswordSalt, password)
+	if subtle.ConstantTimeCompare(hash[:], spf.PasswordHash[:]) != 1 {
+		return modules.NewSpendingPolicyViolation("incorrect send password")
+	}
+	return nil
+}
+
+// SpendingPolicy returns the wallet's spending policy, the amount sent in the
+// last 24 hours that counts toward its daily limit, and whether sends require
+// the send password.
+func (w *Wallet) SpendingPolicy() (policy modules.SpendingPolicy, dailySpent types.Currency, sendPassword bool, err error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.SpendingPolicy{}, types.ZeroCurrency, false, err
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	spf, err := dbGetSpendingPolicy(w.dbTx)
+	if err != nil {
+		return modules.SpendingPolicy{}, types.ZeroCurrency, false, err
+	}
+	dailySpent, err = w.dailySpent()
+	if err != nil {
+		return modules.SpendingPolicy{}, types.ZeroCurrency, false, err
+	}
+	return spf.Policy, dailySpent, spf.hasPassword(), nil
+}
+
+// SetSpendingPolicy replaces the wallet's spending policy. If a send password
+// is set, sendPassword must match it.
+func (w *Wallet) SetSpendingPolicy(policy modules.SpendingPolicy, sendPassword string) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	spf, err := dbGetSpendingPolicy(w.dbTx)
+	if err != nil {
+		return err
+	}
+	if err := spf.checkPassword(sendPassword); err != nil {
+		return err
+	}
+	spf.Policy = policy
+	return dbPutSpendingPolicy(w.dbTx, spf)
+}
+
+// SetSendPassword changes the password required to send siacoins or
+// siafunds, to sign transactions, to bump fees and to change the spending
+// policy from sendPassword to newPassword. An empty
+// newPassword removes the requirement.
+func (w *Wallet) SetSendPassword(sendPassword, newPassword string) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	spf, err := dbGetSpendingPolicy(w.dbTx)
+	if err != nil {
+		return err
+	}
+	if err := spf.checkPassword(sendPassword); err != nil {
+		return err
+	}
+	spf.PasswordSalt = [32]byte{}
+	spf.PasswordHash = crypto.Hash{}
+	if newPassword != "" {
+		fastrand.Read(spf.PasswordSalt[:])
+		spf.PasswordHash = crypto.HashAll(spf.PasswordSalt, newPassword)
+	}
+	return dbPutSpendingPolicy(w.dbTx, spf)
+}
+
+// CheckSendPassword returns an error if sendPassword does not match the
+// wallet's send password. It always succeeds if no send password is set. It is
+// used to guard operations that expose the wallet's seeds, which would
+// otherwise get around the spending policy.
+func (w *Wallet) CheckSendPassword(sendPassword string) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.checkSendPassword(sendPassword)
+}
+
+// dailySpent returns the amount sent in the last 24 hours that counts toward
+// the daily limit of the spending policy.
+func (w *Wallet) dailySpent() (types.Currency, error) {
+	records, err := dbGetSpendHistory(w.dbTx)
+	if err != nil {
+		return types.ZeroCurrency, err
+	}
+	cutoff := types.Timestamp(time.Now().Add(-spendWindow).Unix())
+	var spent types.Currency
+	for _, r := range records {
+		if r.Timestamp > cutoff {
+			spent = spent.Add(r.Amount)
+		}
+	}
+	return spent, nil
+}
+
+// checkSendPassword returns a SpendingPolicyViolation if a send password is
+// set and sendPassword does not match it.
+func (w *Wallet) checkSendPassword(sendPassword string) error {
+	spf, err := dbGetSpendingPolicy(w.dbTx)
+	if err != nil {
+		return err
+	}
+	return spf.checkPassword(sendPassword)
+}
+
+// authorizeSpend checks that sending outputs and paying fee complies with the
+// limits and allowlist of the wallet's spending policy, returning a
+// SpendingPolicyViolation if it does not. Outputs sent to the wallet's own
+// addresses are always allowed and do not count toward the limits. If the
+// send is allowed and record is set, it is added to the spend history, and
+// the record is returned so that it can be removed if the send fails.
+func (w *Wallet) authorizeSpend(outputs []types.SiacoinOutput, fee types.Currency, record bool) (spendRecord, error) {
+	spf, err := dbGetSpendingPolicy(w.dbTx)
+	if err != nil {
+		return spendRecord{}, err
+	}
+	policy := spf.Policy
+
+	allowed := make(map[types.UnlockHash]struct{})
+	for _, addr := range policy.Allowlist {
+		allowed[addr] = struct{}{}
+	}
+	amount := fee
+	for _, sco := range outputs {
+		if w.isWalletAddress(sco.UnlockHash) {
+			continue
+		}
+		if _, ok := allowed[sco.UnlockHash]; !ok && len(policy.Allowlist) != 0 {
+			return spendRecord{}, modules.NewSpendingPolicyViolation("address " + sco.UnlockHash.String() + " is not in the allowlist")
+		}
+		amount = amount.Add(sco.Value)
+	}
+
+	if !policy.TransactionLimit.IsZero() && amount.Cmp(policy.TransactionLimit) > 0 {
+		return spendRecord{}, modules.NewSpendingPolicyViolation("sending " + amount.HumanString() + " exceeds the transaction limit of " + policy.TransactionLimit.HumanString())
+	}
+	dailySpent, err := w.dailySpent()
+	if err != nil {
+		return spendRecord{}, err
+	}
+	if !policy.DailyLimit.IsZero() && dailySpent.Add(amount).Cmp(policy.DailyLimit) > 0 {
+		return spendRecord{}, modules.NewSpendingPolicyViolation("sending " + amount.HumanString() + " exceeds the daily limit of " + policy.DailyLimit.HumanString() + "; " + dailySpent.HumanString() + " has been sent in the last 24 hours")
+	}
+
+	r := spendRecord{Timestamp: types.CurrentTimestamp(), Amount: amount}
+	if !record {
+		return r, nil
+	}
+	records, err := dbGetSpendHistory(w.dbTx)
+	if err != nil {
+		return spendRecord{}, err
+	}
+	// Drop the records that no longer count toward the daily limit.
+	cutoff := types.Timestamp(time.Now().Add(-spendWindow).Unix())
+	kept := records[:0]
+	for _, old := range records {
+		if old.Timestamp > cutoff {
+			kept = append(kept, old)
+		}
+	}
+	return r, dbPutSpendHistory(w.dbTx, append(kept, r))
+}
+
+// siafundDestinations returns a zero-value siacoin output for the destination
+// of each of sfos, so that authorizeSpend checks siafund sends against the
+// allowlist without counting them toward the siacoin limits.
+func siafundDestinations(sfos []types.SiafundOutput) []types.SiacoinOutput {
+	outputs := make([]types.SiacoinOutput, len(sfos))
+	for i, sfo := range sfos {
+		outputs[i] = types.SiacoinOutput{Value: types.ZeroCurrency, UnlockHash: sfo.UnlockHash}
+	}
+	return outputs
+}
+
+// managedAuthorizeSend checks sendPassword and calls authorizeSpend,
+// recording the send.
+func (w *Wallet) managedAuthorizeSend(outputs []types.SiacoinOutput, fee types.Currency, sendPassword string) (spendRecord, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.checkSendPassword(sendPassword); err != nil {
+		return spendRecord{}, err
+	}
+	return w.authorizeSpend(outputs, fee, true)
+}
+
+// undoSpend removes r from the spend history after the send it records has
+// failed.
+func (w *Wallet) undoSpend(r spendRecord) error {
+	records, err := dbGetSpendHistory(w.dbTx)
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		if records[i].Timestamp == r.Timestamp && records[i].Amount.Equals(r.Amount) {
+			records = append(records[:i], records[i+1:]...)
+			break
+		}
+	}
+	return dbPutSpendHistory(w.dbTx, records)
+}
+
+// managedUndoSpend calls undoSpend.
+func (w *Wallet) managedUndoSpend(r spendRecord) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.undoSpend(r); err != nil {
+		w.log.Println("ERROR: failed to remove a failed send from the spend history:", err)
+	}
+}
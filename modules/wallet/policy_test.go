@@ -0,0 +1,189 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestSpendingPolicy tests that sends are refused when they exceed the limits
+// of the spending policy, go to addresses outside of the allowlist, or lack
+// the send password.
+func TestSpendingPolicy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	var dest, other types.UnlockHash
+	dest[0] = 1
+	other[0] = 2
+	fee := types.SiacoinPrecision
+	send := func(amount types.Currency, addr types.UnlockHash, password string) error {
+		_, _, err := wt.wallet.SendSiacoinsWithOptions([]types.SiacoinOutput{{Value: amount, UnlockHash: addr}}, modules.SendOptions{
+			Fee:          fee,
+			SendPassword: password,
+		})
+		return err
+	}
+	isViolation := func(err error) bool {
+		_, ok := err.(modules.SpendingPolicyViolation)
+		return ok
+	}
+
+	policy := modules.SpendingPolicy{
+		TransactionLimit: types.SiacoinPrecision.Mul64(100),
+		DailyLimit:       types.SiacoinPrecision.Mul64(150),
+		Allowlist:        []types.UnlockHash{dest},
+	}
+	if err := wt.wallet.SetSpendingPolicy(policy, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// The fee counts toward the transaction limit.
+	if err := send(types.SiacoinPrecision.Mul64(100), dest, ""); !isViolation(err) {
+		t.Fatal("expected a spending policy violation, got", err)
+	}
+	if err := send(types.SiacoinPrecision.Mul64(10), other, ""); !isViolation(err) {
+		t.Fatal("expected a spending policy violation, got", err)
+	}
+	if err := send(types.SiacoinPrecision.Mul64(99), dest, ""); err != nil {
+		t.Fatal(err)
+	}
+	// Dry runs are checked, but do not count toward the daily limit.
+	_, _, err = wt.wallet.SendSiacoinsWithOptions([]types.SiacoinOutput{{Value: types.SiacoinPrecision.Mul64(49), UnlockHash: dest}}, modules.SendOptions{Fee: fee, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := send(types.SiacoinPrecision.Mul64(50), dest, ""); !isViolation(err) {
+		t.Fatal("expected a spending policy violation, got", err)
+	}
+	_, dailySpent, _, err := wt.wallet.SpendingPolicy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dailySpent.Equals(types.SiacoinPrecision.Mul64(100)) {
+		t.Fatal("wrong amount spent today:", dailySpent)
+	}
+
+	// Sends to the wallet's own addresses are not limited.
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(1000), uc.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once a send password is set, it is required to send and to change the
+	// policy.
+	if err := wt.wallet.SetSendPassword("", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.SetSpendingPolicy(modules.SpendingPolicy{}, "wrong"); !isViolation(err) {
+		t.Fatal("expected a spending policy violation, got", err)
+	}
+	if err := wt.wallet.SetSpendingPolicy(modules.SpendingPolicy{}, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.SendSiacoins(types.SiacoinPrecision, dest); !isViolation(err) {
+		t.Fatal("expected a spending policy violation, got", err)
+	}
+	if err := send(types.SiacoinPrecision.Mul64(200), other, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.SetSendPassword("secret", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, sendPassword, _ := wt.wallet.SpendingPolicy(); sendPassword {
+		t.Fatal("send password was not removed")
+	}
+}
+
+// TestSendPasswordRequired tests that signing transactions, sending siafunds
+// and bumping fees require the send password once one is set.
+func TestSendPasswordRequired(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+	err = wt.wallet.LoadSiagKeys(wt.walletMasterKey, []string{"../../types/siag0of1of1.siakey"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	isViolation := func(err error) bool {
+		_, ok := err.(modules.SpendingPolicyViolation)
+		return ok
+	}
+
+	var dest types.UnlockHash
+	dest[0] = 1
+	fee := types.SiacoinPrecision
+	txns, _, err := wt.wallet.SendSiacoinsWithOptions([]types.SiacoinOutput{{Value: types.SiacoinPrecision.Mul64(100), UnlockHash: dest}}, modules.SendOptions{Fee: fee})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.SetSendPassword("", "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Signing.
+	var uo modules.UnspentOutput
+	outputs, err := wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range outputs {
+		if o.FundType == types.SpecifierSiacoinOutput && !o.WatchOnly && o.ConfirmationHeight > 0 {
+			uo = o
+			break
+		}
+	}
+	txn, err := buildUnsignedTransaction(wt, uo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.SignTransaction(&txn, nil, ""); !isViolation(err) {
+		t.Fatal("expected a spending policy violation, got", err)
+	}
+	if len(txn.TransactionSignatures) != 0 {
+		t.Fatal("transaction was signed despite the violation")
+	}
+	if err := wt.wallet.SignTransaction(&txn, nil, "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sending siafunds.
+	if _, err := wt.wallet.SendSiafunds(types.NewCurrency64(12), dest, "wrong"); !isViolation(err) {
+		t.Fatal("expected a spending policy violation, got", err)
+	}
+	if _, err := wt.wallet.SendSiafunds(types.NewCurrency64(12), dest, "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Bumping a fee.
+	if _, err := wt.wallet.BumpFee(txns[0].ID(), fee.Mul64(2), ""); !isViolation(err) {
+		t.Fatal("expected a spending policy violation, got", err)
+	}
+	if _, err := wt.wallet.BumpFee(txns[0].ID(), fee.Mul64(2), "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The allowlist applies to siafund destinations as well.
+	policy := modules.SpendingPolicy{Allowlist: []types.UnlockHash{dest}}
+	if err := wt.wallet.SetSpendingPolicy(policy, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.SendSiafunds(types.NewCurrency64(1), types.UnlockHash{2}, "secret"); !isViolation(err) {
+		t.Fatal("expected a spending policy violation, got", err)
+	}
+}
@@ -43,14 +43,40 @@ var numInitialKeys = func() uint64 {
 	}
 }()
 
+// numInitialPurposeKeys is the number of keys generated by the seedScanner
+// in each derivation namespace other than modules.PurposeDefault before
+// scanning the blockchain for the first time. Far fewer keys are needed than
+// for the default namespace, because the modules use far fewer addresses than
+// the wallet itself; namespaces that turn out to be used more are grown
+// according to the largest index found in them.
+var numInitialPurposeKeys = func() uint64 {
+	switch build.Release {
+	case "dev":
+		return 1e3
+	case "standard":
+		return 10e3
+	case "testing":
+		return 100
+	default:
+		panic("unrecognized build.Release")
+	}
+}()
+
 // A scannedOutput is an output found in the blockchain that was generated
 // from a given seed.
 type scannedOutput struct {
 	id        types.OutputID
 	value     types.Currency
+	purpose   modules.AddressPurpose
 	seedIndex uint64
 }
 
+// A purposeIndex is the position of a key in a derivation namespace of a seed.
+type purposeIndex struct {
+	purpose modules.AddressPurpose
+	index   uint64
+}
+
 // A seedScanner scans the blockchain for addresses that belong to a given
 // seed.
 type seedScanner struct {
@@ -61,6 +87,12 @@ type seedScanner struct {
 	siacoinOutputs   map[types.SiacoinOutputID]scannedOutput
 	siafundOutputs   map[types.SiafundOutputID]scannedOutput
 
+	// The keys of the other derivation namespaces are tracked separately, so
+	// that each namespace can grow independently.
+	purposeKeys             map[types.UnlockHash]purposeIndex
+	numPurposeKeys          map[modules.AddressPurpose]uint64
+	largestPurposeIndexSeen map[modules.AddressPurpose]uint64 // only contains purposes that have appeared in the blockchain
+
 	log *persist.Logger
 }
 
@@ -76,23 +108,62 @@ func (s *seedScanner) generateKeys(n uint64) {
 	}
 }
 
+// generatePurposeKeys generates n additional keys from the seedScanner's seed
+// in the derivation namespace of purpose.
+func (s *seedScanner) generatePurposeKeys(purpose modules.AddressPurpose, n uint64) {
+	if purpose == modules.PurposeDefault {
+		s.generateKeys(n)
+		return
+	}
+	initialProgress := s.numPurposeKeys[purpose]
+	for i, k := range generatePurposeKeys(s.seed, purpose, initialProgress, n) {
+		s.purposeKeys[k.UnlockConditions.UnlockHash()] = purposeIndex{purpose, initialProgress + uint64(i)}
+	}
+	s.numPurposeKeys[purpose] = initialProgress + n
+}
+
+// lookup returns the derivation namespace and index of the key with the given
+// unlock hash.
+func (s *seedScanner) lookup(uh types.UnlockHash) (purposeIndex, bool) {
+	if index, exists := s.keys[uh]; exists {
+		return purposeIndex{modules.PurposeDefault, index}, true
+	}
+	pi, exists := s.purposeKeys[uh]
+	return pi, exists
+}
+
+// markSeen updates the largest index seen of the namespace of pi.
+func (s *seedScanner) markSeen(pi purposeIndex) {
+	s.log.Debugln("Seed scanner found a key used at index", pi.index, "for purpose", pi.purpose)
+	if pi.purpose == modules.PurposeDefault {
+		if pi.index > s.largestIndexSeen {
+			s.largestIndexSeen = pi.index
+		}
+		return
+	}
+	if largest, seen := s.largestPurposeIndexSeen[pi.purpose]; !seen || pi.index > largest {
+		s.largestPurposeIndexSeen[pi.purpose] = pi.index
+	}
+}
+
 // ProcessConsensusChange scans the blockchain for information relevant to the
 // seedScanner.
 func (s *seedScanner) ProcessConsensusChange(cc modules.ConsensusChange) {
 	// update outputs
 	for _, diff := range cc.SiacoinOutputDiffs {
 		if diff.Direction == modules.DiffApply {
-			if index, exists := s.keys[diff.SiacoinOutput.UnlockHash]; exists && diff.SiacoinOutput.Value.Cmp(s.dustThreshold) > 0 {
+			if pi, exists := s.lookup(diff.SiacoinOutput.UnlockHash); exists && diff.SiacoinOutput.Value.Cmp(s.dustThreshold) > 0 {
 				s.siacoinOutputs[diff.ID] = scannedOutput{
 					id:        types.OutputID(diff.ID),
 					value:     diff.SiacoinOutput.Value,
-					seedIndex: index,
+					purpose:   pi.purpose,
+					seedIndex: pi.index,
 				}
 			}
 		} else if diff.Direction == modules.DiffRevert {
 			// NOTE: DiffRevert means the output was either spent or was in a
 			// block that was reverted.
-			if _, exists := s.keys[diff.SiacoinOutput.UnlockHash]; exists {
+			if _, exists := s.lookup(diff.SiacoinOutput.UnlockHash); exists {
 				delete(s.siacoinOutputs, diff.ID)
 			}
 		}
@@ -101,39 +172,32 @@ func (s *seedScanner) ProcessConsensusChange(cc modules.ConsensusChange) {
 		if diff.Direction == modules.DiffApply {
 			// do not compare against dustThreshold here; we always want to
 			// sweep every siafund found
-			if index, exists := s.keys[diff.SiafundOutput.UnlockHash]; exists {
+			if pi, exists := s.lookup(diff.SiafundOutput.UnlockHash); exists {
 				s.siafundOutputs[diff.ID] = scannedOutput{
 					id:        types.OutputID(diff.ID),
 					value:     diff.SiafundOutput.Value,
-					seedIndex: index,
+					purpose:   pi.purpose,
+					seedIndex: pi.index,
 				}
 			}
 		} else if diff.Direction == modules.DiffRevert {
 			// NOTE: DiffRevert means the output was either spent or was in a
 			// block that was reverted.
-			if _, exists := s.keys[diff.SiafundOutput.UnlockHash]; exists {
+			if _, exists := s.lookup(diff.SiafundOutput.UnlockHash); exists {
 				delete(s.siafundOutputs, diff.ID)
 			}
 		}
 	}
 
-	// update s.largestIndexSeen
+	// update the largest index seen of each namespace
 	for _, diff := range cc.SiacoinOutputDiffs {
-		index, exists := s.keys[diff.SiacoinOutput.UnlockHash]
-		if exists {
-			s.log.Debugln("Seed scanner found a key used at index", index)
-			if index > s.largestIndexSeen {
-				s.largestIndexSeen = index
-			}
+		if pi, exists := s.lookup(diff.SiacoinOutput.UnlockHash); exists {
+			s.markSeen(pi)
 		}
 	}
 	for _, diff := range cc.SiafundOutputDiffs {
-		index, exists := s.keys[diff.SiafundOutput.UnlockHash]
-		if exists {
-			s.log.Debugln("Seed scanner found a key used at index", index)
-			if index > s.largestIndexSeen {
-				s.largestIndexSeen = index
-			}
+		if pi, exists := s.lookup(diff.SiafundOutput.UnlockHash); exists {
+			s.markSeen(pi)
 		}
 	}
 }
@@ -145,28 +209,53 @@ func (s *seedScanner) scan(cs modules.ConsensusSet, cancel <-chan struct{}) erro
 	// generate a bunch of keys and scan the blockchain looking for them. If
 	// none of the 'upper' half of the generated keys are found, we are done;
 	// otherwise, generate more keys and try again (bounded by a sane
-	// default).
+	// default). Each derivation namespace is grown independently.
 	//
 	// NOTE: since scanning is very slow, we aim to only scan once, which
 	// means generating many keys.
-	var numKeys uint64 = numInitialKeys
-	for s.numKeys() < maxScanKeys {
-		s.generateKeys(numKeys)
+	numKeys := map[modules.AddressPurpose]uint64{modules.PurposeDefault: numInitialKeys}
+	for _, purpose := range modules.AddressPurposes {
+		numKeys[purpose] = numInitialPurposeKeys
+	}
+	for len(numKeys) > 0 {
+		for purpose, n := range numKeys {
+			s.generatePurposeKeys(purpose, n)
+		}
 		if err := cs.ConsensusSetSubscribe(s, modules.ConsensusChangeBeginning, cancel); err != nil {
 			return err
 		}
 		cs.Unsubscribe(s)
-		if s.largestIndexSeen < s.numKeys()/2 {
-			return nil
-		}
-		// increase number of keys generated each iteration, capping so that
-		// we do not exceed maxScanKeys
-		numKeys *= scanMultiplier
-		if numKeys > maxScanKeys-s.numKeys() {
-			numKeys = maxScanKeys - s.numKeys()
+
+		next := make(map[modules.AddressPurpose]uint64)
+		for purpose, n := range numKeys {
+			generated, largest, seen := s.numKeys(), s.largestIndexSeen, true
+			if purpose != modules.PurposeDefault {
+				generated = s.numPurposeKeys[purpose]
+				largest, seen = s.largestPurposeIndexSeen[purpose]
+			}
+			if !seen || largest < generated/2 {
+				continue
+			}
+			if generated >= maxScanKeys {
+				return errMaxKeys
+			}
+			// increase number of keys generated each iteration, capping so
+			// that we do not exceed maxScanKeys. The other derivation
+			// namespaces start with a small lookahead, which is then sized
+			// by the largest index found in that namespace.
+			if purpose == modules.PurposeDefault {
+				n *= scanMultiplier
+			} else {
+				n = (largest+1)*scanMultiplier - generated
+			}
+			if n > maxScanKeys-generated {
+				n = maxScanKeys - generated
+			}
+			next[purpose] = n
 		}
+		numKeys = next
 	}
-	return errMaxKeys
+	return nil
 }
 
 // newSeedScanner returns a new seedScanner.
@@ -177,6 +266,10 @@ func newSeedScanner(seed modules.Seed, log *persist.Logger) *seedScanner {
 		siacoinOutputs: make(map[types.SiacoinOutputID]scannedOutput),
 		siafundOutputs: make(map[types.SiafundOutputID]scannedOutput),
 
+		purposeKeys:             make(map[types.UnlockHash]purposeIndex),
+		numPurposeKeys:          make(map[modules.AddressPurpose]uint64),
+		largestPurposeIndexSeen: make(map[modules.AddressPurpose]uint64),
+
 		log: log,
 	}
 }
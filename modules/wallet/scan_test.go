@@ -34,8 +34,11 @@ func TestScanLargeIndex(t *testing.T) {
 	}
 
 	// set the wallet's seed progress to a high number and then mine some coins.
+	// The miner's payouts use their own derivation namespace, so its progress
+	// is raised as well.
 	wt.wallet.mu.Lock()
 	dbPutPrimarySeedProgress(wt.wallet.dbTx, numInitialKeys+1)
+	dbPutPurposeSeedProgress(wt.wallet.dbTx, modules.PurposeMiner, numInitialPurposeKeys+1)
 	wt.wallet.mu.Unlock()
 	if err != nil {
 		t.Fatal(err)
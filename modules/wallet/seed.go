@@ -14,7 +14,8 @@ import (
 )
 
 var (
-	errKnownSeed = errors.New("seed is already known")
+	errKnownSeed      = errors.New("seed is already known")
+	errUnknownPurpose = errors.New("unknown address purpose")
 )
 
 type (
@@ -44,8 +45,31 @@ func generateSpendableKey(seed modules.Seed, index uint64) spendableKey {
 	}
 }
 
+// generatePurposeKey creates the keys and unlock conditions for seed at a
+// given index in the derivation namespace of purpose. The keys of
+// PurposeDefault are the keys created by generateSpendableKey.
+func generatePurposeKey(seed modules.Seed, purpose modules.AddressPurpose, index uint64) spendableKey {
+	if purpose == modules.PurposeDefault {
+		return generateSpendableKey(seed, index)
+	}
+	sk, pk := crypto.GenerateKeyPairDeterministic(crypto.HashAll(seed, string(purpose), index))
+	return spendableKey{
+		UnlockConditions: types.UnlockConditions{
+			PublicKeys:         []types.SiaPublicKey{types.Ed25519PublicKey(pk)},
+			SignaturesRequired: 1,
+		},
+		SecretKeys: []crypto.SecretKey{sk},
+	}
+}
+
 // generateKeys generates n keys from seed, starting from index start.
 func generateKeys(seed modules.Seed, start, n uint64) []spendableKey {
+	return generatePurposeKeys(seed, modules.PurposeDefault, start, n)
+}
+
+// generatePurposeKeys generates n keys from seed in the derivation namespace
+// of purpose, starting from index start.
+func generatePurposeKeys(seed modules.Seed, purpose modules.AddressPurpose, start, n uint64) []spendableKey {
 	// generate in parallel, one goroutine per core.
 	keys := make([]spendableKey, n)
 	var wg sync.WaitGroup
@@ -57,7 +81,7 @@ func generateKeys(seed modules.Seed, start, n uint64) []spendableKey {
 				// NOTE: don't bother trying to optimize generateSpendableKey;
 				// profiling shows that ed25519 key generation consumes far
 				// more CPU time than encoding or hashing.
-				keys[i] = generateSpendableKey(seed, start+i)
+				keys[i] = generatePurposeKey(seed, purpose, start+i)
 			}
 		}(uint64(cpu))
 	}
@@ -65,6 +89,20 @@ func generateKeys(seed modules.Seed, start, n uint64) []spendableKey {
 	return keys
 }
 
+// validPurpose returns true if purpose is PurposeDefault or one of
+// modules.AddressPurposes.
+func validPurpose(purpose modules.AddressPurpose) bool {
+	if purpose == modules.PurposeDefault {
+		return true
+	}
+	for _, p := range modules.AddressPurposes {
+		if p == purpose {
+			return true
+		}
+	}
+	return false
+}
+
 // createSeedFile creates and encrypts a seedFile.
 func createSeedFile(masterKey crypto.TwofishKey, seed modules.Seed) seedFile {
 	var sf seedFile
@@ -93,51 +131,84 @@ func decryptSeedFile(masterKey crypto.TwofishKey, sf seedFile) (seed modules.See
 	return seed, nil
 }
 
+// newPurposeLookahead returns an empty lookahead for every purpose in
+// modules.AddressPurposes.
+func newPurposeLookahead() map[modules.AddressPurpose]map[types.UnlockHash]uint64 {
+	lookahead := make(map[modules.AddressPurpose]map[types.UnlockHash]uint64)
+	for _, purpose := range modules.AddressPurposes {
+		lookahead[purpose] = make(map[types.UnlockHash]uint64)
+	}
+	return lookahead
+}
+
+// lookaheadFor returns the future keys of the primary seed in the
+// derivation namespace of purpose.
+func (w *Wallet) lookaheadFor(purpose modules.AddressPurpose) map[types.UnlockHash]uint64 {
+	if purpose == modules.PurposeDefault {
+		return w.lookahead
+	}
+	return w.purposeLookahead[purpose]
+}
+
 // regenerateLookahead creates future keys up to a maximum of maxKeys keys
-func (w *Wallet) regenerateLookahead(start uint64) {
+func (w *Wallet) regenerateLookahead(purpose modules.AddressPurpose, start uint64) {
 	// Check how many keys need to be generated
+	lookahead := w.lookaheadFor(purpose)
 	maxKeys := maxLookahead(start)
-	existingKeys := uint64(len(w.lookahead))
+	existingKeys := uint64(len(lookahead))
 
-	for i, k := range generateKeys(w.primarySeed, start+existingKeys, maxKeys-existingKeys) {
-		w.lookahead[k.UnlockConditions.UnlockHash()] = start + existingKeys + uint64(i)
+	for i, k := range generatePurposeKeys(w.primarySeed, purpose, start+existingKeys, maxKeys-existingKeys) {
+		lookahead[k.UnlockConditions.UnlockHash()] = start + existingKeys + uint64(i)
 	}
 }
 
 // integrateSeed generates n spendableKeys from the seed and loads them into
 // the wallet.
 func (w *Wallet) integrateSeed(seed modules.Seed, n uint64) {
-	for _, sk := range generateKeys(seed, 0, n) {
+	w.integratePurposeKeys(seed, modules.PurposeDefault, n)
+}
+
+// integratePurposeKeys generates n spendableKeys from the seed in the
+// derivation namespace of purpose and loads them into the wallet.
+func (w *Wallet) integratePurposeKeys(seed modules.Seed, purpose modules.AddressPurpose, n uint64) {
+	for _, sk := range generatePurposeKeys(seed, purpose, 0, n) {
 		w.keys[sk.UnlockConditions.UnlockHash()] = sk
 	}
 }
 
 // nextPrimarySeedAddress fetches the next n addresses from the primary seed.
 func (w *Wallet) nextPrimarySeedAddresses(tx *bolt.Tx, n uint64) ([]types.UnlockConditions, error) {
+	return w.nextPurposeSeedAddresses(tx, modules.PurposeDefault, n)
+}
+
+// nextPurposeSeedAddresses fetches the next n addresses from the primary seed
+// in the derivation namespace of purpose.
+func (w *Wallet) nextPurposeSeedAddresses(tx *bolt.Tx, purpose modules.AddressPurpose, n uint64) ([]types.UnlockConditions, error) {
 	// Check that the wallet has been unlocked.
 	if !w.unlocked {
 		return []types.UnlockConditions{}, modules.ErrLockedWallet
 	}
 
 	// Fetch and increment the seed progress.
-	progress, err := dbGetPrimarySeedProgress(tx)
+	progress, err := dbGetPurposeSeedProgress(tx, purpose)
 	if err != nil {
 		return []types.UnlockConditions{}, err
 	}
-	if err = dbPutPrimarySeedProgress(tx, progress+n); err != nil {
+	if err = dbPutPurposeSeedProgress(tx, purpose, progress+n); err != nil {
 		return []types.UnlockConditions{}, err
 	}
 	// Integrate the next keys into the wallet, and return the unlock
 	// conditions. Also remove new keys from the future keys and update them
 	// according to new progress
-	spendableKeys := generateKeys(w.primarySeed, progress, n)
+	lookahead := w.lookaheadFor(purpose)
+	spendableKeys := generatePurposeKeys(w.primarySeed, purpose, progress, n)
 	ucs := make([]types.UnlockConditions, 0, len(spendableKeys))
 	for _, spendableKey := range spendableKeys {
 		w.keys[spendableKey.UnlockConditions.UnlockHash()] = spendableKey
-		delete(w.lookahead, spendableKey.UnlockConditions.UnlockHash())
+		delete(lookahead, spendableKey.UnlockConditions.UnlockHash())
 		ucs = append(ucs, spendableKey.UnlockConditions)
 	}
-	w.regenerateLookahead(progress + n)
+	w.regenerateLookahead(purpose, progress+n)
 
 	return ucs, nil
 }
@@ -219,6 +290,28 @@ func (w *Wallet) NextAddress() (types.UnlockConditions, error) {
 	return ucs[0], nil
 }
 
+// NextAddressForPurpose returns an unlock hash that is ready to receive
+// siacoins or siafunds. The address is generated using the primary address
+// seed, in the derivation namespace of purpose.
+func (w *Wallet) NextAddressForPurpose(purpose modules.AddressPurpose) (types.UnlockConditions, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.UnlockConditions{}, err
+	}
+	defer w.tg.Done()
+	if !validPurpose(purpose) {
+		return types.UnlockConditions{}, errUnknownPurpose
+	}
+
+	w.mu.Lock()
+	ucs, err := w.nextPurposeSeedAddresses(w.dbTx, purpose, 1)
+	w.syncDB() // ensure durability of reported address
+	w.mu.Unlock()
+	if err != nil {
+		return types.UnlockConditions{}, err
+	}
+	return ucs[0], nil
+}
+
 // LoadSeed will track all of the addresses generated by the input seed,
 // reclaiming any funds that were lost due to a deleted file or lost encryption
 // key. An error will be returned if the seed has already been integrated with
@@ -290,6 +383,11 @@ func (w *Wallet) LoadSeed(masterKey crypto.TwofishKey, seed modules.Seed) error
 
 		// load the seed's keys
 		w.integrateSeed(seed, seedProgress)
+		for purpose, index := range s.largestPurposeIndexSeen {
+			progress := index + 500
+			progress += progress / 25
+			w.integratePurposeKeys(seed, purpose, progress)
+		}
 		w.seeds = append(w.seeds, seed)
 
 		// delete the set of processed transactions; they will be recreated
@@ -409,7 +507,7 @@ func (w *Wallet) SweepSeed(seed modules.Seed) (coins, funds types.Currency, err
 		var sweptCoins, sweptFunds types.Currency // total values of swept outputs
 		for _, output := range txnSiacoinOutputs {
 			// construct a siacoin input that spends the output
-			sk := generateSpendableKey(seed, output.seedIndex)
+			sk := generatePurposeKey(seed, output.purpose, output.seedIndex)
 			tb.AddSiacoinInput(types.SiacoinInput{
 				ParentID:         types.SiacoinOutputID(output.id),
 				UnlockConditions: sk.UnlockConditions,
@@ -419,7 +517,7 @@ func (w *Wallet) SweepSeed(seed modules.Seed) (coins, funds types.Currency, err
 		}
 		for _, output := range txnSiafundOutputs {
 			// construct a siafund input that spends the output
-			sk := generateSpendableKey(seed, output.seedIndex)
+			sk := generatePurposeKey(seed, output.purpose, output.seedIndex)
 			tb.AddSiafundInput(types.SiafundInput{
 				ParentID:         types.SiafundOutputID(output.id),
 				UnlockConditions: sk.UnlockConditions,
@@ -487,11 +585,11 @@ func (w *Wallet) SweepSeed(seed modules.Seed) (coins, funds types.Currency, err
 		// access to the signing keys)
 		txn, parents := tb.View()
 		for _, output := range txnSiacoinOutputs {
-			sk := generateSpendableKey(seed, output.seedIndex)
+			sk := generatePurposeKey(seed, output.purpose, output.seedIndex)
 			addSignatures(&txn, types.FullCoveredFields, sk.UnlockConditions, crypto.Hash(output.id), sk)
 		}
 		for _, sfo := range txnSiafundOutputs {
-			sk := generateSpendableKey(seed, sfo.seedIndex)
+			sk := generatePurposeKey(seed, sfo.purpose, sfo.seedIndex)
 			addSignatures(&txn, types.FullCoveredFields, sk.UnlockConditions, crypto.Hash(sfo.id), sk)
 		}
 		// Usually, all the inputs will come from swept outputs. However, there is
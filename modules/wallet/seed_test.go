@@ -265,7 +265,7 @@ func TestSweepSeedFunds(t *testing.T) {
 	sk := generateSpendableKey(seed, 1)
 
 	// Send some siafunds to the address.
-	_, err = wt.wallet.SendSiafunds(types.NewCurrency64(12), sk.UnlockConditions.UnlockHash())
+	_, err = wt.wallet.SendSiafunds(types.NewCurrency64(12), sk.UnlockConditions.UnlockHash(), "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -341,14 +341,14 @@ func TestSweepSeedSentFunds(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		_, err = wt.wallet.SendSiafunds(types.NewCurrency64(1), uc.UnlockHash())
+		_, err = wt.wallet.SendSiafunds(types.NewCurrency64(1), uc.UnlockHash(), "")
 		if err != nil {
 			t.Fatal(err)
 		}
 		wt.addBlockNoPayout()
 	}
 	// send some funds to the void
-	_, err = wt.wallet.SendSiafunds(types.NewCurrency64(10), types.UnlockHash{})
+	_, err = wt.wallet.SendSiafunds(types.NewCurrency64(10), types.UnlockHash{}, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -359,7 +359,7 @@ func TestSweepSeedSentFunds(t *testing.T) {
 	sk := generateSpendableKey(seed, 1)
 
 	// Send some siafunds to the address.
-	_, err = wt.wallet.SendSiafunds(types.NewCurrency64(12), sk.UnlockConditions.UnlockHash())
+	_, err = wt.wallet.SendSiafunds(types.NewCurrency64(12), sk.UnlockConditions.UnlockHash(), "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -423,7 +423,7 @@ func TestSweepSeedCoinsAndFunds(t *testing.T) {
 
 	// Send some siafunds to the address.
 	for i := 0; i < 12; i++ {
-		_, err = wt.wallet.SendSiafunds(types.NewCurrency64(1), sk.UnlockConditions.UnlockHash())
+		_, err = wt.wallet.SendSiafunds(types.NewCurrency64(1), sk.UnlockConditions.UnlockHash(), "")
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -477,3 +477,87 @@ func TestGenerateKeys(t *testing.T) {
 		}
 	}
 }
+
+// TestPurposeAddresses tests that each derivation namespace has its own index
+// space, and that the seed scanner finds outputs sent to purpose addresses.
+func TestPurposeAddresses(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[types.UnlockHash]struct{}{uc.UnlockHash(): {}}
+	addrs := make(map[modules.AddressPurpose]types.UnlockHash)
+	for _, purpose := range modules.AddressPurposes {
+		wt.wallet.mu.Lock()
+		progress, err := dbGetPurposeSeedProgress(wt.wallet.dbTx, purpose)
+		wt.wallet.mu.Unlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		uc, err := wt.wallet.NextAddressForPurpose(purpose)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := seen[uc.UnlockHash()]; ok {
+			t.Fatal("purpose", purpose, "returned an address of another namespace")
+		}
+		seen[uc.UnlockHash()] = struct{}{}
+		addrs[purpose] = uc.UnlockHash()
+
+		wt.wallet.mu.Lock()
+		newProgress, err := dbGetPurposeSeedProgress(wt.wallet.dbTx, purpose)
+		wt.wallet.mu.Unlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if newProgress != progress+1 {
+			t.Fatalf("expected %v progress to be %v, got %v", purpose, progress+1, newProgress)
+		}
+		if !wt.wallet.isWalletAddress(uc.UnlockHash()) {
+			t.Fatal("purpose address is not tracked by the wallet")
+		}
+	}
+	if _, err := wt.wallet.NextAddressForPurpose("bogus"); err != errUnknownPurpose {
+		t.Fatal("expected errUnknownPurpose, got", err)
+	}
+
+	// send coins to the renter address and check that the seed scanner finds
+	// them in the renter namespace.
+	_, err = wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(100), addrs[modules.PurposeRenter])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	seed, _, err := wt.wallet.PrimarySeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newSeedScanner(seed, wt.wallet.log)
+	if err := s.scan(wt.cs, wt.wallet.tg.StopChan()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.largestPurposeIndexSeen[modules.PurposeRenter]; !ok {
+		t.Fatal("seed scanner did not find the renter address")
+	}
+	found := false
+	for _, sco := range s.siacoinOutputs {
+		sk := generatePurposeKey(seed, sco.purpose, sco.seedIndex)
+		if sco.purpose == modules.PurposeRenter && sk.UnlockConditions.UnlockHash() == addrs[modules.PurposeRenter] {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("seed scanner did not find the output sent to the renter address")
+	}
+}
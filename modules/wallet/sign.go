@@ -0,0 +1,421 @@
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errNoInputs is returned when asked to sign a transaction that has no
+	// inputs.
+	errNoInputs = errors.New("transaction has no inputs to sign")
+
+	// errUnknownAddress is returned when the wallet does not hold the keys
+	// for an address.
+	errUnknownAddress = errors.New("wallet does not own the requested address")
+)
+
+// inputUnlockConditions returns the UnlockConditions of the siacoin or
+// siafund input in txn that spends the output with the given id.
+func inputUnlockConditions(txn types.Transaction, parentID crypto.Hash) (types.UnlockConditions, bool) {
+	for _, sci := range txn.SiacoinInputs {
+		if crypto.Hash(sci.ParentID) == parentID {
+			return sci.UnlockConditions, true
+		}
+	}
+	for _, sfi := range txn.SiafundInputs {
+		if crypto.Hash(sfi.ParentID) == parentID {
+			return sfi.UnlockConditions, true
+		}
+	}
+	return types.UnlockConditions{}, false
+}
+
+// inputParentIDs returns the parent ids of every input in txn.
+func inputParentIDs(txn types.Transaction) []crypto.Hash {
+	var ids []crypto.Hash
+	for _, sci := range txn.SiacoinInputs {
+		ids = append(ids, crypto.Hash(sci.ParentID))
+	}
+	for _, sfi := range txn.SiafundInputs {
+		ids = append(ids, crypto.Hash(sfi.ParentID))
+	}
+	return ids
+}
+
+// addCosignatures adds signatures covering the whole transaction for each
+// public key of uc that spendKey can sign for and that has not signed yet,
+// stopping once uc.SignaturesRequired signatures are present. This allows
+// several cosigners to sign the same multisig input in turn. An error is
+// returned if the input still needs signatures but none of them can come from
+// spendKey.
+func addCosignatures(txn *types.Transaction, uc types.UnlockConditions, parentID crypto.Hash, spendKey spendableKey) error {
+	signed := make(map[uint64]struct{})
+	for _, sig := range txn.TransactionSignatures {
+		if sig.ParentID == parentID {
+			signed[sig.PublicKeyIndex] = struct{}{}
+		}
+	}
+	contributed := false
+	for i, pk := range uc.PublicKeys {
+		if uint64(len(signed)) >= uc.SignaturesRequired {
+			return nil
+		}
+		for _, key := range spendKey.SecretKeys {
+			pubKey := key.PublicKey()
+			if !bytes.Equal(pk.Key, pubKey[:]) {
+				continue
+			}
+			contributed = true
+			if _, ok := signed[uint64(i)]; ok {
+				break
+			}
+			txn.TransactionSignatures = append(txn.TransactionSignatures, types.TransactionSignature{
+				ParentID:       parentID,
+				CoveredFields:  types.FullCoveredFields,
+				PublicKeyIndex: uint64(i),
+			})
+			sigIndex := len(txn.TransactionSignatures) - 1
+			encodedSig := crypto.SignHash(txn.SigHash(sigIndex), key)
+			txn.TransactionSignatures[sigIndex].Signature = encodedSig[:]
+			signed[uint64(i)] = struct{}{}
+			break
+		}
+	}
+	if !contributed && uint64(len(signed)) < uc.SignaturesRequired {
+		return fmt.Errorf("no signatures could be added to input %v", parentID)
+	}
+	return nil
+}
+
+// signTransaction signs the inputs of txn identified by toSign using keys. If
+// txn already contains unsigned TransactionSignatures for an input, those
+// signatures are filled in, preserving the CoveredFields chosen by whoever
+// built the transaction. Otherwise signatures covering the whole transaction
+// are added. Multisig inputs are signed with whichever of their keys are
+// known, leaving the remaining signatures to other cosigners. An error is
+// returned if a signature can not be filled in, unless it belongs to a
+// multisig input to which at least one signature was added.
+func signTransaction(txn *types.Transaction, keys map[types.UnlockHash]spendableKey, toSign []crypto.Hash) error {
+	for _, id := range toSign {
+		uc, ok := inputUnlockConditions(*txn, id)
+		if !ok {
+			return fmt.Errorf("transaction has no input with parent id %v", id)
+		}
+		sk, ok := keys[uc.UnlockHash()]
+		if !ok {
+			sk, ok = cosignerKey(keys, uc)
+		}
+		if !ok {
+			return fmt.Errorf("no key found for input %v", id)
+		}
+
+		var filled, missing int
+		for i := range txn.TransactionSignatures {
+			sig := txn.TransactionSignatures[i]
+			if sig.ParentID != id || len(sig.Signature) != 0 {
+				continue
+			}
+			if sig.PublicKeyIndex >= uint64(len(uc.PublicKeys)) {
+				return fmt.Errorf("signature for input %v has an invalid public key index", id)
+			}
+			pk := uc.PublicKeys[sig.PublicKeyIndex]
+			missing++
+			for _, key := range sk.SecretKeys {
+				pubKey := key.PublicKey()
+				if !bytes.Equal(pk.Key, pubKey[:]) {
+					continue
+				}
+				encodedSig := crypto.SignHash(txn.SigHash(i), key)
+				txn.TransactionSignatures[i].Signature = encodedSig[:]
+				filled++
+				missing--
+				break
+			}
+		}
+		if filled+missing == 0 {
+			if err := addCosignatures(txn, uc, id, sk); err != nil {
+				return err
+			}
+			continue
+		}
+		if missing > 0 && (uc.SignaturesRequired <= 1 || filled == 0) {
+			return fmt.Errorf("unable to fill %v signatures of input %v: key not found", missing, id)
+		}
+	}
+	return nil
+}
+
+// SignTransaction signs txn using the wallet's keys. toSign lists the parent
+// ids of the inputs that should be signed; if it is empty, every input is
+// signed. A signed transaction can be broadcast by anyone, so signing is
+// subject to the wallet's spending policy: the outputs and miner fees of txn
+// count toward its limits and sendPassword must match the send password if
+// one is set.
+func (w *Wallet) SignTransaction(txn *types.Transaction, toSign []crypto.Hash, sendPassword string) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.ErrLockedWallet
+	}
+	if len(toSign) == 0 {
+		toSign = inputParentIDs(*txn)
+	}
+	if len(toSign) == 0 {
+		return errNoInputs
+	}
+	if err := w.checkSendPassword(sendPassword); err != nil {
+		return err
+	}
+	var fee types.Currency
+	for _, mf := range txn.MinerFees {
+		fee = fee.Add(mf)
+	}
+	outputs := append(siafundDestinations(txn.SiafundOutputs), txn.SiacoinOutputs...)
+	spend, err := w.authorizeSpend(outputs, fee, true)
+	if err != nil {
+		return err
+	}
+	if err := signTransaction(txn, w.keys, toSign); err != nil {
+		w.undoSpend(spend)
+		return err
+	}
+	return nil
+}
+
+// UnlockConditions returns the UnlockConditions of a wallet or multisig
+// address, or of a watched address that was added with its UnlockConditions.
+// These are needed by anyone building a transaction that spends from the
+// address.
+func (w *Wallet) UnlockConditions(addr types.UnlockHash) (types.UnlockConditions, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.UnlockConditions{}, err
+	}
+	defer w.tg.Done()
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if !w.unlocked {
+		return types.UnlockConditions{}, modules.ErrLockedWallet
+	}
+	if sk, ok := w.keys[addr]; ok {
+		return sk.UnlockConditions, nil
+	}
+	if uc, ok := w.watchedUnlockConditions(addr); ok {
+		return uc, nil
+	}
+	return types.UnlockConditions{}, errUnknownAddress
+}
+
+// FundWatchOnlyTransaction builds an unsigned transaction containing outputs,
+// funded from the confirmed outputs of watch-only addresses whose
+// UnlockConditions are known to the wallet. If fee is zero, the fee is
+// estimated from the size of the signed transaction. Change is sent to
+// changeAddr, or back to the address of the first input if changeAddr is
+// empty. The transaction can be signed offline with SignTransaction and
+// broadcast through the transaction pool. Its inputs will not be used again
+// until RespendTimeout blocks have passed.
+func (w *Wallet) FundWatchOnlyTransaction(outputs []types.SiacoinOutput, fee types.Currency, changeAddr types.UnlockHash) (types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.Transaction{}, err
+	}
+	defer w.tg.Done()
+
+	// dustThreshold and the fee estimate have to be obtained separate from
+	// the lock
+	dustThreshold := w.DustThreshold()
+	feePerByte := w.tpool.FeeEstimate(sendFeeTarget)
+
+	var totalOutput types.Currency
+	for _, sco := range outputs {
+		totalOutput = totalOutput.Add(sco.Value)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return types.Transaction{}, modules.ErrLockedWallet
+	}
+	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+
+	// Collect the watched outputs that can be spent, largest first.
+	var so sortedOutputs
+	err = dbForEachWatchedSiacoinOutput(w.dbTx, func(id types.SiacoinOutputID, sco types.SiacoinOutput) {
+		uc, ok := w.watchedUnlockConditions(sco.UnlockHash)
+		if !ok || consensusHeight < uc.Timelock || sco.Value.Cmp(dustThreshold) < 0 {
+			return
+		}
+		if _, ok := w.frozenOutputs[types.OutputID(id)]; ok || w.isPendingOutput(types.OutputID(id), consensusHeight) {
+			return
+		}
+		so.ids = append(so.ids, id)
+		so.outputs = append(so.outputs, sco)
+	})
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	sort.Sort(sort.Reverse(so))
+
+	// Start with a transaction containing the outputs, a change output and a
+	// fee, so that the size estimate accounts for all of them.
+	txn := types.Transaction{
+		SiacoinOutputs: append(append([]types.SiacoinOutput(nil), outputs...), types.SiacoinOutput{UnlockHash: changeAddr}),
+		MinerFees:      []types.Currency{types.ZeroCurrency},
+	}
+	var fund types.Currency
+	var numSigs uint64
+	feeFor := func() types.Currency {
+		if !fee.IsZero() {
+			return fee
+		}
+		// Neither the fee nor the change can exceed the funds, so use the
+		// funds in their place to avoid underestimating their encoded size.
+		est := txn
+		est.SiacoinOutputs = append([]types.SiacoinOutput(nil), txn.SiacoinOutputs...)
+		est.SiacoinOutputs[len(est.SiacoinOutputs)-1].Value = fund
+		est.MinerFees = []types.Currency{fund}
+		size := len(encoding.Marshal(est)) + int(numSigs)*signatureSize
+		return feePerByte.Mul64(uint64(size))
+	}
+	for i := range so.ids {
+		if fund.Cmp(totalOutput.Add(feeFor())) >= 0 {
+			break
+		}
+		uc, _ := w.watchedUnlockConditions(so.outputs[i].UnlockHash)
+		txn.SiacoinInputs = append(txn.SiacoinInputs, types.SiacoinInput{
+			ParentID:         so.ids[i],
+			UnlockConditions: uc,
+		})
+		fund = fund.Add(so.outputs[i].Value)
+		numSigs += uc.SignaturesRequired
+	}
+	fee = feeFor()
+	if len(txn.SiacoinInputs) == 0 || fund.Cmp(totalOutput.Add(fee)) < 0 {
+		return types.Transaction{}, modules.ErrLowBalance
+	}
+
+	// Add the change to the change output. Change that is too small to be
+	// spent is added to the fee instead.
+	change := fund.Sub(totalOutput).Sub(fee)
+	if change.Cmp(dustThreshold) <= 0 {
+		fee = fee.Add(change)
+		txn.SiacoinOutputs = txn.SiacoinOutputs[:len(txn.SiacoinOutputs)-1]
+	} else {
+		changeOutput := &txn.SiacoinOutputs[len(txn.SiacoinOutputs)-1]
+		changeOutput.Value = change
+		if changeOutput.UnlockHash == (types.UnlockHash{}) {
+			changeOutput.UnlockHash = txn.SiacoinInputs[0].UnlockConditions.UnlockHash()
+		}
+	}
+	txn.MinerFees[0] = fee
+
+	for _, sci := range txn.SiacoinInputs {
+		if err := dbPutSpentOutput(w.dbTx, types.OutputID(sci.ParentID), consensusHeight); err != nil {
+			return types.Transaction{}, err
+		}
+	}
+	return txn, nil
+}
+
+// SignTransaction signs txn using keys derived from seed, without requiring
+// a wallet. This allows transactions to be signed on a machine that has no
+// access to the blockchain. toSign lists the parent ids of the inputs that
+// should be signed; if it is empty, every input is signed. Keys are
+// generated in batches until every input can be signed.
+func SignTransaction(txn *types.Transaction, seed modules.Seed, toSign []crypto.Hash) error {
+	if len(toSign) == 0 {
+		toSign = inputParentIDs(*txn)
+	}
+	if len(toSign) == 0 {
+		return errNoInputs
+	}
+
+	// Determine which addresses could hold keys for each input. For multisig
+	// inputs, any of the public keys may belong to the seed.
+	candidates := make(map[types.UnlockHash]struct{})
+	var inputs []types.UnlockConditions
+	for _, id := range toSign {
+		uc, ok := inputUnlockConditions(*txn, id)
+		if !ok {
+			return fmt.Errorf("transaction has no input with parent id %v", id)
+		}
+		inputs = append(inputs, uc)
+		candidates[uc.UnlockHash()] = struct{}{}
+		for _, pk := range uc.PublicKeys {
+			candidates[standardUnlockHash(pk)] = struct{}{}
+		}
+	}
+
+	// Generate keys until there is a key for every input.
+	keys := make(map[types.UnlockHash]spendableKey)
+	missingKey := func() (types.UnlockHash, bool) {
+		for _, uc := range inputs {
+			if _, ok := keys[uc.UnlockHash()]; ok {
+				continue
+			}
+			if _, ok := cosignerKey(keys, uc); !ok {
+				return uc.UnlockHash(), true
+			}
+		}
+		return types.UnlockHash{}, false
+	}
+	// Each derivation namespace is searched independently, the way the seed
+	// scanner searches them, as the progress of one namespace says nothing
+	// about the progress of the others. The number of keys generated in each
+	// namespace grows by scanMultiplier every round, up to maxScanKeys.
+	progress := make(map[modules.AddressPurpose]uint64)
+	batch := map[modules.AddressPurpose]uint64{modules.PurposeDefault: numInitialKeys}
+	for _, purpose := range modules.AddressPurposes {
+		batch[purpose] = numInitialPurposeKeys
+	}
+	for {
+		uh, missing := missingKey()
+		if !missing {
+			break
+		}
+		exhausted := true
+		for purpose, n := range batch {
+			start := progress[purpose]
+			if start >= maxScanKeys {
+				continue
+			}
+			exhausted = false
+			if n > maxScanKeys-start {
+				n = maxScanKeys - start
+			}
+			var generated []spendableKey
+			if purpose == modules.PurposeDefault {
+				generated = generateKeys(seed, start, n)
+			} else {
+				generated = generatePurposeKeys(seed, purpose, start, n)
+			}
+			for _, sk := range generated {
+				uh := sk.UnlockConditions.UnlockHash()
+				if _, ok := candidates[uh]; ok {
+					keys[uh] = sk
+				}
+			}
+			progress[purpose] = start + n
+			batch[purpose] = n * scanMultiplier
+		}
+		if exhausted {
+			return fmt.Errorf("no key for address %v among the first %v keys of any derivation namespace of the seed", uh, maxScanKeys)
+		}
+	}
+	return signTransaction(txn, keys, toSign)
+}
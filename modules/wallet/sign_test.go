@@ -0,0 +1,245 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
+)
+
+// buildUnsignedTransaction creates a transaction that spends the wallet
+// output uo, as an external tool building from /wallet/unspent would.
+func buildUnsignedTransaction(wt *walletTester, uo modules.UnspentOutput) (types.Transaction, error) {
+	uc, err := wt.wallet.UnlockConditions(uo.UnlockHash)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	fee := types.SiacoinPrecision
+	return types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{
+			ParentID:         types.SiacoinOutputID(uo.ID),
+			UnlockConditions: uc,
+		}},
+		SiacoinOutputs: []types.SiacoinOutput{{
+			Value:      uo.Value.Sub(fee),
+			UnlockHash: types.UnlockHash{},
+		}},
+		MinerFees: []types.Currency{fee},
+	}, nil
+}
+
+// TestSignTransaction tests signing externally built transactions, both with
+// the wallet's keys and offline with only the seed.
+func TestSignTransaction(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Mine another block so that the wallet has two mature miner payouts.
+	b, _ := wt.miner.FindBlock()
+	if err := wt.cs.AcceptBlock(b); err != nil {
+		t.Fatal(err)
+	}
+
+	outputs, err := wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var scos []modules.UnspentOutput
+	for _, uo := range outputs {
+		if uo.FundType == types.SpecifierSiacoinOutput && !uo.WatchOnly {
+			scos = append(scos, uo)
+		}
+	}
+	if len(scos) < 2 {
+		t.Fatal("expected at least two spendable outputs, got", len(scos))
+	}
+
+	// Sign using the wallet's keys.
+	txn, err := buildUnsignedTransaction(wt, scos[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.SignTransaction(&txn, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.tpool.AcceptTransactionSet([]types.Transaction{txn}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sign offline using the seed. Supply a prepared signature so that the
+	// covered fields chosen by the builder are used.
+	txn, err = buildUnsignedTransaction(wt, scos[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	parentID := crypto.Hash(txn.SiacoinInputs[0].ParentID)
+	txn.TransactionSignatures = []types.TransactionSignature{{
+		ParentID:      parentID,
+		CoveredFields: types.CoveredFields{WholeTransaction: true},
+	}}
+	if err := SignTransaction(&txn, wt.wallet.primarySeed, []crypto.Hash{parentID}); err != nil {
+		t.Fatal(err)
+	}
+	if len(txn.TransactionSignatures) != 1 || len(txn.TransactionSignatures[0].Signature) == 0 {
+		t.Fatal("prepared signature was not filled in")
+	}
+	if err := wt.tpool.AcceptTransactionSet([]types.Transaction{txn}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Signing an input that does not exist should fail.
+	if err := SignTransaction(&txn, wt.wallet.primarySeed, []crypto.Hash{{1}}); err == nil {
+		t.Fatal("expected error when signing a nonexistent input")
+	}
+}
+
+// TestSignTransactionPurposeKey checks that offline signing finds keys of the
+// other derivation namespaces independently of the progress of the default
+// namespace.
+func TestSignTransactionPurposeKey(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	var seed modules.Seed
+	fastrand.Read(seed[:])
+
+	// Spend an output of a host key far beyond the keys that the default
+	// namespace would need to be searched to.
+	index := 20e3 + numInitialPurposeKeys
+	sk := generatePurposeKeys(seed, modules.PurposeHost, index, 1)[0]
+	parentID := crypto.Hash{1}
+	txn := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{
+			ParentID:         types.SiacoinOutputID(parentID),
+			UnlockConditions: sk.UnlockConditions,
+		}},
+		TransactionSignatures: []types.TransactionSignature{{
+			ParentID:      parentID,
+			CoveredFields: types.CoveredFields{WholeTransaction: true},
+		}},
+	}
+	if err := SignTransaction(&txn, seed, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(txn.TransactionSignatures[0].Signature) == 0 {
+		t.Fatal("host key input was not signed")
+	}
+}
+
+// TestWatchOnlySigning tests the offline signing workflow between two
+// wallets: an online wallet that only watches an address of an offline
+// wallet, builds and funds a transaction spending from it and broadcasts it,
+// and the offline wallet, which signs the transaction without ever seeing
+// the blockchain.
+func TestWatchOnlySigning(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	online, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer online.closeWt()
+	offline, err := createBlankWalletTester(t.Name() + "-offline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer offline.closeWt()
+	var masterKey crypto.TwofishKey
+	fastrand.Read(masterKey[:])
+	if _, err := offline.wallet.Encrypt(masterKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := offline.wallet.Unlock(masterKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// Watch an address of the offline wallet on the online wallet, along with
+	// its UnlockConditions, and fund it.
+	uc, err := offline.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := uc.UnlockHash()
+	if err := online.wallet.AddWatchUnlockConditions([]types.UnlockConditions{uc}, true); err != nil {
+		t.Fatal(err)
+	}
+	if found, err := online.wallet.UnlockConditions(addr); err != nil || found.UnlockHash() != addr {
+		t.Fatal("wrong unlock conditions for watched address:", err)
+	}
+	fund := types.SiacoinPrecision.Mul64(100)
+	if _, err := online.wallet.SendSiacoins(fund, addr); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := online.miner.FindBlock()
+	if err := online.cs.AcceptBlock(b); err != nil {
+		t.Fatal(err)
+	}
+
+	// Build an unsigned transaction on the online wallet. The change should
+	// go back to the watched address.
+	sendAmount := types.SiacoinPrecision.Mul64(30)
+	var dest types.UnlockHash
+	fastrand.Read(dest[:])
+	txn, err := online.wallet.FundWatchOnlyTransaction([]types.SiacoinOutput{{Value: sendAmount, UnlockHash: dest}}, types.ZeroCurrency, types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txn.SiacoinInputs) != 1 || txn.SiacoinInputs[0].UnlockConditions.UnlockHash() != addr {
+		t.Fatal("transaction should spend the watched output")
+	}
+	if len(txn.TransactionSignatures) != 0 {
+		t.Fatal("transaction should be unsigned")
+	}
+	if len(txn.SiacoinOutputs) != 2 || txn.SiacoinOutputs[1].UnlockHash != addr {
+		t.Fatal("change should be sent back to the watched address")
+	}
+	if txn.MinerFees[0].IsZero() {
+		t.Fatal("transaction should pay a fee")
+	}
+	// The watched output is now pending and can not fund another transaction.
+	_, err = online.wallet.FundWatchOnlyTransaction([]types.SiacoinOutput{{Value: sendAmount, UnlockHash: dest}}, types.ZeroCurrency, types.UnlockHash{})
+	if err != modules.ErrLowBalance {
+		t.Fatal("expected ErrLowBalance, got", err)
+	}
+
+	// The online wallet can not sign the transaction, and must not return it
+	// with missing signatures.
+	unsigned := txn
+	if err := online.wallet.SignTransaction(&unsigned, nil, ""); err == nil {
+		t.Fatal("expected an error when signing without the keys")
+	}
+	prepared := txn
+	prepared.TransactionSignatures = []types.TransactionSignature{{
+		ParentID:      crypto.Hash(txn.SiacoinInputs[0].ParentID),
+		CoveredFields: types.FullCoveredFields,
+	}}
+	keys := map[types.UnlockHash]spendableKey{addr: {UnlockConditions: uc}}
+	if err := signTransaction(&prepared, keys, []crypto.Hash{crypto.Hash(txn.SiacoinInputs[0].ParentID)}); err == nil {
+		t.Fatal("expected an error when a prepared signature can not be filled in")
+	}
+
+	// Sign on the offline wallet and broadcast from the online wallet.
+	if err := offline.wallet.SignTransaction(&txn, nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := online.tpool.AcceptTransactionSet([]types.Transaction{txn}); err != nil {
+		t.Fatal(err)
+	}
+	b, _ = online.miner.FindBlock()
+	if err := online.cs.AcceptBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	scBal, _ := online.wallet.WatchOnlyBalance()
+	if !scBal.Equals(txn.SiacoinOutputs[1].Value) {
+		t.Fatalf("watch-only balance should be the change of %v, got %v", txn.SiacoinOutputs[1].Value, scBal)
+	}
+}
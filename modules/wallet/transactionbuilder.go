@@ -22,6 +22,10 @@ var (
 	// errDustOutput indicates an output is not spendable because it is dust.
 	errDustOutput = errors.New("output is too small")
 
+	// errOutputFrozen indicates an output is not spendable because the user
+	// has frozen it.
+	errOutputFrozen = errors.New("output is frozen")
+
 	// errOutputTimelock indicates an output's timelock is still active.
 	errOutputTimelock = errors.New("wallet consensus set height is lower than the output timelock")
 
@@ -97,6 +101,10 @@ func (w *Wallet) checkOutput(tx *bolt.Tx, currentHeight types.BlockHeight, id ty
 	if output.Value.Cmp(dustThreshold) < 0 {
 		return errDustOutput
 	}
+	// Check that the output has not been frozen by the user.
+	if _, ok := w.frozenOutputs[types.OutputID(id)]; ok {
+		return errOutputFrozen
+	}
 	// Check that this output has not recently been spent by the wallet.
 	spendHeight, err := dbGetSpentOutput(tx, types.OutputID(id))
 	if err == nil {
@@ -299,6 +307,9 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) error {
 		if consensusHeight < outputUnlockConditions.Timelock {
 			continue
 		}
+		if _, ok := tb.wallet.frozenOutputs[types.OutputID(sfoid)]; ok {
+			continue
+		}
 
 		// Add a siafund input for this output.
 		parentClaimUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
@@ -182,7 +182,7 @@ func TestIntegrationTransaction(t *testing.T) {
 		t.Error(err)
 	}
 	sentValue = types.NewCurrency64(12)
-	sendTxns, err = wt.wallet.SendSiafunds(sentValue, types.UnlockHash{})
+	sendTxns, err = wt.wallet.SendSiafunds(sentValue, types.UnlockHash{}, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -97,6 +97,16 @@ func (w *Wallet) loadSpendableKey(masterKey crypto.TwofishKey, sk spendableKey)
 
 	// TODO: Check that the key is actually spendable.
 
+	return w.saveSpendableKey(masterKey, sk)
+
+	// w.keys[sk.UnlockConditions.UnlockHash()] = sk -> aids with duplicate
+	// detection, but causes db inconsistency. Rescanning is probably the
+	// solution.
+}
+
+// saveSpendableKey encrypts a spendable key and appends it to the wallet
+// database.
+func (w *Wallet) saveSpendableKey(masterKey crypto.TwofishKey, sk spendableKey) error {
 	// Create a UID and encryption verification.
 	var skf spendableKeyFile
 	fastrand.Read(skf.UID[:])
@@ -116,10 +126,6 @@ func (w *Wallet) loadSpendableKey(masterKey crypto.TwofishKey, sk spendableKey)
 		return err
 	}
 	return w.dbTx.Bucket(bucketWallet).Put(keySpendableKeyFiles, encoding.Marshal(append(current, skf)))
-
-	// w.keys[sk.UnlockConditions.UnlockHash()] = sk -> aids with duplicate
-	// detection, but causes db inconsistency. Rescanning is probably the
-	// solution.
 }
 
 // loadSiagKeys loads a set of siag keyfiles into the wallet, so that the
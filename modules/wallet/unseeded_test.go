@@ -32,7 +32,7 @@ func TestIntegrationLoad1of1Siag(t *testing.T) {
 	}
 
 	// Send some siafunds to the void.
-	_, err = wt.wallet.SendSiafunds(types.NewCurrency64(12), types.UnlockHash{})
+	_, err = wt.wallet.SendSiafunds(types.NewCurrency64(12), types.UnlockHash{}, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -71,7 +71,7 @@ func TestIntegrationLoad2of3Siag(t *testing.T) {
 	}
 
 	// Send some siafunds to the void.
-	_, err = wt.wallet.SendSiafunds(types.NewCurrency64(12), types.UnlockHash{})
+	_, err = wt.wallet.SendSiafunds(types.NewCurrency64(12), types.UnlockHash{}, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -34,32 +34,33 @@ func (w *Wallet) threadedResetSubscriptions() error {
 	return nil
 }
 
-// advanceSeedLookahead generates all keys from the current primary seed progress up to index
-// and adds them to the set of spendable keys.  Therefore the new primary seed progress will
+// advanceSeedLookahead generates all keys from the current seed progress of purpose up to index
+// and adds them to the set of spendable keys.  Therefore the new seed progress will
 // be index+1 and new lookahead keys will be generated starting from index+1
 // Returns true if a blockchain rescan is required
-func (w *Wallet) advanceSeedLookahead(index uint64) (bool, error) {
-	progress, err := dbGetPrimarySeedProgress(w.dbTx)
+func (w *Wallet) advanceSeedLookahead(purpose modules.AddressPurpose, index uint64) (bool, error) {
+	progress, err := dbGetPurposeSeedProgress(w.dbTx, purpose)
 	if err != nil {
 		return false, err
 	}
 	newProgress := index + 1
 
 	// Add spendable keys and remove them from lookahead
-	spendableKeys := generateKeys(w.primarySeed, progress, newProgress-progress)
+	lookahead := w.lookaheadFor(purpose)
+	spendableKeys := generatePurposeKeys(w.primarySeed, purpose, progress, newProgress-progress)
 	for _, key := range spendableKeys {
 		w.keys[key.UnlockConditions.UnlockHash()] = key
-		delete(w.lookahead, key.UnlockConditions.UnlockHash())
+		delete(lookahead, key.UnlockConditions.UnlockHash())
 	}
 
-	// Update the primarySeedProgress
-	dbPutPrimarySeedProgress(w.dbTx, newProgress)
+	// Update the seed progress
+	err = dbPutPurposeSeedProgress(w.dbTx, purpose, newProgress)
 	if err != nil {
 		return false, err
 	}
 
 	// Regenerate lookahead
-	w.regenerateLookahead(newProgress)
+	w.regenerateLookahead(purpose, newProgress)
 
 	// If more than lookaheadRescanThreshold keys were generated
 	// also initialize a rescan just to be safe.
@@ -77,35 +78,76 @@ func (w *Wallet) isWalletAddress(uh types.UnlockHash) bool {
 	return exists
 }
 
+// isWatchedAddress is a helper function that checks if an UnlockHash is one of
+// the wallet's watch-only addresses.
+func (w *Wallet) isWatchedAddress(uh types.UnlockHash) bool {
+	_, exists := w.watchedAddrs[uh]
+	return exists
+}
+
+// isTrackedAddress is a helper function that checks if an UnlockHash is
+// either spendable by the wallet or watched by the wallet. Transactions
+// involving tracked addresses are recorded in the wallet's history.
+func (w *Wallet) isTrackedAddress(uh types.UnlockHash) bool {
+	return w.isWalletAddress(uh) || w.isWatchedAddress(uh)
+}
+
 // updateLookahead uses a consensus change to update the seed progress if one of the outputs
-// contains an unlock hash of the lookahead set. Returns true if a blockchain rescan is required
+// contains an unlock hash of the lookahead set of any purpose. Returns true if a blockchain
+// rescan is required
 func (w *Wallet) updateLookahead(tx *bolt.Tx, cc modules.ConsensusChange) (bool, error) {
-	var largestIndex uint64
-	for _, diff := range cc.SiacoinOutputDiffs {
-		if index, ok := w.lookahead[diff.SiacoinOutput.UnlockHash]; ok {
-			if index > largestIndex {
-				largestIndex = index
+	var needRescan bool
+	for _, purpose := range append([]modules.AddressPurpose{modules.PurposeDefault}, modules.AddressPurposes...) {
+		lookahead := w.lookaheadFor(purpose)
+		var largestIndex uint64
+		var found bool
+		for _, diff := range cc.SiacoinOutputDiffs {
+			if index, ok := lookahead[diff.SiacoinOutput.UnlockHash]; ok {
+				found = true
+				if index > largestIndex {
+					largestIndex = index
+				}
 			}
 		}
-	}
-	for _, diff := range cc.SiafundOutputDiffs {
-		if index, ok := w.lookahead[diff.SiafundOutput.UnlockHash]; ok {
-			if index > largestIndex {
-				largestIndex = index
+		for _, diff := range cc.SiafundOutputDiffs {
+			if index, ok := lookahead[diff.SiafundOutput.UnlockHash]; ok {
+				found = true
+				if index > largestIndex {
+					largestIndex = index
+				}
 			}
 		}
+		if found {
+			rescan, err := w.advanceSeedLookahead(purpose, largestIndex)
+			if err != nil {
+				return false, err
+			}
+			needRescan = needRescan || rescan
+		}
 	}
-	if largestIndex > 0 {
-		return w.advanceSeedLookahead(largestIndex)
-	}
-
-	return false, nil
+	return needRescan, nil
 }
 
 // updateConfirmedSet uses a consensus change to update the confirmed set of
 // outputs as understood by the wallet.
 func (w *Wallet) updateConfirmedSet(tx *bolt.Tx, cc modules.ConsensusChange) error {
 	for _, diff := range cc.SiacoinOutputDiffs {
+		// Outputs of watch-only addresses are tracked separately, so that
+		// the wallet never tries to spend them.
+		if w.isWatchedAddress(diff.SiacoinOutput.UnlockHash) {
+			var err error
+			if diff.Direction == modules.DiffApply {
+				err = dbPutWatchedSiacoinOutput(tx, diff.ID, diff.SiacoinOutput)
+			} else {
+				err = dbDeleteWatchedSiacoinOutput(tx, diff.ID)
+			}
+			if err != nil {
+				w.log.Severe("Could not update watched siacoin output:", err)
+				return err
+			}
+			continue
+		}
+
 		// Verify that the diff is relevant to the wallet.
 		if !w.isWalletAddress(diff.SiacoinOutput.UnlockHash) {
 			continue
@@ -125,6 +167,22 @@ func (w *Wallet) updateConfirmedSet(tx *bolt.Tx, cc modules.ConsensusChange) err
 		}
 	}
 	for _, diff := range cc.SiafundOutputDiffs {
+		// Outputs of watch-only addresses are tracked separately, so that
+		// the wallet never tries to spend them.
+		if w.isWatchedAddress(diff.SiafundOutput.UnlockHash) {
+			var err error
+			if diff.Direction == modules.DiffApply {
+				err = dbPutWatchedSiafundOutput(tx, diff.ID, diff.SiafundOutput)
+			} else {
+				err = dbDeleteWatchedSiafundOutput(tx, diff.ID)
+			}
+			if err != nil {
+				w.log.Severe("Could not update watched siafund output:", err)
+				return err
+			}
+			continue
+		}
+
 		// Verify that the diff is relevant to the wallet.
 		if !w.isWalletAddress(diff.SiafundOutput.UnlockHash) {
 			continue
@@ -182,7 +240,7 @@ func (w *Wallet) revertHistory(tx *bolt.Tx, reverted []types.Block) error {
 
 		// Remove the miner payout transaction if applicable.
 		for i, mp := range block.MinerPayouts {
-			if w.isWalletAddress(mp.UnlockHash) {
+			if w.isTrackedAddress(mp.UnlockHash) {
 				w.log.Println("Miner payout has been reverted due to a reorg:", block.MinerPayoutID(uint64(i)), "::", mp.Value.HumanString())
 				if err := dbDeleteLastProcessedTransaction(tx); err != nil {
 					w.log.Severe("Could not revert transaction:", err)
@@ -241,7 +299,7 @@ func (w *Wallet) computeProcessedTransactionsFromBlock(tx *bolt.Tx, block types.
 	// Find ProcessedTransactions from miner payouts.
 	relevant := false
 	for _, mp := range block.MinerPayouts {
-		relevant = relevant || w.isWalletAddress(mp.UnlockHash)
+		relevant = relevant || w.isTrackedAddress(mp.UnlockHash)
 	}
 	if relevant {
 		w.log.Println("Wallet has received new miner payouts:", block.ID())
@@ -271,16 +329,16 @@ func (w *Wallet) computeProcessedTransactionsFromBlock(tx *bolt.Tx, block types.
 		// Determine if transaction is relevant.
 		relevant := false
 		for _, sci := range txn.SiacoinInputs {
-			relevant = relevant || w.isWalletAddress(sci.UnlockConditions.UnlockHash())
+			relevant = relevant || w.isTrackedAddress(sci.UnlockConditions.UnlockHash())
 		}
 		for _, sco := range txn.SiacoinOutputs {
-			relevant = relevant || w.isWalletAddress(sco.UnlockHash)
+			relevant = relevant || w.isTrackedAddress(sco.UnlockHash)
 		}
 		for _, sfi := range txn.SiafundInputs {
-			relevant = relevant || w.isWalletAddress(sfi.UnlockConditions.UnlockHash())
+			relevant = relevant || w.isTrackedAddress(sfi.UnlockConditions.UnlockHash())
 		}
 		for _, sfo := range txn.SiafundOutputs {
-			relevant = relevant || w.isWalletAddress(sfo.UnlockHash)
+			relevant = relevant || w.isTrackedAddress(sfo.UnlockHash)
 		}
 
 		// Only create a ProcessedTransaction if transaction is relevant.
@@ -531,10 +589,10 @@ func (w *Wallet) ReceiveUpdatedUnconfirmedTransactions(diff *modules.Transaction
 			// determine whether transaction is relevant to the wallet
 			relevant := false
 			for _, sci := range txn.SiacoinInputs {
-				relevant = relevant || w.isWalletAddress(sci.UnlockConditions.UnlockHash())
+				relevant = relevant || w.isTrackedAddress(sci.UnlockConditions.UnlockHash())
 			}
 			for _, sco := range txn.SiacoinOutputs {
-				relevant = relevant || w.isWalletAddress(sco.UnlockHash)
+				relevant = relevant || w.isTrackedAddress(sco.UnlockHash)
 			}
 
 			// only create a ProcessedTransaction if txn is relevant
@@ -0,0 +1,128 @@
+package wallet
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errConsolidateNotNeeded is returned when the wallet has fewer than two
+	// spendable outputs to consolidate.
+	errConsolidateNotNeeded = errors.New("wallet does not have enough spendable outputs to consolidate")
+)
+
+// FreezeOutputs prevents the wallet from spending the given outputs. Frozen
+// outputs are skipped when funding transactions and by the defragger, and are
+// rejected when selected explicitly.
+func (w *Wallet) FreezeOutputs(ids []types.OutputID) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.ErrLockedWallet
+	}
+	for _, id := range ids {
+		_, scErr := dbGetSiacoinOutput(w.dbTx, types.SiacoinOutputID(id))
+		_, sfErr := dbGetSiafundOutput(w.dbTx, types.SiafundOutputID(id))
+		if scErr != nil && sfErr != nil {
+			return errUnknownOutput
+		}
+	}
+	for _, id := range ids {
+		w.frozenOutputs[id] = struct{}{}
+	}
+	return dbPutFrozenOutputs(w.dbTx, w.frozenOutputList())
+}
+
+// UnfreezeOutputs allows the wallet to spend the given outputs again.
+func (w *Wallet) UnfreezeOutputs(ids []types.OutputID) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.ErrLockedWallet
+	}
+	for _, id := range ids {
+		delete(w.frozenOutputs, id)
+	}
+	return dbPutFrozenOutputs(w.dbTx, w.frozenOutputList())
+}
+
+// ConsolidateOutputs spends up to maxInputs of the wallet's smallest
+// spendable siacoin outputs into a single new output, reducing the size and
+// fee of future transactions. If maxInputs is zero, defragBatchSize is used.
+// Unlike the defragger, it runs on demand regardless of how many outputs the
+// wallet has.
+func (w *Wallet) ConsolidateOutputs(maxInputs int, dryRun bool) ([]types.Transaction, types.Currency, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, types.ZeroCurrency, err
+	}
+	defer w.tg.Done()
+	if maxInputs <= 0 {
+		maxInputs = defragBatchSize
+	}
+
+	// dustThreshold has to be obtained separate from the lock
+	dustThreshold := w.DustThreshold()
+
+	inputs, err := func() ([]types.SiacoinOutputID, error) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.unlocked {
+			return nil, modules.ErrLockedWallet
+		}
+		consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+		if err != nil {
+			return nil, err
+		}
+
+		// Collect the spendable outputs, smallest first.
+		var so sortedOutputs
+		err = dbForEachSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
+			if w.checkOutput(w.dbTx, consensusHeight, scoid, sco, dustThreshold) == nil {
+				so.ids = append(so.ids, scoid)
+				so.outputs = append(so.outputs, sco)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(so.ids) < 2 {
+			return nil, errConsolidateNotNeeded
+		}
+		sort.Sort(so)
+		if len(so.ids) > maxInputs {
+			so.ids = so.ids[:maxInputs]
+		}
+		return so.ids, nil
+	}()
+	if err != nil {
+		return nil, types.ZeroCurrency, err
+	}
+	// Consolidation only pays a fee, so it is not subject to the spending
+	// policy.
+	return w.managedSendSiacoinsWithOptions(nil, modules.SendOptions{
+		Inputs: inputs,
+		DryRun: dryRun,
+	}, false)
+}
+
+// frozenOutputList returns the ids of the frozen outputs as a slice.
+func (w *Wallet) frozenOutputList() []types.OutputID {
+	ids := make([]types.OutputID, 0, len(w.frozenOutputs))
+	for id := range w.frozenOutputs {
+		ids = append(ids, id)
+	}
+	return ids
+}
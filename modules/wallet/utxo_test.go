@@ -0,0 +1,137 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// spendableSiacoinOutputs returns the ids of the wallet's spendable siacoin
+// outputs, as reported by UnspentOutputs.
+func spendableSiacoinOutputs(wt *walletTester) (map[types.OutputID]modules.UnspentOutput, error) {
+	outputs, err := wt.wallet.UnspentOutputs()
+	if err != nil {
+		return nil, err
+	}
+	spendable := make(map[types.OutputID]modules.UnspentOutput)
+	for _, uo := range outputs {
+		if uo.FundType == types.SpecifierSiacoinOutput && uo.Spendable {
+			spendable[uo.ID] = uo
+		}
+	}
+	return spendable, nil
+}
+
+// TestFreezeAndConsolidate tests that frozen outputs are excluded from
+// spending and consolidation, and that consolidation merges the remaining
+// outputs.
+func TestFreezeAndConsolidate(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Mine blocks until the wallet has three mature miner payouts.
+	for i := 0; i < 2; i++ {
+		b, _ := wt.miner.FindBlock()
+		if err := wt.cs.AcceptBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	spendable, err := spendableSiacoinOutputs(wt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spendable) < 3 {
+		t.Fatal("expected at least three spendable outputs, got", len(spendable))
+	}
+	var frozen modules.UnspentOutput
+	for _, uo := range spendable {
+		if uo.ConfirmationHeight == 0 || uo.Dust || uo.Pending || uo.Frozen {
+			t.Fatal("spendable output has wrong status:", uo)
+		}
+		frozen = uo
+	}
+
+	// Freezing an unknown output should fail.
+	if err := wt.wallet.FreezeOutputs([]types.OutputID{{1}}); err != errUnknownOutput {
+		t.Fatal("expected errUnknownOutput, got", err)
+	}
+
+	// A frozen output is reported as such and cannot be spent explicitly.
+	if err := wt.wallet.FreezeOutputs([]types.OutputID{frozen.ID}); err != nil {
+		t.Fatal(err)
+	}
+	outputs, err := wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, uo := range outputs {
+		if uo.ID == frozen.ID && (!uo.Frozen || uo.Spendable) {
+			t.Fatal("frozen output has wrong status:", uo)
+		}
+	}
+	sends := []types.SiacoinOutput{{Value: types.SiacoinPrecision, UnlockHash: types.UnlockHash{}}}
+	_, _, err = wt.wallet.SendSiacoinsWithOptions(sends, modules.SendOptions{
+		Inputs: []types.SiacoinOutputID{types.SiacoinOutputID(frozen.ID)},
+		DryRun: true,
+	})
+	if err != errOutputFrozen {
+		t.Fatal("expected errOutputFrozen, got", err)
+	}
+
+	// Consolidation should skip the frozen output.
+	txns, _, err := wt.wallet.ConsolidateOutputs(0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) != 1 || len(txns[0].SiacoinInputs) != len(spendable)-1 {
+		t.Fatal("consolidation used the wrong number of inputs")
+	}
+	for _, sci := range txns[0].SiacoinInputs {
+		if types.OutputID(sci.ParentID) == frozen.ID {
+			t.Fatal("consolidation spent a frozen output")
+		}
+	}
+	if len(wt.tpool.TransactionList()) != 0 {
+		t.Fatal("dry run broadcast the consolidation")
+	}
+
+	// maxInputs limits the number of outputs consolidated.
+	txns, _, err = wt.wallet.ConsolidateOutputs(2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns[0].SiacoinInputs) != 2 || len(txns[0].SiacoinOutputs) != 1 {
+		t.Fatal("consolidation should merge two outputs into one")
+	}
+	if len(wt.tpool.TransactionList()) != 1 {
+		t.Fatal("consolidation was not broadcast")
+	}
+	outputs, err = wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, uo := range outputs {
+		if uo.ID == types.OutputID(txns[0].SiacoinInputs[0].ParentID) && (!uo.Pending || uo.Spendable) {
+			t.Fatal("consolidated output should be pending:", uo)
+		}
+	}
+
+	// After unfreezing, the output is spendable again.
+	if err := wt.wallet.UnfreezeOutputs([]types.OutputID{frozen.ID}); err != nil {
+		t.Fatal(err)
+	}
+	spendable, err = spendableSiacoinOutputs(wt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := spendable[frozen.ID]; !ok {
+		t.Fatal("unfrozen output is not spendable")
+	}
+}
@@ -74,6 +74,30 @@ type Wallet struct {
 	keys      map[types.UnlockHash]spendableKey
 	lookahead map[types.UnlockHash]uint64
 
+	// purposeLookahead holds the future keys of the primary seed in each
+	// derivation namespace of modules.AddressPurposes. The future keys of
+	// modules.PurposeDefault are held in lookahead.
+	purposeLookahead map[modules.AddressPurpose]map[types.UnlockHash]uint64
+
+	// watchedAddrs is the set of watch-only addresses. The wallet tracks the
+	// outputs and transactions of these addresses, but does not have the
+	// keys needed to spend from them.
+	watchedAddrs map[types.UnlockHash]struct{}
+
+	// multisigConds holds the UnlockConditions of the multisig addresses
+	// tracked by the wallet. Multisig addresses are also watched, since the
+	// wallet cannot spend from them without its cosigners.
+	multisigConds map[types.UnlockHash]types.UnlockConditions
+
+	// watchedConds holds the UnlockConditions of the watch-only addresses
+	// that were added along with them. The wallet can build unsigned
+	// transactions spending from these addresses, to be signed offline.
+	watchedConds map[types.UnlockHash]types.UnlockConditions
+
+	// frozenOutputs is the set of outputs that the user has frozen. Frozen
+	// outputs are never used to fund transactions or by the defragger.
+	frozenOutputs map[types.OutputID]struct{}
+
 	// unconfirmedProcessedTransactions tracks unconfirmed transactions.
 	//
 	// TODO: Replace this field with a linked list. Currently when a new
@@ -150,8 +174,13 @@ func NewCustomWallet(cs modules.ConsensusSet, tpool modules.TransactionPool, per
 		cs:    cs,
 		tpool: tpool,
 
-		keys:      make(map[types.UnlockHash]spendableKey),
-		lookahead: make(map[types.UnlockHash]uint64),
+		keys:             make(map[types.UnlockHash]spendableKey),
+		lookahead:        make(map[types.UnlockHash]uint64),
+		purposeLookahead: newPurposeLookahead(),
+		watchedAddrs:     make(map[types.UnlockHash]struct{}),
+		multisigConds:    make(map[types.UnlockHash]types.UnlockConditions),
+		watchedConds:     make(map[types.UnlockHash]types.UnlockConditions),
+		frozenOutputs:    make(map[types.OutputID]struct{}),
 
 		unconfirmedSets: make(map[modules.TransactionSetID][]types.TransactionID),
 
@@ -181,6 +210,39 @@ func NewCustomWallet(cs modules.ConsensusSet, tpool modules.TransactionPool, per
 		w.syncDB()
 	}
 
+	// load the watch-only and multisig addresses and the frozen outputs, which
+	// do not require the wallet to be unlocked
+	watchedAddrs, err := dbGetWatchedAddresses(w.dbTx)
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range watchedAddrs {
+		w.watchedAddrs[addr] = struct{}{}
+	}
+	multisigConds, err := dbGetMultisigConditions(w.dbTx)
+	if err != nil {
+		return nil, err
+	}
+	for _, uc := range multisigConds {
+		w.multisigConds[uc.UnlockHash()] = uc
+		w.watchedAddrs[uc.UnlockHash()] = struct{}{}
+	}
+	watchedConds, err := dbGetWatchedConditions(w.dbTx)
+	if err != nil {
+		return nil, err
+	}
+	for _, uc := range watchedConds {
+		w.watchedConds[uc.UnlockHash()] = uc
+		w.watchedAddrs[uc.UnlockHash()] = struct{}{}
+	}
+	frozenOutputs, err := dbGetFrozenOutputs(w.dbTx)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range frozenOutputs {
+		w.frozenOutputs[id] = struct{}{}
+	}
+
 	// make sure we commit on shutdown
 	w.tg.AfterStop(func() {
 		err := w.dbTx.Commit()
@@ -0,0 +1,266 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errWatchSpendableAddress is returned when trying to watch an address
+	// that the wallet can already spend from.
+	errWatchSpendableAddress = errors.New("cannot watch an address that belongs to the wallet")
+)
+
+// resetHistory clears the wallet's transaction history and watched outputs
+// and resets the consensus change ID and height in preparation for a rescan.
+func (w *Wallet) resetHistory() error {
+	for _, bucket := range [][]byte{
+		bucketProcessedTransactions,
+		bucketProcessedTxnIndex,
+		bucketAddrTransactions,
+		bucketOutputHeights,
+		bucketWatchedSiacoinOutputs,
+		bucketWatchedSiafundOutputs,
+	} {
+		if err := w.dbTx.DeleteBucket(bucket); err != nil {
+			return err
+		}
+		if _, err := w.dbTx.CreateBucket(bucket); err != nil {
+			return err
+		}
+	}
+	w.unconfirmedProcessedTransactions = nil
+
+	err := dbPutConsensusChangeID(w.dbTx, modules.ConsensusChangeBeginning)
+	if err != nil {
+		return err
+	}
+	return dbPutConsensusHeight(w.dbTx, 0)
+}
+
+// managedRescan resubscribes the wallet to the consensus set and transaction
+// pool from the beginning of the blockchain. The caller must hold the
+// scanLock.
+func (w *Wallet) managedRescan() error {
+	w.cs.Unsubscribe(w)
+	w.tpool.Unsubscribe(w)
+
+	done := make(chan struct{})
+	go w.rescanMessage(done)
+	defer close(done)
+
+	err := w.cs.ConsensusSetSubscribe(w, modules.ConsensusChangeBeginning, w.tg.StopChan())
+	if err != nil {
+		return err
+	}
+	w.tpool.TransactionPoolSubscribe(w)
+	return nil
+}
+
+// AddWatchAddresses instructs the wallet to track the outputs and
+// transactions of addrs without being able to spend from them. If unused is
+// true, the addresses are assumed to have never appeared in the blockchain
+// and no rescan is performed.
+func (w *Wallet) AddWatchAddresses(addrs []types.UnlockHash, unused bool) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+	return w.managedAddWatchAddresses(addrs, nil, unused)
+}
+
+// AddWatchUnlockConditions instructs the wallet to watch the addresses of
+// ucs, like AddWatchAddresses. The UnlockConditions are kept so that the
+// wallet can build unsigned transactions spending from the addresses with
+// FundWatchOnlyTransaction.
+func (w *Wallet) AddWatchUnlockConditions(ucs []types.UnlockConditions, unused bool) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+	addrs := make([]types.UnlockHash, len(ucs))
+	for i, uc := range ucs {
+		addrs[i] = uc.UnlockHash()
+	}
+	return w.managedAddWatchAddresses(addrs, ucs, unused)
+}
+
+// managedAddWatchAddresses watches addrs and stores ucs, which are the
+// UnlockConditions of some of the addresses.
+func (w *Wallet) managedAddWatchAddresses(addrs []types.UnlockHash, ucs []types.UnlockConditions, unused bool) error {
+	if !w.scanLock.TryLock() {
+		return errScanInProgress
+	}
+	defer w.scanLock.Unlock()
+
+	err := func() error {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.unlocked {
+			return modules.ErrLockedWallet
+		}
+		for _, addr := range addrs {
+			if w.isWalletAddress(addr) {
+				return errWatchSpendableAddress
+			}
+		}
+
+		for _, addr := range addrs {
+			w.watchedAddrs[addr] = struct{}{}
+		}
+		for _, uc := range ucs {
+			w.watchedConds[uc.UnlockHash()] = uc
+		}
+		if err := dbPutWatchedAddresses(w.dbTx, w.watchedAddrList()); err != nil {
+			return err
+		}
+		if err := dbPutWatchedConditions(w.dbTx, w.watchedCondsList()); err != nil {
+			return err
+		}
+		if unused {
+			return nil
+		}
+		return w.resetHistory()
+	}()
+	if err != nil || unused {
+		return err
+	}
+	return w.managedRescan()
+}
+
+// RemoveWatchAddresses instructs the wallet to stop tracking addrs. Multisig
+// addresses and the UnlockConditions of watched addresses are forgotten as
+// well. If unused is true, the addresses are
+// assumed to have never appeared in the blockchain and no rescan is
+// performed.
+func (w *Wallet) RemoveWatchAddresses(addrs []types.UnlockHash, unused bool) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+
+	if !w.scanLock.TryLock() {
+		return errScanInProgress
+	}
+	defer w.scanLock.Unlock()
+
+	err := func() error {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.unlocked {
+			return modules.ErrLockedWallet
+		}
+
+		for _, addr := range addrs {
+			delete(w.watchedAddrs, addr)
+			delete(w.multisigConds, addr)
+			delete(w.watchedConds, addr)
+		}
+		if err := dbPutWatchedAddresses(w.dbTx, w.watchedAddrList()); err != nil {
+			return err
+		}
+		if err := dbPutWatchedConditions(w.dbTx, w.watchedCondsList()); err != nil {
+			return err
+		}
+		if err := dbPutMultisigConditions(w.dbTx, w.multisigCondsList()); err != nil {
+			return err
+		}
+		if unused {
+			return w.pruneWatchedOutputs()
+		}
+		return w.resetHistory()
+	}()
+	if err != nil || unused {
+		return err
+	}
+	return w.managedRescan()
+}
+
+// pruneWatchedOutputs deletes any watched outputs whose address is no longer
+// being watched.
+func (w *Wallet) pruneWatchedOutputs() error {
+	var scoids []types.SiacoinOutputID
+	dbForEachWatchedSiacoinOutput(w.dbTx, func(id types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if !w.isWatchedAddress(sco.UnlockHash) {
+			scoids = append(scoids, id)
+		}
+	})
+	for _, id := range scoids {
+		if err := dbDeleteWatchedSiacoinOutput(w.dbTx, id); err != nil {
+			return err
+		}
+	}
+	var sfoids []types.SiafundOutputID
+	dbForEachWatchedSiafundOutput(w.dbTx, func(id types.SiafundOutputID, sfo types.SiafundOutput) {
+		if !w.isWatchedAddress(sfo.UnlockHash) {
+			sfoids = append(sfoids, id)
+		}
+	})
+	for _, id := range sfoids {
+		if err := dbDeleteWatchedSiafundOutput(w.dbTx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WatchAddresses returns the set of addresses that the wallet is watching.
+func (w *Wallet) WatchAddresses() ([]types.UnlockHash, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer w.tg.Done()
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.watchedAddrList(), nil
+}
+
+// WatchOnlyBalance returns the confirmed balance of the wallet's watch-only
+// addresses. These coins cannot be spent by the wallet.
+func (w *Wallet) WatchOnlyBalance() (siacoinBalance types.Currency, siafundBalance types.Currency) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// ensure durability of reported balance
+	w.syncDB()
+
+	dbForEachWatchedSiacoinOutput(w.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
+		siacoinBalance = siacoinBalance.Add(sco.Value)
+	})
+	dbForEachWatchedSiafundOutput(w.dbTx, func(_ types.SiafundOutputID, sfo types.SiafundOutput) {
+		siafundBalance = siafundBalance.Add(sfo.Value)
+	})
+	return
+}
+
+// watchedAddrList returns the watch-only addresses as a slice.
+func (w *Wallet) watchedAddrList() []types.UnlockHash {
+	addrs := make([]types.UnlockHash, 0, len(w.watchedAddrs))
+	for addr := range w.watchedAddrs {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+// watchedCondsList returns the UnlockConditions of the watch-only addresses as
+// a slice.
+func (w *Wallet) watchedCondsList() []types.UnlockConditions {
+	ucs := make([]types.UnlockConditions, 0, len(w.watchedConds))
+	for _, uc := range w.watchedConds {
+		ucs = append(ucs, uc)
+	}
+	return ucs
+}
+
+// watchedUnlockConditions returns the UnlockConditions of a watched address,
+// if the wallet knows them.
+func (w *Wallet) watchedUnlockConditions(addr types.UnlockHash) (types.UnlockConditions, bool) {
+	if uc, ok := w.watchedConds[addr]; ok {
+		return uc, true
+	}
+	uc, ok := w.multisigConds[addr]
+	return uc, ok
+}
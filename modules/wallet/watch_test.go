@@ -0,0 +1,95 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
+)
+
+// TestWatchAddresses checks that the wallet tracks the balance and history of
+// watch-only addresses without treating their outputs as spendable.
+func TestWatchAddresses(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Send coins to an address that does not belong to the wallet.
+	var addr types.UnlockHash
+	fastrand.Read(addr[:])
+	sendAmount := types.SiacoinPrecision.Mul64(50)
+	_, err = wt.wallet.SendSiacoins(sendAmount, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := wt.miner.FindBlock()
+	if err := wt.cs.AcceptBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	scBal, _ := wt.wallet.WatchOnlyBalance()
+	if !scBal.IsZero() {
+		t.Fatal("watch-only balance should be zero before watching any addresses")
+	}
+
+	// Watching a wallet address should fail.
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.AddWatchAddresses([]types.UnlockHash{uc.UnlockHash()}, false); err != errWatchSpendableAddress {
+		t.Fatal("expected errWatchSpendableAddress, got", err)
+	}
+
+	// Watch the address. The rescan should pick up the earlier payment.
+	confirmedBefore, _, _ := wt.wallet.ConfirmedBalance()
+	if err := wt.wallet.AddWatchAddresses([]types.UnlockHash{addr}, false); err != nil {
+		t.Fatal(err)
+	}
+	scBal, _ = wt.wallet.WatchOnlyBalance()
+	if !scBal.Equals(sendAmount) {
+		t.Fatalf("watch-only balance should be %v, got %v", sendAmount, scBal)
+	}
+	if len(wt.wallet.AddressTransactions(addr)) != 1 {
+		t.Fatal("expected one transaction for the watched address, got", len(wt.wallet.AddressTransactions(addr)))
+	}
+	confirmedAfter, _, _ := wt.wallet.ConfirmedBalance()
+	if !confirmedAfter.Equals(confirmedBefore) {
+		t.Fatal("watched outputs should not be counted as spendable", confirmedBefore, confirmedAfter)
+	}
+	addrs, err := wt.wallet.WatchAddresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 1 || addrs[0] != addr {
+		t.Fatal("wrong set of watched addresses:", addrs)
+	}
+
+	// Payments to a watched address should be tracked as they are confirmed.
+	_, err = wt.wallet.SendSiacoins(sendAmount, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ = wt.miner.FindBlock()
+	if err := wt.cs.AcceptBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	scBal, _ = wt.wallet.WatchOnlyBalance()
+	if !scBal.Equals(sendAmount.Mul64(2)) {
+		t.Fatalf("watch-only balance should be %v, got %v", sendAmount.Mul64(2), scBal)
+	}
+
+	// Removing the address should clear its balance.
+	if err := wt.wallet.RemoveWatchAddresses([]types.UnlockHash{addr}, true); err != nil {
+		t.Fatal(err)
+	}
+	scBal, _ = wt.wallet.WatchOnlyBalance()
+	if !scBal.IsZero() {
+		t.Fatal("watch-only balance should be zero after removing the address, got", scBal)
+	}
+}
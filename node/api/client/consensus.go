@@ -3,6 +3,8 @@ package client
 import (
 	"fmt"
 
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/node/api"
 	"github.com/NebulousLabs/Sia/types"
 )
@@ -24,3 +26,15 @@ func (c *Client) ConsensusBlocksHeightGet(height types.BlockHeight) (block types
 	err = c.get("/consensus/blocks?height="+fmt.Sprint(height), &block)
 	return
 }
+
+// ConsensusSnapshotGet requests the /consensus/snapshot api resource
+func (c *Client) ConsensusSnapshotGet() ([]byte, error) {
+	return c.getRawResponse("/consensus/snapshot")
+}
+
+// ConsensusSubscribeGet requests the /consensus/subscribe/:changeid api
+// resource
+func (c *Client) ConsensusSubscribeGet(id modules.ConsensusChangeID, count int) (csg api.ConsensusSubscribeGET, err error) {
+	err = c.get(fmt.Sprintf("/consensus/subscribe/%v?count=%v", crypto.Hash(id), count), &csg)
+	return
+}
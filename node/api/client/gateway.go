@@ -1,6 +1,9 @@
 package client
 
 import (
+	"net/url"
+	"strconv"
+
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/node/api"
 	"github.com/NebulousLabs/errors"
@@ -23,8 +26,56 @@ func (c *Client) GatewayConnectPost(address modules.NetAddress) (err error) {
 	return
 }
 
+// GatewayDisconnectPost uses the /gateway/disconnect/:address endpoint to
+// disconnect from the gateway at address. If unpin is true, the identity key
+// pinned for the address is removed as well.
+func (c *Client) GatewayDisconnectPost(address modules.NetAddress, unpin bool) (err error) {
+	values := url.Values{}
+	values.Set("unpin", strconv.FormatBool(unpin))
+	err = c.post("/gateway/disconnect/"+string(address), values.Encode(), nil)
+	return
+}
+
 // GatewayGet requests the /gateway api resource
 func (c *Client) GatewayGet() (gwg api.GatewayGET, err error) {
 	err = c.get("/gateway", &gwg)
 	return
 }
+
+// GatewaySettingsPost uses the /gateway endpoint to change the gateway's peer
+// and bandwidth limits
+func (c *Client) GatewaySettingsPost(settings modules.GatewaySettings) (err error) {
+	values := url.Values{}
+	values.Set("maxinboundpeers", strconv.Itoa(settings.MaxInboundPeers))
+	values.Set("maxoutboundpeers", strconv.Itoa(settings.MaxOutboundPeers))
+	values.Set("maxdownloadspeed", strconv.FormatInt(settings.MaxDownloadSpeed, 10))
+	values.Set("maxuploadspeed", strconv.FormatInt(settings.MaxUploadSpeed, 10))
+	err = c.post("/gateway", values.Encode(), nil)
+	return
+}
+
+// GatewayBlocklistGet requests the /gateway/blocklist api resource
+func (c *Client) GatewayBlocklistGet() (gbg api.GatewayBlocklistGET, err error) {
+	err = c.get("/gateway/blocklist", &gbg)
+	return
+}
+
+// GatewayBanPost uses the /gateway/blocklist endpoint to add an address to the
+// blocklist
+func (c *Client) GatewayBanPost(address string) (err error) {
+	values := url.Values{}
+	values.Set("action", "ban")
+	values.Set("address", address)
+	err = c.post("/gateway/blocklist", values.Encode(), nil)
+	return
+}
+
+// GatewayUnbanPost uses the /gateway/blocklist endpoint to remove an address
+// from the blocklist
+func (c *Client) GatewayUnbanPost(address string) (err error) {
+	values := url.Values{}
+	values.Set("action", "unban")
+	values.Set("address", address)
+	err = c.post("/gateway/blocklist", values.Encode(), nil)
+	return
+}
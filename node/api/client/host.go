@@ -3,7 +3,9 @@ package client
 import (
 	"net/url"
 	"strconv"
+	"strings"
 
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/node/api"
 )
 
@@ -14,6 +16,19 @@ func (c *Client) HostAnnouncePost() (err error) {
 	return
 }
 
+// HostAnnounceAddrsPost uses the /host/announce endpoint to announce the host
+// to the network with the provided addresses, in order of preference
+func (c *Client) HostAnnounceAddrsPost(addrs ...modules.NetAddress) (err error) {
+	strs := make([]string, len(addrs))
+	for i, addr := range addrs {
+		strs[i] = string(addr)
+	}
+	values := url.Values{}
+	values.Set("netaddress", strings.Join(strs, ","))
+	err = c.post("/host/announce", values.Encode(), nil)
+	return
+}
+
 // HostAcceptingContractsPost uses the /host endpoint to change the acceptingcontracts field of the host's settings
 func (c *Client) HostAcceptingContractsPost(acceptingContracts bool) (err error) {
 	values := url.Values{}
@@ -32,8 +47,31 @@ func (c *Client) HostStorageFoldersAddPost(path string, size uint64) (err error)
 	return
 }
 
+// HostStorageFoldersMigratePost uses the /host/storage/folders/migrate api
+// endpoint to move a storage folder of a host to a new path
+func (c *Client) HostStorageFoldersMigratePost(path, newPath string) (err error) {
+	values := url.Values{}
+	values.Set("path", path)
+	values.Set("newpath", newPath)
+	err = c.post("/host/storage/folders/migrate", values.Encode(), nil)
+	return
+}
+
+// HostStorageFoldersMigrateGet requests the /host/storage/folders/migrate
+// endpoint.
+func (c *Client) HostStorageFoldersMigrateGet() (smg api.StorageMigrationsGET, err error) {
+	err = c.get("/host/storage/folders/migrate", &smg)
+	return
+}
+
 // HostGet requests the /host endpoint.
 func (c *Client) HostGet() (hg api.HostGET, err error) {
 	err = c.get("/host", &hg)
 	return
 }
+
+// HostStorageObligationsGet requests the /host/storageobligations endpoint.
+func (c *Client) HostStorageObligationsGet() (hsog api.HostStorageObligationsGET, err error) {
+	err = c.get("/host/storageobligations", &hsog)
+	return
+}
@@ -0,0 +1,19 @@
+package client
+
+import (
+	"github.com/NebulousLabs/Sia/node/api"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TpoolTransactionsGet requests the /tpool/transactions api resource
+func (c *Client) TpoolTransactionsGet() (ttg api.TpoolTransactionsGET, err error) {
+	err = c.get("/tpool/transactions", &ttg)
+	return
+}
+
+// TpoolTransactionsAddressGet requests the /tpool/transactions api resource,
+// only returning the sets that spend from or send to addr
+func (c *Client) TpoolTransactionsAddressGet(addr types.UnlockHash) (ttg api.TpoolTransactionsGET, err error) {
+	err = c.get("/tpool/transactions?address="+addr.String(), &ttg)
+	return
+}
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"net/url"
 	"strconv"
+	"strings"
 
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/node/api"
 	"github.com/NebulousLabs/Sia/types"
 )
@@ -16,6 +19,15 @@ func (c *Client) WalletAddressGet() (wag api.WalletAddressGET, err error) {
 	return
 }
 
+// WalletAddressPurposeGet requests a new address from the /wallet/address
+// endpoint in the derivation namespace of purpose.
+func (c *Client) WalletAddressPurposeGet(purpose modules.AddressPurpose) (wag api.WalletAddressGET, err error) {
+	values := url.Values{}
+	values.Set("purpose", string(purpose))
+	err = c.get("/wallet/address?"+values.Encode(), &wag)
+	return
+}
+
 // WalletInitPost uses the /wallet/init endpoint to initialize and encrypt a
 // wallet
 func (c *Client) WalletInitPost(password string, force bool) (wip api.WalletInitPOST, err error) {
@@ -32,6 +44,135 @@ func (c *Client) WalletGet() (wg api.WalletGET, err error) {
 	return
 }
 
+// WalletBackupGet uses the /wallet/backup endpoint to create a copy of the
+// wallet database at destination.
+func (c *Client) WalletBackupGet(destination, sendPassword string) (err error) {
+	values := url.Values{}
+	values.Set("destination", destination)
+	values.Set("sendpassword", sendPassword)
+	err = c.get("/wallet/backup?"+values.Encode(), nil)
+	return
+}
+
+// WalletBackupPost uses the /wallet/backup endpoint to create a portable
+// backup of the wallet at destination, encrypted with backupPassword.
+func (c *Client) WalletBackupPost(destination, backupPassword, sendPassword string) (err error) {
+	values := url.Values{}
+	values.Set("destination", destination)
+	values.Set("backuppassword", backupPassword)
+	values.Set("sendpassword", sendPassword)
+	err = c.post("/wallet/backup", values.Encode(), nil)
+	return
+}
+
+// WalletBackupRestorePost uses the /wallet/backup/restore endpoint to load a
+// portable backup from source.
+func (c *Client) WalletBackupRestorePost(source, encryptionPassword, backupPassword string) (err error) {
+	values := url.Values{}
+	values.Set("source", source)
+	values.Set("encryptionpassword", encryptionPassword)
+	values.Set("backuppassword", backupPassword)
+	err = c.post("/wallet/backup/restore", values.Encode(), nil)
+	return
+}
+
+// WalletBumpFeePost uses the /wallet/bumpfee endpoint to raise the fee of an
+// unconfirmed transaction to fee.
+func (c *Client) WalletBumpFeePost(txid types.TransactionID, fee types.Currency, sendPassword string) (wbp api.WalletBumpFeePOST, err error) {
+	values := url.Values{}
+	values.Set("transactionid", txid.String())
+	values.Set("fee", fee.String())
+	values.Set("sendpassword", sendPassword)
+	err = c.post("/wallet/bumpfee", values.Encode(), &wbp)
+	return
+}
+
+// WalletConsolidatePost uses the /wallet/consolidate endpoint to merge up to
+// maxInputs of the wallet's smallest outputs into a single output.
+func (c *Client) WalletConsolidatePost(maxInputs int, dryRun bool) (wcp api.WalletConsolidatePOST, err error) {
+	values := url.Values{}
+	if maxInputs > 0 {
+		values.Set("maxinputs", strconv.Itoa(maxInputs))
+	}
+	values.Set("dryrun", strconv.FormatBool(dryRun))
+	err = c.post("/wallet/consolidate", values.Encode(), &wcp)
+	return
+}
+
+// WalletFreezePost uses the /wallet/freeze endpoint to freeze or, if remove
+// is true, unfreeze the given outputs.
+func (c *Client) WalletFreezePost(ids []types.OutputID, remove bool) (err error) {
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = id.String()
+	}
+	values := url.Values{}
+	values.Set("outputids", strings.Join(strs, ","))
+	values.Set("remove", strconv.FormatBool(remove))
+	err = c.post("/wallet/freeze", values.Encode(), nil)
+	return
+}
+
+// WalletLabelsGet requests the /wallet/labels endpoint and returns the
+// address and transaction labels of the wallet.
+func (c *Client) WalletLabelsGet() (wlg api.WalletLabelsGET, err error) {
+	err = c.get("/wallet/labels", &wlg)
+	return
+}
+
+// WalletAddressLabelPost uses the /wallet/labels endpoint to assign a label
+// to an address. An empty label removes the address's label.
+func (c *Client) WalletAddressLabelPost(addr types.UnlockHash, label string) (err error) {
+	values := url.Values{}
+	values.Set("address", addr.String())
+	values.Set("label", label)
+	err = c.post("/wallet/labels", values.Encode(), nil)
+	return
+}
+
+// WalletTransactionLabelPost uses the /wallet/labels endpoint to assign a
+// label to a transaction. An empty label removes the transaction's label.
+func (c *Client) WalletTransactionLabelPost(txid types.TransactionID, label string) (err error) {
+	values := url.Values{}
+	values.Set("transactionid", txid.String())
+	values.Set("label", label)
+	err = c.post("/wallet/labels", values.Encode(), nil)
+	return
+}
+
+// WalletPolicyGet requests the /wallet/policy api resource.
+func (c *Client) WalletPolicyGet() (wpg api.WalletPolicyGET, err error) {
+	err = c.get("/wallet/policy", &wpg)
+	return
+}
+
+// WalletPolicyPost uses the /wallet/policy endpoint to replace the wallet's
+// spending policy. sendPassword must match the wallet's send password, if one
+// is set.
+func (c *Client) WalletPolicyPost(policy modules.SpendingPolicy, sendPassword string) (err error) {
+	addrs := make([]string, len(policy.Allowlist))
+	for i, addr := range policy.Allowlist {
+		addrs[i] = addr.String()
+	}
+	values := url.Values{}
+	values.Set("transactionlimit", policy.TransactionLimit.String())
+	values.Set("dailylimit", policy.DailyLimit.String())
+	values.Set("allowlist", strings.Join(addrs, ","))
+	values.Set("sendpassword", sendPassword)
+	err = c.post("/wallet/policy", values.Encode(), nil)
+	return
+}
+
+// WalletSendPasswordPost uses the /wallet/policy endpoint to change the
+// wallet's send password. An empty newPassword removes the send password.
+func (c *Client) WalletSendPasswordPost(sendPassword, newPassword string) (err error) {
+	values := url.Values{}
+	values.Set("sendpassword", sendPassword)
+	values.Set("newsendpassword", newPassword)
+	err = c.post("/wallet/policy", values.Encode(), nil)
+	return
+}
+
 // WalletSiacoinsMultiPost uses the /wallet/siacoin api endpoint to send money
 // to multiple addresses at once
 func (c *Client) WalletSiacoinsMultiPost(outputs []types.SiacoinOutput) (wsp api.WalletSiacoinsPOST, err error) {
@@ -55,6 +196,42 @@ func (c *Client) WalletSiacoinsPost(amount types.Currency, destination types.Unl
 	return
 }
 
+// WalletSiacoinsOptionsPost uses the /wallet/siacoins api endpoint to send
+// money to multiple addresses, using opts to control input selection, fees and
+// change.
+func (c *Client) WalletSiacoinsOptionsPost(outputs []types.SiacoinOutput, opts modules.SendOptions) (wsp api.WalletSiacoinsPOST, err error) {
+	values := url.Values{}
+	marshaledOutputs, err := json.Marshal(outputs)
+	if err != nil {
+		return api.WalletSiacoinsPOST{}, err
+	}
+	values.Set("outputs", string(marshaledOutputs))
+	if !opts.Fee.IsZero() {
+		values.Set("fee", opts.Fee.String())
+	}
+	if !opts.FeePerByte.IsZero() {
+		values.Set("feeperbyte", opts.FeePerByte.String())
+	}
+	if len(opts.Inputs) > 0 {
+		ids := make([]string, len(opts.Inputs))
+		for i, id := range opts.Inputs {
+			ids[i] = id.String()
+		}
+		values.Set("inputs", strings.Join(ids, ","))
+	}
+	if opts.ChangeAddress != (types.UnlockHash{}) {
+		values.Set("changeaddress", opts.ChangeAddress.String())
+	}
+	if opts.DryRun {
+		values.Set("dryrun", "true")
+	}
+	if opts.SendPassword != "" {
+		values.Set("sendpassword", opts.SendPassword)
+	}
+	err = c.post("/wallet/siacoins", values.Encode(), &wsp)
+	return
+}
+
 // WalletTransactionsGet requests the/wallet/transactions api resource for a
 // certain startheight and endheight
 func (c *Client) WalletTransactionsGet(startHeight types.BlockHeight, endHeight types.BlockHeight) (wtg api.WalletTransactionsGET, err error) {
@@ -63,6 +240,15 @@ func (c *Client) WalletTransactionsGet(startHeight types.BlockHeight, endHeight
 	return
 }
 
+// WalletTransactionsLabelGet requests the /wallet/transactions api resource
+// for a certain startheight and endheight, returning only the transactions
+// whose label or address labels contain search.
+func (c *Client) WalletTransactionsLabelGet(startHeight types.BlockHeight, endHeight types.BlockHeight, search string) (wtg api.WalletTransactionsGET, err error) {
+	err = c.get(fmt.Sprintf("/wallet/transactions?startheight=%v&endheight=%v&label=%v",
+		startHeight, endHeight, url.QueryEscape(search)), &wtg)
+	return
+}
+
 // WalletUnlockPost uses the /wallet/unlock endpoint to unlock the wallet with
 // a given encryption key. Per default this key is the seed.
 func (c *Client) WalletUnlockPost(password string) (err error) {
@@ -71,3 +257,105 @@ func (c *Client) WalletUnlockPost(password string) (err error) {
 	err = c.post("/wallet/unlock", values.Encode(), nil)
 	return
 }
+
+// WalletWatchGet requests the /wallet/watch api resource
+func (c *Client) WalletWatchGet() (wwg api.WalletWatchGET, err error) {
+	err = c.get("/wallet/watch", &wwg)
+	return
+}
+
+// WalletWatchPost uses the /wallet/watch endpoint to add or remove watch-only
+// addresses. If unused is true, the wallet will not rescan the blockchain.
+func (c *Client) WalletWatchPost(addrs []types.UnlockHash, remove, unused bool) (err error) {
+	addrStrs := make([]string, len(addrs))
+	for i, addr := range addrs {
+		addrStrs[i] = addr.String()
+	}
+	values := url.Values{}
+	values.Set("addresses", strings.Join(addrStrs, ","))
+	values.Set("remove", strconv.FormatBool(remove))
+	values.Set("unused", strconv.FormatBool(unused))
+	err = c.post("/wallet/watch", values.Encode(), nil)
+	return
+}
+
+// WalletWatchUnlockConditionsPost uses the /wallet/watch endpoint to watch
+// the addresses of ucs. The wallet keeps the UnlockConditions so that it can
+// build unsigned transactions spending from the addresses.
+func (c *Client) WalletWatchUnlockConditionsPost(ucs []types.UnlockConditions, unused bool) (err error) {
+	ucBytes, err := json.Marshal(ucs)
+	if err != nil {
+		return err
+	}
+	values := url.Values{}
+	values.Set("unlockconditions", string(ucBytes))
+	values.Set("unused", strconv.FormatBool(unused))
+	err = c.post("/wallet/watch", values.Encode(), nil)
+	return
+}
+
+// WalletWatchFundPost uses the /wallet/watch/fund endpoint to build an
+// unsigned transaction sending outputs, funded from watch-only addresses.
+func (c *Client) WalletWatchFundPost(outputs []types.SiacoinOutput) (wwfp api.WalletWatchFundPOST, err error) {
+	outputsBytes, err := json.Marshal(outputs)
+	if err != nil {
+		return api.WalletWatchFundPOST{}, err
+	}
+	values := url.Values{}
+	values.Set("outputs", string(outputsBytes))
+	err = c.post("/wallet/watch/fund", values.Encode(), &wwfp)
+	return
+}
+
+// WalletSignPost uses the /wallet/sign endpoint to sign the inputs of txn
+// identified by toSign. If toSign is empty, every input is signed.
+func (c *Client) WalletSignPost(txn types.Transaction, toSign []crypto.Hash, sendPassword string) (wsp api.WalletSignPOST, err error) {
+	txnBytes, err := json.Marshal(txn)
+	if err != nil {
+		return api.WalletSignPOST{}, err
+	}
+	ids := make([]string, len(toSign))
+	for i, id := range toSign {
+		ids[i] = id.String()
+	}
+	values := url.Values{}
+	values.Set("transaction", string(txnBytes))
+	values.Set("tosign", strings.Join(ids, ","))
+	values.Set("sendpassword", sendPassword)
+	err = c.post("/wallet/sign", values.Encode(), &wsp)
+	return
+}
+
+// WalletUnlockConditionsGet requests the /wallet/unlockconditions/:addr api
+// resource
+func (c *Client) WalletUnlockConditionsGet(addr types.UnlockHash) (wucg api.WalletUnlockConditionsGET, err error) {
+	err = c.get("/wallet/unlockconditions/"+addr.String(), &wucg)
+	return
+}
+
+// WalletUnspentGet requests the /wallet/unspent api resource
+func (c *Client) WalletUnspentGet() (wug api.WalletUnspentGET, err error) {
+	err = c.get("/wallet/unspent", &wug)
+	return
+}
+
+// WalletMultisigGet requests the /wallet/multisig api resource
+func (c *Client) WalletMultisigGet() (wmg api.WalletMultisigGET, err error) {
+	err = c.get("/wallet/multisig", &wmg)
+	return
+}
+
+// WalletMultisigPost uses the /wallet/multisig endpoint to create a multisig
+// address requiring 'required' signatures from pubkeys.
+func (c *Client) WalletMultisigPost(pubkeys []types.SiaPublicKey, required uint64, unused bool) (wmp api.WalletMultisigPOST, err error) {
+	pkStrs := make([]string, len(pubkeys))
+	for i := range pubkeys {
+		pkStrs[i] = pubkeys[i].String()
+	}
+	values := url.Values{}
+	values.Set("publickeys", strings.Join(pkStrs, ","))
+	values.Set("required", strconv.FormatUint(required, 10))
+	values.Set("unused", strconv.FormatBool(unused))
+	err = c.post("/wallet/multisig", values.Encode(), &wmp)
+	return
+}
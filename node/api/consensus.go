@@ -4,7 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"sync"
 
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
 
 	"github.com/julienschmidt/httprouter"
@@ -18,6 +22,66 @@ type ConsensusGET struct {
 	CurrentBlock types.BlockID     `json:"currentblock"`
 	Target       types.Target      `json:"target"`
 	Difficulty   types.Currency    `json:"difficulty"`
+	SPV          bool              `json:"spv"`
+	HeaderHeight types.BlockHeight `json:"headerheight"`
+}
+
+const (
+	// defaultConsensusSubscribeChanges is the number of consensus changes
+	// returned by /consensus/subscribe if no count is specified.
+	defaultConsensusSubscribeChanges = 10
+
+	// maxConsensusSubscribeChanges is the maximum number of consensus changes
+	// returned by a single call to /consensus/subscribe.
+	maxConsensusSubscribeChanges = 100
+)
+
+// ConsensusChange is a consensus change as returned by /consensus/subscribe.
+// The diffs are in the order in which they were applied, with the diffs of
+// reverted blocks already flipped.
+type ConsensusChange struct {
+	ID                         crypto.Hash                        `json:"id"`
+	RevertedBlocks             []types.Block                      `json:"revertedblocks"`
+	AppliedBlocks              []types.Block                      `json:"appliedblocks"`
+	SiacoinOutputDiffs         []modules.SiacoinOutputDiff        `json:"siacoinoutputdiffs"`
+	FileContractDiffs          []modules.FileContractDiff         `json:"filecontractdiffs"`
+	SiafundOutputDiffs         []modules.SiafundOutputDiff        `json:"siafundoutputdiffs"`
+	DelayedSiacoinOutputDiffs  []modules.DelayedSiacoinOutputDiff `json:"delayedsiacoinoutputdiffs"`
+	SiafundPoolDiffs           []modules.SiafundPoolDiff          `json:"siafundpooldiffs"`
+	ChildTarget                types.Target                       `json:"childtarget"`
+	MinimumValidChildTimestamp types.Timestamp                    `json:"minimumvalidchildtimestamp"`
+	BlockHeight                types.BlockHeight                  `json:"blockheight"`
+	Synced                     bool                               `json:"synced"`
+}
+
+// ConsensusSubscribeGET contains the consensus changes that follow a
+// consensus change. NextChangeID is the id to request the changes after these
+// ones with.
+type ConsensusSubscribeGET struct {
+	Changes      []ConsensusChange `json:"changes"`
+	NextChangeID crypto.Hash       `json:"nextchangeid"`
+}
+
+// changeCollector is a consensus set subscriber that collects a limited number
+// of consensus changes, closing cancel once the limit is reached.
+type changeCollector struct {
+	changes []modules.ConsensusChange
+	limit   int
+	cancel  chan struct{}
+	mu      sync.Mutex
+}
+
+// ProcessConsensusChange implements modules.ConsensusSetSubscriber.
+func (cc *changeCollector) ProcessConsensusChange(change modules.ConsensusChange) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if len(cc.changes) == cc.limit {
+		return
+	}
+	cc.changes = append(cc.changes, change)
+	if len(cc.changes) == cc.limit {
+		close(cc.cancel)
+	}
 }
 
 // ConsensusHeadersGET contains information from a blocks header.
@@ -35,9 +99,85 @@ func (api *API) consensusHandler(w http.ResponseWriter, req *http.Request, _ htt
 		CurrentBlock: cbid,
 		Target:       currentTarget,
 		Difficulty:   currentTarget.Difficulty(),
+		SPV:          api.cs.SPV(),
+		HeaderHeight: api.cs.HeaderHeight(),
 	})
 }
 
+// consensusSnapshotHandler handles the API calls to /consensus/snapshot. The
+// snapshot is streamed as binary data.
+func (api *API) consensusSnapshotHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if err := api.cs.Snapshot(w); err != nil {
+		WriteError(w, Error{"error when calling /consensus/snapshot: " + err.Error()}, http.StatusInternalServerError)
+	}
+}
+
+// consensusSubscribeHandler handles the API calls to
+// /consensus/subscribe/:changeid. It returns the consensus changes that
+// follow the given change, oldest first.
+func (api *API) consensusSubscribeHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var start crypto.Hash
+	if err := start.LoadString(ps.ByName("changeid")); err != nil {
+		WriteError(w, Error{"error when calling /consensus/subscribe: unable to parse changeid: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	count := defaultConsensusSubscribeChanges
+	if c := req.FormValue("count"); c != "" {
+		n, err := strconv.Atoi(c)
+		if err != nil || n <= 0 || n > maxConsensusSubscribeChanges {
+			WriteError(w, Error{fmt.Sprintf("error when calling /consensus/subscribe: count must be between 1 and %v", maxConsensusSubscribeChanges)}, http.StatusBadRequest)
+			return
+		}
+		count = n
+	}
+
+	// Replay the changes that follow start until enough have been collected.
+	// If the subscriber catches up with the consensus set before that, it is
+	// added to the subscribers and has to be removed again.
+	collector := &changeCollector{
+		limit:  count,
+		cancel: make(chan struct{}),
+	}
+	err := api.cs.ConsensusSetSubscribe(collector, modules.ConsensusChangeID(start), collector.cancel)
+	if err == nil {
+		api.cs.Unsubscribe(collector)
+	}
+	collector.mu.Lock()
+	changes := collector.changes
+	collector.mu.Unlock()
+	if err == modules.ErrInvalidConsensusChangeID {
+		WriteError(w, Error{"error when calling /consensus/subscribe: " + err.Error()}, http.StatusBadRequest)
+		return
+	} else if err != nil && len(changes) != count {
+		WriteError(w, Error{"error when calling /consensus/subscribe: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	csg := ConsensusSubscribeGET{
+		Changes:      make([]ConsensusChange, 0, len(changes)),
+		NextChangeID: start,
+	}
+	for _, cc := range changes {
+		csg.Changes = append(csg.Changes, ConsensusChange{
+			ID:                         crypto.Hash(cc.ID),
+			RevertedBlocks:             cc.RevertedBlocks,
+			AppliedBlocks:              cc.AppliedBlocks,
+			SiacoinOutputDiffs:         cc.SiacoinOutputDiffs,
+			FileContractDiffs:          cc.FileContractDiffs,
+			SiafundOutputDiffs:         cc.SiafundOutputDiffs,
+			DelayedSiacoinOutputDiffs:  cc.DelayedSiacoinOutputDiffs,
+			SiafundPoolDiffs:           cc.SiafundPoolDiffs,
+			ChildTarget:                cc.ChildTarget,
+			MinimumValidChildTimestamp: cc.MinimumValidChildTimestamp,
+			BlockHeight:                cc.BlockHeight,
+			Synced:                     cc.Synced,
+		})
+		csg.NextChangeID = crypto.Hash(cc.ID)
+	}
+	WriteJSON(w, csg)
+}
+
 // consensusBlocksIDHandler handles the API calls to /consensus/blocks
 // endpoint.
 func (api *API) consensusBlocksHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -51,11 +191,11 @@ func (api *API) consensusBlocksHandler(w http.ResponseWriter, req *http.Request,
 	}
 
 	var b types.Block
+	var bid types.BlockID
 	var exists bool
 
 	// Handle request by id
 	if id != "" {
-		var bid types.BlockID
 		if err := bid.LoadString(id); err != nil {
 			WriteError(w, Error{"failed to unmarshal blockid"}, http.StatusBadRequest)
 			return
@@ -70,6 +210,19 @@ func (api *API) consensusBlocksHandler(w http.ResponseWriter, req *http.Request,
 			return
 		}
 		b, exists = api.cs.BlockAtHeight(types.BlockHeight(h))
+		if header, ok := api.cs.HeaderAtHeight(types.BlockHeight(h)); ok {
+			bid = header.ID()
+		}
+	}
+	// Consensus sets in SPV mode only store the header chain, so blocks
+	// that are missing locally are fetched from peers.
+	if !exists && api.cs.SPV() && bid != (types.BlockID{}) {
+		fetched, err := api.cs.FetchBlock(bid)
+		if err != nil {
+			WriteError(w, Error{"failed to fetch block: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		b, exists = fetched, true
 	}
 	// Check if block was found
 	if !exists {
@@ -2,8 +2,11 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"testing"
 
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -83,3 +86,71 @@ func TestConsensusValidateTransactionSet(t *testing.T) {
 		t.Fatal("expected validation error")
 	}
 }
+
+// TestConsensusSubscribe probes the GET call to
+// /consensus/subscribe/:changeid.
+func TestConsensusSubscribe(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// Page through all changes from the genesis block, two at a time.
+	var changes []ConsensusChange
+	next := crypto.Hash(modules.ConsensusChangeBeginning)
+	for {
+		var csg ConsensusSubscribeGET
+		if err := st.getAPI(fmt.Sprintf("/consensus/subscribe/%v?count=2", next), &csg); err != nil {
+			t.Fatal(err)
+		}
+		if len(csg.Changes) > 2 {
+			t.Fatal("too many changes returned:", len(csg.Changes))
+		}
+		if len(csg.Changes) == 0 {
+			if csg.NextChangeID != next {
+				t.Fatal("next change id should not move without new changes")
+			}
+			break
+		}
+		changes = append(changes, csg.Changes...)
+		next = csg.NextChangeID
+	}
+	if types.BlockHeight(len(changes)) != st.cs.Height()+1 {
+		t.Fatalf("expected %v changes, got %v", st.cs.Height()+1, len(changes))
+	}
+	for i, cc := range changes {
+		b, _ := st.cs.BlockAtHeight(types.BlockHeight(i))
+		if cc.BlockHeight != types.BlockHeight(i) || len(cc.AppliedBlocks) != 1 || cc.AppliedBlocks[0].ID() != b.ID() {
+			t.Fatal("wrong block applied by change", i)
+		}
+	}
+	if len(changes[0].SiafundOutputDiffs) == 0 {
+		t.Fatal("genesis change should create siafund outputs")
+	}
+
+	// A new block should be returned after the last change.
+	b, err := st.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var csg ConsensusSubscribeGET
+	if err := st.getAPI(fmt.Sprintf("/consensus/subscribe/%v", next), &csg); err != nil {
+		t.Fatal(err)
+	}
+	if len(csg.Changes) != 1 || csg.Changes[0].AppliedBlocks[0].ID() != b.ID() || csg.NextChangeID != csg.Changes[0].ID {
+		t.Fatal("expected a single change applying the new block")
+	}
+
+	// Unknown change ids and invalid counts should be rejected.
+	if err := st.getAPI(fmt.Sprintf("/consensus/subscribe/%v", crypto.Hash{2}), &csg); err == nil {
+		t.Fatal("expected an error for an unknown change id")
+	}
+	if err := st.getAPI(fmt.Sprintf("/consensus/subscribe/%v?count=0", next), &csg); err == nil {
+		t.Fatal("expected an error for an invalid count")
+	}
+}
@@ -0,0 +1,348 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// EventConsensus is the name of the events that summarize consensus
+	// changes.
+	EventConsensus = "consensus"
+
+	// EventTransactionPool is the name of the events that summarize
+	// transaction pool diffs.
+	EventTransactionPool = "tpool"
+
+	// EventUpload is the name of the events sent when a renter upload
+	// completes.
+	EventUpload = "upload"
+
+	// EventDownload is the name of the events sent when a renter download
+	// completes or fails.
+	EventDownload = "download"
+
+	// EventError is the name of the event sent before the stream is closed
+	// because of an error.
+	EventError = "error"
+
+	// maxPendingEvents is the number of events that may be queued for a
+	// client before the stream is closed. Clients that fall behind can resume
+	// from the last consensus change they received.
+	maxPendingEvents = 100e3
+)
+
+var (
+	// renterEventInterval is how often the renter is checked for completed
+	// uploads and downloads.
+	renterEventInterval = build.Select(build.Var{
+		Standard: 5 * time.Second,
+		Dev:      time.Second,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
+)
+
+type (
+	// ConsensusEvent summarizes a consensus change. Its ID can be used to
+	// resume the event stream.
+	ConsensusEvent struct {
+		ID                 crypto.Hash           `json:"id"`
+		Height             types.BlockHeight     `json:"height"`
+		AppliedBlocks      []types.BlockID       `json:"appliedblocks"`
+		RevertedBlocks     []types.BlockID       `json:"revertedblocks"`
+		Synced             bool                  `json:"synced"`
+		WalletTransactions []types.TransactionID `json:"wallettransactions"`
+	}
+
+	// TransactionPoolEvent summarizes a transaction pool diff.
+	TransactionPoolEvent struct {
+		AppliedTransactionSets  []TransactionPoolEventSet `json:"appliedtransactionsets"`
+		RevertedTransactionSets []crypto.Hash             `json:"revertedtransactionsets"`
+	}
+
+	// TransactionPoolEventSet is a transaction set that was added to the
+	// transaction pool.
+	TransactionPoolEventSet struct {
+		ID             crypto.Hash           `json:"id"`
+		TransactionIDs []types.TransactionID `json:"transactionids"`
+	}
+
+	// UploadEvent is sent when a renter file has been fully uploaded.
+	UploadEvent struct {
+		SiaPath  string `json:"siapath"`
+		Filesize uint64 `json:"filesize"`
+	}
+
+	// DownloadEvent is sent when a renter download completes. Error is empty
+	// unless the download failed.
+	DownloadEvent struct {
+		SiaPath     string    `json:"siapath"`
+		Destination string    `json:"destination"`
+		EndTime     time.Time `json:"endtime"`
+		Error       string    `json:"error"`
+	}
+
+	// streamEvent is an event queued for an event stream.
+	streamEvent struct {
+		id   string
+		name string
+		data interface{}
+
+		// blockTxns are the transactions of the applied blocks of a
+		// consensus event. They are matched against the wallet when the
+		// event is written, outside of the consensus set's subscriber
+		// callback.
+		blockTxns []types.TransactionID
+	}
+
+	// eventStream subscribes to the consensus set and transaction pool on
+	// behalf of a single /events client. Subscriber callbacks only queue
+	// events, so that a slow client never blocks the modules.
+	eventStream struct {
+		queue    []streamEvent
+		overflow bool
+		notify   chan struct{}
+		mu       sync.Mutex
+	}
+)
+
+// newEventStream returns an empty eventStream.
+func newEventStream() *eventStream {
+	return &eventStream{
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// push queues an event and wakes up the writer.
+func (es *eventStream) push(e streamEvent) {
+	es.mu.Lock()
+	if len(es.queue) >= maxPendingEvents {
+		es.overflow = true
+	} else {
+		es.queue = append(es.queue, e)
+	}
+	es.mu.Unlock()
+	select {
+	case es.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pop returns all queued events and whether the queue overflowed.
+func (es *eventStream) pop() ([]streamEvent, bool) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	events := es.queue
+	es.queue = nil
+	return events, es.overflow
+}
+
+// ProcessConsensusChange implements modules.ConsensusSetSubscriber.
+func (es *eventStream) ProcessConsensusChange(cc modules.ConsensusChange) {
+	ce := ConsensusEvent{
+		ID:                 crypto.Hash(cc.ID),
+		Height:             cc.BlockHeight,
+		AppliedBlocks:      make([]types.BlockID, 0, len(cc.AppliedBlocks)),
+		RevertedBlocks:     make([]types.BlockID, 0, len(cc.RevertedBlocks)),
+		Synced:             cc.Synced,
+		WalletTransactions: []types.TransactionID{},
+	}
+	var txns []types.TransactionID
+	for _, b := range cc.RevertedBlocks {
+		ce.RevertedBlocks = append(ce.RevertedBlocks, b.ID())
+	}
+	for _, b := range cc.AppliedBlocks {
+		ce.AppliedBlocks = append(ce.AppliedBlocks, b.ID())
+		for _, txn := range b.Transactions {
+			txns = append(txns, txn.ID())
+		}
+	}
+	es.push(streamEvent{
+		id:        ce.ID.String(),
+		name:      EventConsensus,
+		data:      ce,
+		blockTxns: txns,
+	})
+}
+
+// ReceiveUpdatedUnconfirmedTransactions implements
+// modules.TransactionPoolSubscriber.
+func (es *eventStream) ReceiveUpdatedUnconfirmedTransactions(diff *modules.TransactionPoolDiff) {
+	te := TransactionPoolEvent{
+		AppliedTransactionSets:  make([]TransactionPoolEventSet, 0, len(diff.AppliedTransactions)),
+		RevertedTransactionSets: make([]crypto.Hash, 0, len(diff.RevertedTransactions)),
+	}
+	for _, ts := range diff.AppliedTransactions {
+		te.AppliedTransactionSets = append(te.AppliedTransactionSets, TransactionPoolEventSet{
+			ID:             crypto.Hash(ts.ID),
+			TransactionIDs: ts.IDs,
+		})
+	}
+	for _, id := range diff.RevertedTransactions {
+		te.RevertedTransactionSets = append(te.RevertedTransactionSets, crypto.Hash(id))
+	}
+	es.push(streamEvent{name: EventTransactionPool, data: te})
+}
+
+// writeEvent writes a server-sent event and flushes it to the client.
+func writeEvent(w http.ResponseWriter, id, name string, data interface{}) error {
+	js, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, js); err != nil {
+		return err
+	}
+	w.(http.Flusher).Flush()
+	return nil
+}
+
+// renterEvents compares the current renter state with the previous one,
+// returning events for the uploads and downloads that completed in between.
+// The seen maps are updated in place.
+func (api *API) renterEvents(uploaded map[string]struct{}, downloaded map[string]struct{}) []streamEvent {
+	var events []streamEvent
+	for _, f := range api.renter.FileList() {
+		_, seen := uploaded[f.SiaPath]
+		if f.UploadProgress >= 100 && !seen {
+			uploaded[f.SiaPath] = struct{}{}
+			events = append(events, streamEvent{name: EventUpload, data: UploadEvent{
+				SiaPath:  f.SiaPath,
+				Filesize: f.Filesize,
+			}})
+		}
+	}
+	for _, d := range api.renter.DownloadHistory() {
+		key := d.SiaPath + "\x00" + d.Destination + "\x00" + d.StartTime.String()
+		_, seen := downloaded[key]
+		if d.Completed && !seen {
+			downloaded[key] = struct{}{}
+			events = append(events, streamEvent{name: EventDownload, data: DownloadEvent{
+				SiaPath:     d.SiaPath,
+				Destination: d.Destination,
+				EndTime:     d.EndTime,
+				Error:       d.Error,
+			}})
+		}
+	}
+	return events
+}
+
+// eventsHandler handles the API call to /events. It streams server-sent
+// events for consensus changes, transaction pool diffs and completed renter
+// transfers until the client disconnects.
+func (api *API) eventsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if _, ok := w.(http.Flusher); !ok {
+		WriteError(w, Error{"error when calling /events: streaming is not supported"}, http.StatusInternalServerError)
+		return
+	}
+
+	// Resume from the requested consensus change. Browsers reconnecting an
+	// EventSource send the id of the last event they received.
+	start := modules.ConsensusChangeRecent
+	changeID := req.FormValue("consensuschange")
+	if changeID == "" {
+		changeID = req.Header.Get("Last-Event-ID")
+	}
+	if changeID != "" {
+		var id crypto.Hash
+		if err := id.LoadString(changeID); err != nil {
+			WriteError(w, Error{"error when calling /events: unable to parse consensuschange: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		start = modules.ConsensusChangeID(id)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+
+	es := newEventStream()
+
+	// Subscribe in a separate goroutine so that the changes replayed while
+	// resuming are streamed as they arrive.
+	cancel := make(chan struct{})
+	subscribed := make(chan error, 1)
+	go func() {
+		subscribed <- api.cs.ConsensusSetSubscribe(es, start, cancel)
+	}()
+	defer func() {
+		close(cancel)
+		if err := <-subscribed; err == nil {
+			api.cs.Unsubscribe(es)
+		}
+	}()
+	if api.tpool != nil {
+		api.tpool.TransactionPoolSubscribe(es)
+		defer api.tpool.Unsubscribe(es)
+	}
+
+	// Record the transfers that completed before the client connected, so
+	// that only new completions are reported.
+	var renterTick <-chan time.Time
+	uploaded := make(map[string]struct{})
+	downloaded := make(map[string]struct{})
+	if api.renter != nil {
+		api.renterEvents(uploaded, downloaded)
+		ticker := time.NewTicker(renterEventInterval)
+		defer ticker.Stop()
+		renterTick = ticker.C
+	}
+
+	subErr := (<-chan error)(subscribed)
+	for {
+		var events []streamEvent
+		overflow := false
+		select {
+		case <-req.Context().Done():
+			return
+		case err := <-subErr:
+			// Put the result back for the deferred cleanup.
+			subscribed <- err
+			subErr = nil
+			if err != nil {
+				writeEvent(w, "", EventError, Error{"unable to subscribe to the consensus set: " + err.Error()})
+				return
+			}
+			continue
+		case <-es.notify:
+			events, overflow = es.pop()
+		case <-renterTick:
+			events = api.renterEvents(uploaded, downloaded)
+		}
+
+		for _, e := range events {
+			if ce, ok := e.data.(ConsensusEvent); ok && api.wallet != nil {
+				for _, txid := range e.blockTxns {
+					if _, found := api.wallet.Transaction(txid); found {
+						ce.WalletTransactions = append(ce.WalletTransactions, txid)
+					}
+				}
+				e.data = ce
+			}
+			if err := writeEvent(w, e.id, e.name, e.data); err != nil {
+				return
+			}
+		}
+		if overflow {
+			writeEvent(w, "", EventError, Error{"too many pending events, resume from the last consensus change"})
+			return
+		}
+	}
+}
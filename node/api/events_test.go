@@ -0,0 +1,167 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// testEvent is a server-sent event read by readEvent.
+type testEvent struct {
+	id   string
+	name string
+	data string
+}
+
+// readEvent reads the next server-sent event from an event stream.
+func readEvent(r *bufio.Reader) (e testEvent, err error) {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return e, err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && e.name != "":
+			return e, nil
+		case strings.HasPrefix(line, "id: "):
+			e.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			e.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			e.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// readEventOfType reads events from an event stream until one with the given
+// name is found, decoding its data into obj.
+func readEventOfType(r *bufio.Reader, name string, obj interface{}) (testEvent, error) {
+	for {
+		e, err := readEvent(r)
+		if err != nil {
+			return e, err
+		}
+		if e.name == name {
+			return e, json.Unmarshal([]byte(e.data), obj)
+		}
+	}
+}
+
+// TestEvents checks that the /events stream reports consensus changes and
+// transaction pool diffs, and that it can be resumed from a consensus change.
+func TestEvents(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+	url := "http://" + st.server.listener.Addr().String() + "/events"
+
+	// An invalid consensus change should be rejected.
+	resp, err := HttpGET(url + "?consensuschange=foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatal("expected status 400 for an invalid consensus change, got", resp.StatusCode)
+	}
+
+	// Stream all consensus changes from the beginning.
+	resp, err = HttpGET(url + "?consensuschange=" + crypto.Hash(modules.ConsensusChangeBeginning).String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatal("wrong content type:", ct)
+	}
+	r := bufio.NewReader(resp.Body)
+	var resumeID string
+	for h := types.BlockHeight(0); h <= st.cs.Height(); h++ {
+		var ce ConsensusEvent
+		e, err := readEventOfType(r, EventConsensus, &ce)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ce.Height != h || len(ce.AppliedBlocks) != 1 {
+			t.Fatalf("expected a change applying block %v, got height %v with %v blocks", h, ce.Height, len(ce.AppliedBlocks))
+		}
+		if b, _ := st.cs.BlockAtHeight(h); ce.AppliedBlocks[0] != b.ID() {
+			t.Fatal("wrong block applied at height", h)
+		}
+		if e.id != ce.ID.String() {
+			t.Fatal("event id does not match the consensus change id")
+		}
+		if h == st.cs.Height()-1 {
+			resumeID = e.id
+		}
+	}
+
+	// A wallet transaction should be reported by the transaction pool and
+	// by the consensus change that confirms it.
+	txns, err := st.wallet.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := txns[len(txns)-1].ID()
+	var te TransactionPoolEvent
+	if _, err := readEventOfType(r, EventTransactionPool, &te); err != nil {
+		t.Fatal(err)
+	}
+	if len(te.AppliedTransactionSets) == 0 {
+		t.Fatal("transaction pool event has no applied transaction sets")
+	}
+	b, err := st.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ce ConsensusEvent
+	if _, err := readEventOfType(r, EventConsensus, &ce); err != nil {
+		t.Fatal(err)
+	}
+	if ce.Height != st.cs.Height() || len(ce.AppliedBlocks) != 1 || ce.AppliedBlocks[0] != b.ID() {
+		t.Fatal("consensus event does not report the mined block")
+	}
+	found := false
+	for _, id := range ce.WalletTransactions {
+		found = found || id == txid
+	}
+	if !found {
+		t.Fatal("consensus event does not report the confirmed wallet transaction")
+	}
+
+	// Resuming with Last-Event-ID should replay the changes that followed.
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("User-Agent", "Sia-Agent")
+	req.Header.Set("Last-Event-ID", resumeID)
+	resp2, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp2.Body.Close()
+	r2 := bufio.NewReader(resp2.Body)
+	for h := st.cs.Height() - 1; h <= st.cs.Height(); h++ {
+		var ce ConsensusEvent
+		if _, err := readEventOfType(r2, EventConsensus, &ce); err != nil {
+			t.Fatal(err)
+		}
+		if ce.Height != h {
+			t.Fatalf("resumed stream reported height %v, expected %v", ce.Height, h)
+		}
+	}
+}
@@ -1,21 +1,33 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
 
 	"github.com/julienschmidt/httprouter"
 )
 
 // GatewayGET contains the fields returned by a GET call to "/gateway".
 type GatewayGET struct {
-	NetAddress modules.NetAddress `json:"netaddress"`
-	Peers      []modules.Peer     `json:"peers"`
+	NetAddress modules.NetAddress      `json:"netaddress"`
+	PublicKey  types.SiaPublicKey      `json:"publickey"`
+	Peers      []modules.Peer          `json:"peers"`
+	Settings   modules.GatewaySettings `json:"settings"`
+	Traffic    []modules.PeerTraffic   `json:"traffic"`
 }
 
-// gatewayHandler handles the API call asking for the gatway status.
-func (api *API) gatewayHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+// GatewayBlocklistGET contains the fields returned by a GET call to
+// "/gateway/blocklist".
+type GatewayBlocklistGET struct {
+	Blocklist []string          `json:"blocklist"`
+	Bans      []modules.PeerBan `json:"bans"`
+}
+
+// gatewayHandlerGET handles the API call asking for the gatway status.
+func (api *API) gatewayHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	peers := api.gateway.Peers()
 	// nil slices are marshalled as 'null' in JSON, whereas 0-length slices are
 	// marshalled as '[]'. The latter is preferred, indicating that the value
@@ -23,7 +35,50 @@ func (api *API) gatewayHandler(w http.ResponseWriter, req *http.Request, _ httpr
 	if peers == nil {
 		peers = make([]modules.Peer, 0)
 	}
-	WriteJSON(w, GatewayGET{api.gateway.Address(), peers})
+	WriteJSON(w, GatewayGET{
+		NetAddress: api.gateway.Address(),
+		PublicKey:  api.gateway.PublicKey(),
+		Peers:      peers,
+		Settings:   api.gateway.Settings(),
+		Traffic:    api.gateway.Traffic(),
+	})
+}
+
+// gatewayHandlerPOST handles the API call changing the gateway's peer and
+// bandwidth limits.
+func (api *API) gatewayHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	settings := api.gateway.Settings()
+	// Scan the peer limits. (optional parameters)
+	if i := req.FormValue("maxinboundpeers"); i != "" {
+		if _, err := fmt.Sscan(i, &settings.MaxInboundPeers); err != nil {
+			WriteError(w, Error{"error when calling /gateway: unable to parse maxinboundpeers: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if o := req.FormValue("maxoutboundpeers"); o != "" {
+		if _, err := fmt.Sscan(o, &settings.MaxOutboundPeers); err != nil {
+			WriteError(w, Error{"error when calling /gateway: unable to parse maxoutboundpeers: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Scan the bandwidth limits. (optional parameters)
+	if d := req.FormValue("maxdownloadspeed"); d != "" {
+		if _, err := fmt.Sscan(d, &settings.MaxDownloadSpeed); err != nil {
+			WriteError(w, Error{"error when calling /gateway: unable to parse maxdownloadspeed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if u := req.FormValue("maxuploadspeed"); u != "" {
+		if _, err := fmt.Sscan(u, &settings.MaxUploadSpeed); err != nil {
+			WriteError(w, Error{"error when calling /gateway: unable to parse maxuploadspeed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := api.gateway.SetSettings(settings); err != nil {
+		WriteError(w, Error{"error when calling /gateway: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
 }
 
 // gatewayConnectHandler handles the API call to add a peer to the gateway.
@@ -38,9 +93,25 @@ func (api *API) gatewayConnectHandler(w http.ResponseWriter, req *http.Request,
 	WriteSuccess(w)
 }
 
-// gatewayDisconnectHandler handles the API call to remove a peer from the gateway.
+// gatewayDisconnectHandler handles the API call to remove a peer from the
+// gateway. If 'unpin' is set, the identity key pinned for the node is removed
+// as well, and the node does not need to be connected.
 func (api *API) gatewayDisconnectHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	addr := modules.NetAddress(ps.ByName("netaddress"))
+	if req.FormValue("unpin") == "true" {
+		if err := api.gateway.Unpin(addr); err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+		connected := false
+		for _, p := range api.gateway.Peers() {
+			connected = connected || p.NetAddress == addr
+		}
+		if !connected {
+			WriteSuccess(w)
+			return
+		}
+	}
 	err := api.gateway.Disconnect(addr)
 	if err != nil {
 		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
@@ -49,3 +120,38 @@ func (api *API) gatewayDisconnectHandler(w http.ResponseWriter, req *http.Reques
 
 	WriteSuccess(w)
 }
+
+// gatewayBlocklistHandlerGET handles the API call to list the blocklist and
+// the temporarily banned peers.
+func (api *API) gatewayBlocklistHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	blocklist, bans := api.gateway.Blocklist()
+	WriteJSON(w, GatewayBlocklistGET{
+		Blocklist: blocklist,
+		Bans:      bans,
+	})
+}
+
+// gatewayBlocklistHandlerPOST handles the API call to add an address to or
+// remove an address from the blocklist.
+func (api *API) gatewayBlocklistHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	addr := req.FormValue("address")
+	if addr == "" {
+		WriteError(w, Error{"error when calling /gateway/blocklist: address must be specified"}, http.StatusBadRequest)
+		return
+	}
+	var err error
+	switch action := req.FormValue("action"); action {
+	case "ban":
+		err = api.gateway.Ban(addr)
+	case "unban":
+		err = api.gateway.Unban(addr)
+	default:
+		WriteError(w, Error{"error when calling /gateway/blocklist: action must be 'ban' or 'unban'"}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"error when calling /gateway/blocklist: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
@@ -1,9 +1,13 @@
 package api
 
 import (
+	"errors"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/modules/gateway"
 )
 
@@ -111,3 +115,189 @@ func TestGatewayPeerDisconnect(t *testing.T) {
 		t.Fatal("/gateway/disconnect did not disconnect from peer", peer.Address())
 	}
 }
+
+// TestGatewayPeerUnpin checks that /gateway/disconnect with 'unpin' removes
+// the identity key pinned for a peer.
+func TestGatewayPeerUnpin(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	peer, err := gateway.New("localhost:0", false, build.TempDir("api", t.Name()+"2", "gateway"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := peer.Close()
+		if err != nil {
+			panic(err)
+		}
+	}()
+	err = st.stdPostAPI("/gateway/connect/"+string(peer.Address()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := url.Values{}
+	values.Set("unpin", "true")
+	if err := st.stdPostAPI("/gateway/disconnect/"+string(peer.Address()), values); err != nil {
+		t.Fatal(err)
+	}
+	var info GatewayGET
+	if err := st.getAPI("/gateway", &info); err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Peers) != 0 {
+		t.Fatal("/gateway/disconnect did not disconnect from peer", peer.Address())
+	}
+	// The peer is no longer pinned.
+	if err := st.stdPostAPI("/gateway/disconnect/"+string(peer.Address()), values); err == nil {
+		t.Fatal("expected unpinning a node without a pinned key to fail")
+	}
+}
+
+// TestGatewayBlocklist checks that /gateway/blocklist bans and unbans peers.
+func TestGatewayBlocklist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	peer, err := gateway.New("localhost:0", false, build.TempDir("api", t.Name()+"2", "gateway"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := peer.Close()
+		if err != nil {
+			panic(err)
+		}
+	}()
+	err = st.stdPostAPI("/gateway/connect/"+string(peer.Address()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := url.Values{}
+	values.Set("action", "ban")
+	values.Set("address", string(peer.Address()))
+	if err := st.stdPostAPI("/gateway/blocklist", values); err != nil {
+		t.Fatal(err)
+	}
+	var gbg GatewayBlocklistGET
+	if err := st.getAPI("/gateway/blocklist", &gbg); err != nil {
+		t.Fatal(err)
+	}
+	if len(gbg.Blocklist) != 1 || gbg.Blocklist[0] != string(peer.Address()) {
+		t.Fatal("peer was not added to the blocklist:", gbg.Blocklist)
+	}
+	var info GatewayGET
+	if err := st.getAPI("/gateway", &info); err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Peers) != 0 {
+		t.Fatal("banned peer was not disconnected")
+	}
+	if err := st.stdPostAPI("/gateway/connect/"+string(peer.Address()), nil); err == nil {
+		t.Fatal("expected connecting to a banned peer to fail")
+	}
+
+	values.Set("action", "unban")
+	if err := st.stdPostAPI("/gateway/blocklist", values); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.stdPostAPI("/gateway/connect/"+string(peer.Address()), nil); err != nil {
+		t.Fatal(err)
+	}
+	values.Set("action", "foo")
+	if err := st.stdPostAPI("/gateway/blocklist", values); err == nil {
+		t.Fatal("expected an invalid action to fail")
+	}
+}
+
+// TestGatewaySettings checks that POST /gateway changes the gateway's limits
+// and that GET /gateway reports the traffic of each peer.
+func TestGatewaySettings(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	values := url.Values{}
+	values.Set("maxinboundpeers", "5")
+	values.Set("maxoutboundpeers", "3")
+	values.Set("maxdownloadspeed", "1000000")
+	if err := st.stdPostAPI("/gateway", values); err != nil {
+		t.Fatal(err)
+	}
+	var info GatewayGET
+	if err := st.getAPI("/gateway", &info); err != nil {
+		t.Fatal(err)
+	}
+	expected := modules.GatewaySettings{
+		MaxInboundPeers:  5,
+		MaxOutboundPeers: 3,
+		MaxDownloadSpeed: 1e6,
+	}
+	if info.Settings != expected {
+		t.Fatal("settings were not changed:", info.Settings)
+	}
+	values = url.Values{}
+	values.Set("maxuploadspeed", "-1")
+	if err := st.stdPostAPI("/gateway", values); err == nil {
+		t.Fatal("expected a negative speed to be rejected")
+	}
+	values.Set("maxuploadspeed", "foo")
+	if err := st.stdPostAPI("/gateway", values); err == nil {
+		t.Fatal("expected an invalid speed to be rejected")
+	}
+
+	// Connecting to a peer calls the ShareNodes RPC, which should show up in
+	// the traffic of the peer.
+	peer, err := gateway.New("localhost:0", false, build.TempDir("api", t.Name()+"2", "gateway"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := peer.Close()
+		if err != nil {
+			panic(err)
+		}
+	}()
+	if err := st.stdPostAPI("/gateway/connect/"+string(peer.Address()), nil); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if err := st.getAPI("/gateway", &info); err != nil {
+			return err
+		}
+		if len(info.Traffic) != 1 || info.Traffic[0].NetAddress != peer.Address() {
+			return errors.New("expected the traffic of a single peer")
+		}
+		for _, rpc := range info.Traffic[0].RPCs {
+			if rpc.Name == "ShareNod" && rpc.Calls > 0 && rpc.Download > 0 {
+				return nil
+			}
+		}
+		return errors.New("ShareNodes traffic was not reported")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
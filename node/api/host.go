@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/modules"
@@ -30,6 +31,7 @@ type (
 		ExternalSettings     modules.HostExternalSettings     `json:"externalsettings"`
 		FinancialMetrics     modules.HostFinancialMetrics     `json:"financialmetrics"`
 		InternalSettings     modules.HostInternalSettings     `json:"internalsettings"`
+		MaintenanceStatus    modules.HostMaintenanceStatus    `json:"maintenancestatus"`
 		NetworkMetrics       modules.HostNetworkMetrics       `json:"networkmetrics"`
 		ConnectabilityStatus modules.HostConnectabilityStatus `json:"connectabilitystatus"`
 		WorkingStatus        modules.HostWorkingStatus        `json:"workingstatus"`
@@ -42,12 +44,26 @@ type (
 		ConversionRate float64        `json:"conversionrate"`
 	}
 
+	// HostStorageObligationsGET contains the information that is returned
+	// after a GET request to /host/storageobligations - the storage
+	// obligations of the host along with their health.
+	HostStorageObligationsGET struct {
+		StorageObligations []modules.StorageObligation `json:"storageobligations"`
+	}
+
 	// StorageGET contains the information that is returned after a GET request
 	// to /host/storage - a bunch of information about the status of storage
 	// management on the host.
 	StorageGET struct {
 		Folders []modules.StorageFolderMetadata `json:"folders"`
 	}
+
+	// StorageMigrationsGET contains the information that is returned after a
+	// GET request to /host/storage/folders/migrate - the storage folders that
+	// are being migrated to a new path, along with their progress.
+	StorageMigrationsGET struct {
+		Folders []modules.StorageFolderMetadata `json:"folders"`
+	}
 )
 
 // folderIndex determines the index of the storage folder with the provided
@@ -67,6 +83,7 @@ func (api *API) hostHandlerGET(w http.ResponseWriter, req *http.Request, _ httpr
 	es := api.host.ExternalSettings()
 	fm := api.host.FinancialMetrics()
 	is := api.host.InternalSettings()
+	ms := api.host.MaintenanceStatus()
 	nm := api.host.NetworkMetrics()
 	cs := api.host.ConnectabilityStatus()
 	ws := api.host.WorkingStatus()
@@ -74,6 +91,7 @@ func (api *API) hostHandlerGET(w http.ResponseWriter, req *http.Request, _ httpr
 		ExternalSettings:     es,
 		FinancialMetrics:     fm,
 		InternalSettings:     is,
+		MaintenanceStatus:    ms,
 		NetworkMetrics:       nm,
 		ConnectabilityStatus: cs,
 		WorkingStatus:        ws,
@@ -95,6 +113,14 @@ func (api *API) parseHostSettings(req *http.Request) (modules.HostInternalSettin
 		}
 		settings.AcceptingContracts = x
 	}
+	if req.FormValue("maintenancemode") != "" {
+		var x bool
+		_, err := fmt.Sscan(req.FormValue("maintenancemode"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaintenanceMode = x
+	}
 	if req.FormValue("maxdownloadbatchsize") != "" {
 		var x uint64
 		_, err := fmt.Sscan(req.FormValue("maxdownloadbatchsize"), &x)
@@ -269,7 +295,11 @@ func (api *API) hostHandlerPOST(w http.ResponseWriter, req *http.Request, _ http
 func (api *API) hostAnnounceHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var err error
 	if addr := req.FormValue("netaddress"); addr != "" {
-		err = api.host.AnnounceAddress(modules.NetAddress(addr))
+		var addrs []modules.NetAddress
+		for _, a := range strings.Split(addr, ",") {
+			addrs = append(addrs, modules.NetAddress(strings.TrimSpace(a)))
+		}
+		err = api.host.AnnounceAddresses(addrs)
 	} else {
 		err = api.host.Announce()
 	}
@@ -280,6 +310,15 @@ func (api *API) hostAnnounceHandler(w http.ResponseWriter, req *http.Request, _
 	WriteSuccess(w)
 }
 
+// hostStorageObligationsHandler handles GET requests to the
+// /host/storageobligations API endpoint, returning the storage obligations of
+// the host.
+func (api *API) hostStorageObligationsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostStorageObligationsGET{
+		StorageObligations: api.host.StorageObligations(),
+	})
+}
+
 // storageHandler returns a bunch of information about storage management on
 // the host.
 func (api *API) storageHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -305,6 +344,48 @@ func (api *API) storageFoldersAddHandler(w http.ResponseWriter, req *http.Reques
 	WriteSuccess(w)
 }
 
+// storageFoldersMigrateHandlerGET handles the API call that lists the storage
+// folders which are being migrated to a new path.
+func (api *API) storageFoldersMigrateHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	folders := []modules.StorageFolderMetadata{}
+	for _, sf := range api.host.StorageFolders() {
+		if sf.MigrationPath != "" {
+			folders = append(folders, sf)
+		}
+	}
+	WriteJSON(w, StorageMigrationsGET{
+		Folders: folders,
+	})
+}
+
+// storageFoldersMigrateHandlerPOST starts the migration of a storage folder to
+// a new path.
+func (api *API) storageFoldersMigrateHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	folderPath := req.FormValue("path")
+	if folderPath == "" {
+		WriteError(w, Error{"path parameter is required"}, http.StatusBadRequest)
+		return
+	}
+	newPath := req.FormValue("newpath")
+	if newPath == "" {
+		WriteError(w, Error{"newpath parameter is required"}, http.StatusBadRequest)
+		return
+	}
+
+	storageFolders := api.host.StorageFolders()
+	folderIndex, err := folderIndex(folderPath, storageFolders)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.host.MigrateStorageFolder(uint16(folderIndex), newPath)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // storageFoldersResizeHandler resizes a storage folder in the storage manager.
 func (api *API) storageFoldersResizeHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	folderPath := req.FormValue("path")
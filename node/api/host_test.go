@@ -878,6 +878,64 @@ func TestRemoveEmptyStorageFolder(t *testing.T) {
 	}
 }
 
+// TestMigrateStorageFolder checks that a storage folder can be migrated to a
+// new path through the API.
+func TestMigrateStorageFolder(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// Set up a storage folder for the host.
+	if err := st.setHostStorage(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The new path is required.
+	migrateValues := url.Values{}
+	migrateValues.Set("path", st.dir)
+	if err = st.stdPostAPI("/host/storage/folders/migrate", migrateValues); err == nil {
+		t.Fatal("expected an error when migrating without a new path")
+	}
+
+	// Migrate the storage folder to a new directory.
+	newDir := filepath.Join(st.dir, "migrated")
+	if err = os.MkdirAll(newDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	migrateValues.Set("newpath", newDir)
+	if err = st.stdPostAPI("/host/storage/folders/migrate", migrateValues); err != nil {
+		t.Fatal(err)
+	}
+
+	// Wait for the migration to complete.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		var smg StorageMigrationsGET
+		if err := st.getAPI("/host/storage/folders/migrate", &smg); err != nil {
+			return err
+		}
+		if len(smg.Folders) != 0 {
+			return errors.New("storage folder is still being migrated")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sg StorageGET
+	if err := st.getAPI("/host/storage", &sg); err != nil {
+		t.Fatal(err)
+	}
+	if len(sg.Folders) != 1 || sg.Folders[0].Path != newDir {
+		t.Fatal("storage folder was not migrated to the new path:", sg.Folders)
+	}
+}
+
 // TestRemoveStorageFolderError checks that invalid calls to
 // /host/storage/folders/remove fail with the appropriate error.
 func TestRemoveStorageFolderError(t *testing.T) {
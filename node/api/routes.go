@@ -25,8 +25,11 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 
 	// Consensus API Calls
 	if api.cs != nil {
+		router.GET("/events", RequirePassword(api.eventsHandler, requiredPassword))
 		router.GET("/consensus", api.consensusHandler)
 		router.GET("/consensus/blocks", api.consensusBlocksHandler)
+		router.GET("/consensus/snapshot", RequirePassword(api.consensusSnapshotHandler, requiredPassword))
+		router.GET("/consensus/subscribe/:changeid", api.consensusSubscribeHandler)
 		router.POST("/consensus/validate/transactionset", api.consensusValidateTransactionsetHandler)
 	}
 
@@ -39,7 +42,10 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 
 	// Gateway API Calls
 	if api.gateway != nil {
-		router.GET("/gateway", api.gatewayHandler)
+		router.GET("/gateway", api.gatewayHandlerGET)
+		router.POST("/gateway", RequirePassword(api.gatewayHandlerPOST, requiredPassword))
+		router.GET("/gateway/blocklist", api.gatewayBlocklistHandlerGET)
+		router.POST("/gateway/blocklist", RequirePassword(api.gatewayBlocklistHandlerPOST, requiredPassword))
 		router.POST("/gateway/connect/:netaddress", RequirePassword(api.gatewayConnectHandler, requiredPassword))
 		router.POST("/gateway/disconnect/:netaddress", RequirePassword(api.gatewayDisconnectHandler, requiredPassword))
 	}
@@ -51,10 +57,13 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 		router.POST("/host", RequirePassword(api.hostHandlerPOST, requiredPassword))              // Change the settings of the host.
 		router.POST("/host/announce", RequirePassword(api.hostAnnounceHandler, requiredPassword)) // Announce the host to the network.
 		router.GET("/host/estimatescore", api.hostEstimateScoreGET)
+		router.GET("/host/storageobligations", api.hostStorageObligationsHandler)
 
 		// Calls pertaining to the storage manager that the host uses.
 		router.GET("/host/storage", api.storageHandler)
 		router.POST("/host/storage/folders/add", RequirePassword(api.storageFoldersAddHandler, requiredPassword))
+		router.GET("/host/storage/folders/migrate", api.storageFoldersMigrateHandlerGET)
+		router.POST("/host/storage/folders/migrate", RequirePassword(api.storageFoldersMigrateHandlerPOST, requiredPassword))
 		router.POST("/host/storage/folders/remove", RequirePassword(api.storageFoldersRemoveHandler, requiredPassword))
 		router.POST("/host/storage/folders/resize", RequirePassword(api.storageFoldersResizeHandler, requiredPassword))
 		router.POST("/host/storage/sectors/delete/:merkleroot", RequirePassword(api.storageSectorsDeleteHandler, requiredPassword))
@@ -104,9 +113,7 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 		router.GET("/tpool/raw/:id", api.tpoolRawHandlerGET)
 		router.POST("/tpool/raw", api.tpoolRawHandlerPOST)
 		router.GET("/tpool/confirmed/:id", api.tpoolConfirmedGET)
-
-		// TODO: re-enable this route once the transaction pool API has been finalized
-		//router.GET("/transactionpool/transactions", api.transactionpoolTransactionsHandler)
+		router.GET("/tpool/transactions", api.tpoolTransactionsHandlerGET)
 	}
 
 	// Wallet API Calls
@@ -116,21 +123,38 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 		router.GET("/wallet/address", RequirePassword(api.walletAddressHandler, requiredPassword))
 		router.GET("/wallet/addresses", api.walletAddressesHandler)
 		router.GET("/wallet/backup", RequirePassword(api.walletBackupHandler, requiredPassword))
+		router.POST("/wallet/backup", RequirePassword(api.walletBackupHandler, requiredPassword))
+		router.POST("/wallet/backup/restore", RequirePassword(api.walletBackupRestoreHandler, requiredPassword))
+		router.POST("/wallet/bumpfee", RequirePassword(api.walletBumpFeeHandler, requiredPassword))
+		router.POST("/wallet/consolidate", RequirePassword(api.walletConsolidateHandler, requiredPassword))
+		router.POST("/wallet/freeze", RequirePassword(api.walletFreezeHandler, requiredPassword))
 		router.POST("/wallet/init", RequirePassword(api.walletInitHandler, requiredPassword))
 		router.POST("/wallet/init/seed", RequirePassword(api.walletInitSeedHandler, requiredPassword))
+		router.GET("/wallet/labels", api.walletLabelsHandlerGET)
+		router.POST("/wallet/labels", RequirePassword(api.walletLabelsHandlerPOST, requiredPassword))
 		router.POST("/wallet/lock", RequirePassword(api.walletLockHandler, requiredPassword))
+		router.GET("/wallet/multisig", api.walletMultisigHandlerGET)
+		router.POST("/wallet/multisig", RequirePassword(api.walletMultisigHandlerPOST, requiredPassword))
+		router.GET("/wallet/policy", RequirePassword(api.walletPolicyHandlerGET, requiredPassword))
+		router.POST("/wallet/policy", RequirePassword(api.walletPolicyHandlerPOST, requiredPassword))
 		router.POST("/wallet/seed", RequirePassword(api.walletSeedHandler, requiredPassword))
 		router.GET("/wallet/seeds", RequirePassword(api.walletSeedsHandler, requiredPassword))
 		router.POST("/wallet/siacoins", RequirePassword(api.walletSiacoinsHandler, requiredPassword))
 		router.POST("/wallet/siafunds", RequirePassword(api.walletSiafundsHandler, requiredPassword))
 		router.POST("/wallet/siagkey", RequirePassword(api.walletSiagkeyHandler, requiredPassword))
+		router.POST("/wallet/sign", RequirePassword(api.walletSignHandler, requiredPassword))
 		router.POST("/wallet/sweep/seed", RequirePassword(api.walletSweepSeedHandler, requiredPassword))
 		router.GET("/wallet/transaction/:id", api.walletTransactionHandler)
 		router.GET("/wallet/transactions", api.walletTransactionsHandler)
 		router.GET("/wallet/transactions/:addr", api.walletTransactionsAddrHandler)
 		router.GET("/wallet/verify/address/:addr", api.walletVerifyAddressHandler)
+		router.GET("/wallet/unlockconditions/:addr", api.walletUnlockConditionsHandler)
 		router.POST("/wallet/unlock", RequirePassword(api.walletUnlockHandler, requiredPassword))
+		router.GET("/wallet/unspent", api.walletUnspentHandler)
 		router.POST("/wallet/changepassword", RequirePassword(api.walletChangePasswordHandler, requiredPassword))
+		router.GET("/wallet/watch", RequirePassword(api.walletWatchHandlerGET, requiredPassword))
+		router.POST("/wallet/watch", RequirePassword(api.walletWatchHandlerPOST, requiredPassword))
+		router.POST("/wallet/watch/fund", RequirePassword(api.walletWatchFundHandler, requiredPassword))
 	}
 
 	// Apply UserAgent middleware and return the Router
@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/base64"
 	"net/http"
+	"strconv"
 
 	"github.com/julienschmidt/httprouter"
 
@@ -13,10 +14,13 @@ import (
 )
 
 type (
-	// TpoolFeeGET contains the current estimated fee
+	// TpoolFeeGET contains the current estimated fee, and the fee estimated
+	// to confirm a transaction within Target blocks.
 	TpoolFeeGET struct {
-		Minimum types.Currency `json:"minimum"`
-		Maximum types.Currency `json:"maximum"`
+		Minimum  types.Currency    `json:"minimum"`
+		Maximum  types.Currency    `json:"maximum"`
+		Target   types.BlockHeight `json:"target"`
+		Estimate types.Currency    `json:"estimate"`
 	}
 
 	// TpoolRawGET contains the requested transaction encoded to the raw
@@ -32,8 +36,40 @@ type (
 	TpoolConfirmedGET struct {
 		Confirmed bool `json:"confirmed"`
 	}
+
+	// TpoolTransactionsGET contains the transaction sets in the transaction
+	// pool, with the sets paying the highest fee per byte first.
+	TpoolTransactionsGET struct {
+		Sets []modules.PoolTransactionSet `json:"sets"`
+	}
 )
 
+// transactionRelatedToAddress returns whether txn spends an output of addr or
+// creates an output for it.
+func transactionRelatedToAddress(txn types.Transaction, addr types.UnlockHash) bool {
+	for _, sci := range txn.SiacoinInputs {
+		if sci.UnlockConditions.UnlockHash() == addr {
+			return true
+		}
+	}
+	for _, sco := range txn.SiacoinOutputs {
+		if sco.UnlockHash == addr {
+			return true
+		}
+	}
+	for _, sfi := range txn.SiafundInputs {
+		if sfi.UnlockConditions.UnlockHash() == addr {
+			return true
+		}
+	}
+	for _, sfo := range txn.SiafundOutputs {
+		if sfo.UnlockHash == addr {
+			return true
+		}
+	}
+	return false
+}
+
 // decodeTransactionID will decode a transaction id from a string.
 func decodeTransactionID(txidStr string) (types.TransactionID, error) {
 	txid := new(crypto.Hash)
@@ -47,10 +83,21 @@ func decodeTransactionID(txidStr string) (types.TransactionID, error) {
 // tpoolFeeHandlerGET returns the current estimated fee. Transactions with
 // fees are lower than the estimated fee may take longer to confirm.
 func (api *API) tpoolFeeHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	target := types.BlockHeight(1)
+	if t := req.FormValue("target"); t != "" {
+		n, err := strconv.ParseUint(t, 10, 64)
+		if err != nil || n == 0 {
+			WriteError(w, Error{"error when calling /tpool/fee: target must be a number of blocks greater than 0"}, http.StatusBadRequest)
+			return
+		}
+		target = types.BlockHeight(n)
+	}
 	min, max := api.tpool.FeeEstimation()
 	WriteJSON(w, TpoolFeeGET{
-		Minimum: min,
-		Maximum: max,
+		Minimum:  min,
+		Maximum:  max,
+		Target:   target,
+		Estimate: api.tpool.FeeEstimate(target),
 	})
 }
 
@@ -133,3 +180,33 @@ func (api *API) tpoolConfirmedGET(w http.ResponseWriter, req *http.Request, ps h
 		Confirmed: confirmed,
 	})
 }
+
+// tpoolTransactionsHandlerGET returns the transaction sets in the transaction
+// pool. If an address is given, only the sets with a transaction that spends
+// from or sends to the address are returned.
+func (api *API) tpoolTransactionsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	sets := api.tpool.TransactionSets()
+	if addrStr := req.FormValue("address"); addrStr != "" {
+		addr, err := scanAddress(addrStr)
+		if err != nil {
+			WriteError(w, Error{"error when calling /tpool/transactions: could not read address: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		var filtered []modules.PoolTransactionSet
+		for _, set := range sets {
+			for _, pt := range set.Transactions {
+				if transactionRelatedToAddress(pt.Transaction, addr) {
+					filtered = append(filtered, set)
+					break
+				}
+			}
+		}
+		sets = filtered
+	}
+	if sets == nil {
+		sets = []modules.PoolTransactionSet{}
+	}
+	WriteJSON(w, TpoolTransactionsGET{
+		Sets: sets,
+	})
+}
@@ -199,6 +199,29 @@ func TestTransactionPoolFee(t *testing.T) {
 	if !min.Equals(fees.Minimum) || !max.Equals(fees.Maximum) {
 		t.Fatal("fee mismatch")
 	}
+	if fees.Target != 1 || !fees.Estimate.Equals(st.tpool.FeeEstimate(1)) {
+		t.Fatal("estimate mismatch for the default target:", fees.Target, fees.Estimate)
+	}
+
+	// Estimate the fee for a later confirmation.
+	err = st.getAPI("/tpool/fee?target=10", &fees)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.Target != 10 || !fees.Estimate.Equals(st.tpool.FeeEstimate(10)) {
+		t.Fatal("estimate mismatch for a target of 10 blocks:", fees.Target, fees.Estimate)
+	}
+	if fees.Estimate.Cmp(fees.Minimum) < 0 {
+		t.Fatal("estimate is below the minimum fee")
+	}
+
+	// Invalid targets should be rejected.
+	for _, target := range []string{"0", "-1", "foo"} {
+		err = st.getAPI("/tpool/fee?target="+target, &fees)
+		if err == nil {
+			t.Fatal("expected an error for target", target)
+		}
+	}
 }
 
 // TestTransactionPoolConfirmed tests the /tpool/confirmed endpoint.
@@ -252,3 +275,82 @@ func TestTransactionPoolConfirmed(t *testing.T) {
 		t.Fatal("transaction should not be confirmed")
 	}
 }
+
+// TestTransactionPoolTransactions tests the /tpool/transactions endpoint.
+func TestTransactionPoolTransactions(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// The transaction pool should be empty.
+	var ttg TpoolTransactionsGET
+	err = st.getAPI("/tpool/transactions", &ttg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ttg.Sets == nil || len(ttg.Sets) != 0 {
+		t.Fatal("expected an empty list of sets, got", ttg.Sets)
+	}
+
+	// Send coins to an address.
+	dest := types.UnlockHash{1}
+	txns, err := st.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(100), dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = st.getAPI("/tpool/transactions", &ttg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ttg.Sets) != 1 || len(ttg.Sets[0].Transactions) != len(txns) {
+		t.Fatal("expected a single set with the sent transactions, got", ttg.Sets)
+	}
+	set := ttg.Sets[0]
+	if set.Size == 0 || set.Fees.IsZero() || set.FeePerByte.IsZero() {
+		t.Fatal("set is missing its size or fees:", set.Size, set.Fees, set.FeePerByte)
+	}
+	for i, pt := range set.Transactions {
+		if pt.ID != txns[i].ID() || pt.Transaction.ID() != pt.ID {
+			t.Fatal("transactions do not match the sent transactions")
+		}
+	}
+
+	// Filter the sets by address.
+	err = st.getAPI("/tpool/transactions?address="+dest.String(), &ttg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ttg.Sets) != 1 || ttg.Sets[0].ID != set.ID {
+		t.Fatal("expected the set sending to the address, got", ttg.Sets)
+	}
+	err = st.getAPI("/tpool/transactions?address="+types.UnlockHash{2}.String(), &ttg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ttg.Sets) != 0 {
+		t.Fatal("expected no sets for an unrelated address, got", ttg.Sets)
+	}
+	err = st.getAPI("/tpool/transactions?address=foo", &ttg)
+	if err == nil {
+		t.Fatal("expected an error for an invalid address")
+	}
+
+	// Once confirmed, the set should leave the transaction pool.
+	_, err = st.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = st.getAPI("/tpool/transactions", &ttg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ttg.Sets) != 0 {
+		t.Fatal("expected the pool to be empty after mining, got", ttg.Sets)
+	}
+}
@@ -30,6 +30,9 @@ type (
 		SiacoinClaimBalance types.Currency `json:"siacoinclaimbalance"`
 		SiafundBalance      types.Currency `json:"siafundbalance"`
 
+		WatchOnlySiacoinBalance types.Currency `json:"watchonlysiacoinbalance"`
+		WatchOnlySiafundBalance types.Currency `json:"watchonlysiafundbalance"`
+
 		DustThreshold types.Currency `json:"dustthreshold"`
 	}
 
@@ -45,16 +48,57 @@ type (
 		Addresses []types.UnlockHash `json:"addresses"`
 	}
 
+	// WalletBumpFeePOST contains the ids of the transactions submitted by a
+	// POST call to /wallet/bumpfee.
+	WalletBumpFeePOST struct {
+		TransactionIDs []types.TransactionID `json:"transactionids"`
+	}
+
+	// WalletConsolidatePOST contains the transaction and fee of a
+	// consolidation, returned by a POST call to /wallet/consolidate.
+	// Transactions is only populated for dry runs.
+	WalletConsolidatePOST struct {
+		TransactionIDs []types.TransactionID `json:"transactionids"`
+		Transactions   []types.Transaction   `json:"transactions,omitempty"`
+		Fee            types.Currency        `json:"fee"`
+	}
+
 	// WalletInitPOST contains the primary seed that gets generated during a
 	// POST call to /wallet/init.
 	WalletInitPOST struct {
 		PrimarySeed string `json:"primaryseed"`
 	}
 
+	// WalletLabelsGET contains the address and transaction labels of the
+	// wallet, returned by a GET call to /wallet/labels.
+	WalletLabelsGET struct {
+		AddressLabels     []modules.AddressLabel     `json:"addresslabels"`
+		TransactionLabels []modules.TransactionLabel `json:"transactionlabels"`
+	}
+
+	// LabeledTransaction is a ProcessedTransaction along with the labels of
+	// the transaction and of the addresses that appear in it.
+	LabeledTransaction struct {
+		modules.ProcessedTransaction
+		Label         string                 `json:"label,omitempty"`
+		AddressLabels []modules.AddressLabel `json:"addresslabels,omitempty"`
+	}
+
+	// WalletPolicyGET contains the spending policy of the wallet, returned
+	// by a GET call to /wallet/policy.
+	WalletPolicyGET struct {
+		modules.SpendingPolicy
+		DailySpent   types.Currency `json:"dailyspent"`
+		SendPassword bool           `json:"sendpassword"`
+	}
+
 	// WalletSiacoinsPOST contains the transaction sent in the POST call to
-	// /wallet/siacoins.
+	// /wallet/siacoins. Fee is only reported when coin control parameters are
+	// used, and Transactions is only populated for dry runs.
 	WalletSiacoinsPOST struct {
 		TransactionIDs []types.TransactionID `json:"transactionids"`
+		Transactions   []types.Transaction   `json:"transactions,omitempty"`
+		Fee            types.Currency        `json:"fee"`
 	}
 
 	// WalletSiafundsPOST contains the transaction sent in the POST call to
@@ -63,6 +107,26 @@ type (
 		TransactionIDs []types.TransactionID `json:"transactionids"`
 	}
 
+	// WalletMultisigAddress is a multisig address tracked by the wallet,
+	// along with the UnlockConditions needed to spend from it.
+	WalletMultisigAddress struct {
+		Address          types.UnlockHash       `json:"address"`
+		UnlockConditions types.UnlockConditions `json:"unlockconditions"`
+	}
+
+	// WalletMultisigGET contains the multisig addresses tracked by the
+	// wallet, returned by a GET call to /wallet/multisig.
+	WalletMultisigGET struct {
+		Addresses []WalletMultisigAddress `json:"addresses"`
+	}
+
+	// WalletMultisigPOST contains the multisig address created by a POST
+	// call to /wallet/multisig.
+	WalletMultisigPOST struct {
+		Address          types.UnlockHash       `json:"address"`
+		UnlockConditions types.UnlockConditions `json:"unlockconditions"`
+	}
+
 	// WalletSeedsGET contains the seeds used by the wallet.
 	WalletSeedsGET struct {
 		PrimarySeed        string   `json:"primaryseed"`
@@ -70,6 +134,12 @@ type (
 		AllSeeds           []string `json:"allseeds"`
 	}
 
+	// WalletSignPOST contains the signed transaction returned by a POST call
+	// to /wallet/sign.
+	WalletSignPOST struct {
+		Transaction types.Transaction `json:"transaction"`
+	}
+
 	// WalletSweepPOST contains the coins and funds returned by a call to
 	// /wallet/sweep.
 	WalletSweepPOST struct {
@@ -80,22 +150,34 @@ type (
 	// WalletTransactionGETid contains the transaction returned by a call to
 	// /wallet/transaction/:id
 	WalletTransactionGETid struct {
-		Transaction modules.ProcessedTransaction `json:"transaction"`
+		Transaction LabeledTransaction `json:"transaction"`
 	}
 
 	// WalletTransactionsGET contains the specified set of confirmed and
 	// unconfirmed transactions.
 	WalletTransactionsGET struct {
-		ConfirmedTransactions   []modules.ProcessedTransaction `json:"confirmedtransactions"`
-		UnconfirmedTransactions []modules.ProcessedTransaction `json:"unconfirmedtransactions"`
+		ConfirmedTransactions   []LabeledTransaction `json:"confirmedtransactions"`
+		UnconfirmedTransactions []LabeledTransaction `json:"unconfirmedtransactions"`
 	}
 
 	// WalletTransactionsGETaddr contains the set of wallet transactions
 	// relevant to the input address provided in the call to
 	// /wallet/transaction/:addr
 	WalletTransactionsGETaddr struct {
-		ConfirmedTransactions   []modules.ProcessedTransaction `json:"confirmedtransactions"`
-		UnconfirmedTransactions []modules.ProcessedTransaction `json:"unconfirmedtransactions"`
+		ConfirmedTransactions   []LabeledTransaction `json:"confirmedtransactions"`
+		UnconfirmedTransactions []LabeledTransaction `json:"unconfirmedtransactions"`
+	}
+
+	// WalletUnlockConditionsGET contains the UnlockConditions of a wallet
+	// address, returned by a GET call to /wallet/unlockconditions/:addr.
+	WalletUnlockConditionsGET struct {
+		UnlockConditions types.UnlockConditions `json:"unlockconditions"`
+	}
+
+	// WalletUnspentGET contains the unspent outputs of the wallet, returned
+	// by a GET call to /wallet/unspent.
+	WalletUnspentGET struct {
+		Outputs []modules.UnspentOutput `json:"outputs"`
 	}
 
 	// WalletVerifyAddressGET contains a bool indicating if the address passed to
@@ -103,6 +185,18 @@ type (
 	WalletVerifyAddressGET struct {
 		Valid bool `json:"valid"`
 	}
+
+	// WalletWatchGET contains the set of addresses that the wallet is
+	// watching, returned by a GET call to /wallet/watch.
+	WalletWatchGET struct {
+		Addresses []types.UnlockHash `json:"addresses"`
+	}
+
+	// WalletWatchFundPOST contains the unsigned transaction returned by a
+	// POST call to /wallet/watch/fund.
+	WalletWatchFundPOST struct {
+		Transaction types.Transaction `json:"transaction"`
+	}
 )
 
 // encryptionKeys enumerates the possible encryption keys that can be derived
@@ -120,10 +214,97 @@ func encryptionKeys(seedStr string) (validKeys []crypto.TwofishKey) {
 	return validKeys
 }
 
+// transactionLabeler attaches the wallet's labels to processed
+// transactions.
+type transactionLabeler struct {
+	addrLabels map[types.UnlockHash]string
+	txnLabels  map[types.TransactionID]string
+}
+
+// newTransactionLabeler fetches the labels of wallet.
+func newTransactionLabeler(wallet modules.Wallet) (transactionLabeler, error) {
+	addrLabels, err := wallet.AddressLabels()
+	if err != nil {
+		return transactionLabeler{}, err
+	}
+	txnLabels, err := wallet.TransactionLabels()
+	if err != nil {
+		return transactionLabeler{}, err
+	}
+	tl := transactionLabeler{
+		addrLabels: make(map[types.UnlockHash]string),
+		txnLabels:  make(map[types.TransactionID]string),
+	}
+	for _, al := range addrLabels {
+		tl.addrLabels[al.Address] = al.Label
+	}
+	for _, txl := range txnLabels {
+		tl.txnLabels[txl.TransactionID] = txl.Label
+	}
+	return tl, nil
+}
+
+// label returns pt along with its labels.
+func (tl transactionLabeler) label(pt modules.ProcessedTransaction) LabeledTransaction {
+	lt := LabeledTransaction{
+		ProcessedTransaction: pt,
+		Label:                tl.txnLabels[pt.TransactionID],
+	}
+	seen := make(map[types.UnlockHash]struct{})
+	addLabel := func(addr types.UnlockHash) {
+		label, ok := tl.addrLabels[addr]
+		if _, dup := seen[addr]; !ok || dup {
+			return
+		}
+		seen[addr] = struct{}{}
+		lt.AddressLabels = append(lt.AddressLabels, modules.AddressLabel{Address: addr, Label: label})
+	}
+	for _, input := range pt.Inputs {
+		addLabel(input.RelatedAddress)
+	}
+	for _, output := range pt.Outputs {
+		// miner fees don't have an address, so skip them
+		if output.FundType != types.SpecifierMinerFee {
+			addLabel(output.RelatedAddress)
+		}
+	}
+	return lt
+}
+
+// labelAll labels pts, keeping only the transactions whose label or address
+// labels contain search. The search is case-insensitive; an empty search
+// matches every transaction.
+func (tl transactionLabeler) labelAll(pts []modules.ProcessedTransaction, search string) []LabeledTransaction {
+	search = strings.ToLower(search)
+	var lts []LabeledTransaction
+	for _, pt := range pts {
+		lt := tl.label(pt)
+		match := search == "" || strings.Contains(strings.ToLower(lt.Label), search)
+		for _, al := range lt.AddressLabels {
+			match = match || strings.Contains(strings.ToLower(al.Label), search)
+		}
+		if match {
+			lts = append(lts, lt)
+		}
+	}
+	return lts
+}
+
+// spendingPolicyStatus returns the HTTP status code for an error returned by
+// a wallet send. Sends that were refused by the wallet's spending policy are
+// reported as forbidden; other errors are reported with status.
+func spendingPolicyStatus(err error, status int) int {
+	if _, ok := err.(modules.SpendingPolicyViolation); ok {
+		return http.StatusForbidden
+	}
+	return status
+}
+
 // walletHander handles API calls to /wallet.
 func (api *API) walletHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	siacoinBal, siafundBal, siaclaimBal := api.wallet.ConfirmedBalance()
 	siacoinsOut, siacoinsIn := api.wallet.UnconfirmedBalance()
+	watchSiacoinBal, watchSiafundBal := api.wallet.WatchOnlyBalance()
 	dustThreshold := api.wallet.DustThreshold()
 	WriteJSON(w, WalletGET{
 		Encrypted:  api.wallet.Encrypted(),
@@ -138,6 +319,9 @@ func (api *API) walletHandler(w http.ResponseWriter, req *http.Request, _ httpro
 		SiafundBalance:      siafundBal,
 		SiacoinClaimBalance: siaclaimBal,
 
+		WatchOnlySiacoinBalance: watchSiacoinBal,
+		WatchOnlySiafundBalance: watchSiafundBal,
+
 		DustThreshold: dustThreshold,
 	})
 }
@@ -167,7 +351,7 @@ func (api *API) wallet033xHandler(w http.ResponseWriter, req *http.Request, _ ht
 
 // walletAddressHandler handles API calls to /wallet/address.
 func (api *API) walletAddressHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
-	unlockConditions, err := api.wallet.NextAddress()
+	unlockConditions, err := api.wallet.NextAddressForPurpose(modules.AddressPurpose(req.FormValue("purpose")))
 	if err != nil {
 		WriteError(w, Error{"error when calling /wallet/addresses: " + err.Error()}, http.StatusBadRequest)
 		return
@@ -192,7 +376,24 @@ func (api *API) walletBackupHandler(w http.ResponseWriter, req *http.Request, _
 		WriteError(w, Error{"error when calling /wallet/backup: destination must be an absolute path"}, http.StatusBadRequest)
 		return
 	}
-	err := api.wallet.CreateBackup(destination)
+	// Passwords in query strings end up in logs and shell histories, so
+	// portable backups can only be created through POST.
+	if req.Method == http.MethodGet && req.URL.Query().Get("backuppassword") != "" {
+		WriteError(w, Error{"error when calling /wallet/backup: backuppassword must be sent in the body of a POST request"}, http.StatusBadRequest)
+		return
+	}
+	// Backups contain the wallet's seeds, which can be used to spend around
+	// the spending policy.
+	err := api.wallet.CheckSendPassword(req.FormValue("sendpassword"))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/backup: " + err.Error()}, spendingPolicyStatus(err, http.StatusBadRequest))
+		return
+	}
+	if req.PostFormValue("backuppassword") != "" {
+		err = api.wallet.ExportBackup(req.PostFormValue("backuppassword"), destination)
+	} else {
+		err = api.wallet.CreateBackup(destination)
+	}
 	if err != nil {
 		WriteError(w, Error{"error when calling /wallet/backup: " + err.Error()}, http.StatusBadRequest)
 		return
@@ -200,6 +401,114 @@ func (api *API) walletBackupHandler(w http.ResponseWriter, req *http.Request, _
 	WriteSuccess(w)
 }
 
+// walletBackupRestoreHandler handles API calls to /wallet/backup/restore.
+func (api *API) walletBackupRestoreHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	source := req.FormValue("source")
+	// Check that the source is absolute.
+	if !filepath.IsAbs(source) {
+		WriteError(w, Error{"error when calling /wallet/backup/restore: source must be an absolute path"}, http.StatusBadRequest)
+		return
+	}
+	if req.FormValue("backuppassword") == "" {
+		WriteError(w, Error{"error when calling /wallet/backup/restore: backuppassword must be provided"}, http.StatusBadRequest)
+		return
+	}
+	var encryptionKey crypto.TwofishKey
+	if req.FormValue("encryptionpassword") != "" {
+		encryptionKey = crypto.TwofishKey(crypto.HashObject(req.FormValue("encryptionpassword")))
+	}
+	err := api.wallet.LoadBackup(encryptionKey, req.FormValue("backuppassword"), source)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/backup/restore: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletBumpFeeHandler handles API calls to /wallet/bumpfee.
+func (api *API) walletBumpFeeHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var txid types.TransactionID
+	if err := txid.UnmarshalJSON([]byte(`"` + req.FormValue("transactionid") + `"`)); err != nil {
+		WriteError(w, Error{"error when calling /wallet/bumpfee: could not parse transaction id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	fee, ok := scanAmount(req.FormValue("fee"))
+	if !ok {
+		WriteError(w, Error{"could not read fee from POST call to /wallet/bumpfee"}, http.StatusBadRequest)
+		return
+	}
+
+	txns, err := api.wallet.BumpFee(txid, fee, req.FormValue("sendpassword"))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/bumpfee: " + err.Error()}, spendingPolicyStatus(err, http.StatusBadRequest))
+		return
+	}
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletBumpFeePOST{
+		TransactionIDs: txids,
+	})
+}
+
+// walletConsolidateHandler handles API calls to /wallet/consolidate.
+func (api *API) walletConsolidateHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var maxInputs int
+	if req.FormValue("maxinputs") != "" {
+		var err error
+		maxInputs, err = strconv.Atoi(req.FormValue("maxinputs"))
+		if err != nil || maxInputs < 2 {
+			WriteError(w, Error{"error when calling /wallet/consolidate: maxinputs must be an integer of at least 2"}, http.StatusBadRequest)
+			return
+		}
+	}
+	dryRun := req.FormValue("dryrun") == "true"
+
+	txns, fee, err := api.wallet.ConsolidateOutputs(maxInputs, dryRun)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/consolidate: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	resp := WalletConsolidatePOST{
+		TransactionIDs: txids,
+		Fee:            fee,
+	}
+	if dryRun {
+		resp.Transactions = txns
+	}
+	WriteJSON(w, resp)
+}
+
+// walletFreezeHandler handles API calls to /wallet/freeze.
+func (api *API) walletFreezeHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var ids []types.OutputID
+	for _, idStr := range strings.Split(req.FormValue("outputids"), ",") {
+		id, err := scanHash(idStr)
+		if err != nil {
+			WriteError(w, Error{"error when calling /wallet/freeze: could not parse output id " + idStr}, http.StatusBadRequest)
+			return
+		}
+		ids = append(ids, types.OutputID(id))
+	}
+
+	var err error
+	if req.FormValue("remove") == "true" {
+		err = api.wallet.UnfreezeOutputs(ids)
+	} else {
+		err = api.wallet.FreezeOutputs(ids)
+	}
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/freeze: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // walletInitHandler handles API calls to /wallet/init.
 func (api *API) walletInitHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var encryptionKey crypto.TwofishKey
@@ -266,6 +575,181 @@ func (api *API) walletInitSeedHandler(w http.ResponseWriter, req *http.Request,
 	WriteSuccess(w)
 }
 
+// walletLabelsHandlerGET handles GET calls to /wallet/labels.
+func (api *API) walletLabelsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	addrLabels, err := api.wallet.AddressLabels()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/labels: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	txnLabels, err := api.wallet.TransactionLabels()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/labels: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletLabelsGET{
+		AddressLabels:     addrLabels,
+		TransactionLabels: txnLabels,
+	})
+}
+
+// walletLabelsHandlerPOST handles POST calls to /wallet/labels.
+func (api *API) walletLabelsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	addrStr, txidStr := req.FormValue("address"), req.FormValue("transactionid")
+	label := req.FormValue("label")
+	var err error
+	switch {
+	case addrStr != "" && txidStr != "":
+		WriteError(w, Error{"error when calling /wallet/labels: only one of address and transactionid may be specified"}, http.StatusBadRequest)
+		return
+	case addrStr != "":
+		addr, parseErr := scanAddress(addrStr)
+		if parseErr != nil {
+			WriteError(w, Error{"error when calling /wallet/labels: could not parse address: " + parseErr.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = api.wallet.SetAddressLabel(addr, label)
+	case txidStr != "":
+		var txid types.TransactionID
+		if parseErr := txid.UnmarshalJSON([]byte(`"` + txidStr + `"`)); parseErr != nil {
+			WriteError(w, Error{"error when calling /wallet/labels: could not parse transaction id: " + parseErr.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = api.wallet.SetTransactionLabel(txid, label)
+	default:
+		WriteError(w, Error{"error when calling /wallet/labels: an address or transactionid must be specified"}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/labels: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletMultisigHandlerGET handles GET calls to /wallet/multisig.
+func (api *API) walletMultisigHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	ucs, err := api.wallet.MultisigAddresses()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/multisig: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	addrs := make([]WalletMultisigAddress, 0, len(ucs))
+	for _, uc := range ucs {
+		addrs = append(addrs, WalletMultisigAddress{
+			Address:          uc.UnlockHash(),
+			UnlockConditions: uc,
+		})
+	}
+	WriteJSON(w, WalletMultisigGET{
+		Addresses: addrs,
+	})
+}
+
+// walletMultisigHandlerPOST handles POST calls to /wallet/multisig.
+func (api *API) walletMultisigHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var pubkeys []types.SiaPublicKey
+	for _, pkStr := range strings.Split(req.FormValue("publickeys"), ",") {
+		var pk types.SiaPublicKey
+		pk.LoadString(pkStr)
+		if len(pk.Key) == 0 {
+			WriteError(w, Error{"error when calling /wallet/multisig: could not parse public key " + pkStr}, http.StatusBadRequest)
+			return
+		}
+		pubkeys = append(pubkeys, pk)
+	}
+	required, err := strconv.ParseUint(req.FormValue("required"), 10, 64)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/multisig: could not parse required signatures: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	unused := req.FormValue("unused") == "true"
+
+	uc, err := api.wallet.AddMultisigAddress(pubkeys, required, unused)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/multisig: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletMultisigPOST{
+		Address:          uc.UnlockHash(),
+		UnlockConditions: uc,
+	})
+}
+
+// walletPolicyHandlerGET handles GET calls to /wallet/policy.
+func (api *API) walletPolicyHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	policy, dailySpent, sendPassword, err := api.wallet.SpendingPolicy()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/policy: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletPolicyGET{
+		SpendingPolicy: policy,
+		DailySpent:     dailySpent,
+		SendPassword:   sendPassword,
+	})
+}
+
+// walletPolicyHandlerPOST handles POST calls to /wallet/policy. Only the
+// policy parameters that are supplied are changed.
+func (api *API) walletPolicyHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if err := req.ParseForm(); err != nil {
+		WriteError(w, Error{"error when calling /wallet/policy: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	policy, _, _, err := api.wallet.SpendingPolicy()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/policy: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	changed := false
+	if req.FormValue("transactionlimit") != "" {
+		limit, ok := scanAmount(req.FormValue("transactionlimit"))
+		if !ok {
+			WriteError(w, Error{"could not read transactionlimit from POST call to /wallet/policy"}, http.StatusBadRequest)
+			return
+		}
+		policy.TransactionLimit = limit
+		changed = true
+	}
+	if req.FormValue("dailylimit") != "" {
+		limit, ok := scanAmount(req.FormValue("dailylimit"))
+		if !ok {
+			WriteError(w, Error{"could not read dailylimit from POST call to /wallet/policy"}, http.StatusBadRequest)
+			return
+		}
+		policy.DailyLimit = limit
+		changed = true
+	}
+	if _, ok := req.Form["allowlist"]; ok {
+		policy.Allowlist = nil
+		if req.FormValue("allowlist") != "" {
+			for _, addrStr := range strings.Split(req.FormValue("allowlist"), ",") {
+				addr, err := scanAddress(addrStr)
+				if err != nil {
+					WriteError(w, Error{"error when calling /wallet/policy: could not parse address " + addrStr}, http.StatusBadRequest)
+					return
+				}
+				policy.Allowlist = append(policy.Allowlist, addr)
+			}
+		}
+		changed = true
+	}
+
+	sendPassword := req.FormValue("sendpassword")
+	if changed {
+		err = api.wallet.SetSpendingPolicy(policy, sendPassword)
+	}
+	if _, ok := req.Form["newsendpassword"]; ok && err == nil {
+		err = api.wallet.SetSendPassword(sendPassword, req.FormValue("newsendpassword"))
+	}
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/policy: " + err.Error()}, spendingPolicyStatus(err, http.StatusBadRequest))
+		return
+	}
+	WriteSuccess(w)
+}
+
 // walletSeedHandler handles API calls to /wallet/seed.
 func (api *API) walletSeedHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// Get the seed using the ditionary + phrase
@@ -339,6 +823,12 @@ func (api *API) walletSeedsHandler(w http.ResponseWriter, req *http.Request, _ h
 		dictionary = mnemonics.English
 	}
 
+	// The seeds can be used to spend around the spending policy.
+	if err := api.wallet.CheckSendPassword(req.FormValue("sendpassword")); err != nil {
+		WriteError(w, Error{"error when calling /wallet/seeds: " + err.Error()}, spendingPolicyStatus(err, http.StatusBadRequest))
+		return
+	}
+
 	// Get the primary seed information.
 	primarySeed, addrsRemaining, err := api.wallet.PrimarySeed()
 	if err != nil {
@@ -375,7 +865,7 @@ func (api *API) walletSeedsHandler(w http.ResponseWriter, req *http.Request, _ h
 
 // walletSiacoinsHandler handles API calls to /wallet/siacoins.
 func (api *API) walletSiacoinsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
-	var txns []types.Transaction
+	var outputs []types.SiacoinOutput
 	if req.FormValue("outputs") != "" {
 		// multiple amounts + destinations
 		if req.FormValue("amount") != "" || req.FormValue("destination") != "" {
@@ -383,17 +873,11 @@ func (api *API) walletSiacoinsHandler(w http.ResponseWriter, req *http.Request,
 			return
 		}
 
-		var outputs []types.SiacoinOutput
 		err := json.Unmarshal([]byte(req.FormValue("outputs")), &outputs)
 		if err != nil {
 			WriteError(w, Error{"could not decode outputs: " + err.Error()}, http.StatusInternalServerError)
 			return
 		}
-		txns, err = api.wallet.SendSiacoinsMulti(outputs)
-		if err != nil {
-			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
-			return
-		}
 	} else {
 		// single amount + destination
 		amount, ok := scanAmount(req.FormValue("amount"))
@@ -406,22 +890,91 @@ func (api *API) walletSiacoinsHandler(w http.ResponseWriter, req *http.Request,
 			WriteError(w, Error{"could not read address from POST call to /wallet/siacoins"}, http.StatusBadRequest)
 			return
 		}
+		outputs = []types.SiacoinOutput{{Value: amount, UnlockHash: dest}}
+	}
 
-		txns, err = api.wallet.SendSiacoins(amount, dest)
+	// Parse the optional coin control parameters.
+	var opts modules.SendOptions
+	useOpts := false
+	if req.FormValue("fee") != "" {
+		fee, ok := scanAmount(req.FormValue("fee"))
+		if !ok {
+			WriteError(w, Error{"could not read fee from POST call to /wallet/siacoins"}, http.StatusBadRequest)
+			return
+		}
+		opts.Fee = fee
+		useOpts = true
+	}
+	if req.FormValue("feeperbyte") != "" {
+		feePerByte, ok := scanAmount(req.FormValue("feeperbyte"))
+		if !ok {
+			WriteError(w, Error{"could not read feeperbyte from POST call to /wallet/siacoins"}, http.StatusBadRequest)
+			return
+		}
+		opts.FeePerByte = feePerByte
+		useOpts = true
+	}
+	if req.FormValue("inputs") != "" {
+		for _, idStr := range strings.Split(req.FormValue("inputs"), ",") {
+			id, err := scanHash(idStr)
+			if err != nil {
+				WriteError(w, Error{"could not read input " + idStr + " from POST call to /wallet/siacoins"}, http.StatusBadRequest)
+				return
+			}
+			opts.Inputs = append(opts.Inputs, types.SiacoinOutputID(id))
+		}
+		useOpts = true
+	}
+	if req.FormValue("changeaddress") != "" {
+		changeAddr, err := scanAddress(req.FormValue("changeaddress"))
 		if err != nil {
-			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
+			WriteError(w, Error{"could not read changeaddress from POST call to /wallet/siacoins"}, http.StatusBadRequest)
 			return
 		}
+		opts.ChangeAddress = changeAddr
+		useOpts = true
+	}
+	if req.FormValue("dryrun") != "" {
+		dryRun, err := strconv.ParseBool(req.FormValue("dryrun"))
+		if err != nil {
+			WriteError(w, Error{"could not read dryrun from POST call to /wallet/siacoins"}, http.StatusBadRequest)
+			return
+		}
+		opts.DryRun = dryRun
+		useOpts = true
+	}
+	if req.FormValue("sendpassword") != "" {
+		opts.SendPassword = req.FormValue("sendpassword")
+		useOpts = true
+	}
 
+	var txns []types.Transaction
+	var fee types.Currency
+	var err error
+	if useOpts {
+		txns, fee, err = api.wallet.SendSiacoinsWithOptions(outputs, opts)
+	} else if req.FormValue("outputs") != "" {
+		txns, err = api.wallet.SendSiacoinsMulti(outputs)
+	} else {
+		txns, err = api.wallet.SendSiacoins(outputs[0].Value, outputs[0].UnlockHash)
+	}
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, spendingPolicyStatus(err, http.StatusInternalServerError))
+		return
 	}
 
 	var txids []types.TransactionID
 	for _, txn := range txns {
 		txids = append(txids, txn.ID())
 	}
-	WriteJSON(w, WalletSiacoinsPOST{
+	resp := WalletSiacoinsPOST{
 		TransactionIDs: txids,
-	})
+		Fee:            fee,
+	}
+	if opts.DryRun {
+		resp.Transactions = txns
+	}
+	WriteJSON(w, resp)
 }
 
 // walletSiafundsHandler handles API calls to /wallet/siafunds.
@@ -437,9 +990,9 @@ func (api *API) walletSiafundsHandler(w http.ResponseWriter, req *http.Request,
 		return
 	}
 
-	txns, err := api.wallet.SendSiafunds(amount, dest)
+	txns, err := api.wallet.SendSiafunds(amount, dest, req.FormValue("sendpassword"))
 	if err != nil {
-		WriteError(w, Error{"error when calling /wallet/siafunds: " + err.Error()}, http.StatusInternalServerError)
+		WriteError(w, Error{"error when calling /wallet/siafunds: " + err.Error()}, spendingPolicyStatus(err, http.StatusInternalServerError))
 		return
 	}
 	var txids []types.TransactionID
@@ -451,6 +1004,35 @@ func (api *API) walletSiafundsHandler(w http.ResponseWriter, req *http.Request,
 	})
 }
 
+// walletSignHandler handles API calls to /wallet/sign.
+func (api *API) walletSignHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var txn types.Transaction
+	err := json.Unmarshal([]byte(req.FormValue("transaction")), &txn)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/sign: could not decode transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var toSign []crypto.Hash
+	if req.FormValue("tosign") != "" {
+		for _, idStr := range strings.Split(req.FormValue("tosign"), ",") {
+			id, err := scanHash(idStr)
+			if err != nil {
+				WriteError(w, Error{"error when calling /wallet/sign: could not parse input id " + idStr}, http.StatusBadRequest)
+				return
+			}
+			toSign = append(toSign, id)
+		}
+	}
+	err = api.wallet.SignTransaction(&txn, toSign, req.FormValue("sendpassword"))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/sign: " + err.Error()}, spendingPolicyStatus(err, http.StatusBadRequest))
+		return
+	}
+	WriteJSON(w, WalletSignPOST{
+		Transaction: txn,
+	})
+}
+
 // walletSweepSeedHandler handles API calls to /wallet/sweep/seed.
 func (api *API) walletSweepSeedHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// Get the seed using the ditionary + phrase
@@ -491,8 +1073,13 @@ func (api *API) walletTransactionHandler(w http.ResponseWriter, req *http.Reques
 		WriteError(w, Error{"error when calling /wallet/transaction/:id  :  transaction not found"}, http.StatusBadRequest)
 		return
 	}
+	tl, err := newTransactionLabeler(api.wallet)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/transaction/:id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
 	WriteJSON(w, WalletTransactionGETid{
-		Transaction: txn,
+		Transaction: tl.label(txn),
 	})
 }
 
@@ -520,10 +1107,16 @@ func (api *API) walletTransactionsHandler(w http.ResponseWriter, req *http.Reque
 		return
 	}
 	unconfirmedTxns := api.wallet.UnconfirmedTransactions()
+	tl, err := newTransactionLabeler(api.wallet)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/transactions: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
 
+	search := req.FormValue("label")
 	WriteJSON(w, WalletTransactionsGET{
-		ConfirmedTransactions:   confirmedTxns,
-		UnconfirmedTransactions: unconfirmedTxns,
+		ConfirmedTransactions:   tl.labelAll(confirmedTxns, search),
+		UnconfirmedTransactions: tl.labelAll(unconfirmedTxns, search),
 	})
 }
 
@@ -541,9 +1134,14 @@ func (api *API) walletTransactionsAddrHandler(w http.ResponseWriter, req *http.R
 
 	confirmedATs := api.wallet.AddressTransactions(addr)
 	unconfirmedATs := api.wallet.AddressUnconfirmedTransactions(addr)
+	tl, err := newTransactionLabeler(api.wallet)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/transactions: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
 	WriteJSON(w, WalletTransactionsGETaddr{
-		ConfirmedTransactions:   confirmedATs,
-		UnconfirmedTransactions: unconfirmedATs,
+		ConfirmedTransactions:   tl.labelAll(confirmedATs, ""),
+		UnconfirmedTransactions: tl.labelAll(unconfirmedATs, ""),
 	})
 }
 
@@ -589,6 +1187,36 @@ func (api *API) walletChangePasswordHandler(w http.ResponseWriter, req *http.Req
 	WriteError(w, Error{"error when calling /wallet/changepassword: " + modules.ErrBadEncryptionKey.Error()}, http.StatusBadRequest)
 }
 
+// walletUnlockConditionsHandler handles API calls to
+// /wallet/unlockconditions/:addr.
+func (api *API) walletUnlockConditionsHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	addr, err := scanAddress(ps.ByName("addr"))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/unlockconditions: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	uc, err := api.wallet.UnlockConditions(addr)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/unlockconditions: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletUnlockConditionsGET{
+		UnlockConditions: uc,
+	})
+}
+
+// walletUnspentHandler handles API calls to /wallet/unspent.
+func (api *API) walletUnspentHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	outputs, err := api.wallet.UnspentOutputs()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/unspent: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, WalletUnspentGET{
+		Outputs: outputs,
+	})
+}
+
 // walletVerifyAddressHandler handles API calls to /wallet/verify/address/:addr.
 func (api *API) walletVerifyAddressHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	addrString := ps.ByName("addr")
@@ -596,3 +1224,115 @@ func (api *API) walletVerifyAddressHandler(w http.ResponseWriter, req *http.Requ
 	err := new(types.UnlockHash).LoadString(addrString)
 	WriteJSON(w, WalletVerifyAddressGET{Valid: err == nil})
 }
+
+// walletWatchHandlerGET handles GET calls to /wallet/watch.
+func (api *API) walletWatchHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	addrs, err := api.wallet.WatchAddresses()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/watch: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletWatchGET{
+		Addresses: addrs,
+	})
+}
+
+// walletWatchHandlerPOST handles POST calls to /wallet/watch.
+func (api *API) walletWatchHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var ucs []types.UnlockConditions
+	if req.FormValue("unlockconditions") != "" {
+		err := json.Unmarshal([]byte(req.FormValue("unlockconditions")), &ucs)
+		if err != nil {
+			WriteError(w, Error{"error when calling /wallet/watch: could not decode unlockconditions: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	var addrs []types.UnlockHash
+	if req.FormValue("addresses") != "" || len(ucs) == 0 {
+		for _, addrStr := range strings.Split(req.FormValue("addresses"), ",") {
+			addr, err := scanAddress(addrStr)
+			if err != nil {
+				WriteError(w, Error{"error when calling /wallet/watch: could not parse address " + addrStr}, http.StatusBadRequest)
+				return
+			}
+			addrs = append(addrs, addr)
+		}
+	}
+	remove := req.FormValue("remove") == "true"
+	unused := req.FormValue("unused") == "true"
+
+	var err error
+	if remove {
+		for _, uc := range ucs {
+			addrs = append(addrs, uc.UnlockHash())
+		}
+		err = api.wallet.RemoveWatchAddresses(addrs, unused)
+	} else {
+		if len(addrs) > 0 {
+			err = api.wallet.AddWatchAddresses(addrs, unused)
+		}
+		if err == nil && len(ucs) > 0 {
+			err = api.wallet.AddWatchUnlockConditions(ucs, unused)
+		}
+	}
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/watch: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletWatchFundHandler handles POST calls to /wallet/watch/fund.
+func (api *API) walletWatchFundHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var outputs []types.SiacoinOutput
+	if req.FormValue("outputs") != "" {
+		if req.FormValue("amount") != "" || req.FormValue("destination") != "" {
+			WriteError(w, Error{"error when calling /wallet/watch/fund: cannot supply both 'outputs' and single amount+destination pair"}, http.StatusBadRequest)
+			return
+		}
+		err := json.Unmarshal([]byte(req.FormValue("outputs")), &outputs)
+		if err != nil {
+			WriteError(w, Error{"error when calling /wallet/watch/fund: could not decode outputs: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	} else {
+		amount, ok := scanAmount(req.FormValue("amount"))
+		if !ok {
+			WriteError(w, Error{"error when calling /wallet/watch/fund: could not read amount"}, http.StatusBadRequest)
+			return
+		}
+		dest, err := scanAddress(req.FormValue("destination"))
+		if err != nil {
+			WriteError(w, Error{"error when calling /wallet/watch/fund: could not read destination"}, http.StatusBadRequest)
+			return
+		}
+		outputs = []types.SiacoinOutput{{Value: amount, UnlockHash: dest}}
+	}
+	var fee types.Currency
+	if req.FormValue("fee") != "" {
+		var ok bool
+		fee, ok = scanAmount(req.FormValue("fee"))
+		if !ok {
+			WriteError(w, Error{"error when calling /wallet/watch/fund: could not read fee"}, http.StatusBadRequest)
+			return
+		}
+	}
+	var changeAddr types.UnlockHash
+	if req.FormValue("changeaddress") != "" {
+		var err error
+		changeAddr, err = scanAddress(req.FormValue("changeaddress"))
+		if err != nil {
+			WriteError(w, Error{"error when calling /wallet/watch/fund: could not read changeaddress"}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	txn, err := api.wallet.FundWatchOnlyTransaction(outputs, fee, changeAddr)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/watch/fund: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletWatchFundPOST{
+		Transaction: txn,
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -838,6 +839,48 @@ func TestWalletRelativePathErrorBackup(t *testing.T) {
 	}
 }
 
+// TestWalletBackupPassword checks that portable backups are only created
+// when the backup password is sent in the body of a POST request.
+func TestWalletBackupPassword(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	walletTestDir := build.TempDir("api", t.Name(), "backups")
+	err = os.MkdirAll(walletTestDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(walletTestDir, "portable.backup")
+
+	// A password in the query string should be rejected.
+	vals := url.Values{}
+	vals.Set("destination", dest)
+	vals.Set("backuppassword", "foo")
+	err = st.stdGetAPI("/wallet/backup?" + vals.Encode())
+	if err == nil || !strings.Contains(err.Error(), "POST") {
+		t.Fatal("expected GET with a backup password to fail, got", err)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Fatal("backup should not have been created:", err)
+	}
+
+	// Sent in a POST body, it should create a portable backup.
+	err = st.stdPostAPI("/wallet/backup", vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dest); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // Tests that the /wallet/033x call checks for relative paths.
 func TestWalletRelativePathError033x(t *testing.T) {
 	if testing.Short() {
@@ -1050,6 +1093,139 @@ func TestWalletReset(t *testing.T) {
 	}
 }
 
+// TestWalletSendPasswordHandlers checks that /wallet/sign and
+// /wallet/siafunds refuse to sign or send without the send password.
+func TestWalletSendPasswordHandlers(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	if err := st.wallet.LoadSiagKeys(st.walletKey, []string{"../../types/siag0of1of1.siakey"}); err != nil {
+		t.Fatal(err)
+	}
+	policyValues := url.Values{}
+	policyValues.Set("newsendpassword", "secret")
+	if err := st.stdPostAPI("/wallet/policy", policyValues); err != nil {
+		t.Fatal(err)
+	}
+
+	// Build a transaction spending one of the wallet's outputs.
+	var wug WalletUnspentGET
+	if err := st.getAPI("/wallet/unspent", &wug); err != nil {
+		t.Fatal(err)
+	}
+	var uo modules.UnspentOutput
+	for _, o := range wug.Outputs {
+		if o.FundType == types.SpecifierSiacoinOutput && o.ConfirmationHeight > 0 {
+			uo = o
+			break
+		}
+	}
+	uc, err := st.wallet.UnlockConditions(uo.UnlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txn := types.Transaction{
+		SiacoinInputs:  []types.SiacoinInput{{ParentID: types.SiacoinOutputID(uo.ID), UnlockConditions: uc}},
+		SiacoinOutputs: []types.SiacoinOutput{{Value: uo.Value.Sub(types.SiacoinPrecision)}},
+		MinerFees:      []types.Currency{types.SiacoinPrecision},
+	}
+	txnBytes, _ := json.Marshal(txn)
+	signValues := url.Values{}
+	signValues.Set("transaction", string(txnBytes))
+	err = st.stdPostAPI("/wallet/sign", signValues)
+	if err == nil || !strings.Contains(err.Error(), "send password is required") {
+		t.Fatal("expected /wallet/sign to require the send password, got", err)
+	}
+	signValues.Set("sendpassword", "secret")
+	var wsp WalletSignPOST
+	if err := st.postAPI("/wallet/sign", signValues, &wsp); err != nil {
+		t.Fatal(err)
+	}
+	if len(wsp.Transaction.TransactionSignatures) == 0 {
+		t.Fatal("transaction was not signed")
+	}
+
+	siafundValues := url.Values{}
+	siafundValues.Set("amount", "10")
+	siafundValues.Set("destination", types.UnlockHash{}.String())
+	err = st.stdPostAPI("/wallet/siafunds", siafundValues)
+	if err == nil || !strings.Contains(err.Error(), "send password is required") {
+		t.Fatal("expected /wallet/siafunds to require the send password, got", err)
+	}
+	siafundValues.Set("sendpassword", "secret")
+	if err := st.stdPostAPI("/wallet/siafunds", siafundValues); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestWalletSeedsSendPassword checks that /wallet/seeds and /wallet/backup
+// do not expose the wallet's seeds without the send password.
+func TestWalletSeedsSendPassword(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	policyValues := url.Values{}
+	policyValues.Set("newsendpassword", "secret")
+	if err := st.stdPostAPI("/wallet/policy", policyValues); err != nil {
+		t.Fatal(err)
+	}
+
+	var wsg WalletSeedsGET
+	err = st.getAPI("/wallet/seeds", &wsg)
+	if err == nil || !strings.Contains(err.Error(), "send password is required") {
+		t.Fatal("expected /wallet/seeds to require the send password, got", err)
+	}
+	err = st.getAPI("/wallet/seeds?sendpassword=wrong", &wsg)
+	if err == nil || !strings.Contains(err.Error(), "incorrect send password") {
+		t.Fatal("expected /wallet/seeds to reject a wrong send password, got", err)
+	}
+	if err := st.getAPI("/wallet/seeds?sendpassword=secret", &wsg); err != nil {
+		t.Fatal(err)
+	}
+	if wsg.PrimarySeed == "" {
+		t.Fatal("primary seed was not returned")
+	}
+
+	// Neither a database copy nor a portable backup can be created without
+	// the send password.
+	backupPath := filepath.Join(st.dir, "test.backup")
+	backupValues := url.Values{}
+	backupValues.Set("destination", backupPath)
+	err = st.stdGetAPI("/wallet/backup?" + backupValues.Encode())
+	if err == nil || !strings.Contains(err.Error(), "send password is required") {
+		t.Fatal("expected GET /wallet/backup to require the send password, got", err)
+	}
+	backupValues.Set("backuppassword", "backup")
+	err = st.stdPostAPI("/wallet/backup", backupValues)
+	if err == nil || !strings.Contains(err.Error(), "send password is required") {
+		t.Fatal("expected POST /wallet/backup to require the send password, got", err)
+	}
+	if _, err := os.Stat(backupPath); !os.IsNotExist(err) {
+		t.Fatal("backup was created without the send password")
+	}
+	backupValues.Set("sendpassword", "secret")
+	if err := st.stdPostAPI("/wallet/backup", backupValues); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(backupPath); err != nil {
+		t.Fatal("backup was not created:", err)
+	}
+}
+
 func TestWalletSiafunds(t *testing.T) {
 	if testing.Short() {
 		t.SkipNow()